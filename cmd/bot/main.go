@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -45,8 +46,17 @@ func main() {
 		logger.Fatal("Failed to connect to database", err)
 	}
 
-	// Run GORM auto-migration
-	if err := database.AutoMigrate(db); err != nil {
+	// Production schema changes go through cmd/migrate; elsewhere apply them on startup
+	if cfg.AppEnv == "production" {
+		pending, err := database.PendingMigrations(db)
+		if err != nil {
+			logger.Fatal("Failed to check migrations", err)
+		}
+		if len(pending) > 0 {
+			logger.Fatal("Database schema is out of date", fmt.Errorf("%d pending migrations starting at %04d_%s; run `go run ./cmd/migrate up`",
+				len(pending), pending[0].Version, pending[0].Name))
+		}
+	} else if _, err := database.MigrateUp(db); err != nil {
 		logger.Fatal("Failed to run migrations", err)
 	}
 
@@ -0,0 +1,96 @@
+// Command ledger checks every user's coin balance against their coin_transactions
+// ledger and, with -apply, writes admin adjustments that close the gaps.
+//
+//	go run ./cmd/ledger            # dry run: report mismatches only
+//	go run ./cmd/ledger -apply     # write correcting transactions
+//	go run ./cmd/ledger -user 42   # check a single user (internal ID)
+//	go run ./cmd/ledger -apply -run 2026-10-16-2
+//
+// A dry run exits with status 1 when mismatches are found, so it can gate cron jobs.
+//
+// Every adjustment is keyed by the run ID (today's date by default) and the user, so
+// re-running an interrupted -apply never corrects a user twice. Pass a new -run to
+// correct users again after a run has already touched them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/database"
+	"github.com/mroshb/game_bot/internal/repositories"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "write correcting admin adjustment transactions (default is a dry run)")
+	userID := flag.Uint("user", 0, "only check this user ID")
+	runID := flag.String("run", time.Now().UTC().Format("2006-01-02"), "ID of this correction run; a run corrects each user at most once")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment")
+	}
+
+	logger.Init()
+	defer logger.Sync()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	coinRepo := repositories.NewCoinRepository(db)
+
+	mismatches, err := coinRepo.FindLedgerMismatches(uint(*userID))
+	if err != nil {
+		log.Fatalf("Failed to check ledger: %v", err)
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Println("✅ All balances match the ledger")
+		return
+	}
+
+	fmt.Printf("%-10s %14s %14s %14s\n", "USER", "BALANCE", "LEDGER", "DRIFT")
+	var total int64
+	for _, m := range mismatches {
+		fmt.Printf("%-10d %14d %14d %+14d\n", m.UserID, m.Balance, m.LedgerSum, m.Drift())
+		total += m.Drift()
+	}
+	fmt.Printf("\n%d mismatched users, total drift %+d coins\n", len(mismatches), total)
+
+	if !*apply {
+		fmt.Println("Dry run: nothing written. Re-run with -apply to record correcting adjustments.")
+		os.Exit(1)
+	}
+
+	corrected := 0
+	for _, m := range mismatches {
+		fixed, err := coinRepo.ReconcileUser(m.UserID, *runID, "اصلاح دفتر سکه")
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeDuplicateRequest {
+			fmt.Printf("⏭ user %d: already corrected in run %s\n", m.UserID, *runID)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("❌ user %d: %v\n", m.UserID, err)
+			continue
+		}
+		if fixed != nil {
+			corrected++
+			fmt.Printf("✔ user %d: recorded %+d\n", fixed.UserID, fixed.Drift())
+		}
+	}
+	fmt.Printf("\n✅ Recorded %d correcting adjustments\n", corrected)
+}
@@ -0,0 +1,116 @@
+// Command migrate applies and rolls back the versioned SQL migrations in
+// internal/database/migrations.
+//
+//	go run ./cmd/migrate up                # apply every pending migration
+//	go run ./cmd/migrate down              # roll back the last migration
+//	go run ./cmd/migrate down -steps 3     # roll back the last three
+//	go run ./cmd/migrate status            # list migrations and when they were applied
+//	go run ./cmd/migrate baseline          # mark an AutoMigrate-built database as at version 1
+//	go run ./cmd/migrate baseline -version 2
+//
+// New migrations are added as the next NNNN_name.up.sql / NNNN_name.down.sql pair.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/database"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back (down)")
+	version := flags.Int("version", 1, "last migration the database already has (baseline)")
+	flags.Parse(os.Args[2:])
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment")
+	}
+
+	logger.Init()
+	defer logger.Sync()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		printMigrations("✔ applied", applied)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("✅ Database is up to date")
+		}
+
+	case "down":
+		if *steps <= 0 {
+			log.Fatalf("-steps must be positive")
+		}
+		rolledBack, err := database.MigrateDown(db, *steps)
+		printMigrations("✔ rolled back", rolledBack)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("Nothing to roll back")
+		}
+
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %v", err)
+		}
+		pending := 0
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			} else {
+				pending++
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, applied)
+		}
+		fmt.Printf("\n%d migrations, %d pending\n", len(states), pending)
+
+	case "baseline":
+		marked, err := database.Baseline(db, *version)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		printMigrations("✔ marked applied", marked)
+
+	default:
+		usage()
+	}
+}
+
+func printMigrations(action string, migrations []database.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [-steps N] | status | baseline [-version N]")
+	os.Exit(2)
+}
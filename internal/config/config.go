@@ -4,12 +4,19 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	// Telegram
-	BotToken string
+	BotToken  string
+	BotAPIURL string // Bot API server, e.g. a self-hosted one or a test stand-in; empty uses api.telegram.org
+
+	// How updates arrive: "polling" (default) or "webhook"
+	UpdateMode    string
+	WebhookURL    string // public base URL that reaches AppPort
+	WebhookSecret string // checked against Telegram's secret token header
 
 	// Database
 	DBHost     string
@@ -31,8 +38,17 @@ type Config struct {
 	UploadMaxSize int64
 
 	// Rate Limiting
-	RateLimitPerUser int
+	RateLimitPerUser int // weighted updates a user may send per minute, 0 disables the limit
 	RateLimitPerIP   int
+	FloodMaxStrikes  int // times a user may hit the limit before being muted, 0 never mutes
+	FloodMuteMinutes int
+
+	// Outbound messages; Telegram allows about 30 messages a second and 1 per chat
+	SendGlobalPerSecond int // messages sent per second across all chats
+	SendChatPerSecond   int // messages sent per second to one chat
+	SendMaxRetries      int // retries after network and 5xx errors
+	SendMaxRetryAfter   int // seconds a message may be held by 429s before it is dropped
+	BroadcastPerSecond  int // share of the global rate admin broadcasts may use, 0 uses the default
 
 	// Matchmaking
 	MatchTimeoutMinutes int
@@ -43,11 +59,36 @@ type Config struct {
 	// Game
 	DefaultCoins   int64
 	WinRewardCoins int64
+
+	// Diamonds
+	DiamondToCoinRate int64 // coins paid per exchanged diamond, 0 disables the exchange
+
+	// Coin ledger reconciliation
+	LedgerCheckHours int // hours between automatic ledger checks, 0 disables them
+
+	// Conversation sessions
+	SessionStore    string // "postgres" (default) or "memory"
+	SessionTTLHours int    // hours an idle session is kept, 0 keeps it until its flow ends
+
+	// Moderation
+	ReportEvidenceMessages int
+	ReportSuspendThreshold int
+	ReportSuspendHours     int
+
+	// Payments
+	PaymentProvider    string // "" (receipts only), "zarinpal" or "mock"
+	PaymentCallbackURL string // public base URL that reaches AppPort
+	ZarinpalMerchantID string
+	ZarinpalSandbox    bool
+
+	// Native Telegram invoices, paid with Telegram Stars
+	TelegramPaymentsEnabled bool
 }
 
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
 		BotToken:   getEnv("BOT_TOKEN", ""),
+		BotAPIURL:  strings.TrimSuffix(getEnv("BOT_API_URL", ""), "/"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "gamebot"),
@@ -58,6 +99,10 @@ func LoadConfig() (*Config, error) {
 		JWTSecret: getEnv("JWT_SECRET_KEY", ""),
 		AESKey:    getEnv("AES_ENCRYPTION_KEY", ""),
 
+		UpdateMode:    getEnv("UPDATE_MODE", "polling"),
+		WebhookURL:    strings.TrimSuffix(getEnv("WEBHOOK_URL", ""), "/"),
+		WebhookSecret: getEnv("WEBHOOK_SECRET", ""),
+
 		AppEnv:        getEnv("APP_ENV", "development"),
 		AppPort:       getEnv("APP_PORT", "8080"),
 		LogLevel:      getEnv("LOG_LEVEL", "info"),
@@ -65,6 +110,14 @@ func LoadConfig() (*Config, error) {
 
 		RateLimitPerUser: getEnvInt("RATE_LIMIT_PER_USER", 20),
 		RateLimitPerIP:   getEnvInt("RATE_LIMIT_PER_IP", 100),
+		FloodMaxStrikes:  getEnvInt("FLOOD_MAX_STRIKES", 3),
+		FloodMuteMinutes: getEnvInt("FLOOD_MUTE_MINUTES", 10),
+
+		SendGlobalPerSecond: getEnvInt("SEND_GLOBAL_PER_SECOND", 25),
+		SendChatPerSecond:   getEnvInt("SEND_CHAT_PER_SECOND", 1),
+		SendMaxRetries:      getEnvInt("SEND_MAX_RETRIES", 3),
+		SendMaxRetryAfter:   getEnvInt("SEND_MAX_RETRY_AFTER_SECONDS", 60),
+		BroadcastPerSecond:  getEnvInt("BROADCAST_PER_SECOND", 15),
 
 		MatchTimeoutMinutes: getEnvInt("MATCH_TIMEOUT_MINUTES", 5),
 		MatchCostCoins:      getEnvInt64("MATCH_COST_COINS", 5),
@@ -73,6 +126,24 @@ func LoadConfig() (*Config, error) {
 
 		DefaultCoins:   getEnvInt64("DEFAULT_COINS", 100),
 		WinRewardCoins: getEnvInt64("WIN_REWARD_COINS", 50),
+
+		DiamondToCoinRate: getEnvInt64("DIAMOND_TO_COIN_RATE", 40),
+
+		LedgerCheckHours: getEnvInt("LEDGER_CHECK_HOURS", 24),
+
+		SessionStore:    getEnv("SESSION_STORE", "postgres"),
+		SessionTTLHours: getEnvInt("SESSION_TTL_HOURS", 72),
+
+		ReportEvidenceMessages: getEnvInt("REPORT_EVIDENCE_MESSAGES", 20),
+		ReportSuspendThreshold: getEnvInt("REPORT_SUSPEND_THRESHOLD", 3),
+		ReportSuspendHours:     getEnvInt("REPORT_SUSPEND_HOURS", 72),
+
+		PaymentProvider:    getEnv("PAYMENT_PROVIDER", ""),
+		PaymentCallbackURL: strings.TrimSuffix(getEnv("PAYMENT_CALLBACK_URL", ""), "/"),
+		ZarinpalMerchantID: getEnv("ZARINPAL_MERCHANT_ID", ""),
+		ZarinpalSandbox:    getEnvBool("ZARINPAL_SANDBOX", false),
+
+		TelegramPaymentsEnabled: getEnvBool("TELEGRAM_PAYMENTS_ENABLED", false),
 	}
 
 	// Parse super admin telegram ID
@@ -97,6 +168,9 @@ func (c *Config) Validate() error {
 	if c.BotToken == "" {
 		return fmt.Errorf("BOT_TOKEN is required")
 	}
+	if c.BotAPIURL != "" && !strings.HasPrefix(c.BotAPIURL, "http://") && !strings.HasPrefix(c.BotAPIURL, "https://") {
+		return fmt.Errorf("BOT_API_URL must be an http or https URL")
+	}
 	if c.DBPassword == "" {
 		return fmt.Errorf("DB_PASSWORD is required")
 	}
@@ -112,6 +186,39 @@ func (c *Config) Validate() error {
 	if len(c.AESKey) != 32 {
 		return fmt.Errorf("AES_ENCRYPTION_KEY must be exactly 32 bytes")
 	}
+	if c.RateLimitPerUser < 0 {
+		return fmt.Errorf("RATE_LIMIT_PER_USER must not be negative")
+	}
+	if c.FloodMaxStrikes < 0 {
+		return fmt.Errorf("FLOOD_MAX_STRIKES must not be negative")
+	}
+	if c.FloodMuteMinutes < 0 {
+		return fmt.Errorf("FLOOD_MUTE_MINUTES must not be negative")
+	}
+	if c.SendGlobalPerSecond < 0 || c.SendChatPerSecond < 0 {
+		return fmt.Errorf("SEND_GLOBAL_PER_SECOND and SEND_CHAT_PER_SECOND must not be negative")
+	}
+	if c.SendMaxRetries < 0 || c.SendMaxRetryAfter < 0 {
+		return fmt.Errorf("SEND_MAX_RETRIES and SEND_MAX_RETRY_AFTER_SECONDS must not be negative")
+	}
+	if c.BroadcastPerSecond < 0 {
+		return fmt.Errorf("BROADCAST_PER_SECOND must not be negative")
+	}
+	if c.DiamondToCoinRate < 0 {
+		return fmt.Errorf("DIAMOND_TO_COIN_RATE must not be negative")
+	}
+	if c.LedgerCheckHours < 0 {
+		return fmt.Errorf("LEDGER_CHECK_HOURS must not be negative")
+	}
+	if err := c.validateUpdateMode(); err != nil {
+		return err
+	}
+	if err := c.validateSessions(); err != nil {
+		return err
+	}
+	if err := c.validatePayments(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -132,7 +239,82 @@ func (c *Config) ValidateProductionSecurity() error {
 	if c.SuperAdminTgID == 0 {
 		return fmt.Errorf("SUPER_ADMIN_TELEGRAM_ID must be set in production")
 	}
+	if c.PaymentProvider == "mock" {
+		return fmt.Errorf("PAYMENT_PROVIDER must not be 'mock' in production")
+	}
+
+	return nil
+}
+
+func (c *Config) validateUpdateMode() error {
+	switch c.UpdateMode {
+	case "", "polling":
+		return nil
+	case "webhook":
+	default:
+		return fmt.Errorf("unknown UPDATE_MODE %q", c.UpdateMode)
+	}
+
+	if !strings.HasPrefix(c.WebhookURL, "https://") {
+		return fmt.Errorf("WEBHOOK_URL must be an https URL when UPDATE_MODE is webhook")
+	}
+	if c.WebhookSecret == "" {
+		return fmt.Errorf("WEBHOOK_SECRET is required when UPDATE_MODE is webhook")
+	}
+	// Telegram only accepts 1-256 characters of A-Z, a-z, 0-9, _ and -
+	if len(c.WebhookSecret) > 256 {
+		return fmt.Errorf("WEBHOOK_SECRET must be at most 256 characters")
+	}
+	for _, r := range c.WebhookSecret {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return fmt.Errorf("WEBHOOK_SECRET may only contain letters, digits, '_' and '-'")
+		}
+	}
+	return nil
+}
+
+// GetBotAPIEndpoint returns the Bot API URL pattern, with the token and method left as %s
+func (c *Config) GetBotAPIEndpoint() string {
+	base := c.BotAPIURL
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	return base + "/bot%s/%s"
+}
+
+// IsWebhookMode reports whether updates are pushed by Telegram instead of polled
+func (c *Config) IsWebhookMode() bool {
+	return c.UpdateMode == "webhook"
+}
+
+func (c *Config) validateSessions() error {
+	switch c.SessionStore {
+	case "", "postgres", "memory":
+	default:
+		return fmt.Errorf("unknown SESSION_STORE %q", c.SessionStore)
+	}
+	if c.SessionTTLHours < 0 {
+		return fmt.Errorf("SESSION_TTL_HOURS must not be negative")
+	}
+	return nil
+}
+
+func (c *Config) validatePayments() error {
+	switch c.PaymentProvider {
+	case "":
+		return nil
+	case "zarinpal":
+		if c.ZarinpalMerchantID == "" {
+			return fmt.Errorf("ZARINPAL_MERCHANT_ID is required for the zarinpal payment provider")
+		}
+	case "mock":
+	default:
+		return fmt.Errorf("unknown PAYMENT_PROVIDER %q", c.PaymentProvider)
+	}
 
+	if c.PaymentCallbackURL == "" {
+		return fmt.Errorf("PAYMENT_CALLBACK_URL is required when PAYMENT_PROVIDER is set")
+	}
 	return nil
 }
 
@@ -147,6 +329,22 @@ func (c *Config) GetMatchTimeout() time.Duration {
 	return time.Duration(c.MatchTimeoutMinutes) * time.Minute
 }
 
+func (c *Config) GetReportSuspension() time.Duration {
+	return time.Duration(c.ReportSuspendHours) * time.Hour
+}
+
+func (c *Config) GetFloodMute() time.Duration {
+	return time.Duration(c.FloodMuteMinutes) * time.Minute
+}
+
+func (c *Config) GetSendMaxRetryAfter() time.Duration {
+	return time.Duration(c.SendMaxRetryAfter) * time.Second
+}
+
+func (c *Config) GetSessionTTL() time.Duration {
+	return time.Duration(c.SessionTTLHours) * time.Hour
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -171,3 +369,12 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
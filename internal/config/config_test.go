@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -186,6 +188,18 @@ func TestValidateProductionSecurity(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "Production with mock payment provider",
+			cfg: &Config{
+				AppEnv:          "production",
+				DBSSLMode:       "require",
+				JWTSecret:       "production_secret_key_different",
+				AESKey:          "production_aes_key_32_bytes!",
+				SuperAdminTgID:  123456789,
+				PaymentProvider: "mock",
+			},
+			shouldErr: true,
+		},
 		{
 			name: "Production without super admin",
 			cfg: &Config{
@@ -212,6 +226,306 @@ func TestValidateProductionSecurity(t *testing.T) {
 	}
 }
 
+func TestValidate_Payments(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		callbackURL string
+		merchantID  string
+		shouldErr   bool
+	}{
+		{
+			name:      "Receipts only",
+			shouldErr: false,
+		},
+		{
+			name:        "Zarinpal configured",
+			provider:    "zarinpal",
+			callbackURL: "https://bot.example.com",
+			merchantID:  "merchant",
+			shouldErr:   false,
+		},
+		{
+			name:        "Zarinpal without merchant ID",
+			provider:    "zarinpal",
+			callbackURL: "https://bot.example.com",
+			shouldErr:   true,
+		},
+		{
+			name:      "Mock without callback URL",
+			provider:  "mock",
+			shouldErr: true,
+		},
+		{
+			name:        "Unknown provider",
+			provider:    "paypal",
+			callbackURL: "https://bot.example.com",
+			shouldErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				BotToken:           "token",
+				DBPassword:         "password",
+				JWTSecret:          "this_is_a_test_secret_key_with_32_chars_minimum",
+				AESKey:             "12345678901234567890123456789012",
+				PaymentProvider:    tt.provider,
+				PaymentCallbackURL: tt.callbackURL,
+				ZarinpalMerchantID: tt.merchantID,
+			}
+
+			err := cfg.Validate()
+			if tt.shouldErr && err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Validate() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_DiamondToCoinRate(t *testing.T) {
+	for _, rate := range []int64{0, 40} {
+		cfg := &Config{
+			BotToken:          "token",
+			DBPassword:        "password",
+			JWTSecret:         "this_is_a_test_secret_key_with_32_chars_minimum",
+			AESKey:            "12345678901234567890123456789012",
+			DiamondToCoinRate: rate,
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() with rate %d unexpected error = %v", rate, err)
+		}
+	}
+
+	cfg := &Config{
+		BotToken:          "token",
+		DBPassword:        "password",
+		JWTSecret:         "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:            "12345678901234567890123456789012",
+		DiamondToCoinRate: -1,
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() with negative rate expected error, got nil")
+	}
+}
+
+func TestValidate_LedgerCheckHours(t *testing.T) {
+	cfg := &Config{
+		BotToken:         "token",
+		DBPassword:       "password",
+		JWTSecret:        "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:           "12345678901234567890123456789012",
+		LedgerCheckHours: -1,
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() with negative LEDGER_CHECK_HOURS expected error, got nil")
+	}
+
+	cfg.LedgerCheckHours = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with disabled ledger checks unexpected error = %v", err)
+	}
+}
+
+func TestValidate_FloodControl(t *testing.T) {
+	base := Config{
+		BotToken:   "token",
+		DBPassword: "password",
+		JWTSecret:  "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:     "12345678901234567890123456789012",
+	}
+
+	tests := []struct {
+		name    string
+		limit   int
+		strikes int
+		mute    int
+		wantErr bool
+	}{
+		{"defaults", 20, 3, 10, false},
+		{"limit disabled", 0, 0, 0, false},
+		{"negative limit", -1, 3, 10, true},
+		{"negative strikes", 20, -1, 10, true},
+		{"negative mute", 20, 3, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.RateLimitPerUser = tt.limit
+			cfg.FloodMaxStrikes = tt.strikes
+			cfg.FloodMuteMinutes = tt.mute
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_OutboundLimits(t *testing.T) {
+	base := Config{
+		BotToken:   "token",
+		DBPassword: "password",
+		JWTSecret:  "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:     "12345678901234567890123456789012",
+	}
+
+	tests := []struct {
+		name       string
+		global     int
+		chat       int
+		retries    int
+		retryAfter int
+		broadcast  int
+		wantErr    bool
+	}{
+		{"defaults", 25, 1, 3, 60, 15, false},
+		{"unset", 0, 0, 0, 0, 0, false},
+		{"negative global rate", -1, 1, 3, 60, 15, true},
+		{"negative chat rate", 25, -1, 3, 60, 15, true},
+		{"negative retries", 25, 1, -1, 60, 15, true},
+		{"negative retry after", 25, 1, 3, -1, 15, true},
+		{"negative broadcast rate", 25, 1, 3, 60, -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.SendGlobalPerSecond = tt.global
+			cfg.SendChatPerSecond = tt.chat
+			cfg.SendMaxRetries = tt.retries
+			cfg.SendMaxRetryAfter = tt.retryAfter
+			cfg.BroadcastPerSecond = tt.broadcast
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Sessions(t *testing.T) {
+	base := Config{
+		BotToken:   "token",
+		DBPassword: "password",
+		JWTSecret:  "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:     "12345678901234567890123456789012",
+	}
+
+	tests := []struct {
+		name    string
+		store   string
+		ttl     int
+		wantErr bool
+	}{
+		{"default store", "", 0, false},
+		{"postgres", "postgres", 72, false},
+		{"memory", "memory", 1, false},
+		{"unknown store", "redis", 72, true},
+		{"negative ttl", "postgres", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.SessionStore = tt.store
+			cfg.SessionTTLHours = tt.ttl
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_UpdateMode(t *testing.T) {
+	base := Config{
+		BotToken:   "token",
+		DBPassword: "password",
+		JWTSecret:  "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:     "12345678901234567890123456789012",
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		url     string
+		secret  string
+		wantErr bool
+	}{
+		{"default mode", "", "", "", false},
+		{"polling", "polling", "", "", false},
+		{"webhook", "webhook", "https://bot.example.com", "s3cret_Token-1", false},
+		{"unknown mode", "push", "", "", true},
+		{"webhook without url", "webhook", "", "s3cret", true},
+		{"webhook over http", "webhook", "http://bot.example.com", "s3cret", true},
+		{"webhook without secret", "webhook", "https://bot.example.com", "", true},
+		{"secret with invalid characters", "webhook", "https://bot.example.com", "not allowed!", true},
+		{"secret too long", "webhook", "https://bot.example.com", strings.Repeat("a", 257), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.UpdateMode = tt.mode
+			cfg.WebhookURL = tt.url
+			cfg.WebhookSecret = tt.secret
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_BotAPIURL(t *testing.T) {
+	base := Config{
+		BotToken:   "token",
+		DBPassword: "password",
+		JWTSecret:  "this_is_a_test_secret_key_with_32_chars_minimum",
+		AESKey:     "12345678901234567890123456789012",
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"default server", "", false},
+		{"local server", "http://127.0.0.1:8081", false},
+		{"self-hosted over https", "https://botapi.example.com", false},
+		{"missing scheme", "127.0.0.1:8081", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.BotAPIURL = tt.url
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetBotAPIEndpoint(t *testing.T) {
+	cfg := &Config{}
+	if got, want := cfg.GetBotAPIEndpoint(), "https://api.telegram.org/bot%s/%s"; got != want {
+		t.Errorf("GetBotAPIEndpoint() = %q, want %q", got, want)
+	}
+
+	cfg.BotAPIURL = "http://127.0.0.1:8081"
+	if got, want := cfg.GetBotAPIEndpoint(), "http://127.0.0.1:8081/bot%s/%s"; got != want {
+		t.Errorf("GetBotAPIEndpoint() = %q, want %q", got, want)
+	}
+}
+
 func TestGetDSN(t *testing.T) {
 	cfg := &Config{
 		DBHost:     "localhost",
@@ -242,3 +556,13 @@ func TestGetMatchTimeout(t *testing.T) {
 		t.Errorf("GetMatchTimeout() = %v, want %v", timeout, expected)
 	}
 }
+
+func TestGetReportSuspension(t *testing.T) {
+	cfg := &Config{
+		ReportSuspendHours: 72,
+	}
+
+	if got := cfg.GetReportSuspension(); got != 72*time.Hour {
+		t.Errorf("GetReportSuspension() = %v, want %v", got, 72*time.Hour)
+	}
+}
@@ -0,0 +1,289 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mroshb/game_bot/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Migrations live in migrations/ as NNNN_name.up.sql and NNNN_name.down.sql pairs.
+// Each one runs in its own transaction and is recorded in schema_migrations.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration and when it was applied, nil if it is pending
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	return parseMigrations(migrationFiles, "migrations")
+}
+
+// parseMigrations reads the migration pairs in dir. Every version needs both an up and
+// a down file so any change can be rolled back.
+func parseMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("unexpected file in migrations: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be positive", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrationStatus returns every known migration with when it was applied
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i] = MigrationState{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+	return states, nil
+}
+
+// PendingMigrations returns the migrations that haven't been applied yet
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	states, err := MigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, s := range states {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp applies the pending migrations in order and returns the ones it applied.
+// It stops at the first failure, leaving that migration unapplied.
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	if err := checkBaselined(db); err != nil {
+		return nil, err
+	}
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		logger.Info("Applying migration", "version", m.Version, "name", m.Name)
+		err := runMigration(db, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown rolls back the last steps applied migrations, newest first, and returns
+// the ones it rolled back
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	states, err := MigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(states) - 1; i >= 0 && len(done) < steps; i-- {
+		m := states[i].Migration
+		if states[i].AppliedAt == nil {
+			continue
+		}
+
+		logger.Info("Rolling back migration", "version", m.Version, "name", m.Name)
+		err := runMigration(db, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Baseline records every migration up to version as applied without running it. It is
+// for databases GORM AutoMigrate built before versioned migrations, which already have
+// that schema, and refuses to touch a database that has migration history.
+func Baseline(db *gorm.DB, version int) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > 0 {
+		return nil, fmt.Errorf("database already has %d applied migrations", len(applied))
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = runMigration(db, "", func(tx *sql.Tx) error {
+		for _, m := range migrations {
+			if m.Version > version {
+				break
+			}
+			if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("baseline failed: %w", err)
+	}
+	if len(done) == 0 {
+		return nil, fmt.Errorf("no migration at or below version %d", version)
+	}
+	return done, nil
+}
+
+// checkBaselined stops MigrateUp from recreating the tables of a database that
+// AutoMigrate built but that was never baselined
+func checkBaselined(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 && db.Migrator().HasTable("users") {
+		return fmt.Errorf("database has tables but no migration history; run `go run ./cmd/migrate baseline` first")
+	}
+	return nil
+}
+
+// appliedMigrations returns when each applied version was applied
+func appliedMigrations(db *gorm.DB) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	if !db.Migrator().HasTable("schema_migrations") {
+		return applied, nil
+	}
+
+	var rows []struct {
+		Version   int
+		AppliedAt time.Time
+	}
+	if err := db.Table("schema_migrations").Select("version, applied_at").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, r := range rows {
+		applied[r.Version] = r.AppliedAt
+	}
+	return applied, nil
+}
+
+// runMigration executes script and record in one transaction. It goes through
+// database/sql because the prepared statement cache can't run multi-statement scripts.
+func runMigration(db *gorm.DB, script string, record func(tx *sql.Tx) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if _, err := sqlDB.Exec(createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if script != "" {
+		if _, err := tx.Exec(script); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0010_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON t (c);")},
+		"m/0010_add_index.down.sql": {Data: []byte("DROP INDEX a;")},
+		"m/0002_create_t.up.sql":    {Data: []byte("CREATE TABLE t (c INT);")},
+		"m/0002_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+		"m/0001_initial.up.sql":     {Data: []byte("SELECT 1;")},
+		"m/0001_initial.down.sql":   {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := parseMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("parseMigrations() error = %v", err)
+	}
+
+	want := []struct {
+		version int
+		name    string
+	}{{1, "initial"}, {2, "create_t"}, {10, "add_index"}}
+	if len(migrations) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(migrations), len(want))
+	}
+	for i, w := range want {
+		if migrations[i].Version != w.version || migrations[i].Name != w.name {
+			t.Errorf("migration %d = %d_%s, want %d_%s", i, migrations[i].Version, migrations[i].Name, w.version, w.name)
+		}
+	}
+	if migrations[1].Up != "CREATE TABLE t (c INT);" || migrations[1].Down != "DROP TABLE t;" {
+		t.Errorf("migration 2 scripts = %q / %q", migrations[1].Up, migrations[1].Down)
+	}
+}
+
+func TestParseMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "Missing down",
+			files: fstest.MapFS{
+				"m/0001_initial.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "needs both",
+		},
+		{
+			name: "Unexpected file",
+			files: fstest.MapFS{
+				"m/0001_initial.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "unexpected file",
+		},
+		{
+			name: "Version reused",
+			files: fstest.MapFS{
+				"m/0001_initial.up.sql":   {Data: []byte("SELECT 1;")},
+				"m/0001_initial.down.sql": {Data: []byte("SELECT 1;")},
+				"m/0001_other.up.sql":     {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "two names",
+		},
+		{
+			name: "Version zero",
+			files: fstest.MapFS{
+				"m/0000_initial.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMigrations(tt.files, "m")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseMigrations() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	// Gaps usually mean a file was misnamed
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.Version, i+1)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+-- Migration: Drop the initial schema
+
+DROP TABLE IF EXISTS user_boosters;
+DROP TABLE IF EXISTS quiz_answers;
+DROP TABLE IF EXISTS quiz_rounds;
+DROP TABLE IF EXISTS quiz_matches;
+DROP TABLE IF EXISTS tod_action_logs;
+DROP TABLE IF EXISTS tod_judgment_logs;
+DROP TABLE IF EXISTS tod_player_stats;
+DROP TABLE IF EXISTS tod_turns;
+DROP TABLE IF EXISTS tod_games;
+DROP TABLE IF EXISTS tod_challenges;
+DROP TABLE IF EXISTS village_members;
+DROP TABLE IF EXISTS villages;
+DROP TABLE IF EXISTS game_participants;
+DROP TABLE IF EXISTS game_sessions;
+DROP TABLE IF EXISTS room_members;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS friendships;
+DROP TABLE IF EXISTS matchmaking_queue;
+DROP TABLE IF EXISTS match_sessions;
+DROP TABLE IF EXISTS coin_transactions;
+DROP TABLE IF EXISTS users;
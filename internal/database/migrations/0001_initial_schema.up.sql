@@ -0,0 +1,464 @@
+-- Migration: Initial schema
+-- The tables as GORM AutoMigrate created them before the later migrations were written.
+-- A database AutoMigrate built is at least at this version: record it with
+-- `go run ./cmd/migrate baseline` and then run `up`. The later migrations only create
+-- what is missing, so they also bring up databases built by newer AutoMigrate releases.
+
+CREATE TABLE users (
+    id BIGSERIAL,
+    telegram_id BIGINT NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    gender VARCHAR(10) NOT NULL,
+    age BIGINT NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    province VARCHAR(100),
+    biography TEXT,
+    likes BIGINT DEFAULT 0,
+    profile_photo VARCHAR(500),
+    coin_balance BIGINT NOT NULL DEFAULT 100,
+    diamonds BIGINT NOT NULL DEFAULT 0,
+    level BIGINT NOT NULL DEFAULT 1,
+    xp BIGINT NOT NULL DEFAULT 0,
+    wins BIGINT NOT NULL DEFAULT 0,
+    losses BIGINT NOT NULL DEFAULT 0,
+    draws BIGINT NOT NULL DEFAULT 0,
+    items_inventory TEXT DEFAULT '{}',
+    custom_avatar_id VARCHAR(500),
+    public_id VARCHAR(8),
+    referrer_id BIGINT DEFAULT 0,
+    latitude DECIMAL,
+    longitude DECIMAL,
+    status VARCHAR(20) DEFAULT 'offline',
+    last_daily_bonus TIMESTAMPTZ DEFAULT NULL,
+    daily_bonus_streak BIGINT NOT NULL DEFAULT 0,
+    last_activity TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_user_province_activity ON users (province, last_activity);
+CREATE INDEX idx_user_status_activity ON users (status, last_activity);
+CREATE INDEX idx_users_age ON users (age);
+CREATE INDEX idx_users_city ON users (city);
+CREATE INDEX idx_users_coin_balance ON users (coin_balance);
+CREATE INDEX idx_users_created_at ON users (created_at);
+CREATE INDEX idx_users_gender ON users (gender);
+CREATE INDEX idx_users_last_activity ON users (last_activity);
+CREATE INDEX idx_users_latitude ON users (latitude);
+CREATE INDEX idx_users_level ON users (level);
+CREATE INDEX idx_users_likes ON users (likes);
+CREATE INDEX idx_users_longitude ON users (longitude);
+CREATE UNIQUE INDEX idx_users_public_id ON users (public_id);
+CREATE INDEX idx_users_referrer_id ON users (referrer_id);
+CREATE UNIQUE INDEX idx_users_telegram_id ON users (telegram_id);
+CREATE INDEX idx_users_xp ON users (xp);
+
+CREATE TABLE coin_transactions (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    transaction_type VARCHAR(50) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_coin_transactions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_coin_transactions_created_at ON coin_transactions (created_at);
+CREATE INDEX idx_coin_transactions_transaction_type ON coin_transactions (transaction_type);
+CREATE INDEX idx_coin_transactions_user_id ON coin_transactions (user_id);
+
+CREATE TABLE match_sessions (
+    id BIGSERIAL,
+    user1_id BIGINT NOT NULL,
+    user2_id BIGINT NOT NULL,
+    started_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMPTZ,
+    timeout_at TIMESTAMPTZ,
+    status VARCHAR(20) DEFAULT 'active',
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_match_sessions_user1 FOREIGN KEY (user1_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_match_sessions_user2 FOREIGN KEY (user2_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_match_sessions_created_at ON match_sessions (created_at);
+CREATE INDEX idx_match_sessions_ended_at ON match_sessions (ended_at);
+CREATE INDEX idx_match_sessions_started_at ON match_sessions (started_at);
+CREATE INDEX idx_match_sessions_status ON match_sessions (status);
+CREATE INDEX idx_match_sessions_timeout_at ON match_sessions (timeout_at);
+CREATE INDEX idx_match_sessions_user1_id ON match_sessions (user1_id);
+CREATE INDEX idx_match_sessions_user2_id ON match_sessions (user2_id);
+
+CREATE TABLE matchmaking_queue (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    requested_gender VARCHAR(10),
+    min_age BIGINT,
+    max_age BIGINT,
+    city VARCHAR(100),
+    target_provinces TEXT,
+    game_type VARCHAR(20) DEFAULT 'chat',
+    coins_paid BIGINT DEFAULT 5,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_matchmaking_queue_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_matchmaking_queue_city ON matchmaking_queue (city);
+CREATE INDEX idx_matchmaking_queue_coins_paid ON matchmaking_queue (coins_paid);
+CREATE INDEX idx_matchmaking_queue_created_at ON matchmaking_queue (created_at);
+CREATE INDEX idx_matchmaking_queue_game_type ON matchmaking_queue (game_type);
+CREATE INDEX idx_matchmaking_queue_max_age ON matchmaking_queue (max_age);
+CREATE INDEX idx_matchmaking_queue_min_age ON matchmaking_queue (min_age);
+CREATE INDEX idx_matchmaking_queue_requested_gender ON matchmaking_queue (requested_gender);
+CREATE UNIQUE INDEX idx_matchmaking_queue_user_id ON matchmaking_queue (user_id);
+
+CREATE TABLE friendships (
+    id BIGSERIAL,
+    requester_id BIGINT NOT NULL,
+    addressee_id BIGINT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_friendships_requester FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_friendships_addressee FOREIGN KEY (addressee_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_friendship ON friendships (requester_id, addressee_id);
+
+CREATE TABLE questions (
+    id BIGSERIAL,
+    question_text TEXT NOT NULL,
+    question_type VARCHAR(20) NOT NULL,
+    category VARCHAR(50),
+    difficulty VARCHAR(20),
+    correct_answer TEXT,
+    options JSONB,
+    points BIGINT DEFAULT 10,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_questions_category ON questions (category);
+CREATE INDEX idx_questions_difficulty ON questions (difficulty);
+CREATE INDEX idx_questions_question_type ON questions (question_type);
+
+CREATE TABLE rooms (
+    id BIGSERIAL,
+    room_name VARCHAR(255) NOT NULL,
+    host_id BIGINT NOT NULL,
+    room_type VARCHAR(20) NOT NULL,
+    invite_code VARCHAR(10),
+    max_players BIGINT DEFAULT 10,
+    entry_fee BIGINT DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'waiting',
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_rooms_host FOREIGN KEY (host_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_rooms_host_id ON rooms (host_id);
+CREATE UNIQUE INDEX idx_rooms_invite_code ON rooms (invite_code);
+CREATE INDEX idx_rooms_room_type ON rooms (room_type);
+CREATE INDEX idx_rooms_status ON rooms (status);
+
+CREATE TABLE room_members (
+    id BIGSERIAL,
+    room_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    joined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    is_kicked BOOLEAN DEFAULT false,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_room_members_room FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    CONSTRAINT fk_room_members_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_room_member ON room_members (room_id, user_id);
+
+CREATE TABLE game_sessions (
+    id BIGSERIAL,
+    room_id BIGINT NOT NULL,
+    game_type VARCHAR(20) NOT NULL,
+    current_question_id BIGINT,
+    status VARCHAR(20) DEFAULT 'waiting',
+    turn_user_id BIGINT,
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_game_sessions_room FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    CONSTRAINT fk_game_sessions_current_question FOREIGN KEY (current_question_id) REFERENCES questions(id)
+);
+CREATE INDEX idx_game_sessions_current_question_id ON game_sessions (current_question_id);
+CREATE INDEX idx_game_sessions_room_id ON game_sessions (room_id);
+CREATE INDEX idx_game_sessions_turn_user_id ON game_sessions (turn_user_id);
+
+CREATE TABLE game_participants (
+    id BIGSERIAL,
+    game_session_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    score BIGINT DEFAULT 0,
+    turn_order BIGINT DEFAULT 0,
+    answers JSONB,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_game_participants_game_session FOREIGN KEY (game_session_id) REFERENCES game_sessions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_game_participants_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_game_participants_game_session_id ON game_participants (game_session_id);
+CREATE INDEX idx_game_participants_user_id ON game_participants (user_id);
+
+CREATE TABLE villages (
+    id BIGSERIAL,
+    name VARCHAR(255) NOT NULL,
+    creator_id BIGINT NOT NULL,
+    description TEXT,
+    xp BIGINT NOT NULL DEFAULT 0,
+    level BIGINT NOT NULL DEFAULT 1,
+    score BIGINT NOT NULL DEFAULT 0,
+    member_count BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_villages_creator FOREIGN KEY (creator_id) REFERENCES users(id)
+);
+CREATE UNIQUE INDEX idx_villages_name ON villages (name);
+
+CREATE TABLE village_members (
+    id BIGSERIAL,
+    village_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(20) DEFAULT 'member',
+    joined_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_village_members_village FOREIGN KEY (village_id) REFERENCES villages(id),
+    CONSTRAINT fk_village_members_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_village_member ON village_members (village_id, user_id);
+
+CREATE TABLE tod_challenges (
+    id BIGSERIAL,
+    type VARCHAR(10) NOT NULL,
+    text TEXT NOT NULL,
+    difficulty VARCHAR(20),
+    category VARCHAR(50),
+    gender_target VARCHAR(10),
+    relation_level VARCHAR(20),
+    proof_type VARCHAR(20) NOT NULL,
+    proof_hint TEXT,
+    xp_reward BIGINT DEFAULT 20,
+    coin_reward BIGINT DEFAULT 10,
+    times_used BIGINT DEFAULT 0,
+    acceptance_rate DECIMAL DEFAULT 0,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX idx_tod_challenges_category ON tod_challenges (category);
+CREATE INDEX idx_tod_challenges_difficulty ON tod_challenges (difficulty);
+CREATE INDEX idx_tod_challenges_gender_target ON tod_challenges (gender_target);
+CREATE INDEX idx_tod_challenges_is_active ON tod_challenges (is_active);
+CREATE INDEX idx_tod_challenges_relation_level ON tod_challenges (relation_level);
+CREATE INDEX idx_tod_challenges_type ON tod_challenges (type);
+
+CREATE TABLE tod_games (
+    id BIGSERIAL,
+    match_id BIGINT NOT NULL,
+    state VARCHAR(30) NOT NULL DEFAULT 'matchmaking',
+    current_turn_id BIGINT,
+    active_player_id BIGINT,
+    passive_player_id BIGINT,
+    current_round BIGINT DEFAULT 1,
+    max_rounds BIGINT DEFAULT 10,
+    turn_started_at TIMESTAMPTZ,
+    turn_deadline TIMESTAMPTZ,
+    warning_shown_at TIMESTAMPTZ,
+    allow_items BOOLEAN DEFAULT true,
+    difficulty_level VARCHAR(20) DEFAULT 'normal',
+    started_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    winner_id BIGINT,
+    end_reason VARCHAR(50),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tod_games_match FOREIGN KEY (match_id) REFERENCES match_sessions(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_tod_games_active_player_id ON tod_games (active_player_id);
+CREATE INDEX idx_tod_games_current_turn_id ON tod_games (current_turn_id);
+CREATE UNIQUE INDEX idx_tod_games_match_id ON tod_games (match_id);
+CREATE INDEX idx_tod_games_passive_player_id ON tod_games (passive_player_id);
+CREATE INDEX idx_tod_games_state ON tod_games (state);
+
+CREATE TABLE tod_turns (
+    id BIGSERIAL,
+    game_id BIGINT NOT NULL,
+    round_number BIGINT NOT NULL,
+    player_id BIGINT NOT NULL,
+    judge_id BIGINT NOT NULL,
+    choice VARCHAR(10),
+    chosen_at TIMESTAMPTZ,
+    challenge_id BIGINT,
+    challenge_text TEXT,
+    proof_type VARCHAR(20),
+    proof_data TEXT,
+    proof_submitted_at TIMESTAMPTZ,
+    judgment_result VARCHAR(20),
+    judgment_reason TEXT,
+    judged_at TIMESTAMPTZ,
+    item_used VARCHAR(20),
+    item_used_at TIMESTAMPTZ,
+    xp_awarded BIGINT DEFAULT 0,
+    coins_awarded BIGINT DEFAULT 0,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    timeout_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tod_turns_game FOREIGN KEY (game_id) REFERENCES tod_games(id) ON DELETE CASCADE,
+    CONSTRAINT fk_tod_turns_challenge FOREIGN KEY (challenge_id) REFERENCES tod_challenges(id)
+);
+CREATE INDEX idx_tod_turns_game_id ON tod_turns (game_id);
+CREATE INDEX idx_tod_turns_judge_id ON tod_turns (judge_id);
+CREATE INDEX idx_tod_turns_player_id ON tod_turns (player_id);
+
+CREATE TABLE tod_player_stats (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    games_played BIGINT DEFAULT 0,
+    games_won BIGINT DEFAULT 0,
+    games_lost BIGINT DEFAULT 0,
+    truths_chosen BIGINT DEFAULT 0,
+    dares_chosen BIGINT DEFAULT 0,
+    challenges_completed BIGINT DEFAULT 0,
+    challenges_failed BIGINT DEFAULT 0,
+    judgments_made BIGINT DEFAULT 0,
+    judgments_accepted BIGINT DEFAULT 0,
+    judgments_rejected BIGINT DEFAULT 0,
+    judge_score DECIMAL DEFAULT 100,
+    unfair_judgment_count BIGINT DEFAULT 0,
+    items_used BIGINT DEFAULT 0,
+    shields_owned BIGINT DEFAULT 1,
+    swaps_owned BIGINT DEFAULT 1,
+    mirrors_owned BIGINT DEFAULT 1,
+    avg_response_time BIGINT DEFAULT 0,
+    timeout_count BIGINT DEFAULT 0,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tod_player_stats_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_tod_player_stats_user_id ON tod_player_stats (user_id);
+
+CREATE TABLE tod_judgment_logs (
+    id BIGSERIAL,
+    turn_id BIGINT NOT NULL,
+    judge_id BIGINT NOT NULL,
+    player_id BIGINT NOT NULL,
+    result VARCHAR(20) NOT NULL,
+    proof_quality BIGINT DEFAULT 0,
+    is_suspicious BOOLEAN DEFAULT false,
+    suspicion_reason TEXT,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_tod_judgment_logs_turn FOREIGN KEY (turn_id) REFERENCES tod_turns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_tod_judgment_logs_judge FOREIGN KEY (judge_id) REFERENCES users(id),
+    CONSTRAINT fk_tod_judgment_logs_player FOREIGN KEY (player_id) REFERENCES users(id)
+);
+CREATE INDEX idx_tod_judgment_logs_is_suspicious ON tod_judgment_logs (is_suspicious);
+CREATE INDEX idx_tod_judgment_logs_judge_id ON tod_judgment_logs (judge_id);
+CREATE INDEX idx_tod_judgment_logs_player_id ON tod_judgment_logs (player_id);
+CREATE INDEX idx_tod_judgment_logs_turn_id ON tod_judgment_logs (turn_id);
+
+CREATE TABLE tod_action_logs (
+    id BIGSERIAL,
+    game_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    action_id VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX idx_tod_action_logs_action_id ON tod_action_logs (action_id);
+CREATE INDEX idx_tod_action_logs_game_id ON tod_action_logs (game_id);
+CREATE INDEX idx_tod_action_logs_user_id ON tod_action_logs (user_id);
+
+CREATE TABLE quiz_matches (
+    id BIGSERIAL,
+    user1_id BIGINT NOT NULL,
+    user2_id BIGINT NOT NULL,
+    current_round BIGINT DEFAULT 1,
+    current_question BIGINT DEFAULT 0,
+    state VARCHAR(50) DEFAULT 'waiting_category',
+    turn_user_id BIGINT,
+    user1_total_correct BIGINT DEFAULT 0,
+    user2_total_correct BIGINT DEFAULT 0,
+    user1_total_time_ms BIGINT DEFAULT 0,
+    user2_total_time_ms BIGINT DEFAULT 0,
+    user1_lights_msg_id BIGINT DEFAULT 0,
+    user2_lights_msg_id BIGINT DEFAULT 0,
+    winner_id BIGINT,
+    started_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_activity_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ,
+    timeout_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_quiz_matches_user1 FOREIGN KEY (user1_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_matches_user2 FOREIGN KEY (user2_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_quiz_matches_finished_at ON quiz_matches (finished_at);
+CREATE INDEX idx_quiz_matches_last_activity_at ON quiz_matches (last_activity_at);
+CREATE INDEX idx_quiz_matches_state ON quiz_matches (state);
+CREATE INDEX idx_quiz_matches_timeout_at ON quiz_matches (timeout_at);
+CREATE INDEX idx_quiz_matches_turn_user_id ON quiz_matches (turn_user_id);
+CREATE INDEX idx_quiz_matches_user1_id ON quiz_matches (user1_id);
+CREATE INDEX idx_quiz_matches_user2_id ON quiz_matches (user2_id);
+CREATE INDEX idx_quiz_matches_winner_id ON quiz_matches (winner_id);
+
+CREATE TABLE quiz_rounds (
+    id BIGSERIAL,
+    match_id BIGINT NOT NULL,
+    round_number BIGINT NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    chosen_by_user_id BIGINT NOT NULL,
+    user1_correct_count BIGINT DEFAULT 0,
+    user2_correct_count BIGINT DEFAULT 0,
+    user1_time_ms BIGINT DEFAULT 0,
+    user2_time_ms BIGINT DEFAULT 0,
+    question_ids TEXT,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_quiz_rounds_match FOREIGN KEY (match_id) REFERENCES quiz_matches(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_quiz_rounds_match_id ON quiz_rounds (match_id);
+
+CREATE TABLE quiz_answers (
+    id BIGSERIAL,
+    match_id BIGINT NOT NULL,
+    round_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    question_id BIGINT NOT NULL,
+    question_number BIGINT NOT NULL,
+    answer_index BIGINT,
+    is_correct BOOLEAN DEFAULT false,
+    time_taken_ms BIGINT NOT NULL,
+    booster_used VARCHAR(50),
+    answered_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_quiz_answers_match FOREIGN KEY (match_id) REFERENCES quiz_matches(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_answers_round FOREIGN KEY (round_id) REFERENCES quiz_rounds(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_answers_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_answers_question FOREIGN KEY (question_id) REFERENCES questions(id)
+);
+CREATE INDEX idx_quiz_answers_match_id ON quiz_answers (match_id);
+CREATE INDEX idx_quiz_answers_round_id ON quiz_answers (round_id);
+CREATE INDEX idx_quiz_answers_user_id ON quiz_answers (user_id);
+
+CREATE TABLE user_boosters (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    booster_type VARCHAR(50) NOT NULL,
+    quantity BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_boosters_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_user_booster_type ON user_boosters (user_id, booster_type);
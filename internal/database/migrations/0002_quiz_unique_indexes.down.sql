@@ -0,0 +1,4 @@
+-- Migration: Drop quiz uniqueness indexes
+
+DROP INDEX IF EXISTS idx_quiz_answers_unique;
+DROP INDEX IF EXISTS idx_quiz_rounds_match_round;
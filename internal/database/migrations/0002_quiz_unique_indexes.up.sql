@@ -0,0 +1,9 @@
+-- Migration: Quiz uniqueness and timeouts
+-- Formerly applied by hand with scripts/migrate_quiz_tables.go. A round is played once
+-- per match and every question of it is answered once per player.
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quiz_rounds_match_round ON quiz_rounds (match_id, round_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quiz_answers_unique ON quiz_answers (match_id, round_id, user_id, question_number);
+
+-- Matches from before timeouts were tracked would otherwise never expire
+UPDATE quiz_matches SET timeout_at = CURRENT_TIMESTAMP + INTERVAL '3 days' WHERE timeout_at IS NULL;
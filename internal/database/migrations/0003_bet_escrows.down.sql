@@ -0,0 +1,5 @@
+-- Migration: Drop bet escrows
+
+DROP INDEX IF EXISTS idx_matchmaking_queue_bet_amount;
+ALTER TABLE matchmaking_queue DROP COLUMN IF EXISTS bet_amount;
+DROP TABLE IF EXISTS bet_escrows;
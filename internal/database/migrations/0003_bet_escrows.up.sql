@@ -0,0 +1,22 @@
+-- Migration: Bet escrows
+-- Stakes of betting-mode quiz and ToD matches are held here until the game settles.
+
+CREATE TABLE IF NOT EXISTS bet_escrows (
+    id BIGSERIAL,
+    game_type VARCHAR(20) NOT NULL,
+    game_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'held',
+    payout BIGINT DEFAULT 0,
+    settled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_bet_escrows_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bet_escrow_game_user ON bet_escrows (game_type, game_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_bet_escrows_status ON bet_escrows (status);
+CREATE INDEX IF NOT EXISTS idx_bet_escrows_user_id ON bet_escrows (user_id);
+
+ALTER TABLE matchmaking_queue ADD COLUMN IF NOT EXISTS bet_amount BIGINT DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_matchmaking_queue_bet_amount ON matchmaking_queue (bet_amount);
@@ -0,0 +1,3 @@
+-- Migration: Drop user blocks
+
+DROP TABLE IF EXISTS user_blocks;
@@ -0,0 +1,13 @@
+-- Migration: User blocks
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id BIGSERIAL,
+    blocker_id BIGINT NOT NULL,
+    blocked_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_blocks_blocker FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_blocks_blocked FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_block ON user_blocks (blocker_id, blocked_id);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
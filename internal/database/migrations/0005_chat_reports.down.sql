@@ -0,0 +1,3 @@
+-- Migration: Drop chat reports
+
+DROP TABLE IF EXISTS chat_reports;
@@ -0,0 +1,21 @@
+-- Migration: Chat reports
+
+CREATE TABLE IF NOT EXISTS chat_reports (
+    id BIGSERIAL,
+    reporter_id BIGINT NOT NULL,
+    reported_id BIGINT NOT NULL,
+    match_id BIGINT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    evidence TEXT,
+    status VARCHAR(20) DEFAULT 'pending',
+    reviewer_tg_id BIGINT DEFAULT 0,
+    reviewed_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_chat_reports_reporter FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_chat_reports_reported FOREIGN KEY (reported_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_chat_reports_created_at ON chat_reports (created_at);
+CREATE INDEX IF NOT EXISTS idx_chat_reports_reported_id ON chat_reports (reported_id);
+CREATE INDEX IF NOT EXISTS idx_chat_reports_status ON chat_reports (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_reporter_match ON chat_reports (reporter_id, match_id);
@@ -0,0 +1,3 @@
+-- Migration: Drop user bans
+
+DROP TABLE IF EXISTS user_bans;
@@ -0,0 +1,28 @@
+-- Migration: User bans
+
+CREATE TABLE IF NOT EXISTS user_bans (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    reason VARCHAR(255),
+    expires_at TIMESTAMPTZ,
+    issued_by_tg_id BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_bans_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_ban_user_scope ON user_bans (user_id, scope);
+CREATE INDEX IF NOT EXISTS idx_user_bans_expires_at ON user_bans (expires_at);
+
+-- Suspensions briefly lived on the users table; carry running ones over as global bans
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'suspended_until') THEN
+        INSERT INTO user_bans (user_id, scope, reason, expires_at, issued_by_tg_id, created_at)
+        SELECT id, 'global', COALESCE(suspension_reason, ''), suspended_until, 0, CURRENT_TIMESTAMP
+        FROM users WHERE suspended_until > CURRENT_TIMESTAMP;
+
+        ALTER TABLE users DROP COLUMN suspended_until, DROP COLUMN IF EXISTS suspension_reason;
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+-- Migration: Drop purchase orders
+
+DROP TABLE IF EXISTS purchase_orders;
@@ -0,0 +1,20 @@
+-- Migration: Purchase orders
+-- Coin purchases paid by card-to-card transfer, waiting for admin review of the receipt.
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    package_id BIGINT NOT NULL,
+    coins BIGINT NOT NULL,
+    price_toman BIGINT NOT NULL,
+    receipt_file_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    reviewer_tg_id BIGINT DEFAULT 0,
+    reviewed_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_purchase_orders_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_created_at ON purchase_orders (created_at);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders (status);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_user_id ON purchase_orders (user_id);
@@ -0,0 +1,8 @@
+-- Migration: Drop gateway purchase orders
+
+UPDATE purchase_orders SET receipt_file_id = '' WHERE receipt_file_id IS NULL;
+DROP INDEX IF EXISTS idx_order_provider_authority;
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS ref_id;
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS authority;
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS provider;
+ALTER TABLE purchase_orders ALTER COLUMN receipt_file_id SET NOT NULL;
@@ -0,0 +1,8 @@
+-- Migration: Gateway purchase orders
+-- Orders paid through an online provider carry the provider's invoice instead of a receipt.
+
+ALTER TABLE purchase_orders ALTER COLUMN receipt_file_id DROP NOT NULL;
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS provider VARCHAR(20) DEFAULT '';
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS authority VARCHAR(100);
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS ref_id VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_order_provider_authority ON purchase_orders (provider, authority);
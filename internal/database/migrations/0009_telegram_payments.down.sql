@@ -0,0 +1,3 @@
+-- Migration: Drop Telegram payments
+
+DROP TABLE IF EXISTS telegram_payments;
@@ -0,0 +1,25 @@
+-- Migration: Telegram payments
+-- Telegram Stars payments, kept so a charge is credited once and can be refunded.
+
+CREATE TABLE IF NOT EXISTS telegram_payments (
+    id BIGSERIAL,
+    telegram_payment_charge_id VARCHAR(255) NOT NULL,
+    provider_payment_charge_id VARCHAR(255),
+    user_id BIGINT NOT NULL,
+    product_kind VARCHAR(20) NOT NULL,
+    product_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    total_amount BIGINT NOT NULL,
+    payload VARCHAR(128),
+    status VARCHAR(20) DEFAULT 'paid',
+    refunded_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_telegram_payments_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_telegram_payments_created_at ON telegram_payments (created_at);
+CREATE INDEX IF NOT EXISTS idx_telegram_payments_status ON telegram_payments (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_telegram_payments_telegram_payment_charge_id ON telegram_payments (telegram_payment_charge_id);
+CREATE INDEX IF NOT EXISTS idx_telegram_payments_user_id ON telegram_payments (user_id);
+CREATE INDEX IF NOT EXISTS idx_tg_payment_product ON telegram_payments (product_kind, product_id);
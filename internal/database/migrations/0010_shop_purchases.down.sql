@@ -0,0 +1,3 @@
+-- Migration: Drop shop purchases
+
+DROP TABLE IF EXISTS shop_purchases;
@@ -0,0 +1,16 @@
+-- Migration: Shop purchases
+
+CREATE TABLE IF NOT EXISTS shop_purchases (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    item_id VARCHAR(50) NOT NULL,
+    quantity BIGINT NOT NULL,
+    currency VARCHAR(20) NOT NULL,
+    price BIGINT NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_shop_purchases_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_shop_purchases_created_at ON shop_purchases (created_at);
+CREATE INDEX IF NOT EXISTS idx_shop_purchases_item_id ON shop_purchases (item_id);
+CREATE INDEX IF NOT EXISTS idx_shop_purchases_user_id ON shop_purchases (user_id);
@@ -0,0 +1,32 @@
+-- Migration: Drop inventory items
+-- ToD items go back to the tod_player_stats counters and everything else to
+-- user_boosters; items_inventory comes back empty.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS items_inventory TEXT DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS user_boosters (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    booster_type VARCHAR(50) NOT NULL,
+    quantity BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_user_boosters_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_booster_type ON user_boosters (user_id, booster_type);
+
+INSERT INTO user_boosters (user_id, booster_type, quantity, created_at, updated_at)
+SELECT user_id, item_type, quantity, created_at, updated_at
+FROM inventory_items WHERE item_type NOT IN ('shield', 'swap', 'mirror') AND quantity > 0
+ON CONFLICT (user_id, booster_type) DO UPDATE SET quantity = user_boosters.quantity + EXCLUDED.quantity;
+
+ALTER TABLE tod_player_stats ADD COLUMN IF NOT EXISTS shields_owned BIGINT DEFAULT 1;
+ALTER TABLE tod_player_stats ADD COLUMN IF NOT EXISTS swaps_owned BIGINT DEFAULT 1;
+ALTER TABLE tod_player_stats ADD COLUMN IF NOT EXISTS mirrors_owned BIGINT DEFAULT 1;
+UPDATE tod_player_stats s SET
+    shields_owned = COALESCE((SELECT quantity FROM inventory_items i WHERE i.user_id = s.user_id AND i.item_type = 'shield'), 0),
+    swaps_owned = COALESCE((SELECT quantity FROM inventory_items i WHERE i.user_id = s.user_id AND i.item_type = 'swap'), 0),
+    mirrors_owned = COALESCE((SELECT quantity FROM inventory_items i WHERE i.user_id = s.user_id AND i.item_type = 'mirror'), 0);
+
+DROP TABLE IF EXISTS inventory_items;
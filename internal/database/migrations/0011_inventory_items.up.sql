@@ -0,0 +1,70 @@
+-- Migration: Inventory items
+-- Boosters, ToD items and the users.items_inventory JSON used to be three stores. Their
+-- items are added on top of whatever inventory_items already holds and the old stores
+-- are dropped.
+
+CREATE TABLE IF NOT EXISTS inventory_items (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    item_type VARCHAR(50) NOT NULL,
+    quantity BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_inventory_items_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_user_item ON inventory_items (user_id, item_type);
+
+DO $$
+DECLARE
+    u RECORD;
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'items_inventory') THEN
+        FOR u IN SELECT id, items_inventory FROM users
+                 WHERE items_inventory IS NOT NULL AND items_inventory NOT IN ('', '{}') LOOP
+            BEGIN
+                INSERT INTO inventory_items (user_id, item_type, quantity, created_at, updated_at)
+                SELECT u.id, legacy.item_type, SUM(j.value::BIGINT), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+                FROM json_each_text(u.items_inventory::JSON) j
+                JOIN (VALUES ('shield', 'shield'), ('swap', 'swap'), ('5050', 'remove_2_options'), ('freeze', 'freeze'))
+                    AS legacy (json_key, item_type) ON legacy.json_key = j.key
+                WHERE j.value::BIGINT > 0
+                GROUP BY legacy.item_type
+                ON CONFLICT (user_id, item_type) DO UPDATE SET quantity = inventory_items.quantity + EXCLUDED.quantity;
+            EXCEPTION WHEN invalid_text_representation OR invalid_parameter_value OR numeric_value_out_of_range THEN
+                RAISE WARNING 'Skipping unreadable items_inventory of user %: %', u.id, SQLERRM;
+            END;
+        END LOOP;
+
+        ALTER TABLE users DROP COLUMN items_inventory;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.tables
+               WHERE table_schema = current_schema() AND table_name = 'user_boosters') THEN
+        INSERT INTO inventory_items (user_id, item_type, quantity, created_at, updated_at)
+        SELECT user_id, booster_type, quantity, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+        FROM user_boosters WHERE quantity > 0
+        ON CONFLICT (user_id, item_type) DO UPDATE SET quantity = inventory_items.quantity + EXCLUDED.quantity;
+
+        DROP TABLE user_boosters;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'tod_player_stats' AND column_name = 'shields_owned') THEN
+        INSERT INTO inventory_items (user_id, item_type, quantity, created_at, updated_at)
+        SELECT user_id, item_type, quantity, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+        FROM (
+            SELECT user_id, 'shield' AS item_type, shields_owned AS quantity FROM tod_player_stats
+            UNION ALL
+            SELECT user_id, 'swap', swaps_owned FROM tod_player_stats
+            UNION ALL
+            SELECT user_id, 'mirror', mirrors_owned FROM tod_player_stats
+        ) counters
+        WHERE quantity > 0
+        ON CONFLICT (user_id, item_type) DO UPDATE SET quantity = inventory_items.quantity + EXCLUDED.quantity;
+
+        ALTER TABLE tod_player_stats DROP COLUMN shields_owned, DROP COLUMN swaps_owned, DROP COLUMN mirrors_owned;
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+-- Migration: Drop the diamond ledger
+
+DROP TABLE IF EXISTS diamond_transactions;
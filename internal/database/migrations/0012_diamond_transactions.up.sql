@@ -0,0 +1,21 @@
+-- Migration: Diamond ledger
+-- Diamonds were credited before they had a ledger; balances of accounts without any
+-- ledger entry are logged once as opening entries.
+
+CREATE TABLE IF NOT EXISTS diamond_transactions (
+    id BIGSERIAL,
+    user_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    transaction_type VARCHAR(50) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_diamond_transactions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_diamond_transactions_created_at ON diamond_transactions (created_at);
+CREATE INDEX IF NOT EXISTS idx_diamond_transactions_transaction_type ON diamond_transactions (transaction_type);
+CREATE INDEX IF NOT EXISTS idx_diamond_transactions_user_id ON diamond_transactions (user_id);
+
+INSERT INTO diamond_transactions (user_id, amount, transaction_type, description, created_at)
+SELECT u.id, u.diamonds, 'opening_balance', 'موجودی قبلی', CURRENT_TIMESTAMP FROM users u
+WHERE u.diamonds > 0 AND NOT EXISTS (SELECT 1 FROM diamond_transactions d WHERE d.user_id = u.id);
@@ -0,0 +1,5 @@
+-- Migration: Keep coin opening balances
+-- Registration records the same opening entries, so the backfilled ones can't be told
+-- apart and are kept. Reconciliation needs them either way.
+
+SELECT 1;
@@ -0,0 +1,9 @@
+-- Migration: Coin opening balances
+-- Reconciliation starts from each account's opening_balance entry. Accounts opened
+-- before registration recorded one started with the registration gift
+-- (models.StartingCoins); anything else in their balance is drift for the ledger check
+-- to report.
+
+INSERT INTO coin_transactions (user_id, amount, transaction_type, description, created_at)
+SELECT u.id, 100, 'opening_balance', 'هدیه عضویت', u.created_at FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM coin_transactions c WHERE c.user_id = u.id AND c.transaction_type = 'opening_balance');
@@ -0,0 +1,4 @@
+-- Migration: Drop coin operation idempotency keys
+
+DROP INDEX IF EXISTS idx_coin_transactions_idempotency_key;
+ALTER TABLE coin_transactions DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,5 @@
+-- Migration: Coin operation idempotency keys
+-- A replayed operation finds its key already in the ledger and is a no-op.
+
+ALTER TABLE coin_transactions ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(191);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coin_transactions_idempotency_key ON coin_transactions (idempotency_key);
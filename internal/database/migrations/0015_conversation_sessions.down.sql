@@ -0,0 +1,3 @@
+-- Migration: Drop conversation sessions
+
+DROP TABLE IF EXISTS conversation_sessions;
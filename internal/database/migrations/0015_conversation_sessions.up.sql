@@ -0,0 +1,11 @@
+-- Migration: Conversation sessions
+
+CREATE TABLE IF NOT EXISTS conversation_sessions (
+    telegram_id BIGINT,
+    state VARCHAR(64),
+    data TEXT,
+    expires_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (telegram_id)
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_sessions_expires_at ON conversation_sessions (expires_at);
@@ -0,0 +1,4 @@
+-- Migration: Drop unreachable users
+
+DROP INDEX IF EXISTS idx_users_unreachable_at;
+ALTER TABLE users DROP COLUMN IF EXISTS unreachable_at;
@@ -0,0 +1,5 @@
+-- Migration: Unreachable users
+-- Set while a user has the bot blocked or their account is deleted.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS unreachable_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_users_unreachable_at ON users (unreachable_at);
@@ -0,0 +1,3 @@
+-- Migration: Drop broadcasts
+
+DROP TABLE IF EXISTS broadcasts;
@@ -0,0 +1,31 @@
+-- Migration: Broadcasts
+
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id BIGSERIAL,
+    created_by_tg_id BIGINT NOT NULL,
+    text TEXT,
+    entities TEXT,
+    media_type VARCHAR(20),
+    media_file_id VARCHAR(255),
+    buttons TEXT,
+    segment VARCHAR(20) NOT NULL,
+    province VARCHAR(100),
+    min_level BIGINT DEFAULT 0,
+    max_level BIGINT DEFAULT 0,
+    active_days BIGINT DEFAULT 0,
+    village_id BIGINT DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'draft',
+    progress_message_id BIGINT DEFAULT 0,
+    last_user_id BIGINT DEFAULT 0,
+    total BIGINT DEFAULT 0,
+    delivered BIGINT DEFAULT 0,
+    failed BIGINT DEFAULT 0,
+    blocked BIGINT DEFAULT 0,
+    started_at TIMESTAMPTZ DEFAULT NULL,
+    finished_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_broadcasts_created_at ON broadcasts (created_at);
+CREATE INDEX IF NOT EXISTS idx_broadcasts_status ON broadcasts (status);
@@ -0,0 +1,4 @@
+-- Migration: Drop shop purchase idempotency
+
+DROP INDEX IF EXISTS idx_shop_purchases_idempotency_key;
+ALTER TABLE shop_purchases DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,6 @@
+-- Migration: Shop purchase idempotency
+-- A replayed buy button must not charge twice. Diamond prices have no keyed ledger
+-- entry to dedupe on, so the purchase itself carries the key.
+
+ALTER TABLE shop_purchases ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(191);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_purchases_idempotency_key ON shop_purchases (idempotency_key);
@@ -0,0 +1,6 @@
+-- Migration: Drop Telegram payment review columns
+
+DELETE FROM telegram_payments WHERE user_id IS NULL;
+ALTER TABLE telegram_payments DROP COLUMN IF EXISTS review_note;
+ALTER TABLE telegram_payments DROP COLUMN IF EXISTS payer_tg_id;
+ALTER TABLE telegram_payments ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,8 @@
+-- Migration: Telegram payments needing review
+-- A paid invoice that can't be credited (unknown product, unknown payer, failed write) is
+-- still stored by its charge ID so an admin can credit or refund it. The payer's Telegram ID
+-- is kept because such a payment may have no user.
+
+ALTER TABLE telegram_payments ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE telegram_payments ADD COLUMN IF NOT EXISTS payer_tg_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE telegram_payments ADD COLUMN IF NOT EXISTS review_note VARCHAR(255);
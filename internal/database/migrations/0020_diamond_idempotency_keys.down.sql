@@ -0,0 +1,4 @@
+-- Migration: Drop diamond operation idempotency keys
+
+DROP INDEX IF EXISTS idx_diamond_transactions_idempotency_key;
+ALTER TABLE diamond_transactions DROP COLUMN IF EXISTS idempotency_key;
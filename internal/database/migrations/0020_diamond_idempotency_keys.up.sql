@@ -0,0 +1,5 @@
+-- Migration: Diamond operation idempotency keys
+-- A replayed operation finds its key already in the ledger and is a no-op, as with coins.
+
+ALTER TABLE diamond_transactions ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(191);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_diamond_transactions_idempotency_key ON diamond_transactions (idempotency_key);
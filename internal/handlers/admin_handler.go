@@ -0,0 +1,616 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// ADMIN PANEL (Super admin only)
+// ========================================
+
+// Admin States
+const (
+	StateAdminLookup        = "admin_lookup"
+	StateAdminCoinAmount    = "admin_coin_amount"
+	StateAdminDiamondAmount = "admin_diamond_amount"
+)
+
+// AdminTransactionsLimit is the number of transactions shown on a user's history
+const AdminTransactionsLimit = 20
+
+// AdminBanDurations are the ban lengths offered to admins, in hours (0 = permanent)
+var AdminBanDurations = []int{1, 24, 72, 168, 720, 0}
+
+// adminBanScopes lists ban scopes in the order they are offered to admins
+var adminBanScopes = []string{
+	models.BanScopeGlobal,
+	models.BanScopeChat,
+	models.BanScopeTod,
+	models.BanScopeQuiz,
+	models.BanScopeRooms,
+}
+
+// isSuperAdmin reports whether a Telegram user is the configured super admin
+func (h *HandlerManager) isSuperAdmin(userID int64) bool {
+	return h.Config.SuperAdminTgID != 0 && userID == h.Config.SuperAdminTgID
+}
+
+// ShowAdminPanel shows live counters and the admin actions
+func (h *HandlerManager) ShowAdminPanel(userID int64, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	msg := "🔧 پنل مدیریت\n━━━━━━━━━━━━━━\n" + h.formatAdminStats()
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔍 جستجوی کاربر", "adm_lookup"),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 بروزرسانی آمار", "adm_stats"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚩 صف گزارش‌ها", "reports_page_0"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📢 ارسال همگانی", "adm_bc_new"),
+			tgbotapi.NewInlineKeyboardButtonData("📋 ارسال‌های اخیر", "adm_bc_list"),
+		),
+	)
+
+	bot.SendMessage(userID, msg, keyboard)
+}
+
+// formatAdminStats renders the live counters of the admin panel
+func (h *HandlerManager) formatAdminStats() string {
+	totalUsers, _ := h.UserRepo.CountUsers()
+	onlineUsers, _ := h.UserRepo.CountOnlineUsers()
+	activeMatches, _ := h.MatchRepo.CountActiveMatches()
+	activeQuiz, _ := h.QuizMatchRepo.CountActiveQuizMatches()
+	activeTod, _ := h.TodRepo.CountActiveGames()
+
+	queueSizes, err := h.MatchRepo.GetQueueSizes()
+	if err != nil {
+		logger.Error("Failed to get queue sizes", "error", err)
+	}
+	var queueTotal int64
+	for _, size := range queueSizes {
+		queueTotal += size
+	}
+
+	pendingReports, _ := h.ReportRepo.CountPendingReports()
+	pendingOrders, _ := h.PurchaseRepo.CountPendingOrders()
+
+	msg := "📊 آمار زنده:\n\n"
+	msg += fmt.Sprintf("👥 کل کاربران: %d\n", totalUsers)
+	msg += fmt.Sprintf("🟢 کاربران آنلاین: %d\n", onlineUsers)
+	msg += fmt.Sprintf("⏳ صف جستجو: %d (چت: %d | کوییز: %d | جرعت: %d)\n",
+		queueTotal, queueSizes[models.GameTypeChat], queueSizes[models.GameTypeQuiz], queueSizes[models.GameTypeTod])
+	msg += fmt.Sprintf("💬 چت‌های فعال: %d\n", activeMatches)
+	msg += fmt.Sprintf("🧠 بازی‌های کوییز فعال: %d\n", activeQuiz)
+	msg += fmt.Sprintf("🔥 بازی‌های جرعت فعال: %d\n", activeTod)
+	msg += fmt.Sprintf("🚩 گزارش‌های در انتظار: %d\n", pendingReports)
+	msg += fmt.Sprintf("🧾 سفارش‌های خرید در انتظار: %d\n", pendingOrders)
+	msg += fmt.Sprintf("\n🕐 %s", time.Now().Format("2006/01/02 15:04:05"))
+
+	return msg
+}
+
+// StartAdminLookup asks the admin for a user's PublicID or Telegram ID
+func (h *HandlerManager) StartAdminLookup(userID int64, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	session.State = StateAdminLookup
+	bot.SendMessage(userID, "🔍 آیدی عمومی (PublicID) یا آیدی عددی تلگرام کاربر رو بفرست:\n\nبرای لغو /cancel رو بزن.", nil)
+}
+
+// HandleAdminInput handles text input while the admin is in one of the admin states
+func (h *HandlerManager) HandleAdminInput(message *tgbotapi.Message, session *UserSession, bot BotInterface) {
+	userID := message.From.ID
+	if !h.isSuperAdmin(userID) {
+		session.State = ""
+		return
+	}
+
+	switch session.State {
+	case StateAdminLookup:
+		h.handleAdminLookup(userID, message.Text, session, bot)
+	case StateAdminCoinAmount:
+		h.handleAdminCoinAmount(userID, message.Text, MessageRequestID(message), session, bot)
+	case StateAdminDiamondAmount:
+		h.handleAdminDiamondAmount(userID, message.Text, MessageRequestID(message), session, bot)
+	case StateAdminBroadcastContent:
+		h.handleBroadcastContent(message, session, bot)
+	case StateAdminBroadcastButtons:
+		h.handleBroadcastButtons(userID, message.Text, session, bot)
+	case StateAdminBroadcastTarget:
+		h.handleBroadcastTarget(userID, message.Text, session, bot)
+	}
+}
+
+// handleAdminLookup finds a user by PublicID or Telegram ID
+func (h *HandlerManager) handleAdminLookup(userID int64, input string, session *UserSession, bot BotInterface) {
+	query := strings.TrimPrefix(strings.TrimSpace(utils.NormalizePersianNumbers(input)), "/user_")
+	if query == "" {
+		bot.SendMessage(userID, "⚠️ لطفاً آیدی کاربر رو بفرست.", nil)
+		return
+	}
+
+	var target *models.User
+	if tgID, err := strconv.ParseInt(query, 10, 64); err == nil {
+		target, _ = h.UserRepo.GetUserByTelegramID(tgID)
+	}
+	if target == nil {
+		target, _ = h.UserRepo.GetUserByPublicID(query)
+	}
+
+	if target == nil {
+		bot.SendMessage(userID, "❌ کاربری با این آیدی پیدا نشد! دوباره امتحان کن یا /cancel رو بزن.", nil)
+		return
+	}
+
+	session.State = ""
+	h.ShowAdminUserCard(userID, target.ID, bot)
+}
+
+// ShowAdminUserCard shows a user's account details and the admin actions for them
+func (h *HandlerManager) ShowAdminUserCard(userID int64, targetUserID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	gender := "👨 پسر"
+	if target.Gender == models.GenderFemale {
+		gender = "👩 دختر"
+	}
+
+	msg := fmt.Sprintf("👤 %s\n━━━━━━━━━━━━━━\n", target.FullName)
+	msg += fmt.Sprintf("🆔 آیدی داخلی: %d\n", target.ID)
+	msg += fmt.Sprintf("🔗 آیدی عمومی: /user_%s\n", target.PublicID)
+	msg += fmt.Sprintf("📱 آیدی تلگرام: %d\n", target.TelegramID)
+	msg += fmt.Sprintf("%s | %d سال | %s، %s\n", gender, target.Age, target.City, target.Province)
+	msg += fmt.Sprintf("⭐️ سطح %d (%d XP)\n", target.Level, target.XP)
+	msg += fmt.Sprintf("💰 سکه: %d | 💎 الماس: %d\n", target.CoinBalance, target.Diamonds)
+	msg += fmt.Sprintf("🏆 برد: %d | باخت: %d | مساوی: %d\n", target.Wins, target.Losses, target.Draws)
+	msg += fmt.Sprintf("📶 وضعیت: %s\n", target.Status)
+	msg += fmt.Sprintf("📅 عضویت: %s\n", target.CreatedAt.Format("2006/01/02"))
+	msg += fmt.Sprintf("🕐 آخرین فعالیت: %s\n", target.LastActivity.Format("2006/01/02 15:04"))
+
+	confirmed, _ := h.ReportRepo.CountConfirmedReports(target.ID)
+	msg += fmt.Sprintf("🚩 گزارش‌های تایید شده: %d\n", confirmed)
+
+	bans, err := h.BanRepo.GetActiveBans(target.ID)
+	if err != nil {
+		logger.Error("Failed to get bans", "user_id", target.ID, "error", err)
+	}
+	if len(bans) > 0 {
+		msg += "\n⛔️ محرومیت‌های فعال:\n"
+		for _, ban := range bans {
+			until := "دائمی"
+			if !ban.IsPermanent() {
+				until = ban.ExpiresAt.Format("2006/01/02 15:04")
+			}
+			msg += fmt.Sprintf("▫️ %s تا %s", models.BanScopeTitles[ban.Scope], until)
+			if ban.Reason != "" {
+				msg += fmt.Sprintf(" (%s)", ban.Reason)
+			}
+			msg += "\n"
+		}
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💰 تغییر سکه", fmt.Sprintf("adm_coins_%d", target.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("💎 تغییر الماس", fmt.Sprintf("adm_dia_%d", target.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📜 تراکنش‌ها", fmt.Sprintf("adm_tx_%d", target.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⛔️ محروم کردن", fmt.Sprintf("adm_ban_%d", target.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ رفع محرومیت", fmt.Sprintf("adm_unban_%d", target.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👁 مشاهده پروفایل", fmt.Sprintf("adm_profile_%d", target.ID)),
+		),
+	)
+
+	bot.SendMessage(userID, msg, keyboard)
+}
+
+// ShowAdminUserProfile shows a user's public profile to the admin
+func (h *HandlerManager) ShowAdminUserProfile(userID int64, targetUserID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	h.ShowProfile(userID, target, bot)
+}
+
+// ShowAdminTransactions shows a user's latest coin transactions
+func (h *HandlerManager) ShowAdminTransactions(userID int64, targetUserID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	transactions, err := h.CoinRepo.GetTransactionHistory(target.ID, AdminTransactionsLimit)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت تراکنش‌ها!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("📜 تراکنش‌های %s\n💰 موجودی: %d سکه\n━━━━━━━━━━━━━━\n", target.FullName, target.CoinBalance)
+	if len(transactions) == 0 {
+		msg += "هنوز تراکنشی ثبت نشده."
+	}
+	for _, tx := range transactions {
+		sign := "+"
+		if tx.Amount < 0 {
+			sign = ""
+		}
+		msg += fmt.Sprintf("%s | %s%d | %s\n%s\n\n", tx.CreatedAt.Format("01/02 15:04"), sign, tx.Amount, tx.TransactionType, tx.Description)
+	}
+
+	diamondTxs, err := h.DiamondRepo.GetTransactionHistory(target.ID, AdminTransactionsLimit)
+	if err != nil {
+		logger.Error("Failed to get diamond transactions", "user_id", target.ID, "error", err)
+	}
+	if len(diamondTxs) > 0 {
+		msg += fmt.Sprintf("━━━━━━━━━━━━━━\n💎 موجودی: %d الماس\n\n", target.Diamonds)
+		for _, tx := range diamondTxs {
+			sign := "+"
+			if tx.Amount < 0 {
+				sign = ""
+			}
+			msg += fmt.Sprintf("%s | %s%d💎 | %s\n%s\n\n", tx.CreatedAt.Format("01/02 15:04"), sign, tx.Amount, tx.TransactionType, tx.Description)
+		}
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به کاربر", fmt.Sprintf("adm_user_%d", target.ID)),
+		),
+	)
+	bot.SendMessage(userID, msg, keyboard)
+}
+
+// StartAdminCoinAdjustment asks the admin for the amount to add or remove
+func (h *HandlerManager) StartAdminCoinAdjustment(userID int64, targetUserID uint, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	session.State = StateAdminCoinAmount
+	session.Data["admin_target_user_id"] = target.ID
+
+	bot.SendMessage(userID, fmt.Sprintf("💰 تغییر سکه %s\nموجودی فعلی: %d سکه\n\nمقدار رو بفرست، مثلاً +100 یا -50\nمی‌تونی بعد از عدد دلیل هم بنویسی.\n\nبرای لغو /cancel رو بزن.", target.FullName, target.CoinBalance), nil)
+}
+
+// handleAdminCoinAmount applies a coin adjustment entered as "<+/-amount> [reason]"
+func (h *HandlerManager) handleAdminCoinAmount(userID int64, input, requestID string, session *UserSession, bot BotInterface) {
+	targetUserID, ok := session.Data["admin_target_user_id"].(uint)
+	if !ok {
+		session.State = ""
+		bot.SendMessage(userID, "❌ کاربر انتخاب نشده! دوباره از پنل مدیریت شروع کن.", nil)
+		return
+	}
+
+	amount, reason, ok := parseAdminAdjustment(input)
+	if !ok {
+		bot.SendMessage(userID, "⚠️ مقدار نامعتبر است! مثلاً +100 یا -50", nil)
+		return
+	}
+
+	var err error
+	key := requestKey(requestID, models.TxTypeAdminAdjustment, targetUserID)
+	if amount > 0 {
+		err = h.CoinRepo.AddCoins(targetUserID, amount, models.TxTypeAdminAdjustment, reason, key)
+	} else {
+		err = h.CoinRepo.DeductCoins(targetUserID, -amount, models.TxTypeAdminAdjustment, reason, key)
+	}
+
+	if err != nil {
+		if isDuplicateRequest(err) {
+			return
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeInsufficientFunds {
+			bot.SendMessage(userID, "❌ موجودی کاربر برای این کسر کافی نیست! مقدار دیگری بفرست یا /cancel رو بزن.", nil)
+			return
+		}
+		logger.Error("Failed to adjust coins", "target_user_id", targetUserID, "amount", amount, "error", err)
+		bot.SendMessage(userID, "❌ خطا در تغییر سکه!", nil)
+		return
+	}
+
+	session.State = ""
+	delete(session.Data, "admin_target_user_id")
+
+	logger.Info("Admin coin adjustment", "admin", userID, "target_user_id", targetUserID, "amount", amount, "reason", reason)
+
+	if target, _ := h.UserRepo.GetUserByID(targetUserID); target != nil {
+		if amount > 0 {
+			bot.SendMessage(target.TelegramID, fmt.Sprintf("🎁 %d سکه توسط مدیریت به حسابت اضافه شد.", amount), nil)
+		} else {
+			bot.SendMessage(target.TelegramID, fmt.Sprintf("➖ %d سکه توسط مدیریت از حسابت کسر شد.", -amount), nil)
+		}
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("✅ تغییر %+d سکه ثبت شد.", amount), nil)
+	h.ShowAdminUserCard(userID, targetUserID, bot)
+}
+
+// StartAdminDiamondAdjustment asks the admin for the diamonds to add or remove
+func (h *HandlerManager) StartAdminDiamondAdjustment(userID int64, targetUserID uint, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	session.State = StateAdminDiamondAmount
+	session.Data["admin_target_user_id"] = target.ID
+
+	bot.SendMessage(userID, fmt.Sprintf("💎 تغییر الماس %s\nموجودی فعلی: %d الماس\n\nمقدار رو بفرست، مثلاً +10 یا -5\nمی‌تونی بعد از عدد دلیل هم بنویسی.\n\nبرای لغو /cancel رو بزن.", target.FullName, target.Diamonds), nil)
+}
+
+// handleAdminDiamondAmount applies a diamond adjustment entered as "<+/-amount> [reason]"
+func (h *HandlerManager) handleAdminDiamondAmount(userID int64, input, requestID string, session *UserSession, bot BotInterface) {
+	targetUserID, ok := session.Data["admin_target_user_id"].(uint)
+	if !ok {
+		session.State = ""
+		bot.SendMessage(userID, "❌ کاربر انتخاب نشده! دوباره از پنل مدیریت شروع کن.", nil)
+		return
+	}
+
+	amount, reason, ok := parseAdminAdjustment(input)
+	if !ok {
+		bot.SendMessage(userID, "⚠️ مقدار نامعتبر است! مثلاً +10 یا -5", nil)
+		return
+	}
+
+	var err error
+	key := requestKey(requestID, models.TxTypeAdminAdjustment, targetUserID)
+	if amount > 0 {
+		err = h.DiamondRepo.AddDiamonds(targetUserID, amount, models.TxTypeAdminAdjustment, reason, key)
+	} else {
+		err = h.DiamondRepo.DeductDiamonds(targetUserID, -amount, models.TxTypeAdminAdjustment, reason, key)
+	}
+
+	if err != nil {
+		if isDuplicateRequest(err) {
+			return
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeInsufficientFunds {
+			bot.SendMessage(userID, "❌ موجودی الماس کاربر برای این کسر کافی نیست! مقدار دیگری بفرست یا /cancel رو بزن.", nil)
+			return
+		}
+		logger.Error("Failed to adjust diamonds", "target_user_id", targetUserID, "amount", amount, "error", err)
+		bot.SendMessage(userID, "❌ خطا در تغییر الماس!", nil)
+		return
+	}
+
+	session.State = ""
+	delete(session.Data, "admin_target_user_id")
+
+	logger.Info("Admin diamond adjustment", "admin", userID, "target_user_id", targetUserID, "amount", amount, "reason", reason)
+
+	if target, _ := h.UserRepo.GetUserByID(targetUserID); target != nil {
+		if amount > 0 {
+			bot.SendMessage(target.TelegramID, fmt.Sprintf("🎁 %d الماس توسط مدیریت به حسابت اضافه شد.", amount), nil)
+		} else {
+			bot.SendMessage(target.TelegramID, fmt.Sprintf("➖ %d الماس توسط مدیریت از حسابت کسر شد.", -amount), nil)
+		}
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("✅ تغییر %+d الماس ثبت شد.", amount), nil)
+	h.ShowAdminUserCard(userID, targetUserID, bot)
+}
+
+// parseAdminAdjustment reads a balance adjustment entered as "<+/-amount> [reason]"
+func parseAdminAdjustment(input string) (amount int64, reason string, ok bool) {
+	fields := strings.Fields(utils.NormalizePersianNumbers(input))
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+
+	amount, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || amount == 0 {
+		return 0, "", false
+	}
+
+	reason = "تنظیم توسط مدیریت"
+	if len(fields) > 1 {
+		reason = "مدیریت: " + strings.Join(fields[1:], " ")
+	}
+	return amount, reason, true
+}
+
+// ShowAdminBanScopes lets the admin choose what to ban the user from
+func (h *HandlerManager) ShowAdminBanScopes(userID int64, targetUserID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, scope := range adminBanScopes {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(models.BanScopeTitles[scope], fmt.Sprintf("adm_bscope_%d_%s", targetUserID, scope)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به کاربر", fmt.Sprintf("adm_user_%d", targetUserID)),
+	))
+
+	bot.SendMessage(userID, "⛔️ کاربر از کدوم بخش محروم بشه؟", tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// ShowAdminBanDurations lets the admin choose how long the ban lasts
+func (h *HandlerManager) ShowAdminBanDurations(userID int64, targetUserID uint, scope string, bot BotInterface) {
+	if !h.isSuperAdmin(userID) || !models.IsValidBanScope(scope) {
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, hours := range AdminBanDurations {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(formatBanDuration(hours), fmt.Sprintf("adm_bdur_%d_%d_%s", targetUserID, hours, scope)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("⏳ مدت محرومیت از %s:", models.BanScopeTitles[scope]), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleAdminBan bans the user from scope for the chosen number of hours (0 = permanent)
+func (h *HandlerManager) HandleAdminBan(userID int64, targetUserID uint, scope string, hours int, bot BotInterface) {
+	if !h.isSuperAdmin(userID) || !models.IsValidBanScope(scope) || hours < 0 {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	if target.TelegramID == h.Config.SuperAdminTgID {
+		bot.SendMessage(userID, "😊 نمی‌تونی خودت رو محروم کنی!", nil)
+		return
+	}
+
+	if !h.banUser(target, scope, time.Duration(hours)*time.Hour, "تصمیم مدیریت", userID, bot) {
+		bot.SendMessage(userID, "❌ خطا در ثبت محرومیت!", nil)
+		return
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("⛔️ %s از %s محروم شد (%s).", target.FullName, models.BanScopeTitles[scope], formatBanDuration(hours)), nil)
+	h.ShowAdminUserCard(userID, target.ID, bot)
+}
+
+// HandleAdminUnban lifts every running ban of the user
+func (h *HandlerManager) HandleAdminUnban(userID int64, targetUserID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	lifted, err := h.BanRepo.LiftBans(target.ID, "")
+	if err != nil {
+		logger.Error("Failed to lift bans", "user_id", target.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در رفع محرومیت!", nil)
+		return
+	}
+
+	if lifted == 0 {
+		bot.SendMessage(userID, "ℹ️ این کاربر محرومیت فعالی نداره.", nil)
+		return
+	}
+
+	logger.Info("Admin lifted bans", "admin", userID, "user_id", target.ID, "count", lifted)
+	bot.SendMessage(target.TelegramID, "✅ محرومیت شما توسط مدیریت برداشته شد.", bot.GetMainMenuKeyboard(false))
+	bot.SendMessage(userID, fmt.Sprintf("✅ %d محرومیت %s برداشته شد.", lifted, target.FullName), nil)
+}
+
+// formatBanDuration renders a ban length given in hours
+func formatBanDuration(hours int) string {
+	switch {
+	case hours == 0:
+		return "دائمی"
+	case hours%24 == 0:
+		return fmt.Sprintf("%d روز", hours/24)
+	default:
+		return fmt.Sprintf("%d ساعت", hours)
+	}
+}
+
+// LedgerReportLimit is the number of mismatched users listed in the ledger alert
+const LedgerReportLimit = 10
+
+// CheckCoinLedger compares balances with the coin ledger and alerts the admin about drift.
+// It only reports; corrections are written with the cmd/ledger tool.
+func (h *HandlerManager) CheckCoinLedger(bot BotInterface) {
+	mismatches, err := h.CoinRepo.FindLedgerMismatches(0)
+	if err != nil {
+		logger.Error("Coin ledger check failed", "error", err)
+		return
+	}
+	if len(mismatches) == 0 {
+		logger.Info("Coin ledger check passed")
+		return
+	}
+
+	var total int64
+	for _, m := range mismatches {
+		total += m.Drift()
+	}
+	logger.Warn("Coin ledger mismatches found", "users", len(mismatches), "total_drift", total)
+
+	if h.Config.SuperAdminTgID == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("⚠️ مغایرت دفتر سکه\n━━━━━━━━━━━━━━\n👥 کاربران: %d\n💰 مجموع اختلاف: %+d سکه\n\n", len(mismatches), total)
+	for i, m := range mismatches {
+		if i == LedgerReportLimit {
+			msg += "...\n"
+			break
+		}
+		msg += fmt.Sprintf("▫️ کاربر %d: موجودی %d، دفتر %d (%+d)\n", m.UserID, m.Balance, m.LedgerSum, m.Drift())
+	}
+	msg += "\nبرای اصلاح: go run ./cmd/ledger -apply"
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👤 مشاهده اولین کاربر", fmt.Sprintf("adm_user_%d", mismatches[0].UserID)),
+		),
+	)
+	bot.SendMessage(h.Config.SuperAdminTgID, msg, keyboard)
+}
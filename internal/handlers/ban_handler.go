@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// TodJudgeBanDuration is how long repeatedly unfair judges are kept out of Truth or Dare
+const TodJudgeBanDuration = 24 * time.Hour
+
+// RejectBanned looks up a Telegram user and tells them about a running ban in scope.
+// Returns true if the action must be refused. The super admin is never banned.
+func (h *HandlerManager) RejectBanned(userID int64, scope string, bot BotInterface) bool {
+	if userID == h.Config.SuperAdminTgID {
+		return false
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		return false
+	}
+
+	return h.checkBan(user, scope, bot)
+}
+
+// checkBan tells the user about a running ban in scope and reports whether they are banned
+func (h *HandlerManager) checkBan(user *models.User, scope string, bot BotInterface) bool {
+	if user.TelegramID == h.Config.SuperAdminTgID {
+		return false
+	}
+
+	ban, err := h.BanRepo.GetActiveBan(user.ID, scope)
+	if err != nil {
+		logger.Error("Failed to check ban", "user_id", user.ID, "scope", scope, "error", err)
+		return false
+	}
+	if ban == nil {
+		return false
+	}
+
+	var markup interface{}
+	if ban.Scope == models.BanScopeGlobal {
+		markup = tgbotapi.NewRemoveKeyboard(true)
+	}
+	bot.SendMessage(user.TelegramID, BanMessage(ban), markup)
+	return true
+}
+
+// banUser bans a user, pulls them out of whatever the ban covers and tells them about it
+func (h *HandlerManager) banUser(user *models.User, scope string, duration time.Duration, reason string, issuedByTgID int64, bot BotInterface) bool {
+	ban, err := h.BanRepo.BanUser(user.ID, scope, duration, reason, issuedByTgID)
+	if err != nil {
+		logger.Error("Failed to ban user", "user_id", user.ID, "scope", scope, "error", err)
+		return false
+	}
+
+	// Banned users must not be matched from a queue entry they made earlier
+	if scope == models.BanScopeGlobal || scope == models.BanScopeChat || scope == models.BanScopeQuiz || scope == models.BanScopeTod {
+		h.MatchRepo.RemoveFromQueue(user.ID)
+		h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusOnline)
+	}
+
+	if scope == models.BanScopeGlobal || scope == models.BanScopeChat {
+		h.endAnonymousChat(user, bot)
+	}
+
+	bot.SendMessage(user.TelegramID, BanMessage(ban), nil)
+	logger.Info("User banned", "user_id", user.ID, "scope", scope, "duration", duration, "issued_by", issuedByTgID)
+	return true
+}
+
+// endAnonymousChat ends the user's anonymous chat, if any, and lets the partner go
+func (h *HandlerManager) endAnonymousChat(user *models.User, bot BotInterface) {
+	match, _ := h.MatchRepo.GetActiveMatch(user.ID)
+	if match == nil {
+		return
+	}
+
+	if err := h.MatchRepo.EndMatch(match.ID); err != nil {
+		logger.Error("Failed to end match", "match_id", match.ID, "error", err)
+		return
+	}
+
+	otherUserID := match.User1ID
+	if match.User1ID == user.ID {
+		otherUserID = match.User2ID
+	}
+	if other, _ := h.UserRepo.GetUserByID(otherUserID); other != nil {
+		h.UserRepo.UpdateUserStatus(other.ID, models.UserStatusOnline)
+		otherIsAdmin := other.TelegramID == h.Config.SuperAdminTgID
+		bot.SendMessage(other.TelegramID, "👋 طرف مقابل چت را ترک کرد.", bot.GetMainMenuKeyboard(otherIsAdmin))
+	}
+
+	h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusOnline)
+}
+
+// CleanupExpiredBans removes bans whose time is up
+func (h *HandlerManager) CleanupExpiredBans() int64 {
+	count, err := h.BanRepo.DeleteExpiredBans()
+	if err != nil {
+		logger.Error("Failed to clean up expired bans", "error", err)
+		return 0
+	}
+	return count
+}
+
+// BanMessage tells a banned user what they are banned from and until when
+func BanMessage(ban *models.UserBan) string {
+	msg := fmt.Sprintf("⛔️ شما از %s محروم شده‌اید.\n", models.BanScopeTitles[ban.Scope])
+	if ban.Reason != "" {
+		msg += fmt.Sprintf("\n📌 دلیل: %s", ban.Reason)
+	}
+	if ban.IsPermanent() {
+		msg += "\n⏳ مدت: دائمی"
+	} else {
+		msg += fmt.Sprintf("\n⏳ پایان محرومیت: %s", ban.ExpiresAt.Format("2006/01/02 15:04"))
+	}
+	return msg
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// ========================================
+// BETTING MODE (1v1 Quiz & Truth or Dare)
+// ========================================
+
+// ShowBettingMenu shows the stake selection menu for betting games
+func (h *HandlerManager) ShowBettingMenu(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	msg := "💰 بازی شرطی\n━━━━━━━━━━━━━━\n"
+	msg += fmt.Sprintf("💎 موجودی شما: %d سکه\n\n", user.CoinBalance)
+	msg += "مبلغ شرط و نوع بازی رو انتخاب کن.\n"
+	msg += "▫️ سکه‌های هر دو نفر تا پایان بازی امانت نگه داشته میشه\n"
+	msg += "▫️ برنده کل پات رو می‌بره، مساوی نصف نصف\n"
+	msg += "▫️ در صورت انصراف یا تایم‌اوت، سکه‌ها برمی‌گرده"
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, stake := range models.BetStakeOptions {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🧠 کوئیز | %d سکه", stake), fmt.Sprintf("bet_quiz_%d", stake)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔥 جرعت | %d سکه", stake), fmt.Sprintf("bet_tod_%d", stake)),
+		))
+	}
+
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleBetSelection validates the chosen stake and starts matchmaking with it
+func (h *HandlerManager) HandleBetSelection(userID int64, gameType string, amount int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	if !models.IsValidBetStake(amount) {
+		bot.SendMessage(userID, "❌ مبلغ شرط نامعتبر است!", nil)
+		return
+	}
+
+	hasBalance, _ := h.CoinRepo.HasSufficientBalance(user.ID, amount)
+	if !hasBalance {
+		bot.SendMessage(userID, fmt.Sprintf("❌ موجودی کافی ندارید!\n\n💰 مبلغ شرط: %d سکه\n💎 موجودی شما: %d سکه", amount, user.CoinBalance), nil)
+		return
+	}
+
+	switch gameType {
+	case models.GameTypeQuiz:
+		h.startQuizMatchmaking(userID, amount, bot)
+	case models.GameTypeTod:
+		h.startTodMatchmaking(userID, amount, bot)
+	}
+}
+
+// placeGameBet moves both players' stakes into escrow for a freshly created game
+func (h *HandlerManager) placeGameBet(gameType string, gameID, user1ID, user2ID uint, amount int64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	if err := h.CoinRepo.EscrowBet(gameType, gameID, []uint{user1ID, user2ID}, amount); err != nil {
+		logger.Error("Failed to escrow bet", "game_type", gameType, "game_id", gameID, "amount", amount, "error", err)
+		return err
+	}
+
+	logger.Info("Bet escrowed", "game_type", gameType, "game_id", gameID, "amount", amount)
+	return nil
+}
+
+// betFailureMessage explains why a bet game could not start
+func betFailureMessage(err error) string {
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeInsufficientFunds {
+		return "❌ یکی از بازیکنان موجودی کافی برای شرط نداشت. بازی لغو شد و سکه‌ای کسر نشد."
+	}
+	return "❌ خطا در ثبت شرط! بازی لغو شد و سکه‌ای کسر نشد."
+}
+
+// settleGameBet pays out the escrowed pot and tells each player what they got
+func (h *HandlerManager) settleGameBet(gameType string, gameID, winnerID uint, bot BotInterface) {
+	escrows, err := h.CoinRepo.SettleBet(gameType, gameID, winnerID)
+	if err != nil {
+		logger.Error("Failed to settle bet", "game_type", gameType, "game_id", gameID, "error", err)
+		return
+	}
+
+	for _, e := range escrows {
+		user, _ := h.UserRepo.GetUserByID(e.UserID)
+		if user == nil {
+			continue
+		}
+
+		var msg string
+		switch e.Status {
+		case models.BetStatusPaid:
+			msg = fmt.Sprintf("💰 شرط رو بردی!\n\n+%d سکه به حسابت اضافه شد.", e.Payout)
+		case models.BetStatusSplit:
+			msg = fmt.Sprintf("🤝 بازی مساوی شد!\n\n%d سکه از پات به حسابت برگشت.", e.Payout)
+		default:
+			msg = fmt.Sprintf("💸 شرط رو باختی!\n\n%d سکه به برنده رسید.", e.Amount)
+		}
+		bot.SendMessage(user.TelegramID, msg, nil)
+	}
+}
+
+// refundGameBet returns every held stake of a game to its owner
+func (h *HandlerManager) refundGameBet(gameType string, gameID uint, bot BotInterface) {
+	escrows, err := h.CoinRepo.RefundBet(gameType, gameID)
+	if err != nil {
+		logger.Error("Failed to refund bet", "game_type", gameType, "game_id", gameID, "error", err)
+		return
+	}
+
+	for _, e := range escrows {
+		user, _ := h.UserRepo.GetUserByID(e.UserID)
+		if user == nil {
+			continue
+		}
+		bot.SendMessage(user.TelegramID, fmt.Sprintf("↩️ مبلغ شرط (%d سکه) به حسابت برگشت.", e.Payout), nil)
+	}
+}
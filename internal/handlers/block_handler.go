@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// BlockListPageSize is the number of blocked users shown per page
+const BlockListPageSize = 8
+
+// HandleBlockUser blocks another user (e.g. from their profile)
+func (h *HandlerManager) HandleBlockUser(userID int64, targetUserID uint, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	target, err := h.UserRepo.GetUserByID(targetUserID)
+	if err != nil || target == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	if !h.blockUser(user, target, bot) {
+		return
+	}
+
+	// If they are chatting right now, the chat ends immediately
+	if match, _ := h.MatchRepo.GetActiveMatch(user.ID); match != nil &&
+		(match.User1ID == target.ID || match.User2ID == target.ID) {
+		h.endBlockedChat(user, target, match.ID, bot)
+		return
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("🚫 %s بلاک شد.\n\nدیگه با هم مچ نمی‌شید و پیام، لایک، درخواست دوستی یا دعوتی از طرفش دریافت نمی‌کنی.", target.FullName), nil)
+}
+
+// BlockFromChat blocks the anonymous partner of the user's active chat and ends it
+func (h *HandlerManager) BlockFromChat(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	match, _ := h.MatchRepo.GetActiveMatch(user.ID)
+	if match == nil {
+		isAdmin := user.TelegramID == h.Config.SuperAdminTgID
+		bot.SendMessage(userID, "⚠️ شما در چت فعالی نیستید!", bot.GetMainMenuKeyboard(isAdmin))
+		return
+	}
+
+	otherUserID := match.User1ID
+	if match.User1ID == user.ID {
+		otherUserID = match.User2ID
+	}
+
+	other, err := h.UserRepo.GetUserByID(otherUserID)
+	if err != nil || other == nil {
+		bot.SendMessage(userID, "❌ کاربر یافت نشد!", nil)
+		return
+	}
+
+	if !h.blockUser(user, other, bot) {
+		return
+	}
+
+	h.endBlockedChat(user, other, match.ID, bot)
+}
+
+// blockUser stores the block and reports failures to the user
+func (h *HandlerManager) blockUser(user, target *models.User, bot BotInterface) bool {
+	if err := h.BlockRepo.BlockUser(user.ID, target.ID); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			switch appErr.Code {
+			case errors.ErrCodeAlreadyExists:
+				bot.SendMessage(user.TelegramID, "⚠️ این کاربر قبلاً بلاک شده است.", nil)
+				return false
+			case errors.ErrCodeValidationFailed:
+				bot.SendMessage(user.TelegramID, "😊 نمی‌تونی خودت رو بلاک کنی!", nil)
+				return false
+			}
+		}
+		logger.Error("Failed to block user", "blocker_id", user.ID, "blocked_id", target.ID, "error", err)
+		bot.SendMessage(user.TelegramID, "❌ خطا در بلاک کردن کاربر!", nil)
+		return false
+	}
+
+	logger.Info("User blocked", "blocker_id", user.ID, "blocked_id", target.ID)
+	return true
+}
+
+// endBlockedChat ends the chat between a blocker and the blocked user without revealing the block
+func (h *HandlerManager) endBlockedChat(user, other *models.User, matchID uint, bot BotInterface) {
+	if err := h.MatchRepo.EndMatch(matchID); err != nil {
+		logger.Error("Failed to end match after block", "match_id", matchID, "error", err)
+	}
+
+	h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusOnline)
+	h.UserRepo.UpdateUserStatus(other.ID, models.UserStatusOnline)
+
+	otherIsAdmin := other.TelegramID == h.Config.SuperAdminTgID
+	bot.SendMessage(other.TelegramID, "👋 طرف مقابل چت را ترک کرد.", bot.GetMainMenuKeyboard(otherIsAdmin))
+
+	isAdmin := user.TelegramID == h.Config.SuperAdminTgID
+	bot.SendMessage(user.TelegramID, "🚫 کاربر بلاک شد و چت پایان یافت.\n\nدیگه با این کاربر مچ نمی‌شی.", bot.GetMainMenuKeyboard(isAdmin))
+
+	logger.Info("Match ended by block", "match_id", matchID, "ended_by", user.ID)
+}
+
+// ShowBlockedUsers shows a page of the user's block list with unblock buttons
+func (h *HandlerManager) ShowBlockedUsers(userID int64, page int, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	if page < 0 {
+		page = 0
+	}
+
+	blocks, total, err := h.BlockRepo.GetBlockedUsers(user.ID, page*BlockListPageSize, BlockListPageSize)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت لیست بلاک شده‌ها!", nil)
+		return
+	}
+
+	if total == 0 {
+		bot.SendMessage(userID, "🚫 لیست بلاک شده‌های شما خالی است.", nil)
+		return
+	}
+
+	// Page may be past the end after unblocking the last entry
+	totalPages := int((total + BlockListPageSize - 1) / BlockListPageSize)
+	if len(blocks) == 0 && page > 0 {
+		h.ShowBlockedUsers(userID, totalPages-1, bot)
+		return
+	}
+
+	msg := fmt.Sprintf("🚫 لیست بلاک شده‌ها (%d نفر)\n📄 صفحه %d از %d\n\nبرای آنبلاک روی اسم هر کاربر بزن:", total, page+1, totalPages)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, block := range blocks {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ آنبلاک %s", block.Blocked.FullName), fmt.Sprintf("unblock_%d_%d", block.BlockedID, page)),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️ قبلی", fmt.Sprintf("blocks_page_%d", page-1)))
+	}
+	if page < totalPages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("بعدی ▶️", fmt.Sprintf("blocks_page_%d", page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleUnblock removes a block and refreshes the block list page
+func (h *HandlerManager) HandleUnblock(userID int64, targetUserID uint, page int, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		return
+	}
+
+	if err := h.BlockRepo.UnblockUser(user.ID, targetUserID); err != nil {
+		bot.SendMessage(userID, "⚠️ این کاربر در لیست بلاک شما نیست.", nil)
+		return
+	}
+
+	target, _ := h.UserRepo.GetUserByID(targetUserID)
+	if target != nil {
+		bot.SendMessage(userID, fmt.Sprintf("✅ %s از لیست بلاک خارج شد.", target.FullName), nil)
+	}
+
+	logger.Info("User unblocked", "blocker_id", user.ID, "blocked_id", targetUserID)
+	h.ShowBlockedUsers(userID, page, bot)
+}
+
+// isBlockedWith reports whether two users have a block between them in either direction
+func (h *HandlerManager) isBlockedWith(user1ID, user2ID uint) bool {
+	blocked, err := h.BlockRepo.IsBlocked(user1ID, user2ID)
+	if err != nil {
+		logger.Error("Failed to check block", "user1", user1ID, "user2", user2ID, "error", err)
+		return false
+	}
+	return blocked
+}
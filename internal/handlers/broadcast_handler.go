@@ -0,0 +1,796 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories"
+	"github.com/mroshb/game_bot/internal/sender"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// ADMIN BROADCASTS
+// ========================================
+
+// Broadcast compose states
+const (
+	StateAdminBroadcastContent = "admin_bc_content"
+	StateAdminBroadcastButtons = "admin_bc_buttons"
+	StateAdminBroadcastTarget  = "admin_bc_target"
+)
+
+const (
+	// DefaultBroadcastPerSecond is used when the config leaves the broadcast rate at 0
+	DefaultBroadcastPerSecond = 15
+	// BroadcastListLimit is the number of broadcasts shown in the admin's list
+	BroadcastListLimit = 10
+	// MaxBroadcastButtons is the number of link buttons a broadcast can carry
+	MaxBroadcastButtons = 6
+
+	// broadcastReportInterval is how often the admin's progress message is refreshed
+	broadcastReportInterval = 5 * time.Second
+)
+
+// broadcastContent is a broadcast ready to be addressed to each recipient
+type broadcastContent struct {
+	broadcast *models.Broadcast
+	entities  []tgbotapi.MessageEntity
+	markup    *tgbotapi.InlineKeyboardMarkup // nil without buttons
+}
+
+// newBroadcastContent decodes the formatting and buttons stored with a broadcast
+func newBroadcastContent(b *models.Broadcast) (*broadcastContent, error) {
+	content := &broadcastContent{broadcast: b}
+
+	if b.Entities != "" {
+		if err := json.Unmarshal([]byte(b.Entities), &content.entities); err != nil {
+			return nil, fmt.Errorf("invalid broadcast entities: %w", err)
+		}
+	}
+
+	if b.Buttons != "" {
+		var buttons []models.BroadcastButton
+		if err := json.Unmarshal([]byte(b.Buttons), &buttons); err != nil {
+			return nil, fmt.Errorf("invalid broadcast buttons: %w", err)
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, button := range buttons {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(button.Text, button.URL)))
+		}
+		if len(rows) > 0 {
+			markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+			content.markup = &markup
+		}
+	}
+
+	return content, nil
+}
+
+// message builds the broadcast for one chat
+func (c *broadcastContent) message(chatID int64) tgbotapi.Chattable {
+	b := c.broadcast
+	file := tgbotapi.FileID(b.MediaFileID)
+
+	switch b.MediaType {
+	case models.BroadcastMediaPhoto:
+		msg := tgbotapi.NewPhoto(chatID, file)
+		msg.Caption, msg.CaptionEntities = b.Text, c.entities
+		if c.markup != nil {
+			msg.ReplyMarkup = *c.markup
+		}
+		return msg
+	case models.BroadcastMediaVideo:
+		msg := tgbotapi.NewVideo(chatID, file)
+		msg.Caption, msg.CaptionEntities = b.Text, c.entities
+		if c.markup != nil {
+			msg.ReplyMarkup = *c.markup
+		}
+		return msg
+	case models.BroadcastMediaAnimation:
+		msg := tgbotapi.NewAnimation(chatID, file)
+		msg.Caption, msg.CaptionEntities = b.Text, c.entities
+		if c.markup != nil {
+			msg.ReplyMarkup = *c.markup
+		}
+		return msg
+	case models.BroadcastMediaDocument:
+		msg := tgbotapi.NewDocument(chatID, file)
+		msg.Caption, msg.CaptionEntities = b.Text, c.entities
+		if c.markup != nil {
+			msg.ReplyMarkup = *c.markup
+		}
+		return msg
+	default:
+		msg := tgbotapi.NewMessage(chatID, b.Text)
+		msg.Entities = c.entities
+		if c.markup != nil {
+			msg.ReplyMarkup = *c.markup
+		}
+		return msg
+	}
+}
+
+// StartBroadcastCompose asks the admin for the message to broadcast
+func (h *HandlerManager) StartBroadcastCompose(userID int64, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	session.State = StateAdminBroadcastContent
+	delete(session.Data, "admin_broadcast_id")
+	bot.SendMessage(userID, "📢 پیام همگانی رو بفرست:\nمتن، عکس، ویدیو، گیف یا فایل (با کپشن). قالب‌بندی متن حفظ می‌شه.\n\nبرای لغو /cancel رو بزن.", nil)
+}
+
+// handleBroadcastContent stores the admin's message as a new draft broadcast
+func (h *HandlerManager) handleBroadcastContent(message *tgbotapi.Message, session *UserSession, bot BotInterface) {
+	userID := message.From.ID
+
+	broadcast := &models.Broadcast{
+		CreatedByTgID: userID,
+		Text:          message.Text,
+		Segment:       models.BroadcastSegmentAll,
+	}
+	entities := message.Entities
+
+	switch {
+	case len(message.Photo) > 0:
+		broadcast.MediaType = models.BroadcastMediaPhoto
+		broadcast.MediaFileID = message.Photo[len(message.Photo)-1].FileID
+	case message.Video != nil:
+		broadcast.MediaType = models.BroadcastMediaVideo
+		broadcast.MediaFileID = message.Video.FileID
+	case message.Animation != nil:
+		// Checked before Document: Telegram sends GIFs with both set
+		broadcast.MediaType = models.BroadcastMediaAnimation
+		broadcast.MediaFileID = message.Animation.FileID
+	case message.Document != nil:
+		broadcast.MediaType = models.BroadcastMediaDocument
+		broadcast.MediaFileID = message.Document.FileID
+	}
+	if broadcast.MediaType != "" {
+		broadcast.Text = message.Caption
+		entities = message.CaptionEntities
+	}
+
+	if broadcast.MediaType == "" && strings.TrimSpace(broadcast.Text) == "" {
+		bot.SendMessage(userID, "⚠️ فقط متن، عکس، ویدیو، گیف یا فایل رو می‌شه همگانی فرستاد. دوباره بفرست یا /cancel رو بزن.", nil)
+		return
+	}
+
+	if len(entities) > 0 {
+		encoded, err := json.Marshal(entities)
+		if err != nil {
+			logger.Error("Failed to encode broadcast entities", "error", err)
+			bot.SendMessage(userID, "❌ خطا در ذخیره پیام!", nil)
+			return
+		}
+		broadcast.Entities = string(encoded)
+	}
+
+	if err := h.BroadcastRepo.CreateBroadcast(broadcast); err != nil {
+		logger.Error("Failed to create broadcast", "error", err)
+		bot.SendMessage(userID, "❌ خطا در ذخیره پیام!", nil)
+		return
+	}
+
+	session.State = StateAdminBroadcastButtons
+	session.Data["admin_broadcast_id"] = broadcast.ID
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏭ بدون دکمه", fmt.Sprintf("adm_bc_nobtn_%d", broadcast.ID)),
+		),
+	)
+	bot.SendMessage(userID, fmt.Sprintf("🔗 دکمه‌های لینک زیر پیام رو بفرست، هر دکمه در یک خط:\nعنوان | https://example.com\n\nحداکثر %d دکمه.", MaxBroadcastButtons), keyboard)
+}
+
+// handleBroadcastButtons stores the link buttons the admin entered
+func (h *HandlerManager) handleBroadcastButtons(userID int64, input string, session *UserSession, bot BotInterface) {
+	broadcast := h.sessionBroadcast(userID, session, bot)
+	if broadcast == nil {
+		return
+	}
+
+	buttons, problem := parseBroadcastButtons(input)
+	if problem != "" {
+		bot.SendMessage(userID, "⚠️ "+problem+"\nدوباره بفرست یا /cancel رو بزن.", nil)
+		return
+	}
+
+	encoded, err := json.Marshal(buttons)
+	if err != nil {
+		logger.Error("Failed to encode broadcast buttons", "error", err)
+		return
+	}
+	broadcast.Buttons = string(encoded)
+	if err := h.BroadcastRepo.UpdateDraft(broadcast); err != nil {
+		logger.Error("Failed to save broadcast buttons", "broadcast_id", broadcast.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ذخیره دکمه‌ها!", nil)
+		return
+	}
+
+	session.State = ""
+	h.ShowBroadcastSegments(userID, broadcast.ID, bot)
+}
+
+// SkipBroadcastButtons moves on to choosing recipients without adding buttons
+func (h *HandlerManager) SkipBroadcastButtons(userID int64, broadcastID uint, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	if session.State == StateAdminBroadcastButtons {
+		session.State = ""
+	}
+	h.ShowBroadcastSegments(userID, broadcastID, bot)
+}
+
+// parseBroadcastButtons reads one "title | url" button per line. The second result
+// tells the admin what is wrong with the input, empty if nothing is.
+func parseBroadcastButtons(input string) ([]models.BroadcastButton, string) {
+	var buttons []models.BroadcastButton
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "|", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Sprintf("خط «%s» باید به شکل «عنوان | لینک» باشه.", line)
+		}
+		text, url := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if text == "" {
+			return nil, fmt.Sprintf("خط «%s» عنوان نداره.", line)
+		}
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "tg://") {
+			return nil, fmt.Sprintf("لینک «%s» باید با https:// یا tg:// شروع بشه.", url)
+		}
+		buttons = append(buttons, models.BroadcastButton{Text: text, URL: url})
+	}
+
+	if len(buttons) == 0 {
+		return nil, "هیچ دکمه‌ای پیدا نشد."
+	}
+	if len(buttons) > MaxBroadcastButtons {
+		return nil, fmt.Sprintf("حداکثر %d دکمه مجازه.", MaxBroadcastButtons)
+	}
+	return buttons, ""
+}
+
+// ShowBroadcastSegments lets the admin choose who receives the broadcast
+func (h *HandlerManager) ShowBroadcastSegments(userID int64, broadcastID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, segment := range models.BroadcastSegments {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(models.BroadcastSegmentTitles[segment], fmt.Sprintf("adm_bc_seg_%d_%s", broadcastID, segment)),
+		))
+	}
+
+	bot.SendMessage(userID, "🎯 پیام برای چه کسانی ارسال بشه؟", tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleBroadcastSegment applies the chosen segment, asking for its value if it has one
+func (h *HandlerManager) HandleBroadcastSegment(userID int64, broadcastID uint, segment string, session *UserSession, bot BotInterface) {
+	if !h.isSuperAdmin(userID) || !models.IsValidBroadcastSegment(segment) {
+		return
+	}
+
+	broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID)
+	if err != nil || broadcast.Status != models.BroadcastStatusDraft {
+		bot.SendMessage(userID, "❌ این پیش‌نویس دیگه قابل ویرایش نیست!", nil)
+		return
+	}
+
+	if segment == models.BroadcastSegmentAll {
+		broadcast.Segment = segment
+		if err := h.BroadcastRepo.UpdateDraft(broadcast); err != nil {
+			logger.Error("Failed to save broadcast segment", "broadcast_id", broadcast.ID, "error", err)
+			bot.SendMessage(userID, "❌ خطا در ذخیره مخاطبان!", nil)
+			return
+		}
+		h.ShowBroadcastPreview(userID, broadcast.ID, bot)
+		return
+	}
+
+	session.State = StateAdminBroadcastTarget
+	session.Data["admin_broadcast_id"] = broadcast.ID
+	session.Data["admin_broadcast_segment"] = segment
+
+	var prompt string
+	switch segment {
+	case models.BroadcastSegmentProvince:
+		prompt = "📍 نام استان رو بفرست، مثلاً تهران"
+	case models.BroadcastSegmentLevel:
+		prompt = "⭐️ بازه سطح رو بفرست، مثلاً 5-20"
+	case models.BroadcastSegmentActive:
+		prompt = "📅 کاربرانی که در چند روز اخیر فعال بودن؟ تعداد روز رو بفرست، مثلاً 7"
+	case models.BroadcastSegmentVillage:
+		prompt = "🏘 آیدی یا نام دهکده رو بفرست"
+	}
+	bot.SendMessage(userID, prompt+"\n\nبرای لغو /cancel رو بزن.", nil)
+}
+
+// handleBroadcastTarget reads the value of the chosen segment
+func (h *HandlerManager) handleBroadcastTarget(userID int64, input string, session *UserSession, bot BotInterface) {
+	broadcast := h.sessionBroadcast(userID, session, bot)
+	if broadcast == nil {
+		return
+	}
+	segment, _ := session.Data["admin_broadcast_segment"].(string)
+	input = strings.TrimSpace(utils.NormalizePersianNumbers(input))
+
+	switch segment {
+	case models.BroadcastSegmentProvince:
+		if input == "" {
+			bot.SendMessage(userID, "⚠️ نام استان رو بفرست.", nil)
+			return
+		}
+		broadcast.Province = input
+	case models.BroadcastSegmentLevel:
+		minLevel, maxLevel, ok := parseLevelRange(input)
+		if !ok {
+			bot.SendMessage(userID, "⚠️ بازه نامعتبره! مثلاً 5-20", nil)
+			return
+		}
+		broadcast.MinLevel, broadcast.MaxLevel = minLevel, maxLevel
+	case models.BroadcastSegmentActive:
+		days, err := strconv.Atoi(input)
+		if err != nil || days <= 0 {
+			bot.SendMessage(userID, "⚠️ تعداد روز نامعتبره! مثلاً 7", nil)
+			return
+		}
+		broadcast.ActiveDays = days
+	case models.BroadcastSegmentVillage:
+		var village *models.Village
+		if id, err := strconv.ParseUint(input, 10, 64); err == nil {
+			village, _ = h.VillageRepo.GetVillageByID(uint(id))
+		}
+		if village == nil {
+			village, _ = h.VillageRepo.GetVillageByName(input)
+		}
+		if village == nil {
+			bot.SendMessage(userID, "❌ دهکده‌ای با این آیدی یا نام پیدا نشد!", nil)
+			return
+		}
+		broadcast.VillageID = village.ID
+	default:
+		session.State = ""
+		return
+	}
+
+	broadcast.Segment = segment
+	if err := h.BroadcastRepo.UpdateDraft(broadcast); err != nil {
+		logger.Error("Failed to save broadcast segment", "broadcast_id", broadcast.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ذخیره مخاطبان!", nil)
+		return
+	}
+
+	session.State = ""
+	delete(session.Data, "admin_broadcast_segment")
+	h.ShowBroadcastPreview(userID, broadcast.ID, bot)
+}
+
+// parseLevelRange reads a level range entered as "min-max"
+func parseLevelRange(input string) (int, int, bool) {
+	parts := strings.FieldsFunc(input, func(r rune) bool { return r == '-' || r == ' ' || r == '_' })
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	minLevel, err1 := strconv.Atoi(parts[0])
+	maxLevel, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || minLevel < 1 || maxLevel < minLevel {
+		return 0, 0, false
+	}
+	return minLevel, maxLevel, true
+}
+
+// sessionBroadcast returns the draft the admin is composing, resetting the state if there is none
+func (h *HandlerManager) sessionBroadcast(userID int64, session *UserSession, bot BotInterface) *models.Broadcast {
+	broadcastID, ok := session.Data["admin_broadcast_id"].(uint)
+	if ok {
+		if broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID); err == nil && broadcast.Status == models.BroadcastStatusDraft {
+			return broadcast
+		}
+	}
+
+	session.State = ""
+	bot.SendMessage(userID, "❌ پیش‌نویسی در حال ویرایش نیست! دوباره از پنل مدیریت شروع کن.", nil)
+	return nil
+}
+
+// ShowBroadcastPreview shows the admin the broadcast as users will see it and who gets it
+func (h *HandlerManager) ShowBroadcastPreview(userID int64, broadcastID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID)
+	if err != nil || broadcast.Status != models.BroadcastStatusDraft {
+		bot.SendMessage(userID, "❌ پیش‌نویس یافت نشد!", nil)
+		return
+	}
+
+	content, err := newBroadcastContent(broadcast)
+	if err != nil {
+		logger.Error("Failed to decode broadcast", "broadcast_id", broadcast.ID, "error", err)
+		return
+	}
+	if _, err := bot.Send(userID, content.message(userID), sender.PriorityNormal); err != nil {
+		bot.SendMessage(userID, "⚠️ پیش‌نمایش ارسال نشد؛ احتمالاً لینک یکی از دکمه‌ها نامعتبره.", nil)
+	}
+
+	recipients, err := h.BroadcastRepo.CountRecipients(broadcast)
+	if err != nil {
+		logger.Error("Failed to count broadcast recipients", "broadcast_id", broadcast.ID, "error", err)
+	}
+
+	msg := "👆 پیش‌نمایش پیام همگانی\n━━━━━━━━━━━━━━\n"
+	msg += fmt.Sprintf("🎯 مخاطبان: %s\n", h.describeBroadcastTarget(broadcast))
+	msg += fmt.Sprintf("👥 تعداد: %d نفر\n", recipients)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ شروع ارسال", fmt.Sprintf("adm_bc_start_%d", broadcast.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎯 تغییر مخاطبان", fmt.Sprintf("adm_bc_target_%d", broadcast.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 حذف", fmt.Sprintf("adm_bc_del_%d", broadcast.ID)),
+		),
+	)
+	bot.SendMessage(userID, msg, keyboard)
+}
+
+// HandleBroadcastStart starts delivering a draft in the background
+func (h *HandlerManager) HandleBroadcastStart(userID int64, broadcastID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID)
+	if err != nil || broadcast.Status != models.BroadcastStatusDraft {
+		bot.SendMessage(userID, "ℹ️ این پیام قبلاً ارسال شده یا حذف شده.", nil)
+		return
+	}
+
+	progressMessageID := bot.SendMessage(userID, "⏳ در حال آماده‌سازی ارسال همگانی...", nil)
+	started, err := h.BroadcastRepo.StartBroadcast(broadcast, progressMessageID)
+	if err != nil {
+		logger.Error("Failed to start broadcast", "broadcast_id", broadcast.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در شروع ارسال!", nil)
+		return
+	}
+	if !started {
+		return
+	}
+
+	logger.Info("Broadcast started", "broadcast_id", broadcast.ID, "admin", userID, "segment", broadcast.Segment, "total", broadcast.Total)
+	go h.runBroadcast(broadcast, bot)
+}
+
+// HandleBroadcastDelete throws away a draft
+func (h *HandlerManager) HandleBroadcastDelete(userID int64, broadcastID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	if err := h.BroadcastRepo.DeleteDraft(broadcastID); err != nil {
+		logger.Error("Failed to delete broadcast", "broadcast_id", broadcastID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در حذف پیش‌نویس!", nil)
+		return
+	}
+	bot.SendMessage(userID, "🗑 پیش‌نویس حذف شد.", nil)
+}
+
+// HandleBroadcastPause stops delivery after the current batch until the admin resumes it
+func (h *HandlerManager) HandleBroadcastPause(userID int64, broadcastID uint, bot BotInterface) {
+	h.setBroadcastStatus(userID, broadcastID, []string{models.BroadcastStatusRunning}, models.BroadcastStatusPaused, bot)
+}
+
+// HandleBroadcastResume continues a paused broadcast from where it stopped
+func (h *HandlerManager) HandleBroadcastResume(userID int64, broadcastID uint, bot BotInterface) {
+	broadcast := h.setBroadcastStatus(userID, broadcastID, []string{models.BroadcastStatusPaused}, models.BroadcastStatusRunning, bot)
+	if broadcast != nil {
+		// A runner still waiting on the pause picks this up itself; after a restart there is none
+		go h.runBroadcast(broadcast, bot)
+	}
+}
+
+// HandleBroadcastCancel stops a broadcast for good; recipients already reached stay counted
+func (h *HandlerManager) HandleBroadcastCancel(userID int64, broadcastID uint, bot BotInterface) {
+	h.setBroadcastStatus(userID, broadcastID, []string{models.BroadcastStatusRunning, models.BroadcastStatusPaused}, models.BroadcastStatusCancelled, bot)
+}
+
+// setBroadcastStatus moves a broadcast between states on the admin's request. Returns the
+// updated broadcast, nil if it wasn't in one of from.
+func (h *HandlerManager) setBroadcastStatus(userID int64, broadcastID uint, from []string, status string, bot BotInterface) *models.Broadcast {
+	if !h.isSuperAdmin(userID) {
+		return nil
+	}
+
+	changed, err := h.BroadcastRepo.SetStatus(broadcastID, from, status)
+	if err != nil {
+		logger.Error("Failed to update broadcast status", "broadcast_id", broadcastID, "status", status, "error", err)
+		bot.SendMessage(userID, "❌ خطا در تغییر وضعیت ارسال!", nil)
+		return nil
+	}
+
+	broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID)
+	if err != nil {
+		return nil
+	}
+	if !changed {
+		bot.SendMessage(userID, fmt.Sprintf("ℹ️ وضعیت این ارسال: %s", models.BroadcastStatusTitles[broadcast.Status]), nil)
+		return nil
+	}
+
+	logger.Info("Broadcast status changed", "broadcast_id", broadcastID, "admin", userID, "status", status)
+
+	// A running runner shows the change itself within a second
+	if _, running := h.broadcastRunners.Load(broadcastID); !running {
+		if broadcast.IsFinished() {
+			h.finishBroadcast(broadcast, bot)
+		} else {
+			h.reportBroadcastProgress(broadcast, bot)
+		}
+	}
+	return broadcast
+}
+
+// ShowBroadcastList shows the latest broadcasts with their counters
+func (h *HandlerManager) ShowBroadcastList(userID int64, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	broadcasts, err := h.BroadcastRepo.GetRecentBroadcasts(BroadcastListLimit)
+	if err != nil {
+		logger.Error("Failed to get broadcasts", "error", err)
+		bot.SendMessage(userID, "❌ خطا در دریافت لیست ارسال‌ها!", nil)
+		return
+	}
+	if len(broadcasts) == 0 {
+		bot.SendMessage(userID, "📭 هنوز پیام همگانی‌ای ارسال نشده.", nil)
+		return
+	}
+
+	msg := "📋 ارسال‌های همگانی اخیر\n━━━━━━━━━━━━━━\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, b := range broadcasts {
+		msg += fmt.Sprintf("#%d | %s | %s | %d%%\n✅ %d  ❌ %d  🚫 %d\n\n",
+			b.ID, b.CreatedAt.Format("01/02 15:04"), models.BroadcastStatusTitles[b.Status], b.Progress(), b.Delivered, b.Failed, b.Blocked)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("📢 #%d", b.ID), fmt.Sprintf("adm_bc_view_%d", b.ID)),
+		))
+	}
+
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// ShowBroadcastStatus sends the current progress of a broadcast with its controls
+func (h *HandlerManager) ShowBroadcastStatus(userID int64, broadcastID uint, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	broadcast, err := h.BroadcastRepo.GetBroadcastByID(broadcastID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ ارسال یافت نشد!", nil)
+		return
+	}
+
+	bot.SendMessage(userID, h.formatBroadcastStatus(broadcast), broadcastControls(broadcast))
+}
+
+// ResumeBroadcasts restarts delivery of broadcasts that were running when the bot stopped
+func (h *HandlerManager) ResumeBroadcasts(bot BotInterface) {
+	broadcasts, err := h.BroadcastRepo.GetRunningBroadcasts()
+	if err != nil {
+		logger.Error("Failed to load running broadcasts", "error", err)
+		return
+	}
+
+	for i := range broadcasts {
+		logger.Info("Resuming broadcast", "broadcast_id", broadcasts[i].ID, "last_user_id", broadcasts[i].LastUserID)
+		go h.runBroadcast(&broadcasts[i], bot)
+	}
+}
+
+// runBroadcast delivers a broadcast one batch a second until it is done or cancelled.
+// While paused it waits; the status is read from the database before each batch, so the
+// admin's controls take effect within a second. Only one runner works on a broadcast.
+func (h *HandlerManager) runBroadcast(broadcast *models.Broadcast, bot BotInterface) {
+	if _, running := h.broadcastRunners.LoadOrStore(broadcast.ID, struct{}{}); running {
+		return
+	}
+	defer h.broadcastRunners.Delete(broadcast.ID)
+
+	content, err := newBroadcastContent(broadcast)
+	if err != nil {
+		logger.Error("Failed to decode broadcast", "broadcast_id", broadcast.ID, "error", err)
+		h.BroadcastRepo.SetStatus(broadcast.ID, []string{models.BroadcastStatusRunning, models.BroadcastStatusPaused}, models.BroadcastStatusCancelled)
+		return
+	}
+
+	batchSize := h.Config.BroadcastPerSecond
+	if batchSize <= 0 {
+		batchSize = DefaultBroadcastPerSecond
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	lastReport := time.Now()
+	reportedStatus := ""
+	for {
+		current, err := h.BroadcastRepo.GetBroadcastByID(broadcast.ID)
+		if err != nil {
+			logger.Error("Failed to reload broadcast", "broadcast_id", broadcast.ID, "error", err)
+			<-ticker.C
+			continue
+		}
+		broadcast.Status, broadcast.FinishedAt = current.Status, current.FinishedAt
+
+		if broadcast.IsFinished() {
+			h.finishBroadcast(broadcast, bot)
+			return
+		}
+		if broadcast.Status == models.BroadcastStatusPaused {
+			if reportedStatus != broadcast.Status {
+				h.reportBroadcastProgress(broadcast, bot)
+				reportedStatus = broadcast.Status
+			}
+			<-ticker.C
+			continue
+		}
+
+		recipients, err := h.BroadcastRepo.GetRecipients(broadcast, broadcast.LastUserID, batchSize)
+		if err != nil {
+			logger.Error("Failed to get broadcast recipients", "broadcast_id", broadcast.ID, "error", err)
+			<-ticker.C
+			continue
+		}
+		if len(recipients) == 0 {
+			if _, err := h.BroadcastRepo.SetStatus(broadcast.ID, []string{models.BroadcastStatusRunning}, models.BroadcastStatusDone); err != nil {
+				logger.Error("Failed to finish broadcast", "broadcast_id", broadcast.ID, "error", err)
+			}
+			continue
+		}
+
+		h.sendBroadcastBatch(content, recipients, bot)
+		if err := h.BroadcastRepo.SaveProgress(broadcast); err != nil {
+			logger.Error("Failed to save broadcast progress", "broadcast_id", broadcast.ID, "error", err)
+		}
+
+		if reportedStatus != broadcast.Status || time.Since(lastReport) >= broadcastReportInterval {
+			h.reportBroadcastProgress(broadcast, bot)
+			reportedStatus = broadcast.Status
+			lastReport = time.Now()
+		}
+		<-ticker.C
+	}
+}
+
+// sendBroadcastBatch sends the broadcast to a batch of recipients at once, leaving the
+// pacing to the outbound queue, and counts the results
+func (h *HandlerManager) sendBroadcastBatch(content *broadcastContent, recipients []repositories.BroadcastRecipient, bot BotInterface) {
+	broadcast := content.broadcast
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, recipient := range recipients {
+		wg.Add(1)
+		go func(chatID int64) {
+			defer wg.Done()
+			_, err := bot.Send(chatID, content.message(chatID), sender.PriorityBulk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				broadcast.Delivered++
+			case sender.IsUnreachable(err):
+				broadcast.Blocked++
+			default:
+				broadcast.Failed++
+			}
+		}(recipient.TelegramID)
+	}
+	wg.Wait()
+
+	broadcast.LastUserID = recipients[len(recipients)-1].ID
+}
+
+// finishBroadcast shows the final counters and tells the admin the broadcast is over
+func (h *HandlerManager) finishBroadcast(broadcast *models.Broadcast, bot BotInterface) {
+	h.reportBroadcastProgress(broadcast, bot)
+
+	title := "✅ ارسال همگانی #%d تمام شد."
+	if broadcast.Status == models.BroadcastStatusCancelled {
+		title = "⏹ ارسال همگانی #%d لغو شد."
+	}
+	msg := fmt.Sprintf(title+"\n\n✅ رسیده: %d\n❌ ناموفق: %d\n🚫 ربات را بلاک کرده: %d",
+		broadcast.ID, broadcast.Delivered, broadcast.Failed, broadcast.Blocked)
+	bot.SendMessage(broadcast.CreatedByTgID, msg, nil)
+
+	logger.Info("Broadcast finished", "broadcast_id", broadcast.ID, "status", broadcast.Status,
+		"delivered", broadcast.Delivered, "failed", broadcast.Failed, "blocked", broadcast.Blocked)
+}
+
+// reportBroadcastProgress refreshes the admin's progress message of a broadcast
+func (h *HandlerManager) reportBroadcastProgress(broadcast *models.Broadcast, bot BotInterface) {
+	if broadcast.ProgressMessageID == 0 {
+		return
+	}
+	bot.EditMessage(broadcast.CreatedByTgID, broadcast.ProgressMessageID, h.formatBroadcastStatus(broadcast), broadcastControls(broadcast))
+}
+
+// formatBroadcastStatus renders the progress of a broadcast
+func (h *HandlerManager) formatBroadcastStatus(broadcast *models.Broadcast) string {
+	msg := fmt.Sprintf("📢 ارسال همگانی #%d\n━━━━━━━━━━━━━━\n", broadcast.ID)
+	msg += fmt.Sprintf("🎯 مخاطبان: %s\n", h.describeBroadcastTarget(broadcast))
+	msg += fmt.Sprintf("📶 وضعیت: %s\n", models.BroadcastStatusTitles[broadcast.Status])
+	msg += fmt.Sprintf("📊 پیشرفت: %d از %d (%d%%)\n\n", broadcast.Processed(), broadcast.Total, broadcast.Progress())
+	msg += fmt.Sprintf("✅ رسیده: %d\n", broadcast.Delivered)
+	msg += fmt.Sprintf("❌ ناموفق: %d\n", broadcast.Failed)
+	msg += fmt.Sprintf("🚫 ربات را بلاک کرده: %d\n", broadcast.Blocked)
+	if broadcast.FinishedAt != nil {
+		msg += fmt.Sprintf("\n🏁 پایان: %s", broadcast.FinishedAt.Format("2006/01/02 15:04"))
+	}
+	return msg
+}
+
+// describeBroadcastTarget renders who a broadcast goes to
+func (h *HandlerManager) describeBroadcastTarget(broadcast *models.Broadcast) string {
+	switch broadcast.Segment {
+	case models.BroadcastSegmentProvince:
+		return "استان " + html.EscapeString(broadcast.Province)
+	case models.BroadcastSegmentLevel:
+		return fmt.Sprintf("سطح %d تا %d", broadcast.MinLevel, broadcast.MaxLevel)
+	case models.BroadcastSegmentActive:
+		return fmt.Sprintf("فعال در %d روز اخیر", broadcast.ActiveDays)
+	case models.BroadcastSegmentVillage:
+		if village, _ := h.VillageRepo.GetVillageByID(broadcast.VillageID); village != nil {
+			return "اعضای دهکده " + html.EscapeString(village.Name)
+		}
+		return fmt.Sprintf("اعضای دهکده #%d", broadcast.VillageID)
+	default:
+		return models.BroadcastSegmentTitles[models.BroadcastSegmentAll]
+	}
+}
+
+// broadcastControls returns the pause, resume and cancel buttons that fit the broadcast's status
+func broadcastControls(broadcast *models.Broadcast) interface{} {
+	switch broadcast.Status {
+	case models.BroadcastStatusRunning:
+		return tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⏸ توقف", fmt.Sprintf("adm_bc_pause_%d", broadcast.ID)),
+				tgbotapi.NewInlineKeyboardButtonData("⏹ لغو", fmt.Sprintf("adm_bc_cancel_%d", broadcast.ID)),
+			),
+		)
+	case models.BroadcastStatusPaused:
+		return tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("▶️ ادامه", fmt.Sprintf("adm_bc_resume_%d", broadcast.ID)),
+				tgbotapi.NewInlineKeyboardButtonData("⏹ لغو", fmt.Sprintf("adm_bc_cancel_%d", broadcast.ID)),
+			),
+		)
+	default:
+		return nil
+	}
+}
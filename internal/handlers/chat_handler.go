@@ -5,6 +5,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/logger"
 )
 
@@ -61,7 +62,11 @@ func (h *HandlerManager) HandleChatMessage(message *tgbotapi.Message, user *mode
 			return
 		}
 
-		if err := h.CoinRepo.DeductCoins(user.ID, msgCost, models.TxTypeMessage, "هزینه ارسال پیام (بعد از پایان match)"); err != nil {
+		if err := h.CoinRepo.DeductCoins(user.ID, msgCost, models.TxTypeMessage, "هزینه ارسال پیام (بعد از پایان match)", requestKey(MessageRequestID(message), models.TxTypeMessage)); err != nil {
+			// A redelivered message was already paid for and forwarded
+			if isDuplicateRequest(err) {
+				return
+			}
 			logger.Error("Failed to deduct coins for message", "error", err)
 			bot.SendMessage(message.From.ID, "❌ خطا در کسر سکه!", nil)
 			return
@@ -69,18 +74,30 @@ func (h *HandlerManager) HandleChatMessage(message *tgbotapi.Message, user *mode
 	}
 
 	// Forward message to other user
-	if err := h.forwardMessage(message, otherUser.TelegramID, bot, ""); err != nil {
+	if err := h.forwardMessage(message, otherUser.TelegramID, bot, "", sender.PriorityNormal); err != nil {
 		logger.Error("Failed to forward message", "error", err)
 		bot.SendMessage(message.From.ID, "❌ خطا در ارسال پیام!", nil)
 		return
 	}
 
+	// Keep the last messages as evidence for abuse reports
+	h.recordChatMessage(match.ID, user.ID, message)
+
 	logger.Debug("Message forwarded", "from", user.ID, "to", otherUser.ID)
 }
 
-func (h *HandlerManager) forwardMessage(message *tgbotapi.Message, targetChatID int64, bot BotInterface, senderName string) error {
-	api := bot.GetAPI().(*tgbotapi.BotAPI)
+func (h *HandlerManager) forwardMessage(message *tgbotapi.Message, targetChatID int64, bot BotInterface, senderName string, priority sender.Priority) error {
+	var err error
+	for _, c := range forwardedMessages(message, targetChatID, senderName) {
+		_, err = bot.Send(targetChatID, c, priority)
+	}
+	return err
+}
 
+// forwardedMessages builds the messages that copy message to targetChatID, in the order
+// they are sent. Stickers and video notes can't carry a caption, so a named sender gets
+// an intro message before them.
+func forwardedMessages(message *tgbotapi.Message, targetChatID int64, senderName string) []tgbotapi.Chattable {
 	prefix := ""
 	if senderName != "" {
 		prefix = fmt.Sprintf("👤 %s:\n━━━━━━━━━━━━━━\n", senderName)
@@ -90,8 +107,7 @@ func (h *HandlerManager) forwardMessage(message *tgbotapi.Message, targetChatID
 	if message.Text != "" {
 		msg := tgbotapi.NewMessage(targetChatID, prefix+message.Text)
 		msg.ParseMode = tgbotapi.ModeMarkdown // Support some formatting if possible
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	caption := prefix
@@ -104,77 +120,68 @@ func (h *HandlerManager) forwardMessage(message *tgbotapi.Message, targetChatID
 		photo := message.Photo[len(message.Photo)-1]
 		msg := tgbotapi.NewPhoto(targetChatID, tgbotapi.FileID(photo.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward voice messages
 	if message.Voice != nil {
 		msg := tgbotapi.NewVoice(targetChatID, tgbotapi.FileID(message.Voice.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward stickers
 	if message.Sticker != nil {
+		msg := tgbotapi.NewSticker(targetChatID, tgbotapi.FileID(message.Sticker.FileID))
 		if senderName != "" {
-			// Stickers don't have captions, so send intro first
 			introMsg := tgbotapi.NewMessage(targetChatID, fmt.Sprintf("👤 %s یک استیکر فرستاد:", senderName))
-			api.Send(introMsg)
+			return []tgbotapi.Chattable{introMsg, msg}
 		}
-		msg := tgbotapi.NewSticker(targetChatID, tgbotapi.FileID(message.Sticker.FileID))
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward videos
 	if message.Video != nil {
 		msg := tgbotapi.NewVideo(targetChatID, tgbotapi.FileID(message.Video.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward documents
 	if message.Document != nil {
 		msg := tgbotapi.NewDocument(targetChatID, tgbotapi.FileID(message.Document.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward audio
 	if message.Audio != nil {
 		msg := tgbotapi.NewAudio(targetChatID, tgbotapi.FileID(message.Audio.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward animations
 	if message.Animation != nil {
 		msg := tgbotapi.NewAnimation(targetChatID, tgbotapi.FileID(message.Animation.FileID))
 		msg.Caption = caption
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	// Forward video notes (round videos)
 	if message.VideoNote != nil {
+		msg := tgbotapi.NewVideoNote(targetChatID, message.VideoNote.Length, tgbotapi.FileID(message.VideoNote.FileID))
 		if senderName != "" {
 			introMsg := tgbotapi.NewMessage(targetChatID, fmt.Sprintf("👤 %s یک ویدیو پیام فرستاد:", senderName))
-			api.Send(introMsg)
+			return []tgbotapi.Chattable{introMsg, msg}
 		}
-		msg := tgbotapi.NewVideoNote(targetChatID, message.VideoNote.Length, tgbotapi.FileID(message.VideoNote.FileID))
-		_, err := api.Send(msg)
-		return err
+		return []tgbotapi.Chattable{msg}
 	}
 
 	return nil
 }
 
-func (h *HandlerManager) SendFriendRequest(fromUserID, toUserID uint, bot BotInterface) error {
+func (h *HandlerManager) SendFriendRequest(fromUserID, toUserID uint, requestID string, bot BotInterface) error {
 	// Check sufficient funds
 	if h.Config.FriendRequestCost > 0 {
 		hasFunds, _ := h.CoinRepo.HasSufficientBalance(fromUserID, h.Config.FriendRequestCost)
@@ -194,7 +201,8 @@ func (h *HandlerManager) SendFriendRequest(fromUserID, toUserID uint, bot BotInt
 
 	// Deduct coins
 	if h.Config.FriendRequestCost > 0 {
-		h.CoinRepo.DeductCoins(fromUserID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی")
+		// Only charged after the request was created; the key also drops a replay that gets this far
+		h.CoinRepo.DeductCoins(fromUserID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی", requestKey(requestID, models.TxTypeFriendRequest))
 	}
 
 	// Get users
@@ -53,6 +53,8 @@ const (
 	BtnRegister       = "📝 ثبت نام"
 	BtnCancel         = "❌ لغو"
 	BtnEndChat        = "🔚 پایان چت"
+	BtnBlockUser      = "🚫 بلاک کاربر"
+	BtnReportUser     = "🚩 گزارش تخلف"
 	BtnSkip           = "⏭️ رد شو"
 	BtnMale           = "👨 پسر"
 	BtnFemale         = "👩 دختر"
@@ -76,6 +78,10 @@ const (
 	BtnFilterAdvanced = "⚙️ جستجوی پیشرفته"
 	BtnBuyCoins       = "💎 خرید سکه"
 	BtnIHavePaid      = "✅ واریز کردم"
+	BtnMyOrders       = "🧾 سفارش‌های من"
+	BtnPayOnline      = "💳 پرداخت آنلاین"
+	BtnPayStars       = "⭐️ خرید با استارز تلگرام"
+	BtnDiamonds       = "💎 الماس‌های من"
 
 	BtnCreateVillage      = "🆕 ساخت دهکده"
 	BtnVillageLeaderboard = "🏅 برترین دهکده‌ها"
@@ -84,18 +90,13 @@ const (
 	BtnVillageChat        = "💬 چت دهکده"
 	BtnVillageGame        = "🎮 بازی دهکده"
 
-	MsgCoinPurchasePlans = `💎 لیست پکیج‌های افزایش سکه:
-
-▫️ ۲۰۰ سکه ⬅️ ۲۰،۰۰۰ تومان
-▫️ ۵۰۰ سکه ⬅️ ۴۵،۰۰۰ تومان (۱۰٪ تخفیف)
-▫️ ۱۰۰۰ سکه ⬅️ ۸۰،۰۰۰ تومان (۲۰٪ تخفیف)
-▫️ ۵۰۰۰ سکه ⬅️ ۳۵۰،۰۰۰ تومان (۳۰٪ تخفیف ویژه)
-
-💳 اطلاعات واریز:
+	MsgCoinPurchasePlans = "💎 لیست پکیج‌های افزایش سکه:"
+	MsgOnlinePaymentInfo = "💳 با «پرداخت آنلاین» مستقیم از درگاه بانکی پرداخت کن؛ سکه‌ها بلافاصله بعد از پرداخت اضافه می‌شن."
+	MsgCoinPaymentInfo   = `💳 اطلاعات واریز:
 ۶۲۱۹۸۶۱۸۲۰۱۷۴۹۳۵
 مهدی محمدی - بلو بانک
 
-⚠️ بعد از واریز مبلغ، حتماً گزینه «واریز کردم» را انتخاب کرده و عکس رسید تراکنش را ارسال نمایید.`
+⚠️ در صورت کارت به کارت، بعد از واریز مبلغ پکیجی که خریدی رو از دکمه‌های زیر انتخاب کن و عکس رسید تراکنش را ارسال نمایید.`
 	MsgRequestReceipt  = "📸 لطفاً عکس رسید یا اسکرین‌شات تراکنش خود را ارسال کنید."
 	MsgPurchasePending = "✅ درخواست شما ثبت شد. پس از بررسی و تأیید واریز، سکه‌ها به حساب شما اضافه خواهد شد. از شکیبایی شما سپاسگزاریم."
 
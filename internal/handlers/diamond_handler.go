@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// DIAMONDS (Premium currency)
+// ========================================
+
+// DiamondHistoryLimit is the number of diamond transactions shown to the user
+const DiamondHistoryLimit = 10
+
+// DiamondExchangeAmounts are the diamond amounts offered on the exchange buttons
+var DiamondExchangeAmounts = []int64{1, 5, 10, 50}
+
+// ShowDiamonds shows the user's diamond balance, history and the coin exchange
+func (h *HandlerManager) ShowDiamonds(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	transactions, err := h.DiamondRepo.GetTransactionHistory(user.ID, DiamondHistoryLimit)
+	if err != nil {
+		logger.Error("Failed to get diamond history", "user_id", user.ID, "error", err)
+	}
+
+	msg := fmt.Sprintf("💎 موجودی الماس: %s\n━━━━━━━━━━━━━━\n", utils.FormatPersianNumber(user.Diamonds))
+	msg += "الماس برای خرید آیتم‌های ویژه فروشگاه و تبدیل به سکه استفاده می‌شه.\n"
+
+	rate := h.Config.DiamondToCoinRate
+	if rate > 0 {
+		msg += fmt.Sprintf("\n🔁 نرخ تبدیل: هر الماس = %s سکه\n", utils.FormatPersianNumber(rate))
+	}
+
+	if len(transactions) > 0 {
+		msg += "\n📊 آخرین تراکنش‌ها:\n"
+		for _, tx := range transactions {
+			sign := "+"
+			if tx.Amount < 0 {
+				sign = ""
+			}
+			msg += fmt.Sprintf("%s%d الماس - %s\n", sign, tx.Amount, tx.Description)
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if rate > 0 {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, amount := range DiamondExchangeAmounts {
+			if amount > user.Diamonds {
+				break
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("💎%s ⬅️ 💰%s", utils.FormatPersianNumber(amount), utils.FormatPersianNumber(amount*rate)),
+				fmt.Sprintf("dia_exch_%d", amount),
+			))
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if h.Config.TelegramPaymentsEnabled {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(BtnPayStars, "stars_shop"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(BtnCoinShop, "shop_open"),
+	))
+
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleDiamondExchange converts diamonds to coins at the configured rate
+func (h *HandlerManager) HandleDiamondExchange(userID int64, diamonds int64, requestID string, bot BotInterface) {
+	if h.Config.DiamondToCoinRate <= 0 {
+		bot.SendMessage(userID, "⚠️ تبدیل الماس فعلاً فعال نیست.", nil)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	coins, err := h.DiamondRepo.ExchangeForCoins(user.ID, diamonds, h.Config.DiamondToCoinRate, requestKey(requestID, models.TxTypeDiamondExchange))
+	if err != nil {
+		if isDuplicateRequest(err) {
+			return
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeInsufficientFunds {
+			bot.SendMessage(userID, "❌ الماس کافی نداری!", nil)
+			return
+		}
+		logger.Error("Diamond exchange failed", "user_id", user.ID, "diamonds", diamonds, "error", err)
+		bot.SendMessage(userID, "❌ خطا در تبدیل الماس! دوباره تلاش کن.", nil)
+		return
+	}
+
+	logger.Info("Diamonds exchanged", "user_id", user.ID, "diamonds", diamonds, "coins", coins)
+	bot.SendMessage(userID, fmt.Sprintf("✅ %s الماس به %s سکه تبدیل شد.", utils.FormatPersianNumber(diamonds), utils.FormatPersianNumber(coins)), nil)
+	h.ShowDiamonds(userID, bot)
+}
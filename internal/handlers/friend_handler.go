@@ -9,7 +9,7 @@ import (
 )
 
 // HandleAddFriend handles general friend requests (Paid)
-func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, bot BotInterface) {
+func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, requestID string, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		return
@@ -22,6 +22,11 @@ func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, bot Bo
 		return
 	}
 
+	if h.isBlockedWith(user.ID, targetUserID) {
+		bot.SendMessage(userID, "🚫 امکان ارسال درخواست دوستی به این کاربر وجود ندارد.", nil)
+		return
+	}
+
 	// Check balance
 	hasFunds, _ := h.CoinRepo.HasSufficientBalance(user.ID, h.Config.FriendRequestCost)
 	if !hasFunds {
@@ -30,7 +35,11 @@ func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, bot Bo
 	}
 
 	// Deduct
-	if err := h.CoinRepo.DeductCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی"); err != nil {
+	chargeKey := requestKey(requestID, models.TxTypeFriendRequest)
+	if err := h.CoinRepo.DeductCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی", chargeKey); err != nil {
+		if isDuplicateRequest(err) {
+			return
+		}
 		bot.SendMessage(userID, "❌ خطا در کسر سکه!", nil)
 		return
 	}
@@ -38,7 +47,7 @@ func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, bot Bo
 	// Send request
 	if err := h.FriendRepo.SendFriendRequest(user.ID, targetUserID); err != nil {
 		logger.Error("Failed to send friend request", "error", err)
-		h.CoinRepo.AddCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeRefund, "بازگشت هزینه درخواست ناموفق")
+		h.CoinRepo.AddCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeRefund, "بازگشت هزینه درخواست ناموفق", refundKey(chargeKey))
 		bot.SendMessage(userID, "⚠️ درخواست قبلاً ارسال شده یا خطایی رخ داد.", nil)
 		return
 	}
@@ -59,7 +68,7 @@ func (h *HandlerManager) HandleAddFriend(userID int64, targetUserID uint, bot Bo
 }
 
 // HandleAddFriendFromMatch handles adding a friend specifically from a match context (Free)
-func (h *HandlerManager) HandleAddFriendFromMatch(userID int64, matchID uint, bot BotInterface) {
+func (h *HandlerManager) HandleAddFriendFromMatch(userID int64, matchID uint, requestID string, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		return
@@ -89,12 +98,18 @@ func (h *HandlerManager) HandleAddFriendFromMatch(userID int64, matchID uint, bo
 		return
 	}
 
+	if h.isBlockedWith(user.ID, targetUserID) {
+		bot.SendMessage(userID, "🚫 امکان ارسال درخواست دوستی به این کاربر وجود ندارد.", nil)
+		return
+	}
+
 	// Normally free if session exists and not Ended/Refunded.
 	isFree := false
 	if match.Status == models.MatchStatusActive || match.Status == models.MatchStatusTimeout {
 		isFree = true
 	}
 
+	chargeKey := requestKey(requestID, models.TxTypeFriendRequest)
 	if !isFree {
 		// Paid request
 		hasFunds, _ := h.CoinRepo.HasSufficientBalance(user.ID, h.Config.FriendRequestCost)
@@ -103,7 +118,10 @@ func (h *HandlerManager) HandleAddFriendFromMatch(userID int64, matchID uint, bo
 			return
 		}
 		// Deduct handled later or here? Let's do it here for clarity.
-		if err := h.CoinRepo.DeductCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی"); err != nil {
+		if err := h.CoinRepo.DeductCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeFriendRequest, "هزینه درخواست دوستی", chargeKey); err != nil {
+			if isDuplicateRequest(err) {
+				return
+			}
 			bot.SendMessage(userID, "❌ خطا در کسر سکه!", nil)
 			return
 		}
@@ -114,7 +132,7 @@ func (h *HandlerManager) HandleAddFriendFromMatch(userID int64, matchID uint, bo
 		logger.Error("Failed to send friend request", "error", err)
 		if !isFree {
 			// Refund on error
-			h.CoinRepo.AddCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeRefund, "بازگشت هزینه درخواست ناموفق")
+			h.CoinRepo.AddCoins(user.ID, h.Config.FriendRequestCost, models.TxTypeRefund, "بازگشت هزینه درخواست ناموفق", refundKey(chargeKey))
 		}
 		bot.SendMessage(userID, "⚠️ درخواست قبلاً ارسال شده یا خطایی رخ داد.", nil)
 		return
@@ -171,6 +189,10 @@ func (h *HandlerManager) HandleFriendRequestAction(userID int64, targetUserID ui
 
 	switch action {
 	case "accept":
+		if h.isBlockedWith(user.ID, targetUserID) {
+			bot.SendMessage(userID, "🚫 امکان قبول درخواست این کاربر وجود ندارد.", nil)
+			return
+		}
 		if err := h.FriendRepo.AcceptFriendRequest(requestID); err != nil {
 			bot.SendMessage(userID, "❌ خطا در قبول درخواست!", nil)
 			return
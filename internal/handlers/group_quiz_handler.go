@@ -1,21 +1,458 @@
-// Group Quiz Game (Quiz of King) - Stub handlers for group quiz games
+// Group Quiz Game (Quiz of King) - multi-player quiz played inside a room
 
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// GroupQuizSession holds in-memory state for an active group quiz
+type GroupQuizSession struct {
+	SessionID     uint
+	RoomID        uint
+	Questions     []models.Question
+	QuestionIndex int // index into Questions of the question being played
+	QuestionStart time.Time
+	QuestionOpen  bool
+
+	Answered    map[uint]bool  // userID -> answered current question
+	TotalTimeMs map[uint]int64 // userID -> total time spent on correct answers (tie-break)
+
+	QuestionTimer *time.Timer
+
+	mu sync.Mutex
+}
+
+var (
+	groupQuizSessions   = make(map[uint]*GroupQuizSession)
+	groupQuizSessionsMu sync.RWMutex
 )
 
-// StartQuizGame - Group quiz game starter (Quiz of King)
-// TODO: Implement group quiz game logic
+func getGroupQuizSession(sessionID uint) *GroupQuizSession {
+	groupQuizSessionsMu.RLock()
+	defer groupQuizSessionsMu.RUnlock()
+	return groupQuizSessions[sessionID]
+}
+
+func cleanupGroupQuizSession(sessionID uint) {
+	groupQuizSessionsMu.Lock()
+	session, exists := groupQuizSessions[sessionID]
+	if exists {
+		if session.QuestionTimer != nil {
+			session.QuestionTimer.Stop()
+		}
+		delete(groupQuizSessions, sessionID)
+	}
+	groupQuizSessionsMu.Unlock()
+}
+
+// activeGameSession returns the room's active game session. A quiz session whose in-memory
+// state is gone (the bot restarted mid-game) can never finish, so it is ended instead of
+// leaving the room stuck.
+func (h *HandlerManager) activeGameSession(roomID uint) *models.GameSession {
+	session, _ := h.GameRepo.GetActiveGameSessionByRoomID(roomID)
+	if session == nil || session.GameType != models.GameTypeQuiz || getGroupQuizSession(session.ID) != nil {
+		return session
+	}
+
+	if err := h.GameRepo.EndGame(session.ID); err != nil {
+		logger.Error("Failed to end stale group quiz", "session_id", session.ID, "room_id", roomID, "error", err)
+		return session
+	}
+	logger.Info("Ended stale group quiz", "session_id", session.ID, "room_id", roomID)
+	return nil
+}
+
+// StartQuizGame starts a group quiz (Quiz of King) in a room (host only)
 func (h *HandlerManager) StartQuizGame(userID int64, roomID uint, bot BotInterface) {
-	logger.Info("Group quiz game requested but not yet implemented", "user_id", userID, "room_id", roomID)
-	bot.SendMessage(userID, "🚧 بازی کوئیز گروهی هنوز در دست توسعه است!", nil)
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	if h.checkBan(user, models.BanScopeRooms, bot) {
+		return
+	}
+
+	isHost, _ := h.RoomRepo.IsHost(roomID, user.ID)
+	if !isHost {
+		bot.SendMessage(userID, "❌ فقط میزبان می‌تواند بازی را شروع کند!", nil)
+		return
+	}
+
+	// Check if game already active
+	if h.activeGameSession(roomID) != nil {
+		bot.SendMessage(userID, "⚠️ یک بازی در حال حاضر فعال است!", nil)
+		return
+	}
+
+	// Check member count
+	members, _ := h.RoomRepo.GetRoomMembers(roomID)
+	if len(members) < models.GroupQuizMinPlayers {
+		bot.SendMessage(userID, fmt.Sprintf("👥 حداقل %s نفر برای شروع بازی لازم است!", utils.FormatPersianNumber(int64(models.GroupQuizMinPlayers))), nil)
+		return
+	}
+
+	questions, err := h.GameRepo.GetQuizQuestions(models.GroupQuizTotalQuestions)
+	if err != nil || len(questions) == 0 {
+		logger.Error("Failed to load group quiz questions", "room_id", roomID, "error", err)
+		bot.SendMessage(userID, "❌ سؤالی برای بازی پیدا نشد!", nil)
+		return
+	}
+
+	// Create session
+	gameSession, err := h.GameRepo.CreateGameSession(roomID, models.GameTypeQuiz)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در ایجاد جلسه بازی!", nil)
+		return
+	}
+
+	// Add members as participants
+	for i, member := range members {
+		if err := h.GameRepo.AddParticipant(gameSession.ID, member.ID, i+1); err != nil {
+			logger.Error("Failed to add participant", "session_id", gameSession.ID, "user_id", member.ID, "error", err)
+			h.GameRepo.EndGame(gameSession.ID)
+			bot.SendMessage(userID, "❌ خطا در افزودن شرکت‌کنندگان!", nil)
+			return
+		}
+	}
+
+	h.GameRepo.StartGame(gameSession.ID)
+
+	groupQuizSessionsMu.Lock()
+	groupQuizSessions[gameSession.ID] = &GroupQuizSession{
+		SessionID:     gameSession.ID,
+		RoomID:        roomID,
+		Questions:     questions,
+		QuestionIndex: -1,
+		Answered:      make(map[uint]bool),
+		TotalTimeMs:   make(map[uint]int64),
+	}
+	groupQuizSessionsMu.Unlock()
+
+	msg := fmt.Sprintf("👑 کوئیز اف کینگ شروع شد!\n\n📊 شرایط بازی:\n▫️ %d سؤال\n▫️ %d ثانیه برای هر سؤال\n▫️ امتیاز بر اساس جواب درست و سرعت\n▫️ برنده %d سکه جایزه می‌گیره!\n\nآماده باشید...",
+		len(questions), models.GroupQuizQuestionTimeSeconds, h.Config.WinRewardCoins)
+	for _, member := range members {
+		bot.SendMessageAsync(member.TelegramID, msg, nil, sender.PriorityGame)
+	}
+
+	logger.Info("Group quiz started", "session_id", gameSession.ID, "room_id", roomID, "players", len(members))
+
+	time.AfterFunc(3*time.Second, func() {
+		h.sendNextGroupQuizQuestion(gameSession.ID, bot)
+	})
+}
+
+// sendNextGroupQuizQuestion advances to the next question and sends it to every player
+func (h *HandlerManager) sendNextGroupQuizQuestion(sessionID uint, bot BotInterface) {
+	state := getGroupQuizSession(sessionID)
+	if state == nil {
+		return
+	}
+
+	// The room may have been closed mid-game
+	gameSession, err := h.GameRepo.GetGameSession(sessionID)
+	if err != nil || gameSession.Status == models.GameStatusFinished {
+		cleanupGroupQuizSession(sessionID)
+		return
+	}
+
+	state.mu.Lock()
+	state.QuestionIndex++
+	if state.QuestionIndex >= len(state.Questions) {
+		state.mu.Unlock()
+		h.endGroupQuiz(sessionID, bot)
+		return
+	}
+	questionIndex := state.QuestionIndex
+	question := state.Questions[questionIndex]
+	state.Answered = make(map[uint]bool)
+	state.QuestionOpen = true
+	state.QuestionStart = time.Now()
+	state.QuestionTimer = time.AfterFunc(models.GroupQuizQuestionTimeSeconds*time.Second, func() {
+		h.closeGroupQuizQuestion(sessionID, questionIndex, bot)
+	})
+	totalQuestions := len(state.Questions)
+	state.mu.Unlock()
+
+	h.GameRepo.UpdateCurrentQuestion(sessionID, question.ID)
+
+	var options []string
+	json.Unmarshal([]byte(question.Options), &options)
+
+	msg := fmt.Sprintf("❓ سؤال %d از %d\n⏱ %d ثانیه فرصت دارید\n\n%s", questionIndex+1, totalQuestions, models.GroupQuizQuestionTimeSeconds, question.QuestionText)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, opt := range options {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(opt, fmt.Sprintf("qok_ans_%d_%d_%d", sessionID, question.ID, i)),
+		))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	for _, player := range h.getGroupQuizPlayers(sessionID, gameSession.RoomID) {
+		bot.SendMessageAsync(player.TelegramID, msg, keyboard, sender.PriorityGame)
+	}
 }
 
-// HandleQuizGameAnswer - Group quiz game answer handler
-// TODO: Implement group quiz game answer logic
+// HandleQuizGameAnswer records a player's answer to the current group quiz question
 func (h *HandlerManager) HandleQuizGameAnswer(userID int64, msgID int, sessionID, questionID uint, answerIdx int, bot BotInterface) {
-	logger.Info("Group quiz answer received but not yet implemented", "user_id", userID, "session_id", sessionID)
-	bot.SendMessage(userID, "🚧 این قابلیت هنوز در دست توسعه است!", nil)
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		return
+	}
+
+	state := getGroupQuizSession(sessionID)
+	if state == nil {
+		bot.SendMessage(userID, "⚠️ این بازی به پایان رسیده است!", nil)
+		return
+	}
+
+	state.mu.Lock()
+	if state.QuestionIndex < 0 || state.QuestionIndex >= len(state.Questions) ||
+		state.Questions[state.QuestionIndex].ID != questionID || !state.QuestionOpen {
+		state.mu.Unlock()
+		bot.SendMessage(userID, "⏰ زمان این سؤال تمام شده است!", nil)
+		return
+	}
+	if state.Answered[user.ID] {
+		state.mu.Unlock()
+		bot.SendMessage(userID, "⚠️ شما قبلاً به این سؤال پاسخ داده‌اید!", nil)
+		return
+	}
+	state.Answered[user.ID] = true
+	question := state.Questions[state.QuestionIndex]
+	questionIndex := state.QuestionIndex
+	timeTakenMs := int(time.Since(state.QuestionStart).Milliseconds())
+	state.mu.Unlock()
+
+	var options []string
+	json.Unmarshal([]byte(question.Options), &options)
+
+	selected := ""
+	if answerIdx >= 0 && answerIdx < len(options) {
+		selected = options[answerIdx]
+	}
+	isCorrect := selected != "" && selected == question.CorrectAnswer
+	points := models.CalculateGroupQuizPoints(isCorrect, timeTakenMs)
+
+	answer := models.GroupQuizAnswer{
+		QuestionID:  questionID,
+		AnswerIdx:   answerIdx,
+		IsCorrect:   isCorrect,
+		TimeTakenMs: timeTakenMs,
+		Points:      points,
+	}
+	if err := h.GameRepo.RecordAnswer(sessionID, user.ID, answer); err != nil {
+		// Not a participant (e.g. joined after the game ended)
+		state.mu.Lock()
+		delete(state.Answered, user.ID)
+		state.mu.Unlock()
+		logger.Error("Failed to record group quiz answer", "session_id", sessionID, "user_id", user.ID, "error", err)
+		bot.SendMessage(userID, "❌ شما در این بازی شرکت ندارید!", nil)
+		return
+	}
+
+	if isCorrect {
+		state.mu.Lock()
+		state.TotalTimeMs[user.ID] += int64(timeTakenMs)
+		state.mu.Unlock()
+	}
+
+	result := fmt.Sprintf("❌ اشتباه! (%.1f ثانیه)", float64(timeTakenMs)/1000.0)
+	if isCorrect {
+		result = fmt.Sprintf("✅ درست! +%d امتیاز (%.1f ثانیه)", points, float64(timeTakenMs)/1000.0)
+	}
+	if msgID != 0 {
+		bot.EditMessage(userID, msgID, fmt.Sprintf("❓ %s\n\n👉 پاسخ شما: %s\n%s", question.QuestionText, selected, result), nil)
+	} else {
+		bot.SendMessage(userID, result, nil)
+	}
+
+	// Close the question early once every player has answered
+	gameSession, err := h.GameRepo.GetGameSession(sessionID)
+	if err != nil {
+		return
+	}
+	players := h.getGroupQuizPlayers(sessionID, gameSession.RoomID)
+
+	state.mu.Lock()
+	allAnswered := true
+	for _, player := range players {
+		if !state.Answered[player.ID] {
+			allAnswered = false
+			break
+		}
+	}
+	state.mu.Unlock()
+
+	if allAnswered {
+		h.closeGroupQuizQuestion(sessionID, questionIndex, bot)
+	}
+}
+
+// closeGroupQuizQuestion stops accepting answers, broadcasts the scoreboard and moves on
+func (h *HandlerManager) closeGroupQuizQuestion(sessionID uint, questionIndex int, bot BotInterface) {
+	state := getGroupQuizSession(sessionID)
+	if state == nil {
+		return
+	}
+
+	// Only the first caller (timer or last answer) closes the question
+	state.mu.Lock()
+	if state.QuestionIndex != questionIndex || !state.QuestionOpen {
+		state.mu.Unlock()
+		return
+	}
+	state.QuestionOpen = false
+	if state.QuestionTimer != nil {
+		state.QuestionTimer.Stop()
+	}
+	question := state.Questions[questionIndex]
+	isLast := questionIndex >= len(state.Questions)-1
+	state.mu.Unlock()
+
+	if isLast {
+		h.endGroupQuiz(sessionID, bot)
+		return
+	}
+
+	header := fmt.Sprintf("⏱ پایان سؤال %d\n✅ جواب درست: %s", questionIndex+1, question.CorrectAnswer)
+	h.BroadcastGroupQuizScoreboard(sessionID, bot, header)
+
+	time.AfterFunc(3*time.Second, func() {
+		h.sendNextGroupQuizQuestion(sessionID, bot)
+	})
+}
+
+// BroadcastGroupQuizScoreboard sends the live scoreboard of a group quiz to all players
+func (h *HandlerManager) BroadcastGroupQuizScoreboard(sessionID uint, bot BotInterface, message string) {
+	gameSession, err := h.GameRepo.GetGameSession(sessionID)
+	if err != nil {
+		return
+	}
+
+	participants := h.rankGroupQuizParticipants(sessionID)
+
+	msg := "👑 کوئیز اف کینگ\n━━━━━━━━━━━━━━\n"
+	if message != "" {
+		msg += message + "\n\n"
+	}
+	msg += "📊 جدول امتیازات:\n"
+	msg += formatGroupQuizScoreboard(participants)
+
+	for _, player := range h.getGroupQuizPlayers(sessionID, gameSession.RoomID) {
+		bot.SendMessageAsync(player.TelegramID, msg, nil, sender.PriorityGame)
+	}
+}
+
+// endGroupQuiz finishes the game, pays the winner and announces the final results
+func (h *HandlerManager) endGroupQuiz(sessionID uint, bot BotInterface) {
+	gameSession, err := h.GameRepo.GetGameSession(sessionID)
+	if err != nil || gameSession.Status == models.GameStatusFinished {
+		cleanupGroupQuizSession(sessionID)
+		return
+	}
+
+	if err := h.GameRepo.EndGame(sessionID); err != nil {
+		logger.Error("Failed to end group quiz", "session_id", sessionID, "error", err)
+	}
+
+	participants := h.rankGroupQuizParticipants(sessionID)
+	cleanupGroupQuizSession(sessionID)
+
+	msg := "🏁 کوئیز اف کینگ تمام شد!\n━━━━━━━━━━━━━━\n📊 نتیجه نهایی:\n"
+	msg += formatGroupQuizScoreboard(participants)
+
+	if len(participants) > 0 && participants[0].Score > 0 {
+		winner := participants[0]
+		if err := h.CoinRepo.AddCoins(winner.UserID, h.Config.WinRewardCoins, models.TxTypeGameReward, "Group quiz win reward", models.CoinOpKey(models.TxTypeGameReward, "group_quiz", sessionID)); err != nil {
+			logger.Error("Failed to pay group quiz winner", "session_id", sessionID, "user_id", winner.UserID, "error", err)
+		}
+		h.UserRepo.AddXP(winner.UserID, models.QuizWinRewardXP)
+		msg += fmt.Sprintf("\n🏆 برنده: %s\n💰 جایزه: +%d سکه | ⭐ +%d امتیاز تجربه", winner.User.FullName, h.Config.WinRewardCoins, models.QuizWinRewardXP)
+	} else {
+		msg += "\n🤷 هیچ‌کس امتیازی نگرفت!"
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👥 بازگشت به اتاق", fmt.Sprintf("room_members_%d", gameSession.RoomID)),
+		),
+	)
+
+	for _, player := range h.getGroupQuizPlayers(sessionID, gameSession.RoomID) {
+		bot.SendMessageAsync(player.TelegramID, msg, keyboard, sender.PriorityGame)
+	}
+
+	logger.Info("Group quiz finished", "session_id", sessionID, "room_id", gameSession.RoomID)
+}
+
+// rankGroupQuizParticipants returns participants ordered by score, then by speed
+func (h *HandlerManager) rankGroupQuizParticipants(sessionID uint) []models.GameParticipant {
+	participants, _ := h.GameRepo.GetParticipants(sessionID)
+
+	totalTimes := make(map[uint]int64)
+	if state := getGroupQuizSession(sessionID); state != nil {
+		state.mu.Lock()
+		for userID, ms := range state.TotalTimeMs {
+			totalTimes[userID] = ms
+		}
+		state.mu.Unlock()
+	}
+
+	sort.SliceStable(participants, func(i, j int) bool {
+		if participants[i].Score != participants[j].Score {
+			return participants[i].Score > participants[j].Score
+		}
+		return totalTimes[participants[i].UserID] < totalTimes[participants[j].UserID]
+	})
+
+	return participants
+}
+
+// getGroupQuizPlayers returns the participants who are still members of the room
+func (h *HandlerManager) getGroupQuizPlayers(sessionID, roomID uint) []models.User {
+	participants, _ := h.GameRepo.GetParticipants(sessionID)
+	members, _ := h.RoomRepo.GetRoomMembers(roomID)
+
+	isParticipant := make(map[uint]bool)
+	for _, p := range participants {
+		isParticipant[p.UserID] = true
+	}
+
+	var players []models.User
+	for _, member := range members {
+		if isParticipant[member.ID] {
+			players = append(players, member)
+		}
+	}
+
+	return players
+}
+
+func formatGroupQuizScoreboard(participants []models.GameParticipant) string {
+	medals := []string{"🥇", "🥈", "🥉"}
+
+	board := ""
+	for i, p := range participants {
+		rank := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			rank = medals[i]
+		}
+		board += fmt.Sprintf("%s %s — %d امتیاز\n", rank, p.User.FullName, p.Score)
+	}
+
+	return board
 }
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+// ========================================
+// IDEMPOTENCY
+// ========================================
+
+// A request ID names the update a user action came from. Telegram delivers the same
+// callback query or message again on retries, so coin operations keyed by it are
+// applied once however often the update is replayed.
+
+// CallbackRequestID returns the request ID of a callback query
+func CallbackRequestID(queryID string) string {
+	if queryID == "" {
+		return ""
+	}
+	return "cbq:" + queryID
+}
+
+// MessageRequestID returns the request ID of a message, or "" for messages without an ID
+func MessageRequestID(message *tgbotapi.Message) string {
+	if message == nil || message.Chat == nil || message.MessageID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("msg:%d:%d", message.Chat.ID, message.MessageID)
+}
+
+// requestKey builds the idempotency key of a coin operation triggered by a request.
+// Without a request ID there is nothing to dedupe against and the key is empty.
+func requestKey(requestID string, parts ...interface{}) string {
+	if requestID == "" {
+		return ""
+	}
+	return models.CoinOpKey(append(parts, requestID)...)
+}
+
+// refundKey keys the refund of a keyed charge, so the refund is paid at most once too
+func refundKey(chargeKey string) string {
+	if chargeKey == "" {
+		return ""
+	}
+	return models.CoinOpKey(models.TxTypeRefund, chargeKey)
+}
+
+// isDuplicateRequest reports whether a coin operation was skipped because it already ran
+func isDuplicateRequest(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Code == errors.ErrCodeDuplicateRequest
+}
@@ -4,47 +4,64 @@ import (
 	"sync"
 
 	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/payment"
 	"github.com/mroshb/game_bot/internal/repositories"
 	"github.com/mroshb/game_bot/internal/services"
-	"gorm.io/gorm"
 )
 
 type HandlerManager struct {
 	Config        *config.Config
-	DB            *gorm.DB
-	UserRepo      *repositories.UserRepository
-	CoinRepo      *repositories.CoinRepository
-	MatchRepo     *repositories.MatchRepository
-	FriendRepo    *repositories.FriendRepository
-	GameRepo      *repositories.GameRepository
-	RoomRepo      *repositories.RoomRepository
-	VillageRepo   *repositories.VillageRepository
-	QuizMatchRepo *repositories.QuizMatchRepository
-	TodRepo       *repositories.TodRepository
+	UserRepo      repositories.UserStore
+	CoinRepo      repositories.CoinStore
+	DiamondRepo   repositories.DiamondStore
+	MatchRepo     repositories.MatchStore
+	FriendRepo    repositories.FriendStore
+	GameRepo      repositories.GameStore
+	RoomRepo      repositories.RoomStore
+	VillageRepo   repositories.VillageStore
+	QuizMatchRepo repositories.QuizMatchStore
+	TodRepo       repositories.TodStore
+	BlockRepo     repositories.BlockStore
+	ReportRepo    repositories.ReportStore
+	BanRepo       repositories.BanStore
+	PurchaseRepo  repositories.PurchaseStore
+	ShopRepo      repositories.ShopStore
+	InventoryRepo repositories.InventoryStore
+	BroadcastRepo repositories.BroadcastStore
+	Payments      payment.Provider // nil when online payments are disabled
 	VillageSvc    *services.VillageService
 
-	searchingUsers sync.Map // userID -> chan struct{} for cancellation
+	searchingUsers   sync.Map // userID -> chan struct{} for cancellation
+	broadcastRunners sync.Map // broadcast ID -> struct{} while a runner delivers it
 }
 
 func NewHandlerManager(
 	cfg *config.Config,
-	db *gorm.DB,
-	userRepo *repositories.UserRepository,
-	coinRepo *repositories.CoinRepository,
-	matchRepo *repositories.MatchRepository,
-	friendRepo *repositories.FriendRepository,
-	gameRepo *repositories.GameRepository,
-	roomRepo *repositories.RoomRepository,
-	villageRepo *repositories.VillageRepository,
-	quizMatchRepo *repositories.QuizMatchRepository,
-	todRepo *repositories.TodRepository,
+	userRepo repositories.UserStore,
+	coinRepo repositories.CoinStore,
+	diamondRepo repositories.DiamondStore,
+	matchRepo repositories.MatchStore,
+	friendRepo repositories.FriendStore,
+	gameRepo repositories.GameStore,
+	roomRepo repositories.RoomStore,
+	villageRepo repositories.VillageStore,
+	quizMatchRepo repositories.QuizMatchStore,
+	todRepo repositories.TodStore,
+	blockRepo repositories.BlockStore,
+	reportRepo repositories.ReportStore,
+	banRepo repositories.BanStore,
+	purchaseRepo repositories.PurchaseStore,
+	shopRepo repositories.ShopStore,
+	inventoryRepo repositories.InventoryStore,
+	broadcastRepo repositories.BroadcastStore,
+	payments payment.Provider,
 	villageSvc *services.VillageService,
 ) *HandlerManager {
 	return &HandlerManager{
 		Config:        cfg,
-		DB:            db,
 		UserRepo:      userRepo,
 		CoinRepo:      coinRepo,
+		DiamondRepo:   diamondRepo,
 		MatchRepo:     matchRepo,
 		FriendRepo:    friendRepo,
 		GameRepo:      gameRepo,
@@ -52,6 +69,14 @@ func NewHandlerManager(
 		VillageRepo:   villageRepo,
 		QuizMatchRepo: quizMatchRepo,
 		TodRepo:       todRepo,
+		BlockRepo:     blockRepo,
+		ReportRepo:    reportRepo,
+		BanRepo:       banRepo,
+		PurchaseRepo:  purchaseRepo,
+		ShopRepo:      shopRepo,
+		InventoryRepo: inventoryRepo,
+		BroadcastRepo: broadcastRepo,
+		Payments:      payments,
 		VillageSvc:    villageSvc,
 	}
 }
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+	"github.com/mroshb/game_bot/internal/sender"
+	"github.com/mroshb/game_bot/internal/services"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("LOG_LEVEL", "error")
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// sentMessage is one message the fake bot was asked to deliver
+type sentMessage struct {
+	chatID int64
+	text   string
+}
+
+// fakeBot records every message handlers send instead of calling Telegram
+type fakeBot struct {
+	mu     sync.Mutex
+	sent   []sentMessage
+	nextID int
+}
+
+func (b *fakeBot) record(chatID int64, text string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	b.sent = append(b.sent, sentMessage{chatID: chatID, text: text})
+	return b.nextID
+}
+
+// messages returns the texts sent to chatID so far
+func (b *fakeBot) messages(chatID int64) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var texts []string
+	for _, m := range b.sent {
+		if m.chatID == chatID {
+			texts = append(texts, m.text)
+		}
+	}
+	return texts
+}
+
+// received reports whether chatID got a message containing substr
+func (b *fakeBot) received(chatID int64, substr string) bool {
+	for _, text := range b.messages(chatID) {
+		if strings.Contains(text, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *fakeBot) SendMessage(chatID int64, text string, keyboard interface{}) int {
+	return b.record(chatID, text)
+}
+
+func (b *fakeBot) DeleteMessage(chatID int64, messageID int) {}
+
+func (b *fakeBot) EditMessage(chatID int64, messageID int, text string, keyboard interface{}) {
+	b.record(chatID, text)
+}
+
+func (b *fakeBot) SendPhoto(chatID int64, photoID string, caption string, keyboard interface{}) int {
+	return b.record(chatID, caption)
+}
+
+func (b *fakeBot) SendMainMenu(chatID int64, isAdmin bool) {}
+
+func (b *fakeBot) GetMainMenuKeyboard(isAdmin bool) interface{}      { return nil }
+func (b *fakeBot) GetGenderKeyboard() interface{}                    { return nil }
+func (b *fakeBot) GetAgeSelectionKeyboard() interface{}              { return nil }
+func (b *fakeBot) GetProvinceKeyboard() interface{}                  { return nil }
+func (b *fakeBot) GetPhotoSelectionKeyboard() interface{}            { return nil }
+func (b *fakeBot) GetPhotoSkipKeyboard() interface{}                 { return nil }
+func (b *fakeBot) GetCancelInlineKeyboard() interface{}              { return nil }
+func (b *fakeBot) GetEditProfileFieldsKeyboard() interface{}         { return nil }
+func (b *fakeBot) GetConfig() interface{}                            { return nil }
+func (b *fakeBot) BotUsername() string                               { return "test_bot" }
+func (b *fakeBot) GetVillageHubKeyboard(hasVillage bool) interface{} { return nil }
+func (b *fakeBot) GetCancelKeyboard() interface{}                    { return nil }
+
+func (b *fakeBot) Send(chatID int64, c tgbotapi.Chattable, priority sender.Priority) (tgbotapi.Message, error) {
+	return tgbotapi.Message{MessageID: b.record(chatID, chattableText(c))}, nil
+}
+
+func (b *fakeBot) SendAsync(chatID int64, c tgbotapi.Chattable, priority sender.Priority) error {
+	b.record(chatID, chattableText(c))
+	return nil
+}
+
+func (b *fakeBot) SendMessageAsync(chatID int64, text string, keyboard interface{}, priority sender.Priority) error {
+	b.record(chatID, text)
+	return nil
+}
+
+// chattableText is the text or caption of a message
+func chattableText(c tgbotapi.Chattable) string {
+	var text string
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		text = m.Text
+	case tgbotapi.EditMessageTextConfig:
+		text = m.Text
+	case tgbotapi.PhotoConfig:
+		text = m.Caption
+	}
+	return text
+}
+
+func (b *fakeBot) SendMessageWithPriority(chatID int64, text string, keyboard interface{}, priority sender.Priority) int {
+	return b.record(chatID, text)
+}
+
+func (b *fakeBot) AnswerCallbackQuery(queryID string, text string, showAlert bool) {}
+
+func (b *fakeBot) EditMessageReplyMarkup(chatID int64, messageID int, keyboard interface{}) {}
+
+// testEnv is a handler manager backed by the in-memory stores
+type testEnv struct {
+	h   *HandlerManager
+	db  *memory.DB
+	bot *fakeBot
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db := memory.NewDB()
+	userRepo := memory.NewUserRepository(db)
+	villageRepo := memory.NewVillageRepository(db)
+
+	cfg := &config.Config{
+		MatchCostCoins:      5,
+		MatchTimeoutMinutes: 5,
+	}
+
+	h := NewHandlerManager(
+		cfg,
+		userRepo,
+		memory.NewCoinRepository(db),
+		memory.NewDiamondRepository(db),
+		memory.NewMatchRepository(db),
+		memory.NewFriendRepository(db),
+		memory.NewGameRepository(db),
+		memory.NewRoomRepository(db),
+		villageRepo,
+		memory.NewQuizMatchRepository(db),
+		memory.NewTodRepository(db),
+		memory.NewBlockRepository(db),
+		memory.NewReportRepository(db),
+		memory.NewBanRepository(db),
+		memory.NewPurchaseRepository(db),
+		memory.NewShopRepository(db),
+		memory.NewInventoryRepository(db),
+		memory.NewBroadcastRepository(db),
+		nil,
+		services.NewVillageService(villageRepo, userRepo),
+	)
+
+	return &testEnv{h: h, db: db, bot: &fakeBot{}}
+}
+
+// newUser registers an online user with the given Telegram ID and coin balance
+func (e *testEnv) newUser(t *testing.T, telegramID int64, name, gender string, coins int64) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		TelegramID:  telegramID,
+		FullName:    name,
+		Gender:      gender,
+		Age:         25,
+		City:        "تهران",
+		Status:      models.UserStatusOnline,
+		CoinBalance: coins,
+	}
+	if err := e.h.UserRepo.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser(%d) error = %v", telegramID, err)
+	}
+	return user
+}
+
+// balance returns the user's current coin balance
+func (e *testEnv) balance(t *testing.T, userID uint) int64 {
+	t.Helper()
+
+	user, err := e.h.UserRepo.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID(%d) error = %v", userID, err)
+	}
+	return user.CoinBalance
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/mroshb/game_bot/pkg/utils"
 )
 
-func (h *HandlerManager) StartMatchmaking(userID int64, requestedGender string, session *UserSession, bot BotInterface) {
+func (h *HandlerManager) StartMatchmaking(userID int64, requestedGender, requestID string, session *UserSession, bot BotInterface) {
 	// Get user
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
@@ -20,6 +20,10 @@ func (h *HandlerManager) StartMatchmaking(userID int64, requestedGender string,
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeChat, bot) {
+		return
+	}
+
 	// Check if already in queue
 	queueEntry, err := h.MatchRepo.GetQueueEntry(user.ID)
 	if err == nil && queueEntry != nil {
@@ -54,8 +58,12 @@ func (h *HandlerManager) StartMatchmaking(userID int64, requestedGender string,
 		return
 	}
 
-	// Deduct coins
-	if err := h.CoinRepo.DeductCoins(user.ID, h.Config.MatchCostCoins, models.TxTypeMatchmaking, "هزینه جستجوی match"); err != nil {
+	// Deduct coins; a replayed search request is dropped instead of paying twice
+	chargeKey := requestKey(requestID, models.TxTypeMatchmaking)
+	if err := h.CoinRepo.DeductCoins(user.ID, h.Config.MatchCostCoins, models.TxTypeMatchmaking, "هزینه جستجوی match", chargeKey); err != nil {
+		if isDuplicateRequest(err) {
+			return
+		}
 		logger.Error("Failed to deduct coins", "error", err)
 		bot.SendMessage(userID, "❌ خطا در کسر سکه!", nil)
 		return
@@ -86,7 +94,7 @@ func (h *HandlerManager) StartMatchmaking(userID int64, requestedGender string,
 	if err := h.MatchRepo.AddToQueue(queue); err != nil {
 		logger.Error("Failed to add to queue", "error", err)
 		// Refund coins
-		h.CoinRepo.AddCoins(user.ID, h.Config.MatchCostCoins, models.TxTypeMatchRefund, "بازگشت هزینه به دلیل خطا")
+		h.CoinRepo.AddCoins(user.ID, h.Config.MatchCostCoins, models.TxTypeMatchRefund, "بازگشت هزینه به دلیل خطا", refundKey(chargeKey))
 		bot.SendMessage(userID, "❌ خطا در افزودن به صف جستجو!", nil)
 		return
 	}
@@ -224,7 +232,7 @@ func (h *HandlerManager) HandleSearchCitySelection(message *tgbotapi.Message, se
 
 	gender := session.Data["search_gender"].(string)
 	session.State = "" // Clear state as we are now in "Searching" mode (handled by UserStatus)
-	h.StartMatchmaking(userID, gender, session, bot)
+	h.StartMatchmaking(userID, gender, MessageRequestID(message), session, bot)
 }
 
 func (h *HandlerManager) findMatch(userID uint, queue *models.MatchmakingQueue, bot BotInterface) {
@@ -296,7 +304,9 @@ func (h *HandlerManager) findMatch(userID uint, queue *models.MatchmakingQueue,
 }
 
 func (h *HandlerManager) createMatchSession(user1ID, user2ID uint, tg1ID, tg2ID int64, bot BotInterface) {
-	// Remove both from queue
+	// Remove both from queue, keeping the entries in case their fees go back
+	entry1, _ := h.MatchRepo.GetQueueEntry(user1ID)
+	entry2, _ := h.MatchRepo.GetQueueEntry(user2ID)
 	h.MatchRepo.RemoveFromQueue(user1ID)
 	h.MatchRepo.RemoveFromQueue(user2ID)
 
@@ -306,8 +316,8 @@ func (h *HandlerManager) createMatchSession(user1ID, user2ID uint, tg1ID, tg2ID
 		logger.Error("Failed to create match session", "error", err)
 
 		// Refund both users
-		h.refundMatchCost(user1ID, tg1ID, bot)
-		h.refundMatchCost(user2ID, tg2ID, bot)
+		h.refundMatchCost(user1ID, tg1ID, entry1, bot)
+		h.refundMatchCost(user2ID, tg2ID, entry2, bot)
 		return
 	}
 
@@ -345,6 +355,10 @@ func ChatKeyboard() tgbotapi.ReplyKeyboardMarkup {
 		),
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton(BtnEndChat),
+			tgbotapi.NewKeyboardButton(BtnBlockUser),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(BtnReportUser),
 		),
 	)
 }
@@ -361,7 +375,7 @@ func (h *HandlerManager) handleQueueTimeout(userID uint, telegramID int64, bot B
 
 	// Refund half coins
 	refundAmount := queueEntry.CoinsPaid / 2
-	if err := h.CoinRepo.AddCoins(userID, refundAmount, models.TxTypeMatchRefund, "بازگشت نصف هزینه به دلیل timeout"); err != nil {
+	if err := h.CoinRepo.AddCoins(userID, refundAmount, models.TxTypeMatchRefund, "بازگشت نصف هزینه به دلیل timeout", models.CoinOpKey(models.TxTypeMatchRefund, "queue", queueEntry.ID)); err != nil {
 		logger.Error("Failed to refund coins", "error", err)
 	}
 
@@ -391,9 +405,13 @@ func (h *HandlerManager) HandleMatchTimeout(userID uint, bot BotInterface) {
 	bot.SendMessage(user.TelegramID, "⏰ زمان چت رایگان تمام شد!\n\n💬 می‌توانید به چت ادامه دهید (هزینه: 2 سکه هر پیام).", nil)
 }
 
-func (h *HandlerManager) refundMatchCost(userID uint, telegramID int64, bot BotInterface) {
-	if err := h.CoinRepo.AddCoins(userID, h.Config.MatchCostCoins, models.TxTypeMatchRefund, "بازگشت هزینه به دلیل خطا"); err != nil {
-		logger.Error("Failed to refund coins", "error", err)
+// refundMatchCost pays back the fee of a queue entry. It is keyed like the other queue
+// refunds, so an entry's fee goes back at most once whichever path refunds it.
+func (h *HandlerManager) refundMatchCost(userID uint, telegramID int64, entry *models.MatchmakingQueue, bot BotInterface) {
+	if entry != nil && entry.CoinsPaid > 0 {
+		if err := h.CoinRepo.AddCoins(userID, entry.CoinsPaid, models.TxTypeMatchRefund, "بازگشت هزینه به دلیل خطا", models.CoinOpKey(models.TxTypeMatchRefund, "queue", entry.ID)); err != nil && !isDuplicateRequest(err) {
+			logger.Error("Failed to refund coins", "error", err)
+		}
 	}
 
 	h.UserRepo.UpdateUserStatus(userID, models.UserStatusOnline)
@@ -463,6 +481,12 @@ func (h *HandlerManager) EndChat(userID int64, bot BotInterface) {
 	isAdmin := user.TelegramID == h.Config.SuperAdminTgID
 	bot.SendMessage(userID, "👋 چت با موفقیت با طرف مقابل پایان یافت.", bot.GetMainMenuKeyboard(isAdmin))
 
+	// Both sides may still report the chat after it ended
+	h.offerChatReport(userID, match.ID, bot)
+	if otherUser != nil {
+		h.offerChatReport(otherUser.TelegramID, match.ID, bot)
+	}
+
 	// Award Village XP for finishing a chat
 	h.VillageSvc.AddXPForUser(user.ID, 10)
 	if otherUser != nil {
@@ -519,7 +543,7 @@ func (h *HandlerManager) HandleAdvancedSearchAge(userID int64, data string, msgI
 }
 
 // HandleAdvancedSearchProvince handles the callback for advanced province selection
-func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string, msgID int, session *UserSession, bot BotInterface) {
+func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string, msgID int, requestID string, session *UserSession, bot BotInterface) {
 	// data: search_province_{action}_{value}
 	parts := strings.Split(data, "_")
 	if len(parts) < 3 {
@@ -537,7 +561,7 @@ func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string,
 	if action == "skip" {
 		// Clear provinces filter
 		session.Data["search_provinces"] = []string{}
-		h.finalizeAdvancedSearch(userID, session, bot)
+		h.finalizeAdvancedSearch(userID, requestID, session, bot)
 		return
 	}
 
@@ -550,7 +574,7 @@ func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string,
 			}
 		}
 		session.Data["search_provinces"] = provinces
-		h.finalizeAdvancedSearch(userID, session, bot)
+		h.finalizeAdvancedSearch(userID, requestID, session, bot)
 		return
 	}
 
@@ -558,7 +582,7 @@ func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string,
 		// Toggle all? Or just select all? Let's say select all "major" ones or just ignore logic for now as it overrides everything.
 		// For simplicity, let's treat "All" as "Any" in filter.
 		session.Data["search_provinces"] = []string{} // Empty means any
-		h.finalizeAdvancedSearch(userID, session, bot)
+		h.finalizeAdvancedSearch(userID, requestID, session, bot)
 		return
 	}
 
@@ -578,7 +602,7 @@ func (h *HandlerManager) HandleAdvancedSearchProvince(userID int64, data string,
 	}
 }
 
-func (h *HandlerManager) finalizeAdvancedSearch(userID int64, session *UserSession, bot BotInterface) {
+func (h *HandlerManager) finalizeAdvancedSearch(userID int64, requestID string, session *UserSession, bot BotInterface) {
 	// Cleanup temporary session keys
 	delete(session.Data, "adv_age_min")
 	delete(session.Data, "adv_selected_provinces")
@@ -590,5 +614,5 @@ func (h *HandlerManager) finalizeAdvancedSearch(userID int64, session *UserSessi
 	}
 
 	session.State = "" // Clear state
-	h.StartMatchmaking(userID, gender, session, bot)
+	h.StartMatchmaking(userID, gender, requestID, session, bot)
 }
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/session"
+)
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestMatchmaking_PairsQueuedUsersAndEndsChat(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 3001, "Alice", models.GenderFemale, 20)
+	bob := env.newUser(t, 3002, "Bob", models.GenderMale, 20)
+
+	env.h.StartMatchmaking(alice.TelegramID, models.RequestedGenderAny, "req-alice", session.New(), env.bot)
+	env.h.StartMatchmaking(bob.TelegramID, models.GenderFemale, "req-bob", session.New(), env.bot)
+
+	if got := env.balance(t, alice.ID); got != 15 {
+		t.Errorf("alice balance after queueing = %d, want 15", got)
+	}
+
+	matched := waitFor(t, 5*time.Second, func() bool {
+		match, _ := env.h.MatchRepo.GetActiveMatch(alice.ID)
+		return match != nil
+	})
+	if !matched {
+		t.Fatal("queued users were not matched")
+	}
+
+	for _, u := range []*models.User{alice, bob} {
+		if inQueue, _ := env.h.MatchRepo.IsUserInQueue(u.ID); inQueue {
+			t.Errorf("%s is still queued after being matched", u.FullName)
+		}
+		user, _ := env.h.UserRepo.GetUserByID(u.ID)
+		if user.Status != models.UserStatusInMatch {
+			t.Errorf("%s status = %q, want %q", u.FullName, user.Status, models.UserStatusInMatch)
+		}
+		if !env.bot.received(u.TelegramID, "پیدا شد") {
+			t.Errorf("%s was not told about the match", u.FullName)
+		}
+	}
+
+	// Searching again while chatting is refused without a charge
+	env.h.StartMatchmaking(alice.TelegramID, models.RequestedGenderAny, "req-alice-2", session.New(), env.bot)
+	if got := env.balance(t, alice.ID); got != 15 {
+		t.Errorf("alice balance after searching mid-chat = %d, want 15", got)
+	}
+
+	env.h.EndChat(bob.TelegramID, env.bot)
+
+	if match, _ := env.h.MatchRepo.GetActiveMatch(alice.ID); match != nil {
+		t.Error("match is still active after EndChat")
+	}
+	if !env.bot.received(alice.TelegramID, "طرف مقابل چت را ترک کرد") {
+		t.Error("partner was not told the chat ended")
+	}
+	for _, u := range []*models.User{alice, bob} {
+		user, _ := env.h.UserRepo.GetUserByID(u.ID)
+		if user.Status != models.UserStatusOnline {
+			t.Errorf("%s status after EndChat = %q, want %q", u.FullName, user.Status, models.UserStatusOnline)
+		}
+	}
+}
+
+func TestMatchmaking_RequiresCoins(t *testing.T) {
+	env := newTestEnv(t)
+	poor := env.newUser(t, 3101, "Poor", models.GenderMale, 2)
+
+	env.h.StartMatchmaking(poor.TelegramID, models.RequestedGenderAny, "req-poor", session.New(), env.bot)
+
+	if inQueue, _ := env.h.MatchRepo.IsUserInQueue(poor.ID); inQueue {
+		t.Error("user without enough coins was queued")
+	}
+	if got := env.balance(t, poor.ID); got != 2 {
+		t.Errorf("balance = %d, want 2", got)
+	}
+	if !env.bot.received(poor.TelegramID, "سکه کافی نداری") {
+		t.Error("user was not told they lack coins")
+	}
+}
+
+func TestMatchmaking_BlockedUsersAreNotPaired(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 3201, "Alice", models.GenderFemale, 20)
+	bob := env.newUser(t, 3202, "Bob", models.GenderMale, 20)
+
+	if err := env.h.BlockRepo.BlockUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+
+	for _, u := range []*models.User{alice, bob} {
+		if err := env.h.MatchRepo.AddToQueue(&models.MatchmakingQueue{
+			UserID:          u.ID,
+			RequestedGender: models.RequestedGenderAny,
+			GameType:        models.GameTypeChat,
+		}); err != nil {
+			t.Fatalf("AddToQueue() error = %v", err)
+		}
+	}
+
+	match, err := env.h.MatchRepo.FindMatch(alice.ID, &models.MatchFilters{
+		Gender:   models.RequestedGenderAny,
+		GameType: models.GameTypeChat,
+	})
+	if err != nil {
+		t.Fatalf("FindMatch() error = %v", err)
+	}
+	if match != nil {
+		t.Errorf("FindMatch() paired blocked users: got %s", match.FullName)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/sender"
 )
 
 // ShowMatchMenu shows the match menu after finding a match
@@ -41,11 +42,7 @@ func (h *HandlerManager) ShowMatchMenu(userID int64, bot BotInterface) {
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // Match menu logic is handled in bot.go for most cases
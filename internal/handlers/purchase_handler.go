@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/payment"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// COIN PURCHASE (Receipts and online gateway)
+// ========================================
+
+// MyOrdersLimit is the number of orders shown on the "my orders" screen
+const MyOrdersLimit = 10
+
+// PaymentGatewayTimeout bounds each call to the payment provider
+const PaymentGatewayTimeout = 15 * time.Second
+
+// ErrPaymentNotCompleted is returned for callbacks of cancelled or unverifiable payments
+var ErrPaymentNotCompleted = errors.New(errors.ErrCodeValidationFailed, "payment not completed")
+
+// ErrPaymentNeedsReview is returned when the gateway took the money but the order couldn't
+// be credited; the admin is alerted to settle it by hand
+var ErrPaymentNeedsReview = errors.New(errors.ErrCodeInternalError, "verified payment not credited")
+
+// FormatCoinPurchasePlans renders the package list and payment info from models.CoinPackages
+func FormatCoinPurchasePlans(onlinePayment bool) string {
+	msg := MsgCoinPurchasePlans + "\n\n"
+	for _, pkg := range models.CoinPackages {
+		msg += "▫️ " + formatCoinPackage(&pkg)
+		if pkg.DiscountPercent > 0 {
+			msg += fmt.Sprintf(" (%s٪ تخفیف)", utils.FormatPersianNumber(int64(pkg.DiscountPercent)))
+		}
+		msg += "\n"
+	}
+	if onlinePayment {
+		msg += "\n" + MsgOnlinePaymentInfo + "\n"
+	}
+	return msg + "\n" + MsgCoinPaymentInfo
+}
+
+// formatCoinPackage renders a package as "<coins> سکه ⬅️ <price> تومان"
+func formatCoinPackage(pkg *models.CoinPackage) string {
+	return fmt.Sprintf("%s سکه ⬅️ %s تومان", utils.FormatPersianNumber(pkg.Coins), utils.FormatPersianNumber(pkg.PriceToman))
+}
+
+func (h *HandlerManager) HandleBuyCoins(userID int64, messageID int, bot BotInterface) {
+	onlinePayment := h.Payments != nil
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, pkg := range models.CoinPackages {
+		coins := utils.FormatPersianNumber(pkg.Coins)
+		row := tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s | %s سکه", BtnIHavePaid, coins), fmt.Sprintf("buy_pkg_%d", pkg.ID)),
+		)
+		if onlinePayment {
+			row = append([]tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s | %s سکه", BtnPayOnline, coins), fmt.Sprintf("pay_pkg_%d", pkg.ID)),
+			}, row...)
+		}
+		rows = append(rows, row)
+	}
+	if h.Config.TelegramPaymentsEnabled {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(BtnPayStars, "stars_shop"),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(BtnMyOrders, "my_orders"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به پروفایل", "edit_profile_back"),
+		),
+	)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	if messageID != 0 {
+		bot.EditMessage(userID, messageID, FormatCoinPurchasePlans(onlinePayment), keyboard)
+	} else {
+		bot.SendMessage(userID, FormatCoinPurchasePlans(onlinePayment), keyboard)
+	}
+}
+
+// HandleSelectCoinPackage remembers the package the user paid for and asks for the receipt
+func (h *HandlerManager) HandleSelectCoinPackage(userID int64, packageID uint, session *UserSession, bot BotInterface) {
+	pkg := models.GetCoinPackage(packageID)
+	if pkg == nil {
+		bot.SendMessage(userID, "❌ پکیج نامعتبر است!", nil)
+		return
+	}
+
+	session.State = StateAwaitingReceipt
+	session.Data["purchase_package_id"] = pkg.ID
+
+	bot.SendMessage(userID, fmt.Sprintf("📦 پکیج انتخابی: %s\n\n%s\n\nبرای لغو /cancel رو بزن.", formatCoinPackage(pkg), MsgRequestReceipt), nil)
+}
+
+func (h *HandlerManager) HandlePurchaseReceipt(userID int64, message *tgbotapi.Message, session *UserSession, bot BotInterface) {
+	packageID, _ := session.Data["purchase_package_id"].(uint)
+	pkg := models.GetCoinPackage(packageID)
+	if pkg == nil {
+		session.State = ""
+		bot.SendMessage(userID, "⚠️ اول پکیجی که خریدی رو انتخاب کن.", nil)
+		h.HandleBuyCoins(userID, 0, bot)
+		return
+	}
+
+	if message.Photo == nil {
+		bot.SendMessage(userID, "❌ خطا! لطفاً رسید خود را به صورت عکس ارسال کنید.", nil)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	receiptFileID := message.Photo[len(message.Photo)-1].FileID
+	order, err := h.PurchaseRepo.CreateOrder(user.ID, pkg, receiptFileID)
+	if err != nil {
+		logger.Error("Failed to create purchase order", "user_id", user.ID, "package_id", pkg.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ثبت سفارش! لطفاً دوباره رسید رو بفرست.", nil)
+		return
+	}
+
+	session.State = ""
+	delete(session.Data, "purchase_package_id")
+
+	logger.Info("Purchase order created", "order_id", order.ID, "user_id", user.ID, "coins", order.Coins)
+
+	// Notify admin
+	adminMsg := fmt.Sprintf("💰 رسید پرداخت جدید دریافت شد!\n\n🧾 سفارش #%d\n📦 %s\n👤 کاربر: %s (%d)\n🆔 آیدی عمومی: /user_%s",
+		order.ID, formatCoinPackage(pkg), user.FullName, userID, user.PublicID)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ تایید و واریز سکه", fmt.Sprintf("order_approve_%d", order.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ رد", fmt.Sprintf("order_reject_%d", order.ID)),
+		),
+	)
+	bot.SendPhoto(h.Config.SuperAdminTgID, receiptFileID, adminMsg, keyboard)
+
+	bot.SendMessage(userID, fmt.Sprintf("🧾 شماره سفارش: #%d\n\n%s", order.ID, MsgPurchasePending), nil)
+}
+
+// HandleOnlinePayment opens a gateway invoice for a package and sends the buyer the pay link.
+// If the gateway fails the buyer is pointed to the card-to-card receipt flow.
+func (h *HandlerManager) HandleOnlinePayment(userID int64, packageID uint, bot BotInterface) {
+	if h.Payments == nil {
+		h.HandleBuyCoins(userID, 0, bot)
+		return
+	}
+
+	pkg := models.GetCoinPackage(packageID)
+	if pkg == nil {
+		bot.SendMessage(userID, "❌ پکیج نامعتبر است!", nil)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	order, err := h.PurchaseRepo.CreateGatewayOrder(user.ID, pkg, h.Payments.Name())
+	if err != nil {
+		logger.Error("Failed to create gateway order", "user_id", user.ID, "package_id", pkg.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ثبت سفارش! دوباره تلاش کن.", nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PaymentGatewayTimeout)
+	defer cancel()
+
+	invoice, err := h.Payments.CreateInvoice(ctx, payment.InvoiceRequest{
+		OrderID:     order.ID,
+		AmountToman: order.PriceToman,
+		Description: fmt.Sprintf("خرید %d سکه - سفارش #%d", order.Coins, order.ID),
+		CallbackURL: payment.CallbackURL(h.Config.PaymentCallbackURL),
+	})
+	if err == nil {
+		err = h.PurchaseRepo.SetOrderAuthority(order.ID, invoice.Authority)
+	}
+	if err != nil {
+		logger.Error("Failed to open payment invoice", "order_id", order.ID, "provider", h.Payments.Name(), "error", err)
+		h.PurchaseRepo.RejectOrder(order.ID, 0)
+		bot.SendMessage(userID, "⚠️ درگاه پرداخت در حال حاضر در دسترس نیست.\n\nمی‌تونی کارت به کارت واریز کنی و رسیدش رو بفرستی.", nil)
+		h.HandleBuyCoins(userID, 0, bot)
+		return
+	}
+
+	logger.Info("Payment invoice opened", "order_id", order.ID, "provider", h.Payments.Name(), "authority", invoice.Authority)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("💳 پرداخت", invoice.PayURL),
+		),
+	)
+	bot.SendMessage(userID, fmt.Sprintf("🧾 سفارش #%d\n📦 %s\n\nبرای پرداخت روی دکمه زیر بزن. بعد از پرداخت، سکه‌ها خودکار به حسابت اضافه می‌شن.", order.ID, formatCoinPackage(pkg)), keyboard)
+}
+
+// HandlePaymentCallback verifies a gateway callback and credits the order's coins.
+// It is safe to call repeatedly for the same payment; coins are credited only once.
+// The status in the callback comes from the buyer's browser, so the order is only
+// credited or rejected on what the provider says when verifying.
+func (h *HandlerManager) HandlePaymentCallback(query url.Values, bot BotInterface) (*models.PurchaseOrder, error) {
+	if h.Payments == nil {
+		return nil, fmt.Errorf("online payments are disabled")
+	}
+
+	callback, err := h.Payments.ParseCallback(query)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := h.PurchaseRepo.GetOrderByAuthority(h.Payments.Name(), callback.Authority)
+	if err != nil {
+		return nil, err
+	}
+
+	switch order.Status {
+	case models.OrderStatusApproved:
+		return order, nil
+	case models.OrderStatusPending:
+	default:
+		// Verifying would capture money for an order that is no longer credited;
+		// left unverified, the gateway refunds the buyer
+		return order, ErrPaymentNotCompleted
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PaymentGatewayTimeout)
+	defer cancel()
+
+	refID, err := h.Payments.Verify(ctx, order.Authority, order.PriceToman)
+	if stderrors.Is(err, payment.ErrNotPaid) {
+		if rejected, _ := h.PurchaseRepo.RejectOrder(order.ID, 0); rejected {
+			bot.SendMessage(order.User.TelegramID, fmt.Sprintf("❌ پرداخت سفارش #%d انجام نشد یا لغو شد.", order.ID), nil)
+		}
+		return order, ErrPaymentNotCompleted
+	}
+	if err != nil {
+		logger.Error("Payment verification failed", "order_id", order.ID, "provider", order.Provider, "paid", callback.Paid, "error", err)
+		return order, ErrPaymentNotCompleted
+	}
+
+	confirmed, credited, err := h.PurchaseRepo.ConfirmPayment(order.ID, refID)
+	if err != nil {
+		logger.Error("Failed to confirm payment", "order_id", order.ID, "ref_id", refID, "error", err)
+		return order, err
+	}
+	if !credited {
+		if confirmed.Status == models.OrderStatusApproved {
+			// A concurrent callback for the same payment credited it
+			return confirmed, nil
+		}
+		logger.Error("Verified payment for an order that is no longer pending", "order_id", order.ID, "status", confirmed.Status, "ref_id", refID)
+		order.Status = confirmed.Status
+		h.alertUnconfirmedPayment(order, refID, bot)
+		return order, ErrPaymentNeedsReview
+	}
+
+	logger.Info("Payment confirmed", "order_id", order.ID, "provider", order.Provider, "ref_id", refID)
+	bot.SendMessage(order.User.TelegramID, fmt.Sprintf("🎉 پرداخت شما تایید شد!\n\n🧾 سفارش #%d\n🔖 کد پیگیری: %s\n💰 %s سکه به حسابت اضافه شد.", order.ID, refID, utils.FormatPersianNumber(order.Coins)), nil)
+
+	return confirmed, nil
+}
+
+// alertUnconfirmedPayment tells the admin about money the gateway took for an order
+// that wasn't credited, so the buyer can be credited or refunded by hand
+func (h *HandlerManager) alertUnconfirmedPayment(order *models.PurchaseOrder, refID string, bot BotInterface) {
+	if h.Config.SuperAdminTgID == 0 {
+		return
+	}
+	bot.SendMessage(h.Config.SuperAdminTgID, fmt.Sprintf("⚠️ پرداخت تایید شده بدون واریز سکه!\n\n🧾 سفارش #%d (%s)\n👤 %s (%d)\n💰 %s سکه | %s تومان\n🔖 کد پیگیری: %s\n\nسکه‌ها را دستی واریز کن یا مبلغ را برگردان.",
+		order.ID, models.OrderStatusTitles[order.Status], order.User.FullName, order.User.TelegramID, utils.FormatPersianNumber(order.Coins), utils.FormatPersianNumber(order.PriceToman), refID), nil)
+}
+
+// HandleOrderReview approves or rejects a purchase order and notifies the buyer
+func (h *HandlerManager) HandleOrderReview(userID int64, orderID uint, approve bool, bot BotInterface) {
+	if !h.isSuperAdmin(userID) {
+		return
+	}
+
+	order, err := h.PurchaseRepo.GetOrderByID(orderID)
+	if err != nil || order.IsGateway() {
+		bot.SendMessage(userID, "❌ سفارش یافت نشد!", nil)
+		return
+	}
+
+	var reviewed bool
+	if approve {
+		_, reviewed, err = h.PurchaseRepo.ApproveOrder(order.ID, userID)
+	} else {
+		reviewed, err = h.PurchaseRepo.RejectOrder(order.ID, userID)
+	}
+	if err != nil {
+		logger.Error("Failed to review purchase order", "order_id", order.ID, "approve", approve, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ثبت نتیجه بررسی!", nil)
+		return
+	}
+	if !reviewed {
+		bot.SendMessage(userID, fmt.Sprintf("ℹ️ سفارش #%d قبلاً بررسی شده است.", order.ID), nil)
+		return
+	}
+
+	logger.Info("Purchase order reviewed", "order_id", order.ID, "approve", approve, "admin", userID)
+
+	if approve {
+		bot.SendMessage(order.User.TelegramID, fmt.Sprintf("🎉 پرداخت شما تایید شد!\n\n🧾 سفارش #%d\n💰 %s سکه به حسابت اضافه شد.", order.ID, utils.FormatPersianNumber(order.Coins)), nil)
+		bot.SendMessage(userID, fmt.Sprintf("✅ سفارش #%d تایید شد و %d سکه به %s واریز شد.", order.ID, order.Coins, order.User.FullName), nil)
+	} else {
+		bot.SendMessage(order.User.TelegramID, fmt.Sprintf("❌ رسید سفارش #%d تایید نشد.\n\nاگر فکر می‌کنی اشتباهی رخ داده، با پشتیبانی در تماس باش.", order.ID), nil)
+		bot.SendMessage(userID, fmt.Sprintf("❌ سفارش #%d رد شد.", order.ID), nil)
+	}
+}
+
+// ShowMyOrders lists the user's latest purchase orders
+func (h *HandlerManager) ShowMyOrders(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	orders, err := h.PurchaseRepo.GetUserOrders(user.ID, MyOrdersLimit)
+	if err != nil {
+		logger.Error("Failed to get purchase orders", "user_id", user.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در دریافت سفارش‌ها!", nil)
+		return
+	}
+
+	msg := "🧾 سفارش‌های من\n━━━━━━━━━━━━━━\n"
+	if len(orders) == 0 {
+		msg += "هنوز سفارشی ثبت نکردی."
+	}
+	for _, order := range orders {
+		msg += fmt.Sprintf("#%d | %s سکه | %s تومان\n%s | %s\n\n",
+			order.ID,
+			utils.FormatPersianNumber(order.Coins),
+			utils.FormatPersianNumber(order.PriceToman),
+			models.OrderStatusTitles[order.Status],
+			order.CreatedAt.Format("2006/01/02 15:04"))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(BtnBuyCoins, "buy_coins"),
+		),
+	)
+	bot.SendMessage(userID, msg, keyboard)
+}
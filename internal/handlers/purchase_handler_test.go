@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/payment"
+)
+
+// stubGateway is a payment provider whose invoices are paid or not regardless of what
+// the callback query claims
+type stubGateway struct {
+	paid     map[string]bool
+	verifies int
+	onVerify func() // runs before Verify answers
+}
+
+func (g *stubGateway) Name() string { return "stub" }
+
+func (g *stubGateway) CreateInvoice(ctx context.Context, req payment.InvoiceRequest) (*payment.Invoice, error) {
+	authority := fmt.Sprintf("STUB%d", req.OrderID)
+	return &payment.Invoice{Authority: authority, PayURL: "https://pay.example.com/" + authority}, nil
+}
+
+func (g *stubGateway) ParseCallback(query url.Values) (*payment.Callback, error) {
+	return &payment.Callback{Authority: query.Get("Authority"), Paid: query.Get("Status") == "OK"}, nil
+}
+
+func (g *stubGateway) Verify(ctx context.Context, authority string, amountToman int64) (string, error) {
+	g.verifies++
+	if g.onVerify != nil {
+		g.onVerify()
+	}
+	if !g.paid[authority] {
+		return "", fmt.Errorf("stub invoice %s: %w", authority, payment.ErrNotPaid)
+	}
+	return "REF-" + authority, nil
+}
+
+// newGatewayOrder opens a gateway order for the first coin package
+func newGatewayOrder(t *testing.T, env *testEnv, gateway *stubGateway, user *models.User) *models.PurchaseOrder {
+	t.Helper()
+
+	env.h.Payments = gateway
+	env.h.HandleOnlinePayment(user.TelegramID, models.CoinPackages[0].ID, env.bot)
+
+	orders, err := env.h.PurchaseRepo.GetUserOrders(user.ID, 1)
+	if err != nil || len(orders) != 1 || orders[0].Authority == "" {
+		t.Fatalf("GetUserOrders() = %+v, %v; want one gateway order", orders, err)
+	}
+	return &orders[0]
+}
+
+func callbackQuery(authority, status string) url.Values {
+	return url.Values{"Authority": {authority}, "Status": {status}}
+}
+
+func TestHandlePaymentCallback_ForgedCancelDoesNotRejectPaidOrder(t *testing.T) {
+	env := newTestEnv(t)
+	buyer := env.newUser(t, 4001, "Buyer", models.GenderMale, 0)
+	gateway := &stubGateway{paid: map[string]bool{}}
+	order := newGatewayOrder(t, env, gateway, buyer)
+	gateway.paid[order.Authority] = true
+	before := env.balance(t, buyer.ID)
+
+	// Anyone holding the authority can send a cancelled status; the gateway says otherwise
+	if _, err := env.h.HandlePaymentCallback(callbackQuery(order.Authority, "NOK"), env.bot); err != nil {
+		t.Fatalf("HandlePaymentCallback(NOK) error = %v", err)
+	}
+	if got := env.balance(t, buyer.ID) - before; got != models.CoinPackages[0].Coins {
+		t.Errorf("coins credited = %d, want %d", got, models.CoinPackages[0].Coins)
+	}
+}
+
+func TestHandlePaymentCallback_RejectedOrderIsNotVerified(t *testing.T) {
+	env := newTestEnv(t)
+	buyer := env.newUser(t, 4002, "Buyer", models.GenderMale, 0)
+	gateway := &stubGateway{paid: map[string]bool{}}
+	order := newGatewayOrder(t, env, gateway, buyer)
+	before := env.balance(t, buyer.ID)
+
+	if _, err := env.h.HandlePaymentCallback(callbackQuery(order.Authority, "NOK"), env.bot); err != ErrPaymentNotCompleted {
+		t.Fatalf("HandlePaymentCallback(NOK) error = %v, want ErrPaymentNotCompleted", err)
+	}
+	if got, _ := env.h.PurchaseRepo.GetOrderByID(order.ID); got.Status != models.OrderStatusRejected {
+		t.Fatalf("order status = %q, want rejected", got.Status)
+	}
+
+	// A late OK must not capture money for the rejected order
+	gateway.paid[order.Authority] = true
+	verifies := gateway.verifies
+	if _, err := env.h.HandlePaymentCallback(callbackQuery(order.Authority, "OK"), env.bot); err != ErrPaymentNotCompleted {
+		t.Errorf("HandlePaymentCallback(OK) error = %v, want ErrPaymentNotCompleted", err)
+	}
+	if gateway.verifies != verifies {
+		t.Error("a rejected order was verified with the gateway")
+	}
+	if got := env.balance(t, buyer.ID); got != before {
+		t.Errorf("balance = %d, want %d", got, before)
+	}
+}
+
+func TestHandlePaymentCallback_VerifiedMoneyForClosedOrderAlertsAdmin(t *testing.T) {
+	env := newTestEnv(t)
+	env.h.Config.SuperAdminTgID = 9000
+	buyer := env.newUser(t, 4003, "Buyer", models.GenderMale, 0)
+	gateway := &stubGateway{paid: map[string]bool{}}
+	order := newGatewayOrder(t, env, gateway, buyer)
+	gateway.paid[order.Authority] = true
+
+	// The order is closed while the gateway is verifying
+	gateway.onVerify = func() { env.h.PurchaseRepo.RejectOrder(order.ID, 0) }
+
+	if _, err := env.h.HandlePaymentCallback(callbackQuery(order.Authority, "OK"), env.bot); err != ErrPaymentNeedsReview {
+		t.Fatalf("HandlePaymentCallback() error = %v, want ErrPaymentNeedsReview", err)
+	}
+	if !env.bot.received(9000, "REF-"+order.Authority) {
+		t.Errorf("admin messages = %q, want an alert with the reference ID", env.bot.messages(9000))
+	}
+}
+
+// starsPayment is the message Telegram sends after a Stars invoice is paid
+func starsPayment(fromTgID int64, payload, chargeID string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		From: &tgbotapi.User{ID: fromTgID},
+		SuccessfulPayment: &tgbotapi.SuccessfulPayment{
+			Currency:                models.TelegramStarsCurrency,
+			TotalAmount:             50,
+			InvoicePayload:          payload,
+			TelegramPaymentChargeID: chargeID,
+		},
+	}
+}
+
+func TestHandleSuccessfulPayment_UncreditablePaymentIsHeld(t *testing.T) {
+	env := newTestEnv(t)
+	env.h.Config.SuperAdminTgID = 9000
+	buyer := env.newUser(t, 7001, "Buyer", models.GenderMale, 100)
+	other := env.newUser(t, 7002, "Other", models.GenderFemale, 100)
+	bundle := models.DiamondBundles[0]
+
+	tests := []struct {
+		name     string
+		fromTgID int64
+		payload  string
+		wantUser *uint
+	}{
+		{"unknown product", buyer.TelegramID, models.InvoicePayload(models.ProductKindDiamonds, 999, buyer.ID), nil},
+		{"payer without account", 7999, models.InvoicePayload(models.ProductKindDiamonds, bundle.ID, buyer.ID), nil},
+		{"invoice of another user", buyer.TelegramID, models.InvoicePayload(models.ProductKindDiamonds, bundle.ID, other.ID), &buyer.ID},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chargeID := fmt.Sprintf("CHARGE-%d", i)
+			env.h.HandleSuccessfulPayment(starsPayment(tt.fromTgID, tt.payload, chargeID), env.bot)
+
+			// The money was taken, so the charge must be on record for a refund or manual credit
+			payment, err := env.h.PurchaseRepo.GetTelegramPayment(chargeID)
+			if err != nil {
+				t.Fatalf("GetTelegramPayment() error = %v", err)
+			}
+			if payment.Status != models.TelegramPaymentStatusNeedsReview {
+				t.Errorf("Status = %q, want %q", payment.Status, models.TelegramPaymentStatusNeedsReview)
+			}
+			if payment.PayerTgID != tt.fromTgID {
+				t.Errorf("PayerTgID = %d, want %d", payment.PayerTgID, tt.fromTgID)
+			}
+			if (payment.UserID == nil) != (tt.wantUser == nil) || (tt.wantUser != nil && *payment.UserID != *tt.wantUser) {
+				t.Errorf("UserID = %v, want %v", payment.UserID, tt.wantUser)
+			}
+			if !env.bot.received(9000, chargeID) {
+				t.Errorf("admin messages = %q, want an alert with the charge ID", env.bot.messages(9000))
+			}
+		})
+	}
+
+	for _, user := range []*models.User{buyer, other} {
+		got, err := env.h.UserRepo.GetUserByID(user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if got.Diamonds != 0 {
+			t.Errorf("user %d diamonds = %d, want 0", user.ID, got.Diamonds)
+		}
+	}
+}
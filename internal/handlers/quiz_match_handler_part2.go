@@ -11,6 +11,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/logger"
 	"github.com/mroshb/game_bot/pkg/utils"
 )
@@ -301,8 +302,8 @@ func (h *HandlerManager) sendQuestionToUser(userTgID int64, userID, matchID uint
 		))
 	}
 
-	user1Booster, _ := h.QuizMatchRepo.GetUserBooster(userID, models.BoosterRemove2Options)
-	user2Booster, _ := h.QuizMatchRepo.GetUserBooster(userID, models.BoosterSecondChance)
+	remove2Count, _ := h.InventoryRepo.GetQuantity(userID, models.BoosterRemove2Options)
+	retryCount, _ := h.InventoryRepo.GetQuantity(userID, models.BoosterSecondChance)
 
 	var boosterRow []tgbotapi.InlineKeyboardButton
 
@@ -318,16 +319,16 @@ func (h *HandlerManager) sendQuestionToUser(userTgID int64, userID, matchID uint
 	}
 	session.mu.Unlock()
 
-	if !usedRemove2 && user1Booster != nil && user1Booster.Quantity > 0 {
+	if !usedRemove2 && remove2Count > 0 {
 		boosterRow = append(boosterRow, tgbotapi.NewInlineKeyboardButtonData(
-			fmt.Sprintf("✂️ حذف 2 گزینه (%d)", user1Booster.Quantity),
+			fmt.Sprintf("✂️ حذف 2 گزینه (%d)", remove2Count),
 			fmt.Sprintf("btn:qboost_r2_%d_%d", matchID, questionNum),
 		))
 	}
 
-	if !usedRetry && user2Booster != nil && user2Booster.Quantity > 0 {
+	if !usedRetry && retryCount > 0 {
 		boosterRow = append(boosterRow, tgbotapi.NewInlineKeyboardButtonData(
-			fmt.Sprintf("🛡 شانس مجدد (%d)", user2Booster.Quantity),
+			fmt.Sprintf("🛡 شانس مجدد (%d)", retryCount),
 			fmt.Sprintf("btn:qboost_rt_%d_%d", matchID, questionNum),
 		))
 	}
@@ -637,7 +638,7 @@ func (h *HandlerManager) UpdateQuizLights(matchID, userID uint, questionNum int,
 	}
 
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, lights)
-	bot.GetAPI().(*tgbotapi.BotAPI).Send(edit)
+	bot.Send(chatID, edit, sender.PriorityGame)
 }
 
 // Continued in quiz_match_handler_part3.go...
@@ -61,7 +61,7 @@ func (h *HandlerManager) HandleBoosterRemove2(userID int64, matchID uint, questi
 	question := session.Questions[questionNum-1]
 	session.mu.Unlock()
 
-	err := h.QuizMatchRepo.UseBooster(user.ID, models.BoosterRemove2Options)
+	err := h.InventoryRepo.Consume(user.ID, models.BoosterRemove2Options, 1)
 	if err != nil {
 		bot.SendMessage(userID, "❌ بوستر کافی ندارید!", nil)
 		return
@@ -170,7 +170,7 @@ func (h *HandlerManager) HandleBoosterRetry(userID int64, matchID uint, question
 	}
 	session.mu.Unlock()
 
-	err := h.QuizMatchRepo.UseBooster(user.ID, models.BoosterSecondChance)
+	err := h.InventoryRepo.Consume(user.ID, models.BoosterSecondChance, 1)
 	if err != nil {
 		bot.SendMessage(userID, "❌ بوستر کافی ندارید!", nil)
 		return
@@ -314,6 +314,11 @@ func (h *HandlerManager) EndQuizRound(matchID uint, bot BotInterface) {
 // END GAME
 // ========================================
 
+// quizResultKey keys the end-of-game coins of a player, so a match ended twice pays once
+func quizResultKey(matchID, userID uint) string {
+	return models.CoinOpKey("quiz_result", matchID, userID)
+}
+
 func (h *HandlerManager) EndQuizGame(matchID uint, bot BotInterface) {
 	match, err := h.QuizMatchRepo.GetQuizMatch(matchID)
 	if err != nil {
@@ -340,17 +345,20 @@ func (h *HandlerManager) EndQuizGame(matchID uint, bot BotInterface) {
 			loserID = match.User2ID
 		}
 		h.QuizMatchRepo.FinishQuizMatch(matchID, winnerID)
-		h.CoinRepo.AddCoins(winnerID, int64(models.QuizWinRewardCoins), "quiz_win", "Quiz game win reward")
+		h.CoinRepo.AddCoins(winnerID, int64(models.QuizWinRewardCoins), "quiz_win", "Quiz game win reward", quizResultKey(matchID, winnerID))
 		h.UserRepo.AddXP(winnerID, models.QuizWinRewardXP)
 		h.UserRepo.AddXP(loserID, models.QuizLoseRewardXP)
 	} else {
 		h.QuizMatchRepo.FinishQuizMatch(matchID, 0)
-		h.CoinRepo.AddCoins(match.User1ID, int64(models.QuizDrawRewardCoins), "quiz_draw", "Quiz game draw reward")
-		h.CoinRepo.AddCoins(match.User2ID, int64(models.QuizDrawRewardCoins), "quiz_draw", "Quiz game draw reward")
+		h.CoinRepo.AddCoins(match.User1ID, int64(models.QuizDrawRewardCoins), "quiz_draw", "Quiz game draw reward", quizResultKey(matchID, match.User1ID))
+		h.CoinRepo.AddCoins(match.User2ID, int64(models.QuizDrawRewardCoins), "quiz_draw", "Quiz game draw reward", quizResultKey(matchID, match.User2ID))
 		h.UserRepo.AddXP(match.User1ID, models.QuizDrawRewardXP)
 		h.UserRepo.AddXP(match.User2ID, models.QuizDrawRewardXP)
 	}
 
+	// Pay out the pot if this was a betting game
+	h.settleGameBet(models.GameTypeQuiz, matchID, winnerID, bot)
+
 	msg1 := "🎮 بازی تمام شد!\n\n"
 	msg1 += "📊 نتیجه نهایی:\n"
 	msg1 += fmt.Sprintf("👤 شما: %d صحیح | ⏱ %.1fث\n", match.User1TotalCorrect, float64(match.User1TotalTimeMs)/1000.0)
@@ -474,6 +482,9 @@ func (h *HandlerManager) HandleQuizTimeout(matchID uint, bot BotInterface) {
 	bot.SendMessage(match.User1.TelegramID, msg, nil)
 	bot.SendMessage(match.User2.TelegramID, msg, nil)
 
+	// Return stakes if this was a betting game
+	h.refundGameBet(models.GameTypeQuiz, matchID, bot)
+
 	cleanupQuizGameSession(matchID)
 
 	// Set status back to online if no other active games
@@ -10,12 +10,21 @@ import (
 
 // StartQuizMatchmaking starts the matchmaking process for quiz games
 func (h *HandlerManager) StartQuizMatchmaking(userID int64, bot BotInterface) {
+	h.startQuizMatchmaking(userID, 0, bot)
+}
+
+// startQuizMatchmaking queues the user for a quiz game with an optional stake (0 = no bet)
+func (h *HandlerManager) startQuizMatchmaking(userID int64, betAmount int64, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeQuiz, bot) {
+		return
+	}
+
 	// Check if user is already in matchmaking queue
 	inQueue, _ := h.MatchRepo.IsUserInQueue(user.ID)
 	if inQueue {
@@ -29,6 +38,7 @@ func (h *HandlerManager) StartQuizMatchmaking(userID int64, bot BotInterface) {
 		RequestedGender: models.RequestedGenderAny,
 		CoinsPaid:       0,
 		GameType:        models.GameTypeQuiz,
+		BetAmount:       betAmount,
 	}
 
 	err = h.MatchRepo.AddToQueue(queue)
@@ -42,7 +52,11 @@ func (h *HandlerManager) StartQuizMatchmaking(userID int64, bot BotInterface) {
 	h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusSearching)
 
 	// Send searching message
-	bot.SendMessage(userID, "🔍 در حال جستجوی حریف برای بازی کوئیز...\n\n⏳ لطفاً صبر کنید...", nil)
+	if betAmount > 0 {
+		bot.SendMessage(userID, fmt.Sprintf("🔍 در حال جستجوی حریف برای بازی کوئیز شرطی...\n\n💰 مبلغ شرط: %d سکه\n⏳ لطفاً صبر کنید...", betAmount), nil)
+	} else {
+		bot.SendMessage(userID, "🔍 در حال جستجوی حریف برای بازی کوئیز...\n\n⏳ لطفاً صبر کنید...", nil)
+	}
 
 	// Try to find a match immediately
 	go h.tryQuizMatchmaking(user.ID, bot)
@@ -54,7 +68,7 @@ func (h *HandlerManager) tryQuizMatchmaking(userID uint, bot BotInterface) {
 	time.Sleep(2 * time.Second)
 
 	// Get user from queue
-	_, err := h.MatchRepo.GetQueueEntry(userID)
+	entry, err := h.MatchRepo.GetQueueEntry(userID)
 	if err != nil {
 		// User might have cancelled
 		return
@@ -62,8 +76,9 @@ func (h *HandlerManager) tryQuizMatchmaking(userID uint, bot BotInterface) {
 
 	// Try to find a match
 	filters := &models.MatchFilters{
-		Gender:   models.RequestedGenderAny,
-		GameType: models.GameTypeQuiz,
+		Gender:    models.RequestedGenderAny,
+		GameType:  models.GameTypeQuiz,
+		BetAmount: entry.BetAmount,
 	}
 	opponent, err := h.MatchRepo.FindMatch(userID, filters)
 	if err != nil || opponent == nil {
@@ -95,6 +110,21 @@ func (h *HandlerManager) tryQuizMatchmaking(userID uint, bot BotInterface) {
 		return
 	}
 
+	// Hold both stakes in escrow for betting games
+	if err := h.placeGameBet(models.GameTypeQuiz, match.ID, userID, opponent.ID, entry.BetAmount); err != nil {
+		h.QuizMatchRepo.TimeoutQuizMatch(match.ID)
+		failMsg := betFailureMessage(err)
+		user, _ := h.UserRepo.GetUserByID(userID)
+		if user != nil {
+			bot.SendMessage(user.TelegramID, failMsg, nil)
+		}
+		bot.SendMessage(opponent.TelegramID, failMsg, nil)
+
+		h.UserRepo.UpdateUserStatus(userID, models.UserStatusOnline)
+		h.UserRepo.UpdateUserStatus(opponent.ID, models.UserStatusOnline)
+		return
+	}
+
 	// Update both users' statuses
 	h.UserRepo.UpdateUserStatus(userID, models.UserStatusInMatch)
 	h.UserRepo.UpdateUserStatus(opponent.ID, models.UserStatusInMatch)
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+)
+
+// queueForQuiz puts the users in the quiz queue without starting the background search
+func queueForQuiz(t *testing.T, env *testEnv, bet int64, users ...*models.User) {
+	t.Helper()
+
+	for _, u := range users {
+		if err := env.h.MatchRepo.AddToQueue(&models.MatchmakingQueue{
+			UserID:          u.ID,
+			RequestedGender: models.RequestedGenderAny,
+			GameType:        models.GameTypeQuiz,
+			BetAmount:       bet,
+		}); err != nil {
+			t.Fatalf("AddToQueue(%d) error = %v", u.ID, err)
+		}
+	}
+}
+
+func TestQuizMatchmaking_EscrowsBets(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 4001, "Alice", models.GenderFemale, 100)
+	bob := env.newUser(t, 4002, "Bob", models.GenderMale, 100)
+	queueForQuiz(t, env, 20, alice, bob)
+
+	env.h.tryQuizMatchmaking(alice.ID, env.bot)
+
+	matches, err := env.h.QuizMatchRepo.GetAllActiveQuizMatchesByUser(alice.ID)
+	if err != nil {
+		t.Fatalf("GetAllActiveQuizMatchesByUser() error = %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("active quiz matches = %d, want 1", len(matches))
+	}
+	match := matches[0]
+	defer cleanupQuizGameSession(match.ID)
+
+	if match.User1ID != alice.ID || match.User2ID != bob.ID {
+		t.Errorf("match players = (%d, %d), want (%d, %d)", match.User1ID, match.User2ID, alice.ID, bob.ID)
+	}
+
+	escrows, err := memory.NewCoinRepository(env.db).GetBetEscrows(models.GameTypeQuiz, match.ID)
+	if err != nil {
+		t.Fatalf("GetBetEscrows() error = %v", err)
+	}
+	if len(escrows) != 2 {
+		t.Errorf("escrows = %d, want 2", len(escrows))
+	}
+	for _, u := range []*models.User{alice, bob} {
+		if got := env.balance(t, u.ID); got != 80 {
+			t.Errorf("%s balance = %d, want 80", u.FullName, got)
+		}
+		if inQueue, _ := env.h.MatchRepo.IsUserInQueue(u.ID); inQueue {
+			t.Errorf("%s is still queued", u.FullName)
+		}
+	}
+
+	env.h.settleGameBet(models.GameTypeQuiz, match.ID, bob.ID, env.bot)
+	if got := env.balance(t, bob.ID); got != 120 {
+		t.Errorf("winner balance after settling = %d, want 120", got)
+	}
+	if got := env.balance(t, alice.ID); got != 80 {
+		t.Errorf("loser balance after settling = %d, want 80", got)
+	}
+}
+
+func TestQuizMatchmaking_UnfundedBetCancelsMatch(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 4101, "Alice", models.GenderFemale, 100)
+	bob := env.newUser(t, 4102, "Bob", models.GenderMale, 100)
+	queueForQuiz(t, env, 50, alice, bob)
+
+	// Bob spends his coins while waiting in the queue
+	if err := env.h.CoinRepo.DeductCoins(bob.ID, 90, models.TxTypeShopPurchase, "test", ""); err != nil {
+		t.Fatalf("DeductCoins() error = %v", err)
+	}
+
+	env.h.tryQuizMatchmaking(alice.ID, env.bot)
+
+	if matches, _ := env.h.QuizMatchRepo.GetAllActiveQuizMatchesByUser(alice.ID); len(matches) != 0 {
+		t.Errorf("active quiz matches = %d, want 0", len(matches))
+	}
+	if got := env.balance(t, alice.ID); got != 100 {
+		t.Errorf("alice balance = %d, want the untouched 100", got)
+	}
+	if got := env.balance(t, bob.ID); got != 10 {
+		t.Errorf("bob balance = %d, want 10", got)
+	}
+	for _, u := range []*models.User{alice, bob} {
+		user, _ := env.h.UserRepo.GetUserByID(u.ID)
+		if user.Status != models.UserStatusOnline {
+			t.Errorf("%s status = %q, want %q", u.FullName, user.Status, models.UserStatusOnline)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// ========================================
+// CHAT ABUSE REPORTS
+// ========================================
+
+const (
+	// ReportWindow is how long after a chat ends its partner can still be reported
+	ReportWindow = 24 * time.Hour
+
+	// ChatTranscriptRetention is how long a chat transcript is kept after its last message
+	ChatTranscriptRetention = ReportWindow
+
+	// ReportQueuePageSize is the number of pending reports listed per page for admins
+	ReportQueuePageSize = 8
+
+	// reportEvidenceContentLimit caps each evidence message when shown to admins
+	reportEvidenceContentLimit = 200
+)
+
+// chatTranscript keeps the most recent relayed messages of an anonymous chat
+type chatTranscript struct {
+	Messages     []models.ReportEvidenceMessage
+	LastActivity time.Time
+}
+
+var (
+	chatTranscripts   = make(map[uint]*chatTranscript) // matchID -> transcript
+	chatTranscriptsMu sync.Mutex
+)
+
+// recordChatMessage appends a relayed message to the match transcript, keeping only the last N
+func (h *HandlerManager) recordChatMessage(matchID, senderID uint, message *tgbotapi.Message) {
+	limit := h.Config.ReportEvidenceMessages
+	if limit <= 0 {
+		return
+	}
+
+	entry := evidenceFromMessage(senderID, message)
+
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+
+	transcript, exists := chatTranscripts[matchID]
+	if !exists {
+		transcript = &chatTranscript{}
+		chatTranscripts[matchID] = transcript
+	}
+
+	transcript.Messages = append(transcript.Messages, entry)
+	if len(transcript.Messages) > limit {
+		transcript.Messages = transcript.Messages[len(transcript.Messages)-limit:]
+	}
+	transcript.LastActivity = entry.SentAt
+}
+
+// snapshotChatTranscript returns a copy of the recorded messages of a match
+func snapshotChatTranscript(matchID uint) []models.ReportEvidenceMessage {
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+
+	transcript, exists := chatTranscripts[matchID]
+	if !exists {
+		return nil
+	}
+
+	messages := make([]models.ReportEvidenceMessage, len(transcript.Messages))
+	copy(messages, transcript.Messages)
+	return messages
+}
+
+// CleanupChatTranscripts drops transcripts of chats that have been idle past the report window
+func (h *HandlerManager) CleanupChatTranscripts() int {
+	chatTranscriptsMu.Lock()
+	defer chatTranscriptsMu.Unlock()
+
+	removed := 0
+	for matchID, transcript := range chatTranscripts {
+		if time.Since(transcript.LastActivity) > ChatTranscriptRetention {
+			delete(chatTranscripts, matchID)
+			removed++
+		}
+	}
+	return removed
+}
+
+// evidenceFromMessage describes a relayed message for the report transcript
+func evidenceFromMessage(senderID uint, message *tgbotapi.Message) models.ReportEvidenceMessage {
+	entry := models.ReportEvidenceMessage{
+		SenderID: senderID,
+		Kind:     "text",
+		Content:  message.Text,
+		SentAt:   time.Now(),
+	}
+
+	if message.Text != "" {
+		return entry
+	}
+
+	entry.Content = message.Caption
+	switch {
+	case len(message.Photo) > 0:
+		entry.Kind = "photo"
+		entry.FileID = message.Photo[len(message.Photo)-1].FileID
+	case message.Voice != nil:
+		entry.Kind = "voice"
+		entry.FileID = message.Voice.FileID
+	case message.Sticker != nil:
+		entry.Kind = "sticker"
+		entry.FileID = message.Sticker.FileID
+		entry.Content = message.Sticker.Emoji
+	case message.Video != nil:
+		entry.Kind = "video"
+		entry.FileID = message.Video.FileID
+	case message.Document != nil:
+		entry.Kind = "document"
+		entry.FileID = message.Document.FileID
+	case message.Audio != nil:
+		entry.Kind = "audio"
+		entry.FileID = message.Audio.FileID
+	case message.Animation != nil:
+		entry.Kind = "animation"
+		entry.FileID = message.Animation.FileID
+	case message.VideoNote != nil:
+		entry.Kind = "video_note"
+		entry.FileID = message.VideoNote.FileID
+	}
+
+	return entry
+}
+
+// StartChatReport starts reporting the partner of the user's active chat
+func (h *HandlerManager) StartChatReport(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	match, _ := h.MatchRepo.GetActiveMatch(user.ID)
+	if match == nil {
+		isAdmin := user.TelegramID == h.Config.SuperAdminTgID
+		bot.SendMessage(userID, "⚠️ شما در چت فعالی نیستید!", bot.GetMainMenuKeyboard(isAdmin))
+		return
+	}
+
+	h.HandleReportStart(userID, match.ID, bot)
+}
+
+// HandleReportStart asks for the report reason of a current or recently ended chat
+func (h *HandlerManager) HandleReportStart(userID int64, matchID uint, bot BotInterface) {
+	user, match, ok := h.getReportableMatch(userID, matchID, bot)
+	if !ok {
+		return
+	}
+
+	if reported, _ := h.ReportRepo.HasReported(user.ID, match.ID); reported {
+		bot.SendMessage(userID, "⚠️ این چت رو قبلاً گزارش کردی. گزارشت در حال بررسیه.", nil)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, reason := range models.ReportReasons {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(models.ReportReasonTitles[reason], fmt.Sprintf("report_reason_%d_%s", match.ID, reason)),
+		))
+	}
+
+	bot.SendMessage(userID, "🚩 گزارش تخلف\n━━━━━━━━━━━━━━\nدلیل گزارش رو انتخاب کن:\n\n▫️ پیام‌های اخیر این چت برای بررسی به مدیریت ارسال میشه\n▫️ هویت تو برای طرف مقابل فاش نمیشه", tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleReportReason files the report with the chat transcript and notifies the admin
+func (h *HandlerManager) HandleReportReason(userID int64, matchID uint, reason string, bot BotInterface) {
+	if !models.IsValidReportReason(reason) {
+		bot.SendMessage(userID, "❌ دلیل گزارش نامعتبر است!", nil)
+		return
+	}
+
+	user, match, ok := h.getReportableMatch(userID, matchID, bot)
+	if !ok {
+		return
+	}
+
+	reportedID := match.User1ID
+	if match.User1ID == user.ID {
+		reportedID = match.User2ID
+	}
+
+	evidence, err := json.Marshal(snapshotChatTranscript(match.ID))
+	if err != nil {
+		logger.Error("Failed to encode report evidence", "match_id", match.ID, "error", err)
+		evidence = []byte("[]")
+	}
+
+	report := &models.ChatReport{
+		ReporterID: user.ID,
+		ReportedID: reportedID,
+		MatchID:    match.ID,
+		Reason:     reason,
+		Evidence:   string(evidence),
+	}
+
+	if err := h.ReportRepo.CreateReport(report); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeAlreadyExists {
+			bot.SendMessage(userID, "⚠️ این چت رو قبلاً گزارش کردی. گزارشت در حال بررسیه.", nil)
+			return
+		}
+		logger.Error("Failed to create report", "reporter_id", user.ID, "match_id", match.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ثبت گزارش!", nil)
+		return
+	}
+
+	logger.Info("Chat report filed", "report_id", report.ID, "reporter_id", user.ID, "reported_id", reportedID, "reason", reason)
+	bot.SendMessage(userID, "✅ گزارشت ثبت شد و توسط مدیریت بررسی میشه.\n\n🙏 ممنون که به امن‌تر شدن فضای چت کمک می‌کنی.", nil)
+
+	if h.Config.SuperAdminTgID != 0 {
+		h.ShowReportDetails(h.Config.SuperAdminTgID, report.ID, bot)
+	}
+}
+
+// getReportableMatch loads a match the user took part in and that is still within the report window
+func (h *HandlerManager) getReportableMatch(userID int64, matchID uint, bot BotInterface) (*models.User, *models.MatchSession, bool) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return nil, nil, false
+	}
+
+	match, err := h.MatchRepo.GetMatchByID(matchID)
+	if err != nil || (match.User1ID != user.ID && match.User2ID != user.ID) {
+		bot.SendMessage(userID, "❌ چت مورد نظر یافت نشد!", nil)
+		return nil, nil, false
+	}
+
+	if match.EndedAt != nil && time.Since(*match.EndedAt) > ReportWindow {
+		bot.SendMessage(userID, "⏰ مهلت گزارش این چت تمام شده است.", nil)
+		return nil, nil, false
+	}
+
+	return user, match, true
+}
+
+// offerChatReport sends the post-chat report button
+func (h *HandlerManager) offerChatReport(telegramID int64, matchID uint, bot BotInterface) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚩 گزارش تخلف", fmt.Sprintf("report_%d", matchID)),
+		),
+	)
+	bot.SendMessage(telegramID, "اگه طرف مقابل رفتار نامناسبی داشت، می‌تونی گزارشش کنی:", keyboard)
+}
+
+// ShowReportQueue lists a page of pending reports for the admin
+func (h *HandlerManager) ShowReportQueue(userID int64, page int, bot BotInterface) {
+	if userID != h.Config.SuperAdminTgID {
+		return
+	}
+
+	if page < 0 {
+		page = 0
+	}
+
+	reports, total, err := h.ReportRepo.GetPendingReports(page*ReportQueuePageSize, ReportQueuePageSize)
+	if err != nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت گزارش‌ها!", nil)
+		return
+	}
+
+	if total == 0 {
+		bot.SendMessage(userID, "✅ صف بررسی خالی است. گزارش جدیدی وجود ندارد.", nil)
+		return
+	}
+
+	totalPages := int((total + ReportQueuePageSize - 1) / ReportQueuePageSize)
+	if len(reports) == 0 && page > 0 {
+		h.ShowReportQueue(userID, totalPages-1, bot)
+		return
+	}
+
+	msg := fmt.Sprintf("🚩 صف بررسی گزارش‌ها (%d مورد)\n📄 صفحه %d از %d", total, page+1, totalPages)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, report := range reports {
+		label := fmt.Sprintf("#%d | %s | %s", report.ID, models.ReportReasonTitles[report.Reason], report.Reported.FullName)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("report_view_%d", report.ID)),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️ قبلی", fmt.Sprintf("reports_page_%d", page-1)))
+	}
+	if page < totalPages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("بعدی ▶️", fmt.Sprintf("reports_page_%d", page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// ShowReportDetails shows a report with its evidence and approve/dismiss buttons to the admin
+func (h *HandlerManager) ShowReportDetails(userID int64, reportID uint, bot BotInterface) {
+	if userID != h.Config.SuperAdminTgID {
+		return
+	}
+
+	report, err := h.ReportRepo.GetReportByID(reportID)
+	if err != nil {
+		bot.SendMessage(userID, "❌ گزارش یافت نشد!", nil)
+		return
+	}
+
+	confirmed, _ := h.ReportRepo.CountConfirmedReports(report.ReportedID)
+
+	msg := fmt.Sprintf("🚩 گزارش #%d\n━━━━━━━━━━━━━━\n", report.ID)
+	msg += fmt.Sprintf("📌 دلیل: %s\n", models.ReportReasonTitles[report.Reason])
+	msg += fmt.Sprintf("👤 گزارش‌دهنده: %s (/user_%s)\n", report.Reporter.FullName, report.Reporter.PublicID)
+	msg += fmt.Sprintf("⚠️ گزارش‌شده: %s (/user_%s)\n", report.Reported.FullName, report.Reported.PublicID)
+	msg += fmt.Sprintf("📊 گزارش‌های تایید شده قبلی: %d از %d\n", confirmed, h.Config.ReportSuspendThreshold)
+	msg += fmt.Sprintf("💬 چت: #%d\n\n", report.MatchID)
+	msg += formatReportEvidence(report)
+
+	if report.Status != models.ReportStatusPending {
+		msg += "\n\nℹ️ این گزارش قبلاً بررسی شده است."
+		bot.SendMessage(userID, msg, nil)
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ تایید تخلف", fmt.Sprintf("report_approve_%d", report.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ رد گزارش", fmt.Sprintf("report_dismiss_%d", report.ID)),
+		),
+	)
+	bot.SendMessage(userID, msg, keyboard)
+}
+
+// formatReportEvidence renders the stored transcript for moderators
+func formatReportEvidence(report *models.ChatReport) string {
+	var messages []models.ReportEvidenceMessage
+	if report.Evidence != "" {
+		if err := json.Unmarshal([]byte(report.Evidence), &messages); err != nil {
+			logger.Error("Failed to decode report evidence", "report_id", report.ID, "error", err)
+		}
+	}
+
+	if len(messages) == 0 {
+		return "📝 پیامی از این چت ثبت نشده است."
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📝 آخرین %d پیام:\n", len(messages)))
+	for _, m := range messages {
+		sender := "🟢 گزارش‌دهنده"
+		if m.SenderID == report.ReportedID {
+			sender = "🔴 گزارش‌شده"
+		}
+
+		content := m.Content
+		if runes := []rune(content); len(runes) > reportEvidenceContentLimit {
+			content = string(runes[:reportEvidenceContentLimit]) + "…"
+		}
+		if m.Kind != "text" {
+			content = strings.TrimSpace(fmt.Sprintf("[%s] %s", m.Kind, content))
+		}
+
+		sb.WriteString(fmt.Sprintf("%s: %s\n", sender, content))
+	}
+
+	return sb.String()
+}
+
+// HandleReportReview applies the admin decision on a report and auto-suspends repeat offenders
+func (h *HandlerManager) HandleReportReview(userID int64, reportID uint, approve bool, bot BotInterface) {
+	if userID != h.Config.SuperAdminTgID {
+		return
+	}
+
+	status := models.ReportStatusDismissed
+	if approve {
+		status = models.ReportStatusConfirmed
+	}
+
+	updated, err := h.ReportRepo.ReviewReport(reportID, status, userID)
+	if err != nil {
+		logger.Error("Failed to review report", "report_id", reportID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ثبت نتیجه بررسی!", nil)
+		return
+	}
+	if !updated {
+		bot.SendMessage(userID, "ℹ️ این گزارش قبلاً بررسی شده است.", nil)
+		return
+	}
+
+	report, err := h.ReportRepo.GetReportByID(reportID)
+	if err != nil {
+		logger.Error("Failed to load reviewed report", "report_id", reportID, "error", err)
+		return
+	}
+
+	logger.Info("Chat report reviewed", "report_id", reportID, "status", status, "reviewer", userID)
+
+	if !approve {
+		bot.SendMessage(userID, fmt.Sprintf("❌ گزارش #%d رد شد.", reportID), nil)
+		return
+	}
+
+	bot.SendMessage(report.Reporter.TelegramID, "✅ گزارشی که ثبت کرده بودی بررسی و تایید شد. ممنون از همراهیت!", nil)
+
+	confirmed, err := h.ReportRepo.CountConfirmedReports(report.ReportedID)
+	if err != nil {
+		logger.Error("Failed to count confirmed reports", "user_id", report.ReportedID, "error", err)
+		return
+	}
+
+	if h.Config.ReportSuspendThreshold > 0 && confirmed >= int64(h.Config.ReportSuspendThreshold) {
+		if !h.suspendReportedUser(&report.Reported, confirmed, bot) {
+			bot.SendMessage(userID, fmt.Sprintf("✅ گزارش #%d تایید شد.\n❌ تعلیق خودکار کاربر %s انجام نشد؛ از پنل مدیریت مسدودش کن.", reportID, report.Reported.FullName), nil)
+			return
+		}
+		bot.SendMessage(userID, fmt.Sprintf("✅ گزارش #%d تایید شد.\n⛔️ کاربر %s با %d گزارش تایید شده به صورت خودکار تعلیق شد.", reportID, report.Reported.FullName, confirmed), nil)
+		return
+	}
+
+	bot.SendMessage(userID, fmt.Sprintf("✅ گزارش #%d تایید شد.\n📊 گزارش‌های تایید شده این کاربر: %d از %d", reportID, confirmed, h.Config.ReportSuspendThreshold), nil)
+}
+
+// suspendReportedUser bans a user that reached the confirmed report threshold from the whole bot.
+// It reports whether the ban was placed.
+func (h *HandlerManager) suspendReportedUser(user *models.User, confirmed int64, bot BotInterface) bool {
+	reason := fmt.Sprintf("%d گزارش تایید شده در چت ناشناس", confirmed)
+	if !h.banUser(user, models.BanScopeGlobal, h.Config.GetReportSuspension(), reason, 0, bot) {
+		return false
+	}
+	logger.Info("User suspended by reports", "user_id", user.ID, "confirmed_reports", confirmed)
+	return true
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+)
+
+func TestHandleReportReview_ThresholdBansReportedUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.h.Config.SuperAdminTgID = 9000
+	env.h.Config.ReportSuspendThreshold = 2
+	env.h.Config.ReportSuspendHours = 72
+	reported := env.newUser(t, 6001, "Reported", models.GenderMale, 100)
+
+	for i, reporterTgID := range []int64{6002, 6003} {
+		reporter := env.newUser(t, reporterTgID, "Reporter", models.GenderFemale, 100)
+		report := &models.ChatReport{
+			ReporterID: reporter.ID,
+			ReportedID: reported.ID,
+			MatchID:    uint(i + 1),
+			Reason:     models.ReportReasonHarassment,
+		}
+		if err := env.h.ReportRepo.CreateReport(report); err != nil {
+			t.Fatalf("CreateReport() error = %v", err)
+		}
+
+		env.h.HandleReportReview(9000, report.ID, true, env.bot)
+
+		ban, err := env.h.BanRepo.GetActiveBan(reported.ID, models.BanScopeGlobal)
+		if err != nil {
+			t.Fatalf("GetActiveBan() error = %v", err)
+		}
+		if i == 0 {
+			if ban != nil {
+				t.Fatalf("banned after one confirmed report: %+v", ban)
+			}
+			continue
+		}
+
+		// The suspension is an ordinary ban, so dispatch and matchmaking enforce it
+		if ban == nil {
+			t.Fatal("no global ban after reaching the report threshold")
+		}
+		if ban.IssuedByTgID != 0 {
+			t.Errorf("IssuedByTgID = %d, want 0 for an automatic ban", ban.IssuedByTgID)
+		}
+		if want := time.Now().Add(72 * time.Hour); ban.ExpiresAt == nil || ban.ExpiresAt.Sub(want).Abs() > time.Minute {
+			t.Errorf("ExpiresAt = %v, want about %v", ban.ExpiresAt, want)
+		}
+		if !env.bot.received(reported.TelegramID, BanMessage(ban)) {
+			t.Errorf("reported user messages = %q, want the ban notice", env.bot.messages(reported.TelegramID))
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/errors"
 	"github.com/mroshb/game_bot/pkg/logger"
 	"github.com/mroshb/game_bot/pkg/utils"
@@ -46,11 +47,7 @@ func (h *HandlerManager) ShowRoomMenu(userID int64, bot BotInterface) {
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // CreateRoom handles room creation
@@ -61,6 +58,10 @@ func (h *HandlerManager) CreateRoom(userID int64, roomType string, session *User
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeRooms, bot) {
+		return
+	}
+
 	// Check coin balance
 	cost := int64(50)
 	if roomType == models.RoomTypePrivate {
@@ -177,7 +178,7 @@ func (h *HandlerManager) HandleRoomCreation(message *tgbotapi.Message, session *
 		roomName := session.Data["room_name"].(string)
 		maxPlayers := session.Data["max_players"].(int)
 
-		roomID := h.CompleteRoomCreation(userID, roomName, roomType, maxPlayers, entryFee, bot)
+		roomID := h.CompleteRoomCreation(userID, roomName, roomType, maxPlayers, entryFee, MessageRequestID(message), bot)
 		if roomID > 0 {
 			session.Data["current_room_id"] = roomID
 			h.ShowRoomMembers(userID, roomID, bot)
@@ -193,7 +194,7 @@ func (h *HandlerManager) HandleRoomCreation(message *tgbotapi.Message, session *
 }
 
 // CompleteRoomCreation completes the room creation process and returns the room ID
-func (h *HandlerManager) CompleteRoomCreation(userID int64, roomName string, roomType string, maxPlayers int, entryFee int64, bot BotInterface) uint {
+func (h *HandlerManager) CompleteRoomCreation(userID int64, roomName string, roomType string, maxPlayers int, entryFee int64, requestID string, bot BotInterface) uint {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات کاربر!", nil)
@@ -216,7 +217,11 @@ func (h *HandlerManager) CompleteRoomCreation(userID int64, roomName string, roo
 		cost = 30
 	}
 
-	if err := h.CoinRepo.DeductCoins(user.ID, cost, models.TxTypeRoomCreation, "هزینه ساخت اتاق"); err != nil {
+	chargeKey := requestKey(requestID, models.TxTypeRoomCreation)
+	if err := h.CoinRepo.DeductCoins(user.ID, cost, models.TxTypeRoomCreation, "هزینه ساخت اتاق", chargeKey); err != nil {
+		if isDuplicateRequest(err) {
+			return 0
+		}
 		logger.Error("Failed to deduct coins", "error", err)
 		bot.SendMessage(userID, "❌ خطا در کسر سکه!", nil)
 		return 0
@@ -225,7 +230,7 @@ func (h *HandlerManager) CompleteRoomCreation(userID int64, roomName string, roo
 	if err := h.RoomRepo.CreateRoom(room); err != nil {
 		logger.Error("Failed to create room", "error", err)
 		// Refund coins
-		h.CoinRepo.AddCoins(user.ID, cost, models.TxTypeRefund, "بازگشت هزینه به دلیل خطا")
+		h.CoinRepo.AddCoins(user.ID, cost, models.TxTypeRefund, "بازگشت هزینه به دلیل خطا", refundKey(chargeKey))
 		bot.SendMessage(userID, "❌ خطا در ساخت اتاق!", nil)
 		return 0
 	}
@@ -284,21 +289,21 @@ func (h *HandlerManager) ListPublicRooms(userID int64, bot BotInterface) {
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // JoinRoom handles joining a room
-func (h *HandlerManager) JoinRoom(userID int64, roomID uint, bot BotInterface) {
+func (h *HandlerManager) JoinRoom(userID int64, roomID uint, requestID string, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات کاربر!", nil)
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeRooms, bot) {
+		return
+	}
+
 	// Check if room exists
 	room, err := h.RoomRepo.GetRoomByID(roomID)
 	if err != nil {
@@ -323,7 +328,10 @@ func (h *HandlerManager) JoinRoom(userID int64, roomID uint, bot BotInterface) {
 				return
 			}
 
-			if err := h.CoinRepo.DeductCoins(user.ID, room.EntryFee, models.TxTypeRoomEntry, fmt.Sprintf("ورود به اتاق %s", room.RoomName)); err != nil {
+			if err := h.CoinRepo.DeductCoins(user.ID, room.EntryFee, models.TxTypeRoomEntry, fmt.Sprintf("ورود به اتاق %s", room.RoomName), requestKey(requestID, models.TxTypeRoomEntry, roomID)); err != nil {
+				if isDuplicateRequest(err) {
+					return
+				}
 				bot.SendMessage(userID, "❌ خطا در کسر سکه ورودی!", nil)
 				return
 			}
@@ -355,7 +363,7 @@ func (h *HandlerManager) JoinRoom(userID int64, roomID uint, bot BotInterface) {
 	}
 
 	// If game is already active, show game menu to the new user and add them to participants
-	session, _ := h.GameRepo.GetActiveGameSessionByRoomID(roomID)
+	session := h.activeGameSession(roomID)
 	if session != nil {
 		// Add as participant if not already there
 		participants, _ := h.GameRepo.GetParticipants(session.ID)
@@ -383,7 +391,7 @@ func (h *HandlerManager) JoinRoom(userID int64, roomID uint, bot BotInterface) {
 }
 
 // JoinRoomByCode handles joining a room by invite code
-func (h *HandlerManager) JoinRoomByCode(userID int64, inviteCode string, bot BotInterface) {
+func (h *HandlerManager) JoinRoomByCode(userID int64, inviteCode, requestID string, bot BotInterface) {
 	// Find room by invite code
 	room, err := h.RoomRepo.GetRoomByInviteCode(utils.NormalizePersianNumbers(strings.TrimSpace(inviteCode)))
 	if err != nil {
@@ -392,7 +400,7 @@ func (h *HandlerManager) JoinRoomByCode(userID int64, inviteCode string, bot Bot
 	}
 
 	// Join room
-	h.JoinRoom(userID, room.ID, bot)
+	h.JoinRoom(userID, room.ID, requestID, bot)
 }
 
 // LeaveRoom handles leaving a room
@@ -466,6 +474,7 @@ func (h *HandlerManager) CloseRoom(userID int64, roomID uint, bot BotInterface)
 	session, _ := h.GameRepo.GetActiveGameSessionByRoomID(roomID)
 	if session != nil {
 		h.GameRepo.EndGame(session.ID)
+		cleanupGroupQuizSession(session.ID)
 	}
 
 	// Close room
@@ -578,11 +587,7 @@ func (h *HandlerManager) ShowManageMembers(userID int64, roomID uint, bot BotInt
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // ShowRoomMembers shows all members of a room
@@ -618,7 +623,7 @@ func (h *HandlerManager) ShowRoomMembers(userID int64, roomID uint, bot BotInter
 	isFull := len(members) >= room.MaxPlayers
 
 	// Check for active game
-	activeSession, _ := h.GameRepo.GetActiveGameSessionByRoomID(roomID)
+	activeSession := h.activeGameSession(roomID)
 	hasActiveGame := activeSession != nil && activeSession.Status != models.GameStatusFinished
 
 	if room.HostID == user.ID {
@@ -665,11 +670,7 @@ func (h *HandlerManager) ShowRoomMembers(userID int64, roomID uint, bot BotInter
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // SendRoomMessage sends a message to all room members
@@ -689,6 +690,10 @@ func (h *HandlerManager) SendRoomMessage(userID int64, roomID uint, message *tgb
 		return false
 	}
 
+	if h.checkBan(user, models.BanScopeRooms, bot) {
+		return true
+	}
+
 	// Get all members
 	members, err := h.RoomRepo.GetRoomMembers(roomID)
 	if err != nil {
@@ -702,8 +707,10 @@ func (h *HandlerManager) SendRoomMessage(userID int64, roomID uint, message *tgb
 			continue // Skip sender
 		}
 
-		// Forward the content with sender name integrated
-		h.forwardMessage(message, member.TelegramID, bot, user.FullName)
+		// Forward the content with sender name integrated, without waiting on each member
+		for _, c := range forwardedMessages(message, member.TelegramID, user.FullName) {
+			bot.SendAsync(member.TelegramID, c, sender.PriorityBulk)
+		}
 	}
 	return true
 }
@@ -742,7 +749,7 @@ func (h *HandlerManager) GetUserRooms(userID int64, bot BotInterface) {
 }
 
 // QuickJoinRoom joins a random public room
-func (h *HandlerManager) QuickJoinRoom(userID int64, bot BotInterface) {
+func (h *HandlerManager) QuickJoinRoom(userID int64, requestID string, bot BotInterface) {
 	rooms, err := h.RoomRepo.GetPublicRooms()
 	if err != nil || len(rooms) == 0 {
 		bot.SendMessage(userID, "📋 هیچ اتاق عمومی فعالی برای ورود سریع پیدا نشد!", nil)
@@ -753,7 +760,7 @@ func (h *HandlerManager) QuickJoinRoom(userID int64, bot BotInterface) {
 	for _, room := range rooms {
 		memberCount, _ := h.RoomRepo.GetMemberCount(room.ID)
 		if memberCount < room.MaxPlayers {
-			h.JoinRoom(userID, room.ID, bot)
+			h.JoinRoom(userID, room.ID, requestID, bot)
 			return
 		}
 	}
@@ -781,11 +788,7 @@ func (h *HandlerManager) InviteFriendToRoom(userID int64, roomID uint, bot BotIn
 	msgConfig := tgbotapi.NewMessage(userID, "👥 دوستت رو انتخاب کن تا دعوت‌نامه براش ارسال بشه:")
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // SendRoomInvitation sends an invitation to a friend
@@ -798,6 +801,11 @@ func (h *HandlerManager) SendRoomInvitation(hostID int64, roomID uint, friendID
 		return
 	}
 
+	if host != nil && h.isBlockedWith(host.ID, friend.ID) {
+		bot.SendMessage(hostID, "🚫 امکان ارسال دعوت‌نامه به این کاربر وجود ندارد.", nil)
+		return
+	}
+
 	msg := fmt.Sprintf("📩 دعوت‌نامه بازی!\n\n👤 %s شما رو به اتاق '%s' دعوت کرده.", host.FullName, room.RoomName)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
@@ -809,11 +817,7 @@ func (h *HandlerManager) SendRoomInvitation(hostID int64, roomID uint, friendID
 	msgConfig := tgbotapi.NewMessage(friend.TelegramID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 
 	bot.SendMessage(hostID, fmt.Sprintf("✅ دعوت‌نامه برای %s ارسال شد.", friend.FullName), nil)
 }
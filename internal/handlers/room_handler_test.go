@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/models"
+)
+
+func TestRoomFlow_CreateJoinKickClose(t *testing.T) {
+	env := newTestEnv(t)
+	host := env.newUser(t, 1001, "Host", models.GenderMale, 100)
+	guest := env.newUser(t, 1002, "Guest", models.GenderFemale, 100)
+	other := env.newUser(t, 1003, "Other", models.GenderMale, 100)
+
+	roomID := env.h.CompleteRoomCreation(host.TelegramID, "Lobby", models.RoomTypePublic, 4, 10, "req-create", env.bot)
+	if roomID == 0 {
+		t.Fatalf("CompleteRoomCreation() = 0, messages: %v", env.bot.messages(host.TelegramID))
+	}
+	if got := env.balance(t, host.ID); got != 50 {
+		t.Errorf("host balance after creating a public room = %d, want 50", got)
+	}
+
+	// A replayed creation request is not charged twice
+	if id := env.h.CompleteRoomCreation(host.TelegramID, "Lobby", models.RoomTypePublic, 4, 10, "req-create", env.bot); id != 0 {
+		t.Errorf("replayed CompleteRoomCreation() = %d, want 0", id)
+	}
+	if got := env.balance(t, host.ID); got != 50 {
+		t.Errorf("host balance after a replayed request = %d, want 50", got)
+	}
+
+	env.h.JoinRoom(guest.TelegramID, roomID, "req-join-guest", env.bot)
+	env.h.JoinRoom(guest.TelegramID, roomID, "req-join-guest", env.bot)
+	env.h.JoinRoom(other.TelegramID, roomID, "req-join-other", env.bot)
+
+	if got := env.balance(t, guest.ID); got != 90 {
+		t.Errorf("guest balance after joining twice = %d, want 90", got)
+	}
+	if count, _ := env.h.RoomRepo.GetMemberCount(roomID); count != 3 {
+		t.Errorf("member count = %d, want 3", count)
+	}
+
+	// Only the host may kick
+	env.h.KickMember(guest.TelegramID, roomID, other.ID, env.bot)
+	if isMember, _ := env.h.RoomRepo.IsMember(roomID, other.ID); !isMember {
+		t.Error("a guest was able to kick another member")
+	}
+
+	env.h.KickMember(host.TelegramID, roomID, other.ID, env.bot)
+	if isMember, _ := env.h.RoomRepo.IsMember(roomID, other.ID); isMember {
+		t.Error("kicked member is still in the room")
+	}
+	if !env.bot.received(other.TelegramID, "اخراج شدید") {
+		t.Error("kicked member was not notified")
+	}
+
+	env.h.LeaveRoom(host.TelegramID, roomID, env.bot)
+	room, err := env.h.RoomRepo.GetRoomByID(roomID)
+	if err != nil {
+		t.Fatalf("GetRoomByID() error = %v", err)
+	}
+	if room.Status != models.RoomStatusClosed {
+		t.Errorf("room status after the host left = %q, want %q", room.Status, models.RoomStatusClosed)
+	}
+	if !env.bot.received(guest.TelegramID, "اتاق توسط هاست بسته شد") {
+		t.Error("guest was not told the room closed")
+	}
+
+	env.h.JoinRoom(other.TelegramID, roomID, "req-join-closed", env.bot)
+	if isMember, _ := env.h.RoomRepo.IsMember(roomID, other.ID); isMember {
+		t.Error("joined a closed room")
+	}
+}
+
+func TestRoomFlow_PrivateRoomByCode(t *testing.T) {
+	env := newTestEnv(t)
+	host := env.newUser(t, 2001, "Host", models.GenderFemale, 30)
+	guest := env.newUser(t, 2002, "Guest", models.GenderMale, 5)
+
+	roomID := env.h.CompleteRoomCreation(host.TelegramID, "Secret", models.RoomTypePrivate, 2, 10, "req-private", env.bot)
+	if roomID == 0 {
+		t.Fatalf("CompleteRoomCreation() = 0, messages: %v", env.bot.messages(host.TelegramID))
+	}
+	if got := env.balance(t, host.ID); got != 0 {
+		t.Errorf("host balance after creating a private room = %d, want 0", got)
+	}
+
+	room, err := env.h.RoomRepo.GetRoomByID(roomID)
+	if err != nil {
+		t.Fatalf("GetRoomByID() error = %v", err)
+	}
+	if room.InviteCode == "" {
+		t.Fatal("private room has no invite code")
+	}
+
+	env.h.JoinRoomByCode(guest.TelegramID, "wrong-code", "req-code-1", env.bot)
+	if !env.bot.received(guest.TelegramID, "کد دعوت نامعتبر") {
+		t.Error("an invalid code was not rejected")
+	}
+
+	// The entry fee is more than the guest has
+	env.h.JoinRoomByCode(guest.TelegramID, room.InviteCode, "req-code-2", env.bot)
+	if isMember, _ := env.h.RoomRepo.IsMember(roomID, guest.ID); isMember {
+		t.Error("guest joined without paying the entry fee")
+	}
+	if got := env.balance(t, guest.ID); got != 5 {
+		t.Errorf("guest balance after a refused join = %d, want 5", got)
+	}
+}
+
+func TestStartQuizGame_EndsQuizLostOnRestart(t *testing.T) {
+	env := newTestEnv(t)
+	host := env.newUser(t, 3001, "Host", models.GenderMale, 100)
+	guest := env.newUser(t, 3002, "Guest", models.GenderFemale, 100)
+
+	roomID := env.h.CompleteRoomCreation(host.TelegramID, "Quiz", models.RoomTypePublic, 4, 10, "req-quiz-room", env.bot)
+	env.h.JoinRoom(guest.TelegramID, roomID, "req-quiz-join", env.bot)
+
+	// A quiz that was running when the bot restarted: the row is active, its in-memory state is gone
+	stale, err := env.h.GameRepo.CreateGameSession(roomID, models.GameTypeQuiz)
+	if err != nil {
+		t.Fatalf("CreateGameSession() error = %v", err)
+	}
+	if err := env.h.GameRepo.StartGame(stale.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+
+	env.h.StartQuizGame(host.TelegramID, roomID, env.bot)
+
+	if env.bot.received(host.TelegramID, "یک بازی در حال حاضر فعال است") {
+		t.Error("the stale quiz still blocks a new game")
+	}
+	session, err := env.h.GameRepo.GetGameSession(stale.ID)
+	if err != nil {
+		t.Fatalf("GetGameSession() error = %v", err)
+	}
+	if session.Status != models.GameStatusFinished {
+		t.Errorf("stale quiz status = %q, want %q", session.Status, models.GameStatusFinished)
+	}
+}
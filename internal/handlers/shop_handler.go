@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// COIN SHOP (Boosters, ToD items and cosmetics)
+// ========================================
+
+// ShowShop lists the catalog items currently on sale, grouped by category
+func (h *HandlerManager) ShowShop(userID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("🛍 فروشگاه\n━━━━━━━━━━━━━━\n💰 سکه: %s | 💎 الماس: %s\n",
+		utils.FormatPersianNumber(user.CoinBalance), utils.FormatPersianNumber(user.Diamonds))
+
+	now := time.Now()
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, category := range models.ShopCategories {
+		var categoryRows [][]tgbotapi.InlineKeyboardButton
+		for i := range models.ShopCatalog {
+			item := &models.ShopCatalog[i]
+			if item.Category != category || !item.IsOnSale(now) {
+				continue
+			}
+			label := fmt.Sprintf("%s | %s", item.Title, formatShopPrice(item))
+			if remaining, limited := h.remainingShopStock(item); limited && remaining <= 0 {
+				label = fmt.Sprintf("%s | تمام شد", item.Title)
+			}
+			categoryRows = append(categoryRows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(label, "shop_item_"+item.ID),
+			))
+		}
+		if len(categoryRows) == 0 {
+			continue
+		}
+		msg += "\n▫️ " + models.ShopCategoryTitles[category]
+		rows = append(rows, categoryRows...)
+	}
+
+	msg += "\n\nروی هر آیتم بزن تا جزئیاتش رو ببینی."
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(BtnIncreaseCoins, "buy_coins"),
+	))
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// ShowShopItem shows an item's details and its buy button
+func (h *HandlerManager) ShowShopItem(userID int64, itemID string, bot BotInterface) {
+	item := models.GetShopItem(itemID)
+	if item == nil || !item.IsOnSale(time.Now()) {
+		bot.SendMessage(userID, "❌ این آیتم در فروشگاه موجود نیست!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("%s\n━━━━━━━━━━━━━━\n%s\n\n📦 تعداد: %s\n🏷 قیمت: %s",
+		item.Title, item.Description, utils.FormatPersianNumber(int64(item.Quantity)), formatShopPrice(item))
+	if item.OriginalPrice > item.Price {
+		msg += fmt.Sprintf(" (به جای %s)", utils.FormatPersianNumber(item.OriginalPrice))
+	}
+	if item.AvailableUntil != nil {
+		msg += fmt.Sprintf("\n⏳ تا %s", item.AvailableUntil.Format("2006/01/02 15:04"))
+	}
+
+	remaining, limited := h.remainingShopStock(item)
+	if limited {
+		msg += fmt.Sprintf("\n📉 موجودی: %s", utils.FormatPersianNumber(max(remaining, 0)))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if !limited || remaining > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛒 خرید", "shop_buy_"+item.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به فروشگاه", "shop_open"),
+	))
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// HandleShopBuy buys an item. Avatar items first ask for the photo to use.
+func (h *HandlerManager) HandleShopBuy(userID int64, itemID, requestID string, session *UserSession, bot BotInterface) {
+	item := models.GetShopItem(itemID)
+	if item == nil || !item.IsOnSale(time.Now()) {
+		bot.SendMessage(userID, "❌ این آیتم در فروشگاه موجود نیست!", nil)
+		return
+	}
+
+	if item.Grant == models.ShopGrantAvatar {
+		session.State = StateShopAvatar
+		session.Data["shop_item_id"] = item.ID
+		bot.SendMessage(userID, fmt.Sprintf("🖼 عکسی که می‌خوای به عنوان آواتار نمایش داده بشه رو بفرست.\n\n🏷 قیمت: %s\nبرای لغو /cancel رو بزن.", formatShopPrice(item)), nil)
+		return
+	}
+
+	h.purchaseShopItem(userID, item, "", requestID, bot)
+}
+
+// HandleShopAvatarPhoto completes an avatar purchase with the photo the buyer sent
+func (h *HandlerManager) HandleShopAvatarPhoto(userID int64, message *tgbotapi.Message, session *UserSession, bot BotInterface) {
+	itemID, _ := session.Data["shop_item_id"].(string)
+	item := models.GetShopItem(itemID)
+	if item == nil || item.Grant != models.ShopGrantAvatar {
+		session.State = ""
+		delete(session.Data, "shop_item_id")
+		h.ShowShop(userID, bot)
+		return
+	}
+
+	if message.Photo == nil {
+		bot.SendMessage(userID, "❌ لطفاً یک عکس بفرست.", nil)
+		return
+	}
+
+	session.State = ""
+	delete(session.Data, "shop_item_id")
+
+	h.purchaseShopItem(userID, item, message.Photo[len(message.Photo)-1].FileID, MessageRequestID(message), bot)
+}
+
+// purchaseShopItem runs the purchase and reports the outcome to the buyer
+func (h *HandlerManager) purchaseShopItem(userID int64, item *models.ShopItem, avatarFileID, requestID string, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	if _, err := h.ShopRepo.Purchase(user.ID, item, avatarFileID, requestKey(requestID, models.TxTypeShopPurchase, item.ID)); err != nil {
+		appErr, _ := err.(*errors.AppError)
+		switch {
+		case isDuplicateRequest(err):
+			// A replayed buy tap; the first one already bought the item
+		case appErr != nil && appErr.Code == errors.ErrCodeInsufficientFunds:
+			if item.Currency == models.CurrencyDiamonds {
+				bot.SendMessage(userID, fmt.Sprintf("❌ الماس کافی نداری!\n\n🏷 قیمت: %s\n💎 موجودی: %s", formatShopPrice(item), utils.FormatPersianNumber(user.Diamonds)), nil)
+			} else {
+				bot.SendMessage(userID, fmt.Sprintf("❌ سکه کافی نداری!\n\n🏷 قیمت: %s\n💰 موجودی: %s", formatShopPrice(item), utils.FormatPersianNumber(user.CoinBalance)), nil)
+			}
+		case appErr != nil && appErr.Code == errors.ErrCodeOutOfStock:
+			bot.SendMessage(userID, "😔 موجودی این آیتم تمام شده!", nil)
+		case appErr != nil && appErr.Code == errors.ErrCodeValidationFailed && item.Grant == models.ShopGrantAvatar && avatarFileID == "":
+			bot.SendMessage(userID, "❌ برای خرید آواتار باید یک عکس بفرستی.", nil)
+		case appErr != nil && appErr.Code == errors.ErrCodeValidationFailed:
+			bot.SendMessage(userID, "❌ این آیتم در فروشگاه موجود نیست!", nil)
+		default:
+			logger.Error("Shop purchase failed", "user_id", user.ID, "item_id", item.ID, "error", err)
+			bot.SendMessage(userID, "❌ خطا در خرید! دوباره تلاش کن.", nil)
+		}
+		return
+	}
+
+	logger.Info("Shop item purchased", "user_id", user.ID, "item_id", item.ID, "currency", item.Currency, "price", item.Price)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛍 ادامه خرید", "shop_open"),
+		),
+	)
+	bot.SendMessage(userID, fmt.Sprintf("✅ خرید انجام شد!\n\n%s × %s به حسابت اضافه شد.", item.Title, utils.FormatPersianNumber(int64(item.Quantity))), keyboard)
+}
+
+// remainingShopStock returns how many units of a limited item are left.
+// limited is false for items without a stock limit.
+func (h *HandlerManager) remainingShopStock(item *models.ShopItem) (remaining int64, limited bool) {
+	if !item.IsLimited() {
+		return 0, false
+	}
+
+	sold, err := h.ShopRepo.CountItemSales(item.ID)
+	if err != nil {
+		logger.Error("Failed to count item sales", "item_id", item.ID, "error", err)
+		return 0, true
+	}
+
+	return int64(item.Stock) - sold, true
+}
+
+// formatShopPrice renders an item's price with its currency
+func formatShopPrice(item *models.ShopItem) string {
+	if item.Currency == models.CurrencyDiamonds {
+		return fmt.Sprintf("%s الماس", utils.FormatPersianNumber(item.Price))
+	}
+	return fmt.Sprintf("%s سکه", utils.FormatPersianNumber(item.Price))
+}
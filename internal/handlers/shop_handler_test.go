@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/session"
+)
+
+func TestReplayedPurchaseTaps_ChargeOnce(t *testing.T) {
+	env := newTestEnv(t)
+	env.h.Config.DiamondToCoinRate = 10
+	buyer := env.newUser(t, 5001, "Buyer", models.GenderFemale, 500)
+	if err := env.h.DiamondRepo.AddDiamonds(buyer.ID, 20, models.TxTypeAdminAdjustment, "test", ""); err != nil {
+		t.Fatalf("AddDiamonds() error = %v", err)
+	}
+
+	// Telegram redelivers the same callback query, so every tap comes in twice
+	for range 2 {
+		env.h.HandleShopBuy(buyer.TelegramID, "tod_shield", CallbackRequestID("q1"), session.New(), env.bot)
+		env.h.HandleShopBuy(buyer.TelegramID, "booster_second_chance_pack", CallbackRequestID("q2"), session.New(), env.bot)
+		env.h.HandleDiamondExchange(buyer.TelegramID, 1, CallbackRequestID("q3"), env.bot)
+	}
+
+	user, err := env.h.UserRepo.GetUserByID(buyer.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if want := int64(500 - 60 + 10); user.CoinBalance != want {
+		t.Errorf("coins = %d, want %d", user.CoinBalance, want)
+	}
+	if want := int64(20 - 4 - 1); user.Diamonds != want {
+		t.Errorf("diamonds = %d, want %d", user.Diamonds, want)
+	}
+
+	// A new tap is a new purchase
+	env.h.HandleShopBuy(buyer.TelegramID, "tod_shield", CallbackRequestID("q4"), session.New(), env.bot)
+	if got := env.balance(t, buyer.ID); got != 500-60+10-60 {
+		t.Errorf("coins after a second purchase = %d, want %d", got, 500-60+10-60)
+	}
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/logger"
+	"github.com/mroshb/game_bot/pkg/utils"
+)
+
+// ========================================
+// TELEGRAM INVOICES (Paid with Telegram Stars)
+// ========================================
+
+// ShowStarsShop lists the coin packages and diamond bundles sold for Telegram Stars
+func (h *HandlerManager) ShowStarsShop(userID int64, bot BotInterface) {
+	if !h.Config.TelegramPaymentsEnabled {
+		bot.SendMessage(userID, "⚠️ پرداخت با استارز فعلاً فعال نیست.", nil)
+		return
+	}
+
+	msg := "⭐️ خرید با استارز تلگرام\n━━━━━━━━━━━━━━\n\n💰 پکیج‌های سکه:\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, pkg := range models.CoinPackages {
+		if pkg.PriceStars <= 0 {
+			continue
+		}
+		msg += fmt.Sprintf("▫️ %s سکه ⬅️ %d ⭐️\n", utils.FormatPersianNumber(pkg.Coins), pkg.PriceStars)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💰 %s سکه | %d ⭐️", utils.FormatPersianNumber(pkg.Coins), pkg.PriceStars), fmt.Sprintf("stars_buy_%s_%d", models.ProductKindCoins, pkg.ID)),
+		))
+	}
+
+	msg += "\n💎 بسته‌های الماس:\n"
+	for _, bundle := range models.DiamondBundles {
+		product := models.GetInvoiceProduct(models.ProductKindDiamonds, bundle.ID)
+		line := fmt.Sprintf("▫️ %s الماس ⬅️ %d ⭐️", utils.FormatPersianNumber(bundle.Diamonds), bundle.PriceStars)
+		if remaining, limited := h.remainingStock(product); limited {
+			if remaining <= 0 {
+				msg += line + " (تمام شد)\n"
+				continue
+			}
+			line += fmt.Sprintf(" (فقط %s عدد باقی مانده)", utils.FormatPersianNumber(remaining))
+		}
+		msg += line + "\n"
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💎 %s الماس | %d ⭐️", utils.FormatPersianNumber(bundle.Diamonds), bundle.PriceStars), fmt.Sprintf("stars_buy_%s_%d", models.ProductKindDiamonds, bundle.ID)),
+		))
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت", "buy_coins"),
+	))
+	bot.SendMessage(userID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// SendProductInvoice sends a native Telegram invoice for a coin package or diamond bundle
+func (h *HandlerManager) SendProductInvoice(userID int64, kind string, productID uint, bot BotInterface) {
+	if !h.Config.TelegramPaymentsEnabled {
+		return
+	}
+
+	product := models.GetInvoiceProduct(kind, productID)
+	if product == nil {
+		bot.SendMessage(userID, "❌ محصول نامعتبر است!", nil)
+		return
+	}
+
+	if remaining, limited := h.remainingStock(product); limited && remaining <= 0 {
+		bot.SendMessage(userID, "😔 موجودی این بسته تمام شده!", nil)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(userID)
+	if err != nil || user == nil {
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
+
+	title, description := invoiceText(product)
+	invoice := tgbotapi.NewInvoice(userID, title, description,
+		models.InvoicePayload(product.Kind, product.ID, user.ID),
+		"", "", models.TelegramStarsCurrency,
+		[]tgbotapi.LabeledPrice{{Label: title, Amount: product.PriceStars}})
+	invoice.SuggestedTipAmounts = []int{}
+
+	if _, err := bot.Send(userID, invoice, sender.PriorityNormal); err != nil {
+		logger.Error("Failed to send invoice", "user_id", user.ID, "kind", product.Kind, "product_id", product.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در ساخت صورتحساب! دوباره تلاش کن.", nil)
+	}
+}
+
+// ValidatePreCheckout checks a pre-checkout query against the catalog, price and stock.
+// Returns false and the message shown to the buyer if the payment must be refused.
+func (h *HandlerManager) ValidatePreCheckout(query *tgbotapi.PreCheckoutQuery) (bool, string) {
+	if !h.Config.TelegramPaymentsEnabled {
+		return false, "پرداخت با استارز فعلاً فعال نیست."
+	}
+
+	kind, productID, userID, err := models.ParseInvoicePayload(query.InvoicePayload)
+	if err != nil {
+		logger.Warn("Pre-checkout with invalid payload", "payload", query.InvoicePayload, "from", query.From.ID)
+		return false, "صورتحساب نامعتبر است."
+	}
+
+	user, err := h.UserRepo.GetUserByTelegramID(query.From.ID)
+	if err != nil || user == nil || user.ID != userID {
+		return false, "این صورتحساب متعلق به شما نیست."
+	}
+
+	product := models.GetInvoiceProduct(kind, productID)
+	if product == nil {
+		return false, "این محصول دیگر فروخته نمی‌شود."
+	}
+
+	if query.Currency != models.TelegramStarsCurrency || query.TotalAmount != product.PriceStars {
+		logger.Warn("Pre-checkout price mismatch", "kind", kind, "product_id", productID, "currency", query.Currency, "amount", query.TotalAmount)
+		return false, "قیمت این محصول تغییر کرده؛ لطفاً دوباره از فروشگاه خرید کن."
+	}
+
+	if remaining, limited := h.remainingStock(product); limited && remaining <= 0 {
+		return false, "موجودی این بسته تمام شده."
+	}
+
+	return true, ""
+}
+
+// HandleSuccessfulPayment records a paid invoice and credits the buyer.
+// Telegram may deliver the same payment twice; the charge ID makes crediting idempotent.
+// A payment that can't be credited is still stored, as needs_review, for an admin to settle.
+func (h *HandlerManager) HandleSuccessfulPayment(message *tgbotapi.Message, bot BotInterface) {
+	paid := message.SuccessfulPayment
+	userID := message.From.ID
+
+	kind, productID, payloadUserID, err := models.ParseInvoicePayload(paid.InvoicePayload)
+	record := &models.TelegramPayment{
+		TelegramPaymentChargeID: paid.TelegramPaymentChargeID,
+		ProviderPaymentChargeID: paid.ProviderPaymentChargeID,
+		PayerTgID:               userID,
+		ProductKind:             kind,
+		ProductID:               productID,
+		Currency:                paid.Currency,
+		TotalAmount:             paid.TotalAmount,
+		Payload:                 paid.InvoicePayload,
+	}
+
+	product := models.GetInvoiceProduct(kind, productID)
+	if err != nil || product == nil {
+		h.holdTelegramPayment(record, "unknown product", bot)
+		bot.SendMessage(userID, fmt.Sprintf("⚠️ پرداخت انجام شد ولی محصول شناسایی نشد. لطفاً با پشتیبانی تماس بگیر.\n🔖 کد پیگیری: %s", paid.TelegramPaymentChargeID), nil)
+		return
+	}
+	record.Amount = product.Amount
+
+	var user *models.User
+	err = retryPaymentWrite(func() error {
+		var lookupErr error
+		user, lookupErr = h.UserRepo.GetUserByTelegramID(userID)
+		return lookupErr
+	})
+	if err != nil {
+		reason := "payer has no account"
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+			reason = fmt.Sprintf("user lookup failed: %v", err)
+		}
+		h.holdTelegramPayment(record, reason, bot)
+		bot.SendMessage(userID, fmt.Sprintf("⚠️ پرداخت انجام شد ولی حساب شما پیدا نشد. لطفاً با پشتیبانی تماس بگیر.\n🔖 کد پیگیری: %s", paid.TelegramPaymentChargeID), nil)
+		return
+	}
+	record.UserID = &user.ID
+	if user.ID != payloadUserID {
+		h.holdTelegramPayment(record, fmt.Sprintf("invoice was issued to user %d", payloadUserID), bot)
+		bot.SendMessage(userID, fmt.Sprintf("⚠️ پرداخت انجام شد ولی این فاکتور برای حساب شما نبود. لطفاً با پشتیبانی تماس بگیر.\n🔖 کد پیگیری: %s", paid.TelegramPaymentChargeID), nil)
+		return
+	}
+
+	var credited bool
+	err = retryPaymentWrite(func() error {
+		var recordErr error
+		credited, recordErr = h.PurchaseRepo.RecordTelegramPayment(record)
+		return recordErr
+	})
+	if err != nil {
+		logger.Error("Failed to record telegram payment", "charge_id", paid.TelegramPaymentChargeID, "user_id", user.ID, "error", err)
+		h.holdTelegramPayment(record, fmt.Sprintf("crediting failed: %v", err), bot)
+		bot.SendMessage(userID, fmt.Sprintf("⚠️ پرداخت انجام شد ولی ثبت آن با خطا مواجه شد. لطفاً با پشتیبانی تماس بگیر.\n🔖 کد پیگیری: %s", paid.TelegramPaymentChargeID), nil)
+		return
+	}
+	if !credited {
+		logger.Info("Duplicate telegram payment ignored", "charge_id", paid.TelegramPaymentChargeID)
+		return
+	}
+
+	logger.Info("Telegram payment credited", "charge_id", paid.TelegramPaymentChargeID, "user_id", user.ID, "kind", product.Kind, "amount", product.Amount)
+
+	unit := "سکه"
+	if product.Kind == models.ProductKindDiamonds {
+		unit = "الماس"
+	}
+	bot.SendMessage(userID, fmt.Sprintf("🎉 پرداخت موفق!\n\n%s %s به حسابت اضافه شد.\n🔖 کد پیگیری: %s", utils.FormatPersianNumber(product.Amount), unit, paid.TelegramPaymentChargeID), nil)
+}
+
+// paymentWriteAttempts is how many times a payment lookup or write is tried before giving up
+const paymentWriteAttempts = 3
+
+// retryPaymentWrite runs fn until it succeeds, retrying only internal (database) errors.
+// Money has already been taken, so a passing database hiccup shouldn't strand it.
+func retryPaymentWrite(fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= paymentWriteAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code != errors.ErrCodeInternalError {
+			return err
+		}
+		if attempt < paymentWriteAttempts {
+			time.Sleep(time.Duration(attempt) * 200 * time.Millisecond)
+		}
+	}
+	return err
+}
+
+// holdTelegramPayment stores a paid invoice that wasn't credited as needs_review and alerts
+// the admin, so the buyer can be credited or refunded by hand
+func (h *HandlerManager) holdTelegramPayment(record *models.TelegramPayment, reason string, bot BotInterface) {
+	logger.Error("Telegram payment needs review", "charge_id", record.TelegramPaymentChargeID, "payload", record.Payload, "from", record.PayerTgID, "reason", reason)
+
+	record.ReviewNote = reason
+	if len(record.ReviewNote) > 255 {
+		record.ReviewNote = record.ReviewNote[:255]
+	}
+	stored := "ثبت شد"
+	err := retryPaymentWrite(func() error {
+		_, holdErr := h.PurchaseRepo.HoldTelegramPayment(record)
+		return holdErr
+	})
+	if err != nil {
+		logger.Error("Failed to store telegram payment for review", "charge_id", record.TelegramPaymentChargeID, "error", err)
+		stored = "ثبت نشد! فقط همین پیام را داری"
+	}
+
+	if h.Config.SuperAdminTgID == 0 {
+		return
+	}
+	bot.SendMessage(h.Config.SuperAdminTgID, fmt.Sprintf("⚠️ پرداخت استارز بدون واریز!\n\n👤 %d\n🧾 %s\n💰 %s %s\n🔖 کد پیگیری: %s\n❗ %s\n📝 در پایگاه داده %s.\n\nدستی واریز کن یا مبلغ را برگردان.",
+		record.PayerTgID, record.Payload, utils.FormatPersianNumber(int64(record.TotalAmount)), record.Currency, record.TelegramPaymentChargeID, reason, stored), nil)
+}
+
+// remainingStock returns how many units of a limited product are left.
+// limited is false for products without a stock limit.
+func (h *HandlerManager) remainingStock(product *models.InvoiceProduct) (remaining int64, limited bool) {
+	if product == nil || product.Stock <= 0 {
+		return 0, false
+	}
+
+	sold, err := h.PurchaseRepo.CountProductSales(product.Kind, product.ID)
+	if err != nil {
+		logger.Error("Failed to count product sales", "kind", product.Kind, "product_id", product.ID, "error", err)
+		return 0, true
+	}
+
+	return int64(product.Stock) - sold, true
+}
+
+// invoiceText returns the title and description of a product's invoice
+func invoiceText(product *models.InvoiceProduct) (string, string) {
+	if product.Kind == models.ProductKindDiamonds {
+		return fmt.Sprintf("%s الماس", utils.FormatPersianNumber(product.Amount)),
+			"بسته الماس برای خرید آیتم‌های ویژه در بازی"
+	}
+	return fmt.Sprintf("%s سکه", utils.FormatPersianNumber(product.Amount)),
+		"پکیج سکه برای چت، بازی و خرید آیتم"
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/google/uuid"
 	"github.com/mroshb/game_bot/internal/models"
 	"github.com/mroshb/game_bot/pkg/logger"
 )
@@ -17,14 +16,21 @@ import (
 
 // StartTodMatchmaking starts matchmaking for Truth or Dare
 func (h *HandlerManager) StartTodMatchmaking(userID int64, bot BotInterface) {
-	// This function is now in tod_matchmaking.go
-	// Keeping this for backward compatibility
+	h.startTodMatchmaking(userID, 0, bot)
+}
+
+// startTodMatchmaking queues the user for a ToD game with an optional stake (0 = no bet)
+func (h *HandlerManager) startTodMatchmaking(userID int64, betAmount int64, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات کاربر!", nil)
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeTod, bot) {
+		return
+	}
+
 	// Check if already in active game
 	activeGame, _ := h.TodRepo.GetActiveGameForUser(user.ID)
 	if activeGame != nil {
@@ -45,6 +51,7 @@ func (h *HandlerManager) StartTodMatchmaking(userID int64, bot BotInterface) {
 		RequestedGender: models.RequestedGenderAny,
 		CoinsPaid:       0,
 		GameType:        models.GameTypeTod,
+		BetAmount:       betAmount,
 	}
 
 	err = h.MatchRepo.AddToQueue(queue)
@@ -58,7 +65,11 @@ func (h *HandlerManager) StartTodMatchmaking(userID int64, bot BotInterface) {
 	h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusSearching)
 
 	// Send searching message
-	bot.SendMessage(userID, "🔍 در حال جستجوی حریف برای بازی جرعت و حقیقت...\n\n⏳ لطفاً صبر کنید...", nil)
+	if betAmount > 0 {
+		bot.SendMessage(userID, fmt.Sprintf("🔍 در حال جستجوی حریف برای بازی جرعت و حقیقت شرطی...\n\n💰 مبلغ شرط: %d سکه\n⏳ لطفاً صبر کنید...", betAmount), nil)
+	} else {
+		bot.SendMessage(userID, "🔍 در حال جستجوی حریف برای بازی جرعت و حقیقت...\n\n⏳ لطفاً صبر کنید...", nil)
+	}
 
 	// Try to find a match immediately
 	go h.tryTodMatchmaking(user.ID, bot)
@@ -70,7 +81,7 @@ func (h *HandlerManager) tryTodMatchmaking(userID uint, bot BotInterface) {
 	time.Sleep(2 * time.Second)
 
 	// Get user from queue
-	_, err := h.MatchRepo.GetQueueEntry(userID)
+	entry, err := h.MatchRepo.GetQueueEntry(userID)
 	if err != nil {
 		// User might have cancelled
 		return
@@ -78,8 +89,9 @@ func (h *HandlerManager) tryTodMatchmaking(userID uint, bot BotInterface) {
 
 	// Try to find a match
 	filters := &models.MatchFilters{
-		Gender:   models.RequestedGenderAny,
-		GameType: models.GameTypeTod,
+		Gender:    models.RequestedGenderAny,
+		GameType:  models.GameTypeTod,
+		BetAmount: entry.BetAmount,
 	}
 	opponent, err := h.MatchRepo.FindMatch(userID, filters)
 	if err != nil || opponent == nil {
@@ -120,6 +132,22 @@ func (h *HandlerManager) tryTodMatchmaking(userID uint, bot BotInterface) {
 		return
 	}
 
+	// Hold both stakes in escrow for betting games
+	if err := h.placeGameBet(models.GameTypeTod, game.ID, userID, opponent.ID, entry.BetAmount); err != nil {
+		h.TodRepo.EndGame(game.ID, 0, "cancelled")
+		h.MatchRepo.EndMatch(matchSession.ID)
+		failMsg := betFailureMessage(err)
+		user, _ := h.UserRepo.GetUserByID(userID)
+		if user != nil {
+			bot.SendMessage(user.TelegramID, failMsg, nil)
+		}
+		bot.SendMessage(opponent.TelegramID, failMsg, nil)
+
+		h.UserRepo.UpdateUserStatus(userID, models.UserStatusOnline)
+		h.UserRepo.UpdateUserStatus(opponent.ID, models.UserStatusOnline)
+		return
+	}
+
 	// Update both users' statuses
 	h.UserRepo.UpdateUserStatus(userID, models.UserStatusInMatch)
 	h.UserRepo.UpdateUserStatus(opponent.ID, models.UserStatusInMatch)
@@ -178,11 +206,7 @@ func (h *HandlerManager) HandleTodCoinFlip(gameID uint, bot BotInterface) {
 	if rand.Intn(2) == 1 {
 		firstPlayer, secondPlayer = secondPlayer, firstPlayer
 		// Update game
-		h.DB.Model(&models.TodGame{}).Where("id = ?", gameID).
-			Updates(map[string]interface{}{
-				"active_player_id":  firstPlayer,
-				"passive_player_id": secondPlayer,
-			})
+		h.TodRepo.SetPlayerOrder(gameID, firstPlayer, secondPlayer)
 	}
 
 	var firstName, secondName string
@@ -314,13 +338,6 @@ func (h *HandlerManager) HandleTodChoice(userID int64, gameID uint, choice strin
 		return
 	}
 
-	// Generate action ID for idempotency
-	actionID := uuid.New().String()
-	if h.TodRepo.IsActionProcessed(gameID, actionID) {
-		return // Already processed
-	}
-	h.TodRepo.MarkActionProcessed(gameID, user.ID, actionID, "choice_"+choice)
-
 	// Get current turn
 	turn, err := h.TodRepo.GetCurrentTurn(gameID)
 	if err != nil {
@@ -328,6 +345,11 @@ func (h *HandlerManager) HandleTodChoice(userID int64, gameID uint, choice strin
 		return
 	}
 
+	// One choice per turn; a repeated tap or callback retry is dropped
+	if claimed, err := h.TodRepo.ClaimAction(gameID, user.ID, fmt.Sprintf("choice:%d", turn.ID), "choice_"+choice); err != nil || !claimed {
+		return
+	}
+
 	// Update turn choice
 	h.TodRepo.UpdateTurnChoice(turn.ID, choice)
 
@@ -417,6 +439,10 @@ func getProofTypeText(proofType string) string {
 
 // StartTodGameWithMatch starts a ToD game with an existing match
 func (h *HandlerManager) StartTodGameWithMatch(userID int64, matchID uint, bot BotInterface) {
+	if h.RejectBanned(userID, models.BanScopeTod, bot) {
+		return
+	}
+
 	match, err := h.MatchRepo.GetMatchByID(matchID)
 	if err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات مچ!", nil)
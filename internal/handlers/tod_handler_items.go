@@ -5,8 +5,9 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/google/uuid"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
+	"github.com/mroshb/game_bot/pkg/errors"
 	"github.com/mroshb/game_bot/pkg/logger"
 )
 
@@ -32,38 +33,33 @@ func (h *HandlerManager) ShowTodItemMenu(userID int64, gameID uint, bot BotInter
 		return
 	}
 
-	// Get player stats for inventory
-	stats, err := h.TodRepo.GetOrCreatePlayerStats(user.ID)
-	if err != nil {
+	// Make sure a first-time player has their starting items
+	if _, err := h.TodRepo.GetOrCreatePlayerStats(user.ID); err != nil {
 		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
 		return
 	}
 
 	msg := "🎒 آیتمهای شما:\n\n━━━━━━━━━━━━━━\n"
-	msg += fmt.Sprintf("🛡 سپر (%d عدد)\nرد نوبت بدون جریمه\n\n", stats.ShieldsOwned)
-	msg += fmt.Sprintf("🔄 تعویض (%d عدد)\nتغییر سوال به سوال دیگر\n\n", stats.SwapsOwned)
-	msg += fmt.Sprintf("🪞 آینه (%d عدد)\nانتقال چالش به حریف\n\n", stats.MirrorsOwned)
-	msg += "━━━━━━━━━━━━━━"
-
 	var rows [][]tgbotapi.InlineKeyboardButton
 
-	if stats.ShieldsOwned > 0 {
-		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🛡 استفاده (%d)", stats.ShieldsOwned), fmt.Sprintf("btn:tod_use_item_%d_shield", gameID)),
-		))
-	}
-
-	if stats.SwapsOwned > 0 {
-		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔄 استفاده (%d)", stats.SwapsOwned), fmt.Sprintf("btn:tod_use_item_%d_swap", gameID)),
-		))
-	}
+	for _, def := range models.ItemRegistry {
+		if def.Game != models.ItemGameTod {
+			continue
+		}
+		count, err := h.InventoryRepo.GetQuantity(user.ID, def.Type)
+		if err != nil {
+			bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+			return
+		}
 
-	if stats.MirrorsOwned > 0 {
-		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🪞 استفاده (%d)", stats.MirrorsOwned), fmt.Sprintf("btn:tod_use_item_%d_mirror", gameID)),
-		))
+		msg += fmt.Sprintf("%s (%d عدد)\n%s\n\n", def.Title, count, def.Description)
+		if count > 0 {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s استفاده (%d)", def.Title, count), fmt.Sprintf("btn:tod_use_item_%d_%s", gameID, def.Type)),
+			))
+		}
 	}
+	msg += "━━━━━━━━━━━━━━"
 
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت", fmt.Sprintf("btn:tod_back_%d", gameID)),
@@ -97,20 +93,6 @@ func (h *HandlerManager) HandleTodItemUse(userID int64, gameID uint, itemType st
 		return
 	}
 
-	// Generate action ID for idempotency
-	actionID := uuid.New().String()
-	if h.TodRepo.IsActionProcessed(gameID, actionID) {
-		return
-	}
-	h.TodRepo.MarkActionProcessed(gameID, user.ID, actionID, "use_item_"+itemType)
-
-	// Try to use item
-	err = h.TodRepo.UseItem(user.ID, itemType)
-	if err != nil {
-		bot.SendMessage(userID, "❌ شما این آیتم را ندارید!", nil)
-		return
-	}
-
 	// Get current turn
 	turn, err := h.TodRepo.GetCurrentTurn(gameID)
 	if err != nil {
@@ -118,13 +100,20 @@ func (h *HandlerManager) HandleTodItemUse(userID int64, gameID uint, itemType st
 		return
 	}
 
-	// Log item usage
-	now := time.Now()
-	h.DB.Model(&models.TodTurn{}).Where("id = ?", turn.ID).
-		Updates(map[string]interface{}{
-			"item_used":    itemType,
-			"item_used_at": now,
-		})
+	// One item per turn; a repeated tap or callback retry must not spend a second one
+	if err := h.TodRepo.UseItem(gameID, turn.ID, user.ID, itemType); err != nil {
+		appErr, _ := err.(*errors.AppError)
+		switch {
+		case isDuplicateRequest(err):
+			bot.SendMessage(userID, "⚠️ در این نوبت قبلاً از یک آیتم استفاده کرده‌اید!", nil)
+		case appErr != nil && appErr.Code == errors.ErrCodeNotFound:
+			bot.SendMessage(userID, "❌ شما این آیتم را ندارید!", nil)
+		default:
+			logger.Error("Failed to use ToD item", "game_id", gameID, "item_type", itemType, "error", err)
+			bot.SendMessage(userID, "❌ خطا در استفاده از آیتم! دوباره تلاش کن.", nil)
+		}
+		return
+	}
 
 	// Apply item effect
 	switch itemType {
@@ -230,8 +219,7 @@ func (h *HandlerManager) EndTodGame(gameID uint, bot BotInterface) {
 	var player1Score, player2Score int
 
 	// Get all turns for this game
-	var turns []models.TodTurn
-	h.DB.Where("game_id = ?", gameID).Find(&turns)
+	turns, _ := h.TodRepo.GetTurns(gameID)
 
 	for _, turn := range turns {
 		if turn.JudgmentResult == "accepted" {
@@ -272,15 +260,18 @@ func (h *HandlerManager) EndTodGame(gameID uint, bot BotInterface) {
 		h.TodRepo.IncrementGamesPlayed(loserID, false)
 
 		// Award winner
-		h.CoinRepo.AddCoins(winnerID, 50, models.TxTypeGameReward, "پاداش برد بازی جرعت و حقیقت")
+		h.CoinRepo.AddCoins(winnerID, 50, models.TxTypeGameReward, "پاداش برد بازی جرعت و حقیقت", todResultKey(models.TxTypeGameReward, gameID, winnerID))
 	} else {
 		// Draw
 		h.TodRepo.IncrementGamesPlayed(game.Match.User1ID, false)
 		h.TodRepo.IncrementGamesPlayed(game.Match.User2ID, false)
-		h.CoinRepo.AddCoins(game.Match.User1ID, 20, models.TxTypeGameReward, "پاداش مساوی")
-		h.CoinRepo.AddCoins(game.Match.User2ID, 20, models.TxTypeGameReward, "پاداش مساوی")
+		h.CoinRepo.AddCoins(game.Match.User1ID, 20, models.TxTypeGameReward, "پاداش مساوی", todResultKey(models.TxTypeGameReward, gameID, game.Match.User1ID))
+		h.CoinRepo.AddCoins(game.Match.User2ID, 20, models.TxTypeGameReward, "پاداش مساوی", todResultKey(models.TxTypeGameReward, gameID, game.Match.User2ID))
 	}
 
+	// Pay out the pot if this was a betting game
+	h.settleGameBet(models.GameTypeTod, gameID, winnerID, bot)
+
 	// Show results
 	h.ShowTodGameResults(game, player1Score, player2Score, winnerID, bot)
 }
@@ -333,6 +324,12 @@ func (h *HandlerManager) ShowTodGameResults(game *models.TodGame, score1, score2
 // TIMEOUT & AFK HANDLING
 // ========================================
 
+// todResultKey keys the end-of-game coins of a player. Win, draw, timeout and quit share it,
+// so a game that is ended twice (e.g. a timeout racing a quit) pays out only once.
+func todResultKey(txType string, gameID, userID uint) string {
+	return models.CoinOpKey(txType, "tod_game", gameID, userID)
+}
+
 // HandleTodTimeout handles game timeout
 func (h *HandlerManager) HandleTodTimeout(gameID uint, bot BotInterface) {
 	game, err := h.TodRepo.GetGameByID(gameID)
@@ -357,13 +354,11 @@ func (h *HandlerManager) HandleTodTimeout(gameID uint, bot BotInterface) {
 	h.TodRepo.IncrementGamesPlayed(timedOutPlayerID, false)
 
 	// Penalize timed out player
-	h.CoinRepo.AddCoins(timedOutPlayerID, -20, models.TxTypePenalty, "جریمه تایم‌اوت")
-	h.TodRepo.UpdatePlayerStats(timedOutPlayerID, map[string]interface{}{
-		"timeout_count": h.DB.Raw("timeout_count + 1"),
-	})
+	h.CoinRepo.AddCoins(timedOutPlayerID, -20, models.TxTypePenalty, "جریمه تایم‌اوت", todResultKey(models.TxTypePenalty, gameID, timedOutPlayerID))
+	h.TodRepo.IncrementTimeoutCount(timedOutPlayerID)
 
 	// Reward winner
-	h.CoinRepo.AddCoins(winnerID, 30, models.TxTypeGameReward, "پاداش برد به دلیل AFK حریف")
+	h.CoinRepo.AddCoins(winnerID, 30, models.TxTypeGameReward, "پاداش برد به دلیل AFK حریف", todResultKey(models.TxTypeGameReward, gameID, winnerID))
 
 	// Get users
 	timedOutUser := getUserByID(timedOutPlayerID, game.Match)
@@ -373,8 +368,11 @@ func (h *HandlerManager) HandleTodTimeout(gameID uint, bot BotInterface) {
 	timeoutMsg := "⏱ زمان تمام شد!\n\n━━━━━━━━━━━━━━\n🏳️ شما به دلیل عدم پاسخ‌گویی باخت فنی شدید\n\n💸 جریمه:\n• -20 سکه\n• -10 XP\n\n━━━━━━━━━━━━━━\n⚠️ توجه: تایم‌اوت مکرر می‌تواند منجر به محدودیت حساب شود"
 	winnerMsg := "🏆 برنده شدید!\n\n━━━━━━━━━━━━━━\nحریف به دلیل AFK باخت فنی شد\n\n💰 پاداش برد:\n• +30 سکه\n• +20 XP"
 
-	bot.SendMessage(timedOutUser.TelegramID, timeoutMsg, nil)
-	bot.SendMessage(winnerUser.TelegramID, winnerMsg, nil)
+	bot.SendMessageWithPriority(timedOutUser.TelegramID, timeoutMsg, nil, sender.PriorityGame)
+	bot.SendMessageWithPriority(winnerUser.TelegramID, winnerMsg, nil, sender.PriorityGame)
+
+	// Return stakes if this was a betting game
+	h.refundGameBet(models.GameTypeTod, gameID, bot)
 
 	logger.Info("ToD game timed out", "game_id", gameID, "timed_out_player", timedOutPlayerID)
 }
@@ -392,7 +390,7 @@ func (h *HandlerManager) SendTodWarning(gameID uint, bot BotInterface) {
 	}
 
 	msg := "⚠️ هشدار!\n\n⏰ فقط 30 ثانیه باقی مانده!\n\nسریع انتخاب کن وگرنه باخت فنی می‌شود!"
-	bot.SendMessage(activeUser.TelegramID, msg, nil)
+	bot.SendMessageWithPriority(activeUser.TelegramID, msg, nil, sender.PriorityGame)
 }
 
 // ========================================
@@ -411,16 +409,30 @@ func (h *HandlerManager) HandleTodQuit(userID int64, gameID uint, bot BotInterfa
 		return
 	}
 
-	// Determine winner (opponent)
-	var winnerID uint
-	if game.ActivePlayerID == user.ID {
+	winnerID := h.forfeitTodGame(game, user.ID, 10)
+
+	// Send messages
+	bot.SendMessage(userID, "🏳️ شما از بازی انصراف دادید\n\n💸 جریمه: -10 سکه", nil)
+
+	winnerUser := getUserByID(winnerID, game.Match)
+	if winnerUser != nil {
+		bot.SendMessage(winnerUser.TelegramID, "🏆 حریف از بازی انصراف داد!\n\n💰 پاداش: +20 سکه", nil)
+	}
+
+	// Return stakes if this was a betting game
+	h.refundGameBet(models.GameTypeTod, gameID, bot)
+}
+
+// forfeitTodGame ends a game in favour of the player who stayed: they get the win and the
+// quit reward, the leaver is fined penalty coins. Returns the winner's user ID.
+func (h *HandlerManager) forfeitTodGame(game *models.TodGame, leaverID uint, penalty int64) uint {
+	winnerID := game.ActivePlayerID
+	if winnerID == leaverID {
 		winnerID = game.PassivePlayerID
-	} else {
-		winnerID = game.ActivePlayerID
 	}
 
 	// End game
-	h.TodRepo.EndGame(gameID, winnerID, "quit")
+	h.TodRepo.EndGame(game.ID, winnerID, "quit")
 
 	// Close match session
 	if game.MatchID > 0 {
@@ -429,21 +441,17 @@ func (h *HandlerManager) HandleTodQuit(userID int64, gameID uint, bot BotInterfa
 
 	// Update stats
 	h.TodRepo.IncrementGamesPlayed(winnerID, true)
-	h.TodRepo.IncrementGamesPlayed(user.ID, false)
+	h.TodRepo.IncrementGamesPlayed(leaverID, false)
 
 	// Penalize quitter
-	h.CoinRepo.AddCoins(user.ID, -10, models.TxTypePenalty, "جریمه انصراف از بازی")
+	if penalty > 0 {
+		h.CoinRepo.AddCoins(leaverID, -penalty, models.TxTypePenalty, "جریمه انصراف از بازی", todResultKey(models.TxTypePenalty, game.ID, leaverID))
+	}
 
 	// Reward winner
-	h.CoinRepo.AddCoins(winnerID, 20, models.TxTypeGameReward, "پاداش برد به دلیل انصراف حریف")
+	h.CoinRepo.AddCoins(winnerID, 20, models.TxTypeGameReward, "پاداش برد به دلیل انصراف حریف", todResultKey(models.TxTypeGameReward, game.ID, winnerID))
 
-	// Send messages
-	bot.SendMessage(userID, "🏳️ شما از بازی انصراف دادید\n\n💸 جریمه: -10 سکه", nil)
-
-	winnerUser := getUserByID(winnerID, game.Match)
-	if winnerUser != nil {
-		bot.SendMessage(winnerUser.TelegramID, "🏆 حریف از بازی انصراف داد!\n\n💰 پاداش: +20 سکه", nil)
-	}
+	return winnerID
 }
 
 // HandleTodNudge handles nudge action
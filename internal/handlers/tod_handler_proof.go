@@ -5,8 +5,8 @@ import (
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/google/uuid"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/logger"
 )
 
@@ -110,19 +110,17 @@ func (h *HandlerManager) HandleTodConfirmProof(userID int64, gameID uint, bot Bo
 		return
 	}
 
-	// Generate action ID for idempotency
-	actionID := uuid.New().String()
-	if h.TodRepo.IsActionProcessed(gameID, actionID) {
-		return
-	}
-	h.TodRepo.MarkActionProcessed(gameID, user.ID, actionID, "confirm_proof")
-
 	// Get current turn
 	turn, err := h.TodRepo.GetCurrentTurn(gameID)
 	if err != nil {
 		return
 	}
 
+	// The proof of a turn is confirmed once
+	if claimed, err := h.TodRepo.ClaimAction(gameID, user.ID, fmt.Sprintf("confirm_proof:%d", turn.ID), "confirm_proof"); err != nil || !claimed {
+		return
+	}
+
 	// Update state
 	h.TodRepo.UpdateGameState(gameID, models.TodStateWaitingJudgment)
 
@@ -166,8 +164,8 @@ func (h *HandlerManager) ShowTodJudgmentScreen(gameID uint, turn *models.TodTurn
 	judgmentMsg := "━━━━━━━━━━━━━━\nآیا حریف چالش را انجام داده؟\n\n⚠️ توجه: رد ناعادلانه باعث کاهش اعتبار داوری شما می‌شود!"
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ قبوله", fmt.Sprintf("btn:tod_judge_%d_accept", gameID)),
-			tgbotapi.NewInlineKeyboardButtonData("❌ قبول نیست", fmt.Sprintf("btn:tod_judge_%d_reject", gameID)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ قبوله", fmt.Sprintf("btn:tod_judge_%d_accepted", gameID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ قبول نیست", fmt.Sprintf("btn:tod_judge_%d_rejected", gameID)),
 		),
 	)
 
@@ -180,29 +178,19 @@ func (h *HandlerManager) ShowTodJudgmentScreen(gameID uint, turn *models.TodTurn
 
 // forwardProof forwards the proof to judge
 func (h *HandlerManager) forwardProof(judgeID int64, turn *models.TodTurn, bot BotInterface) {
-	api := bot.GetAPI()
-	if api == nil {
-		return
-	}
-
-	botAPI, ok := api.(*tgbotapi.BotAPI)
-	if !ok {
-		return
-	}
-
 	switch turn.ProofType {
 	case models.ProofTypeVoice:
 		voice := tgbotapi.NewVoice(judgeID, tgbotapi.FileID(turn.ProofData))
-		botAPI.Send(voice)
+		bot.Send(judgeID, voice, sender.PriorityGame)
 	case models.ProofTypeImage:
 		photo := tgbotapi.NewPhoto(judgeID, tgbotapi.FileID(turn.ProofData))
-		botAPI.Send(photo)
+		bot.Send(judgeID, photo, sender.PriorityGame)
 	case models.ProofTypeVideo:
 		video := tgbotapi.NewVideo(judgeID, tgbotapi.FileID(turn.ProofData))
-		botAPI.Send(video)
+		bot.Send(judgeID, video, sender.PriorityGame)
 	case models.ProofTypeText:
 		msg := tgbotapi.NewMessage(judgeID, fmt.Sprintf("📝 پاسخ: %s", turn.ProofData))
-		botAPI.Send(msg)
+		bot.Send(judgeID, msg, sender.PriorityGame)
 	}
 }
 
@@ -230,19 +218,17 @@ func (h *HandlerManager) HandleTodJudgment(userID int64, gameID uint, result str
 		return
 	}
 
-	// Generate action ID for idempotency
-	actionID := uuid.New().String()
-	if h.TodRepo.IsActionProcessed(gameID, actionID) {
-		return
-	}
-	h.TodRepo.MarkActionProcessed(gameID, user.ID, actionID, "judgment_"+result)
-
 	// Get current turn
 	turn, err := h.TodRepo.GetCurrentTurn(gameID)
 	if err != nil {
 		return
 	}
 
+	// A turn is judged once, whichever button is tapped again
+	if claimed, err := h.TodRepo.ClaimAction(gameID, user.ID, fmt.Sprintf("judgment:%d", turn.ID), "judgment_"+result); err != nil || !claimed {
+		return
+	}
+
 	// Update judgment
 	h.TodRepo.UpdateTurnJudgment(turn.ID, result, "")
 
@@ -265,7 +251,11 @@ func (h *HandlerManager) HandleTodJudgment(userID int64, gameID uint, result str
 			// Ban message
 			banMsg := "🚫 محدودیت داوری!\n\nبه دلیل داوری ناعادلانه مکرر، شما برای 24 ساعت از بازی جرعت و حقیقت محروم شدید"
 			bot.SendMessage(userID, banMsg, nil)
-			// TODO: Implement actual ban logic
+
+			// Only the judge is kept out of new games; the current one plays out
+			if _, err := h.BanRepo.BanUser(user.ID, models.BanScopeTod, TodJudgeBanDuration, "داوری ناعادلانه مکرر", 0); err != nil {
+				logger.Error("Failed to ban unfair judge", "user_id", user.ID, "error", err)
+			}
 		} else if stats.UnfairJudgmentCount >= 3 {
 			// Warning
 			warningMsg := fmt.Sprintf("⚠️ هشدار!\n\nاعتبار داوری شما کاهش یافته است.\n\nامتیاز فعلی: %.0f/100\n\nدلیل: %s\n\nلطفاً منصفانه داوری کنید", newScore, reason)
@@ -281,7 +271,7 @@ func (h *HandlerManager) HandleTodJudgment(userID int64, gameID uint, result str
 			xpAwarded = turn.Challenge.XPReward
 			coinsAwarded = turn.Challenge.CoinReward
 
-			h.CoinRepo.AddCoins(game.ActivePlayerID, int64(coinsAwarded), models.TxTypeGameReward, "پاداش بازی جرعت و حقیقت")
+			h.CoinRepo.AddCoins(game.ActivePlayerID, int64(coinsAwarded), models.TxTypeGameReward, "پاداش بازی جرعت و حقیقت", models.CoinOpKey(models.TxTypeGameReward, "tod_turn", turn.ID))
 			h.VillageSvc.AddXPForUser(game.ActivePlayerID, int64(xpAwarded))
 
 			// Update challenge acceptance rate
@@ -293,7 +283,7 @@ func (h *HandlerManager) HandleTodJudgment(userID int64, gameID uint, result str
 	} else {
 		// Penalize player
 		coinsAwarded = -5
-		h.CoinRepo.AddCoins(game.ActivePlayerID, -5, models.TxTypePenalty, "جریمه رد شدن چالش")
+		h.CoinRepo.AddCoins(game.ActivePlayerID, -5, models.TxTypePenalty, "جریمه رد شدن چالش", models.CoinOpKey(models.TxTypePenalty, "tod_turn", turn.ID))
 
 		// Update stats
 		h.TodRepo.IncrementChallengeCompleted(game.ActivePlayerID, turn.Choice, false)
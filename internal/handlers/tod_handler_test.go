@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+)
+
+// newTodGame matches the two users and starts a Truth or Dare game for them
+func newTodGame(t *testing.T, env *testEnv, first, second *models.User) *models.TodGame {
+	t.Helper()
+
+	match, err := env.h.MatchRepo.CreateMatchSession(first.ID, second.ID, env.h.Config.GetMatchTimeout())
+	if err != nil {
+		t.Fatalf("CreateMatchSession() error = %v", err)
+	}
+	game, err := env.h.TodRepo.CreateGame(match.ID, first.ID, second.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	return game
+}
+
+func TestTodFlow_ChoiceDealsChallenge(t *testing.T) {
+	env := newTestEnv(t)
+	env.db.SeedTodChallenges(models.TodChallenge{
+		Type:         models.TodTypeTruth,
+		Text:         "Tell us a secret",
+		Difficulty:   "easy",
+		GenderTarget: "all",
+		ProofType:    models.ProofTypeText,
+	})
+	alice := env.newUser(t, 5001, "Alice", models.GenderFemale, 50)
+	bob := env.newUser(t, 5002, "Bob", models.GenderMale, 50)
+	game := newTodGame(t, env, alice, bob)
+
+	env.h.HandleTodStart(alice.TelegramID, game.ID, env.bot)
+	if !env.bot.received(alice.TelegramID, "نوبت شما") {
+		t.Error("active player was not shown the choice screen")
+	}
+
+	// The passive player cannot choose
+	env.h.HandleTodChoice(bob.TelegramID, game.ID, models.TodTypeTruth, env.bot)
+	if !env.bot.received(bob.TelegramID, "نوبت شما نیست") {
+		t.Error("passive player's choice was not refused")
+	}
+
+	env.h.HandleTodChoice(alice.TelegramID, game.ID, models.TodTypeTruth, env.bot)
+	// A repeated tap is dropped
+	env.h.HandleTodChoice(alice.TelegramID, game.ID, models.TodTypeTruth, env.bot)
+
+	game, err := env.h.TodRepo.GetGameByID(game.ID)
+	if err != nil {
+		t.Fatalf("GetGameByID() error = %v", err)
+	}
+	if game.State != models.TodStateWaitingProof {
+		t.Errorf("game state = %q, want %q", game.State, models.TodStateWaitingProof)
+	}
+
+	turn, err := env.h.TodRepo.GetCurrentTurn(game.ID)
+	if err != nil {
+		t.Fatalf("GetCurrentTurn() error = %v", err)
+	}
+	if turn.Choice != models.TodTypeTruth || turn.ChallengeText != "Tell us a secret" {
+		t.Errorf("turn = (%q, %q), want the seeded truth challenge", turn.Choice, turn.ChallengeText)
+	}
+
+	var challenges int
+	for _, text := range env.bot.messages(alice.TelegramID) {
+		if strings.Contains(text, "Tell us a secret") {
+			challenges++
+		}
+	}
+	if challenges != 1 {
+		t.Errorf("challenge shown %d times, want 1", challenges)
+	}
+}
+
+func TestTodFlow_QuitForfeitsGame(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 5101, "Alice", models.GenderFemale, 50)
+	bob := env.newUser(t, 5102, "Bob", models.GenderMale, 50)
+	game := newTodGame(t, env, alice, bob)
+
+	env.h.HandleTodQuit(bob.TelegramID, game.ID, env.bot)
+	// A second quit is not fined or rewarded again
+	env.h.HandleTodQuit(bob.TelegramID, game.ID, env.bot)
+
+	game, err := env.h.TodRepo.GetGameByID(game.ID)
+	if err != nil {
+		t.Fatalf("GetGameByID() error = %v", err)
+	}
+	if game.WinnerID == nil || *game.WinnerID != alice.ID {
+		t.Errorf("winner = %v, want %d", game.WinnerID, alice.ID)
+	}
+	if got := env.balance(t, bob.ID); got != 40 {
+		t.Errorf("quitter balance = %d, want 40", got)
+	}
+	if got := env.balance(t, alice.ID); got != 70 {
+		t.Errorf("winner balance = %d, want 70", got)
+	}
+	if match, _ := env.h.MatchRepo.GetActiveMatch(alice.ID); match != nil {
+		t.Error("match is still active after a forfeit")
+	}
+}
+
+func TestTodItemUse_FailedUseKeepsTurnFree(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 5101, "Alice", models.GenderFemale, 50)
+	bob := env.newUser(t, 5102, "Bob", models.GenderMale, 50)
+	game := newTodGame(t, env, alice, bob)
+	env.h.HandleTodStart(alice.TelegramID, game.ID, env.bot)
+
+	// Without a swap the tap is refused, and must not use up the turn's item
+	env.h.HandleTodItemUse(alice.TelegramID, game.ID, models.ItemTypeSwap, env.bot)
+	if !env.bot.received(alice.TelegramID, "این آیتم را ندارید") {
+		t.Fatal("use of a missing item was not refused")
+	}
+
+	if err := memory.NewInventoryRepository(env.db).Grant(alice.ID, models.ItemTypeSwap, 2); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	env.h.HandleTodItemUse(alice.TelegramID, game.ID, models.ItemTypeSwap, env.bot)
+	if !env.bot.received(alice.TelegramID, "سوال تعویض شد") {
+		t.Fatal("swap was not used after buying one")
+	}
+
+	// A second item in the same turn is answered, not spent
+	env.h.HandleTodItemUse(alice.TelegramID, game.ID, models.ItemTypeSwap, env.bot)
+	if !env.bot.received(alice.TelegramID, "قبلاً از یک آیتم استفاده کرده‌اید") {
+		t.Error("second item use in a turn was not answered")
+	}
+	if got, _ := env.h.InventoryRepo.GetQuantity(alice.ID, models.ItemTypeSwap); got != 1 {
+		t.Errorf("swaps left = %d, want 1", got)
+	}
+}
+
+func TestTodUnreachable_GameEndedFirstIsNotForfeitedAgain(t *testing.T) {
+	env := newTestEnv(t)
+	alice := env.newUser(t, 5201, "Alice", models.GenderFemale, 50)
+	bob := env.newUser(t, 5202, "Bob", models.GenderMale, 50)
+	game := newTodGame(t, env, alice, bob)
+
+	// The timeout ends the game while the unreachable cleanup still holds the open game
+	env.h.HandleTodTimeout(game.ID, env.bot)
+	env.h.forfeitTodToUnreachable(game, bob, env.bot)
+
+	for _, user := range []*models.User{alice, bob} {
+		if env.bot.received(user.TelegramID, "در دسترس نیست") {
+			t.Errorf("user %d was told about a forfeit after the timeout ended the game", user.ID)
+		}
+	}
+	got, err := env.h.TodRepo.GetGameByID(game.ID)
+	if err != nil {
+		t.Fatalf("GetGameByID() error = %v", err)
+	}
+	if got.State != models.TodStateForfeit || got.EndReason != "timeout" {
+		t.Errorf("game = (%q, %q), want the timeout result kept", got.State, got.EndReason)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/pkg/logger"
 )
 
@@ -18,6 +19,10 @@ func (h *HandlerManager) StartTruthOrDare(userID int64, bot BotInterface) {
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeTod, bot) {
+		return
+	}
+
 	// Check if user is in an active match
 	match, err := h.MatchRepo.GetActiveMatch(user.ID)
 	if err != nil {
@@ -43,11 +48,7 @@ func (h *HandlerManager) StartTruthOrDare(userID int64, bot BotInterface) {
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = keyboard
 
-	if apiInterface := bot.GetAPI(); apiInterface != nil {
-		if api, ok := apiInterface.(*tgbotapi.BotAPI); ok {
-			api.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // HandleTruthOrDareChoice handles choice in 1v1 and shows category selection
@@ -79,15 +80,11 @@ func (h *HandlerManager) HandleTruthOrDareChoice(userID int64, matchID uint, cho
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
-	if api := bot.GetAPI(); api != nil {
-		if b, ok := api.(*tgbotapi.BotAPI); ok {
-			b.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // HandleMatchTruthOrDareCategorySelection handles category selection for 1v1 match
-func (h *HandlerManager) HandleMatchTruthOrDareCategorySelection(userID int64, matchID uint, category string, bot BotInterface) {
+func (h *HandlerManager) HandleMatchTruthOrDareCategorySelection(userID int64, matchID uint, category, requestID string, bot BotInterface) {
 	user, err := h.UserRepo.GetUserByTelegramID(userID)
 	if err != nil {
 		return
@@ -175,9 +172,9 @@ func (h *HandlerManager) HandleMatchTruthOrDareCategorySelection(userID int64, m
 	}
 
 	// Award coins
-	h.CoinRepo.AddCoins(user.ID, int64(question.Points), models.TxTypeGameReward, "پاداش بازی حقیقت یا جرات")
+	h.CoinRepo.AddCoins(user.ID, int64(question.Points), models.TxTypeGameReward, "پاداش بازی حقیقت یا جرات", requestKey(requestID, models.TxTypeGameReward, user.ID))
 	if otherUser != nil {
-		h.CoinRepo.AddCoins(otherUser.ID, int64(question.Points), models.TxTypeGameReward, "پاداش بازی حقیقت یا جرات")
+		h.CoinRepo.AddCoins(otherUser.ID, int64(question.Points), models.TxTypeGameReward, "پاداش بازی حقیقت یا جرات", requestKey(requestID, models.TxTypeGameReward, otherUser.ID))
 	}
 
 	// Award Village XP
@@ -195,6 +192,10 @@ func (h *HandlerManager) StartGroupTruthDare(userID int64, roomID uint, bot BotI
 		return
 	}
 
+	if h.checkBan(user, models.BanScopeRooms, bot) {
+		return
+	}
+
 	isHost, _ := h.RoomRepo.IsHost(roomID, user.ID)
 	if !isHost {
 		bot.SendMessage(userID, "❌ فقط میزبان می‌تواند بازی را شروع کند!", nil)
@@ -202,8 +203,7 @@ func (h *HandlerManager) StartGroupTruthDare(userID int64, roomID uint, bot BotI
 	}
 
 	// Check if game already active
-	activeSession, _ := h.GameRepo.GetActiveGameSessionByRoomID(roomID)
-	if activeSession != nil {
+	if h.activeGameSession(roomID) != nil {
 		bot.SendMessage(userID, "⚠️ یک بازی در حال حاضر فعال است!", nil)
 		return
 	}
@@ -280,11 +280,7 @@ func (h *HandlerManager) HandleGroupTruthOrDareChoice(userID int64, sessionID ui
 	msgConfig := tgbotapi.NewMessage(userID, msg)
 	msgConfig.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
-	if api := bot.GetAPI(); api != nil {
-		if b, ok := api.(*tgbotapi.BotAPI); ok {
-			b.Send(msgConfig)
-		}
-	}
+	bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityNormal)
 }
 
 // HandleGroupTruthOrDareCategorySelection handles category selection and shows the question
@@ -391,11 +387,7 @@ func (h *HandlerManager) BroadcastGroupGameWithQuestionStatus(sessionID uint, bo
 		msgConfig := tgbotapi.NewMessage(member.TelegramID, message)
 		msgConfig.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
-		if api := bot.GetAPI(); api != nil {
-			if b, ok := api.(*tgbotapi.BotAPI); ok {
-				b.Send(msgConfig)
-			}
-		}
+		bot.Send(msgConfig.ChatID, msgConfig, sender.PriorityGame)
 	}
 }
 
@@ -423,8 +415,14 @@ func (h *HandlerManager) HandleGroupNextTurn(adminID int64, sessionID uint, bot
 
 	// Only reward and progress if we were waiting for the host to confirm completion
 	if session.Status == models.GameStatusWaitingForHost {
-		// Reward the player who just finished
-		h.CoinRepo.AddCoins(session.TurnUserID, 15, models.TxTypeGameReward, "پاداش انجام چالش جرعت یا حقیقت")
+		// Reward the player who just finished; the turn's question keys it, so a repeated tap is dropped
+		var rewardKey string
+		if session.CurrentQuestionID != nil {
+			rewardKey = models.CoinOpKey(models.TxTypeGameReward, "group_turn", session.ID, session.TurnUserID, *session.CurrentQuestionID)
+		}
+		if err := h.CoinRepo.AddCoins(session.TurnUserID, 15, models.TxTypeGameReward, "پاداش انجام چالش جرعت یا حقیقت", rewardKey); isDuplicateRequest(err) {
+			return
+		}
 		// Notify the player - need TelegramID
 		turnUser, _ := h.UserRepo.GetUserByID(session.TurnUserID)
 		if turnUser != nil {
@@ -519,6 +517,12 @@ func (h *HandlerManager) BroadcastGroupGameStatus(sessionID uint, bot BotInterfa
 		return
 	}
 
+	// Group quiz has no turns; show its scoreboard instead
+	if session.GameType == models.GameTypeQuiz {
+		h.BroadcastGroupQuizScoreboard(sessionID, bot, message)
+		return
+	}
+
 	room, _ := h.RoomRepo.GetRoomByID(session.RoomID)
 	members, _ := h.RoomRepo.GetRoomMembers(session.RoomID)
 
@@ -584,10 +588,6 @@ func (h *HandlerManager) BroadcastGroupGameStatus(sessionID uint, bot BotInterfa
 			msgConfig.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons...)
 		}
 
-		if api := bot.GetAPI(); api != nil {
-			if b, ok := api.(*tgbotapi.BotAPI); ok {
-				b.Send(msgConfig)
-			}
-		}
+		bot.SendAsync(msgConfig.ChatID, msgConfig, sender.PriorityGame)
 	}
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// quizOpenStates are the states of a quiz match that hasn't been decided yet
+var quizOpenStates = []string{
+	models.QuizStateWaitingCategory,
+	models.QuizStateCategorySelected,
+	models.QuizStatePlayingQ1,
+	models.QuizStatePlayingQ2,
+	models.QuizStatePlayingQ3,
+	models.QuizStatePlayingQ4,
+	models.QuizStateRoundFinished,
+}
+
+// todOpenStates are the states of a Truth or Dare game that hasn't ended yet
+var todOpenStates = []string{
+	models.TodStateMatchmaking,
+	models.TodStateCoinFlip,
+	models.TodStateWaitingChoice,
+	models.TodStateWaitingProof,
+	models.TodStateWaitingJudgment,
+}
+
+// HandleUnreachable is called when Telegram refuses messages to a user because they
+// blocked the bot or deleted their account. The user is flagged so matchmaking skips
+// them and pulled out of everything that would otherwise wait on them: their queue
+// entry is refunded, their chat ended and their games forfeited to the opponents.
+func (h *HandlerManager) HandleUnreachable(telegramID int64, bot BotInterface) {
+	user, err := h.UserRepo.GetUserByTelegramID(telegramID)
+	if err != nil || user == nil {
+		return
+	}
+
+	marked, err := h.UserRepo.MarkUnreachable(user.ID)
+	if err != nil {
+		logger.Error("Failed to mark user unreachable", "user_id", user.ID, "error", err)
+		return
+	}
+	if !marked {
+		// Already flagged and cleaned up
+		return
+	}
+
+	// Nobody can be matched with them now, so the search fee goes back in full
+	if entry, _ := h.MatchRepo.GetQueueEntry(user.ID); entry != nil {
+		h.MatchRepo.RemoveFromQueue(user.ID)
+		if entry.CoinsPaid > 0 {
+			if err := h.CoinRepo.AddCoins(user.ID, entry.CoinsPaid, models.TxTypeMatchRefund, "بازگشت هزینه جستجو", models.CoinOpKey(models.TxTypeMatchRefund, "queue", entry.ID)); err != nil {
+				logger.Error("Failed to refund queue fee", "user_id", user.ID, "error", err)
+			}
+		}
+	}
+
+	// Games first: a Truth or Dare game runs on a match the chat cleanup would end too
+	if game, _ := h.TodRepo.GetActiveGameForUser(user.ID); game != nil {
+		h.forfeitTodToUnreachable(game, user, bot)
+	}
+
+	quizMatches, _ := h.QuizMatchRepo.GetAllActiveQuizMatchesByUser(user.ID)
+	for i := range quizMatches {
+		h.forfeitQuizToUnreachable(&quizMatches[i], user, bot)
+	}
+
+	h.endAnonymousChat(user, bot)
+
+	rooms, _ := h.RoomRepo.GetUserRooms(user.ID)
+	for _, room := range rooms {
+		h.LeaveRoom(telegramID, room.ID, bot)
+	}
+
+	h.UserRepo.UpdateUserStatus(user.ID, models.UserStatusOffline)
+	logger.Info("User unreachable", "user_id", user.ID, "telegram_id", telegramID, "quiz_matches", len(quizMatches), "rooms", len(rooms))
+}
+
+// MarkReachable clears the unreachable flag of a user who talked to the bot again
+func (h *HandlerManager) MarkReachable(telegramID int64) {
+	if err := h.UserRepo.MarkReachable(telegramID); err != nil {
+		logger.Error("Failed to mark user reachable", "telegram_id", telegramID, "error", err)
+	}
+}
+
+// forfeitTodToUnreachable gives a Truth or Dare game to the opponent of a user who can't
+// play on. There is no quit fine since the user didn't choose to leave.
+func (h *HandlerManager) forfeitTodToUnreachable(game *models.TodGame, user *models.User, bot BotInterface) {
+	// Claim the game so a timeout, a quit or the opponent's move can't end it at the same time
+	success, _ := h.TodRepo.UpdateGameStateAtomic(game.ID, todOpenStates, models.TodStateGameEnd)
+	if !success {
+		return
+	}
+
+	winnerID := h.forfeitTodGame(game, user.ID, 0)
+
+	if winner := getUserByID(winnerID, game.Match); winner != nil {
+		bot.SendMessage(winner.TelegramID, "🏆 حریف دیگه در دسترس نیست و بازی به شما رسید!\n\n💰 پاداش: +20 سکه", nil)
+	}
+
+	// Return stakes if this was a betting game
+	h.refundGameBet(models.GameTypeTod, game.ID, bot)
+}
+
+// forfeitQuizToUnreachable gives a quiz match to the opponent of a user who can't play on
+func (h *HandlerManager) forfeitQuizToUnreachable(match *models.QuizMatch, user *models.User, bot BotInterface) {
+	// Claim the match so a timeout or the last answer can't finish it at the same time
+	success, _ := h.QuizMatchRepo.UpdateQuizMatchStateAtomic(match.ID, quizOpenStates, models.QuizStateGameFinished)
+	if !success {
+		return
+	}
+
+	winner := &match.User1
+	if match.User1ID == user.ID {
+		winner = &match.User2
+	}
+
+	h.QuizMatchRepo.FinishQuizMatch(match.ID, winner.ID)
+	h.CoinRepo.AddCoins(winner.ID, int64(models.QuizWinRewardCoins), "quiz_win", "Quiz game win by forfeit", quizResultKey(match.ID, winner.ID))
+	h.UserRepo.AddXP(winner.ID, models.QuizWinRewardXP)
+
+	// Return stakes if this was a betting game
+	h.refundGameBet(models.GameTypeQuiz, match.ID, bot)
+
+	msg := fmt.Sprintf("🏳️ %s دیگه در دسترس نیست و بازی رو واگذار کرد.\n\n🏆 شما برنده شدید!\n💰 پاداش: +%d سکه | ⭐ +%d امتیاز تجربه",
+		user.FullName, models.QuizWinRewardCoins, models.QuizWinRewardXP)
+	bot.SendMessage(winner.TelegramID, msg, nil)
+
+	cleanupQuizGameSession(match.ID)
+	h.updateQuizPlayerStatus(winner.ID)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,6 +9,8 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/models"
 	"github.com/mroshb/game_bot/internal/security"
+	"github.com/mroshb/game_bot/internal/sender"
+	"github.com/mroshb/game_bot/internal/session"
 	apperrors "github.com/mroshb/game_bot/pkg/errors"
 	"github.com/mroshb/game_bot/pkg/logger"
 	"github.com/mroshb/game_bot/pkg/utils"
@@ -31,17 +32,19 @@ type BotInterface interface {
 	GetCancelInlineKeyboard() interface{}
 	GetEditProfileFieldsKeyboard() interface{}
 	GetConfig() interface{}
-	GetAPI() interface{}
+	BotUsername() string
+	Send(chatID int64, c tgbotapi.Chattable, priority sender.Priority) (tgbotapi.Message, error)
+	SendMessageWithPriority(chatID int64, text string, keyboard interface{}, priority sender.Priority) int
+	SendAsync(chatID int64, c tgbotapi.Chattable, priority sender.Priority) error
+	SendMessageAsync(chatID int64, text string, keyboard interface{}, priority sender.Priority) error
 	AnswerCallbackQuery(queryID string, text string, showAlert bool)
 	GetVillageHubKeyboard(hasVillage bool) interface{}
 	GetCancelKeyboard() interface{}
 	EditMessageReplyMarkup(chatID int64, messageID int, keyboard interface{})
 }
 
-type UserSession struct {
-	State string
-	Data  map[string]interface{}
-}
+// UserSession is the conversation state handlers read and update; the bot stores it between updates
+type UserSession = session.Session
 
 const (
 	StateRegisterName     = "register_name"
@@ -65,6 +68,7 @@ const (
 
 	// Purchase States
 	StateAwaitingReceipt = "awaiting_receipt"
+	StateShopAvatar      = "shop_avatar"
 )
 
 func (h *HandlerManager) HandleRegistration(message *tgbotapi.Message, session *UserSession, bot BotInterface) {
@@ -224,7 +228,7 @@ func (h *HandlerManager) completeRegistration(userID int64, session *UserSession
 			isNew = true
 			user = &models.User{
 				TelegramID:  userID,
-				CoinBalance: 100, // Gift bonus
+				CoinBalance: models.StartingCoins, // Gift bonus
 				Status:      models.UserStatusOffline,
 				Age:         18,  // Default
 				City:        "?", // Legacy
@@ -289,11 +293,11 @@ func (h *HandlerManager) completeRegistration(userID int64, session *UserSession
 			referrerReward := int64(100) // Reward for inviter
 			invitedReward := int64(50)   // Reward for new user
 
-			// Give coins to referrer (inviter)
-			h.CoinRepo.AddCoins(referrerID, referrerReward, models.TxTypeReferralReward, fmt.Sprintf("پاداش دعوت %s", user.FullName))
+			// Give coins to referrer (inviter); both rewards are keyed by the invited user, so each is paid once
+			h.CoinRepo.AddCoins(referrerID, referrerReward, models.TxTypeReferralReward, fmt.Sprintf("پاداش دعوت %s", user.FullName), models.CoinOpKey(models.TxTypeReferralReward, user.ID, "referrer"))
 
 			// Give coins to new user (invited)
-			h.CoinRepo.AddCoins(user.ID, invitedReward, models.TxTypeReferralReward, "پاداش ورود با دعوت")
+			h.CoinRepo.AddCoins(user.ID, invitedReward, models.TxTypeReferralReward, "پاداش ورود با دعوت", models.CoinOpKey(models.TxTypeReferralReward, user.ID, "invited"))
 
 			// Notify referrer about successful referral
 			referralCount, _ := h.UserRepo.GetReferralCount(referrerID)
@@ -345,25 +349,16 @@ func (h *HandlerManager) ShowProfile(userID int64, user *models.User, bot BotInt
 
 	// Format Inventory Items
 	inventoryItems := "خالی"
-	if user.ItemsInventory != "" && user.ItemsInventory != "{}" {
-		var itemsMap map[string]int
-		if err := json.Unmarshal([]byte(user.ItemsInventory), &itemsMap); err == nil {
-			var formattedParts []string
-			itemLabels := map[string]string{
-				"shield": "🛡 سپر",
-				"swap":   "🔄 تعویض",
-				"5050":   "💣 حذف ۲",
-				"freeze": "⏳ انجماد",
-			}
-			for key, val := range itemsMap {
-				if label, ok := itemLabels[key]; ok {
-					formattedParts = append(formattedParts, fmt.Sprintf("%s: %d", label, val))
-				}
-			}
-			if len(formattedParts) > 0 {
-				inventoryItems = strings.Join(formattedParts, " | ")
+	if items, err := h.InventoryRepo.GetItems(user.ID); err == nil && len(items) > 0 {
+		var formattedParts []string
+		for _, item := range items {
+			if def := models.GetItemDef(item.ItemType); def != nil {
+				formattedParts = append(formattedParts, fmt.Sprintf("%s: %d", def.Title, item.Quantity))
 			}
 		}
+		if len(formattedParts) > 0 {
+			inventoryItems = strings.Join(formattedParts, " | ")
+		}
 	}
 
 	// Member since
@@ -444,6 +439,18 @@ func (h *HandlerManager) ShowProfile(userID int64, user *models.User, bot BotInt
 					tgbotapi.NewInlineKeyboardButtonData("👥 درخواست دوستی", fmt.Sprintf("friend_add_%d", user.ID)),
 				))
 			}
+
+			// Block / unblock button
+			hasBlocked, _ := h.BlockRepo.HasBlocked(currentUser.ID, user.ID)
+			if hasBlocked {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("✅ آنبلاک", fmt.Sprintf("unblock_%d_0", user.ID)),
+				))
+			} else {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("🚫 بلاک", fmt.Sprintf("block_%d", user.ID)),
+				))
+			}
 		}
 		keyboard = tgbotapi.NewInlineKeyboardMarkup(rows...)
 	}
@@ -513,13 +520,27 @@ func (h *HandlerManager) HandleDailyBonus(userID int64, queryID string, bot BotI
 		bonusAmount = 200 // Cap bonus
 	}
 
-	user.CoinBalance += bonusAmount
+	// AddCoins both credits the balance and records the transaction; the key allows one bonus per day
+	bonusKey := models.CoinOpKey(models.TxTypeDailyBonus, user.ID, now.Format("2006-01-02"))
+	if err := h.CoinRepo.AddCoins(user.ID, bonusAmount, models.TxTypeDailyBonus, fmt.Sprintf("جایزه روزانه (روز %d)", user.DailyBonusStreak), bonusKey); err != nil {
+		if isDuplicateRequest(err) {
+			if queryID != "" {
+				bot.AnswerCallbackQuery(queryID, "✅ جایزه امروزت رو قبلاً گرفتی!", false)
+			}
+			return
+		}
+		logger.Error("Failed to add daily bonus", "user_id", user.ID, "error", err)
+		if queryID != "" {
+			bot.AnswerCallbackQuery(queryID, "❌ خطا در سیستم!", true)
+		} else {
+			bot.SendMessage(userID, "❌ خطا در دریافت جایزه!", nil)
+		}
+		return
+	}
+
 	user.LastDailyBonus = now
 	h.UserRepo.UpdateUser(user)
 
-	// Record transaction
-	h.CoinRepo.AddCoins(user.ID, bonusAmount, models.TxTypeDailyBonus, fmt.Sprintf("جایزه روزانه (روز %d)", user.DailyBonusStreak))
-
 	bot.SendMessage(userID, fmt.Sprintf("🎁 تبریک! %d سکه امروزت رو گرفتی. فردا بیا تا %d تا بگیری!", bonusAmount, bonusAmount+10), nil)
 	if queryID != "" {
 		bot.AnswerCallbackQuery(queryID, "✅ جایزه با موفقیت دریافت شد!", false)
@@ -720,6 +741,11 @@ func (h *HandlerManager) HandleLike(likerTgID int64, likedUserID uint, bot BotIn
 		return
 	}
 
+	if h.isBlockedWith(liker.ID, likedUserID) {
+		bot.SendMessage(likerTgID, "🚫 امکان لایک کردن این کاربر وجود ندارد.", nil)
+		return
+	}
+
 	alreadyLiked, _ := h.UserRepo.HasLiked(liker.ID, likedUserID)
 	if alreadyLiked {
 		bot.SendMessage(likerTgID, "❤️ شما قبلاً این کاربر را لایک کرده‌اید.", nil)
@@ -788,44 +814,6 @@ func (h *HandlerManager) ShowCoins(userID int64, user *models.User, bot BotInter
 	))
 }
 
-func (h *HandlerManager) HandleBuyCoins(userID int64, messageID int, bot BotInterface) {
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(BtnIHavePaid, "paid_coins"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به پروفایل", "edit_profile_back"),
-		),
-	)
-
-	if messageID != 0 {
-		bot.EditMessage(userID, messageID, MsgCoinPurchasePlans, keyboard)
-	} else {
-		bot.SendMessage(userID, MsgCoinPurchasePlans, keyboard)
-	}
-}
-
-func (h *HandlerManager) HandlePaid(userID int64, session *UserSession, bot BotInterface) {
-	session.State = StateAwaitingReceipt
-	bot.SendMessage(userID, MsgRequestReceipt, nil)
-}
-
-func (h *HandlerManager) HandlePurchaseReceipt(userID int64, message *tgbotapi.Message, session *UserSession, bot BotInterface) {
-	if message.Photo == nil {
-		bot.SendMessage(userID, "❌ خطا! لطفاً رسید خود را به صورت عکس ارسال کنید.", nil)
-		return
-	}
-
-	// Notify admin
-	adminMsg := fmt.Sprintf("💰 رسید پرداخت جدید دریافت شد!\n\n👤 کاربر: %s (%d)\n🆔 آیدی عمومی: %s",
-		message.From.FirstName, userID, session.Data["public_id"])
-
-	bot.SendPhoto(h.Config.SuperAdminTgID, message.Photo[len(message.Photo)-1].FileID, adminMsg, nil)
-
-	session.State = ""
-	bot.SendMessage(userID, MsgPurchasePending, nil)
-}
-
 // ProvinceKeyboard creates an inline keyboard with Iranian provinces
 func ProvinceKeyboard() tgbotapi.InlineKeyboardMarkup {
 	provinces := []string{
@@ -961,44 +949,42 @@ func (h *HandlerManager) ShowInventory(userID int64, bot BotInterface) {
 		return
 	}
 
-	inventoryText := "🎒 کوله‌پشتی شما فعلاً خالی است!\n\nبا شرکت در بازی‌ها و چالش‌ها، آیتم‌های مختلفی به دست بیار که توی بازی بهت کمک می‌کنن."
-	if user.ItemsInventory != "" && user.ItemsInventory != "{}" {
-		// Reuse formatting logic or similar
-		inventoryText = "🎒 موجودی آیتم‌های شما:\n\n"
+	items, err := h.InventoryRepo.GetItems(user.ID)
+	if err != nil {
+		logger.Error("Failed to get inventory", "user_id", user.ID, "error", err)
+		bot.SendMessage(userID, "❌ خطا در دریافت اطلاعات!", nil)
+		return
+	}
 
-		// Map items to descriptions
-		itemDescs := map[string]string{
-			"shield": "🛡 سپر فرار: برای رد کردن چالش‌های سخت بدون کسر امتیاز.",
-			"swap":   "🔄 کارت تعویض: تعویض سوال یا چالش فعلی.",
-			"5050":   "💣 حذف دو گزینه: مخصوص کوییز برای حذف گزینه‌های غلط.",
-			"freeze": "⏳ زمان اضافه: ۱۰ ثانیه وقت بیشتر برای پاسخ‌دهی.",
+	// Group items by the game they're used in
+	byGame := make(map[string][]string)
+	for _, item := range items {
+		def := models.GetItemDef(item.ItemType)
+		if def == nil {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s\nتعداد: %d", def.Title, def.Description, item.Quantity)
+		if item.ExpiresAt != nil {
+			line += fmt.Sprintf("\n⏳ انقضا: %s", item.ExpiresAt.Format("2006/01/02 15:04"))
 		}
+		byGame[def.Game] = append(byGame[def.Game], line)
+	}
 
-		items := strings.ReplaceAll(user.ItemsInventory, "{", "")
-		items = strings.ReplaceAll(items, "}", "")
-		items = strings.ReplaceAll(items, "\"", "")
-		parts := strings.Split(items, ",")
-
-		found := false
-		for _, p := range parts {
-			kv := strings.Split(p, ":")
-			if len(kv) == 2 {
-				key := strings.TrimSpace(kv[0])
-				val := strings.TrimSpace(kv[1])
-				if desc, ok := itemDescs[key]; ok {
-					inventoryText += fmt.Sprintf("%s\nتعداد: %s\n\n", desc, val)
-					found = true
-				}
+	inventoryText := "🎒 کوله‌پشتی شما فعلاً خالی است!\n\nبا شرکت در بازی‌ها و چالش‌ها یا خرید از فروشگاه، آیتم‌های مختلفی به دست بیار که توی بازی بهت کمک می‌کنن."
+	if len(byGame) > 0 {
+		inventoryText = "🎒 موجودی آیتم‌های شما:\n"
+		for _, game := range models.ItemGames {
+			if len(byGame[game]) == 0 {
+				continue
 			}
-		}
-		if !found {
-			inventoryText = "🎒 کوله‌پشتی شما فعلاً خالی است!"
+			inventoryText += "\n" + models.ItemGameTitles[game] + "\n━━━━━━━━━━━━━━\n"
+			inventoryText += strings.Join(byGame[game], "\n\n") + "\n"
 		}
 	}
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🛍 خرید آیتم‌های بیشتر", "shop_items"),
+			tgbotapi.NewInlineKeyboardButtonData("🛍 خرید آیتم‌های بیشتر", "shop_open"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت به پروفایل", "edit_profile_back"),
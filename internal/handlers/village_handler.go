@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/sender"
 )
 
 func (h *HandlerManager) ShowVillageMenu(userID int64, bot BotInterface) {
@@ -115,7 +116,7 @@ func (h *HandlerManager) SendVillageMessage(userID int64, message *tgbotapi.Mess
 
 	for _, m := range members {
 		if m.User.TelegramID != userID {
-			bot.SendMessage(m.User.TelegramID, msgText, nil)
+			bot.SendMessageAsync(m.User.TelegramID, msgText, nil, sender.PriorityBulk)
 		}
 	}
 }
@@ -128,8 +129,7 @@ func (h *HandlerManager) HandleVillageInvite(userID int64, bot BotInterface) {
 		return
 	}
 
-	botUser, _ := (bot.GetAPI().(*tgbotapi.BotAPI)).GetMe()
-	inviteLink := fmt.Sprintf("https://t.me/%s?start=vjoin_%d", botUser.UserName, village.ID)
+	inviteLink := fmt.Sprintf("https://t.me/%s?start=vjoin_%d", bot.BotUsername(), village.ID)
 
 	text := fmt.Sprintf(MsgVillageInviteText, village.Name, inviteLink)
 
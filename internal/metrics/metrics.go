@@ -0,0 +1,313 @@
+// Package metrics keeps the bot's counters in process and serves them, together with
+// gauges read at scrape time, in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry /metrics serves
+var Default = NewRegistry()
+
+// The bot's metrics. Gauges that read live state are registered by the bot with GaugeFunc.
+var (
+	UpdatesTotal   = Default.NewCounterVec("gamebot_updates_total", "Updates processed, by update type.", "type")
+	UpdateDuration = Default.NewHistogramVec("gamebot_update_duration_seconds", "Time spent handling an update, by update type.", DefaultBuckets, "type")
+	CoinsMinted    = Default.NewCounterVec("gamebot_coins_minted_total", "Coins added to balances, by transaction type.", "type")
+	CoinsBurned    = Default.NewCounterVec("gamebot_coins_burned_total", "Coins taken from balances, by transaction type.", "type")
+	TelegramErrors = Default.NewCounterVec("gamebot_telegram_errors_total", "Failed Bot API calls, by error code; network errors are counted as \"network\".", "code")
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RecordCoins counts a ledger entry: positive amounts as minted, negative ones as burned
+func RecordCoins(txType string, amount int64) {
+	switch {
+	case amount > 0:
+		CoinsMinted.Add(float64(amount), txType)
+	case amount < 0:
+		CoinsBurned.Add(float64(-amount), txType)
+	}
+}
+
+// metric is one metric family in a registry
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics written out together
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds m, replacing a metric with the same name
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.metrics {
+		if existing.name() == m.name() {
+			r.metrics[i] = m
+			return
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// ServeHTTP writes every metric in the text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// Write writes every metric in the text exposition format, in registration order
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// desc is what every metric family has
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, kind)
+}
+
+// key joins label values into a map key
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.metricName, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// labelPairs renders {a="x",b="y"}, with extra appended as one more pair when set
+func (d *desc) labelPairs(labelValues []string, extra ...string) string {
+	names := d.labels
+	values := labelValues
+	if len(extra) == 2 {
+		names = append(append([]string{}, names...), extra[0])
+		values = append(append([]string{}, values...), extra[1])
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec is a counter per set of label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counter
+}
+
+type counter struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter with the given label names
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, labels}, values: make(map[string]*counter)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter for labelValues
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter for labelValues
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: %s can't decrease", c.metricName))
+	}
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, ok := c.values[key]
+	if !ok {
+		ctr = &counter{labelValues: append([]string{}, labelValues...)}
+		c.values[key] = ctr
+	}
+	ctr.value += v
+}
+
+// Value returns the counter for labelValues
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ctr, ok := c.values[key]; ok {
+		return ctr.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range sortedKeys(c.values) {
+		ctr := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(ctr.labelValues), formatValue(ctr.value))
+	}
+}
+
+// HistogramVec is a histogram per set of label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	sum         float64
+	count       uint64
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds, in increasing order
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogram)}
+	r.register(h)
+	return h
+}
+
+// Observe adds one observation to the histogram for labelValues
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.sum += v
+	hist.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, key := range sortedKeys(h.values) {
+		hist := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(hist.labelValues, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(hist.labelValues, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(hist.labelValues), formatValue(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(hist.labelValues), hist.count)
+	}
+}
+
+// Sample is one value of a gauge read at scrape time
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// gaugeFunc is a gauge whose samples are collected on every scrape
+type gaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// GaugeFunc registers a gauge read by calling collect on every scrape. Registering the
+// same name again replaces the earlier gauge.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&gaugeFunc{desc: desc{name, help, labels}, collect: collect})
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	g.writeHeader(w, "gauge")
+	for _, s := range g.collect() {
+		g.key(s.LabelValues) // checks the label count
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelPairs(s.LabelValues), formatValue(s.Value))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
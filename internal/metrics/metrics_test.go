@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	errs := r.NewCounterVec("test_errors_total", "Errors, by code.", "code")
+	latency := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "type")
+	r.GaugeFunc("test_queue_depth", "Queued items.", []string{"queue"}, func() []Sample {
+		return []Sample{{LabelValues: []string{`a "quoted" name`}, Value: 3}}
+	})
+
+	errs.Inc("429")
+	errs.Add(2, "403")
+	errs.Inc("429")
+	latency.Observe(0.05, "message")
+	latency.Observe(0.5, "message")
+	latency.Observe(7, "message")
+
+	var b strings.Builder
+	r.Write(&b)
+
+	want := `# HELP test_errors_total Errors, by code.
+# TYPE test_errors_total counter
+test_errors_total{code="403"} 2
+test_errors_total{code="429"} 2
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{type="message",le="0.1"} 1
+test_latency_seconds_bucket{type="message",le="1"} 2
+test_latency_seconds_bucket{type="message",le="+Inf"} 3
+test_latency_seconds_sum{type="message"} 7.55
+test_latency_seconds_count{type="message"} 3
+# HELP test_queue_depth Queued items.
+# TYPE test_queue_depth gauge
+test_queue_depth{queue="a \"quoted\" name"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistry_GaugeFuncReplaces(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("test_gauge", "A gauge.", nil, func() []Sample { return []Sample{{Value: 1}} })
+	r.GaugeFunc("test_gauge", "A gauge.", nil, func() []Sample { return []Sample{{Value: 2}} })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	if strings.Count(body, "# TYPE test_gauge") != 1 || !strings.Contains(body, "test_gauge 2\n") {
+		t.Errorf("body = %q, want only the second gauge", body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestRecordCoins(t *testing.T) {
+	minted := CoinsMinted.Value("test_reward")
+	burned := CoinsBurned.Value("test_fee")
+
+	RecordCoins("test_reward", 30)
+	RecordCoins("test_fee", -5)
+	RecordCoins("test_fee", 0)
+
+	if got := CoinsMinted.Value("test_reward") - minted; got != 30 {
+		t.Errorf("minted = %v, want 30", got)
+	}
+	if got := CoinsBurned.Value("test_fee") - burned; got != 5 {
+		t.Errorf("burned = %v, want 5", got)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// FloodVerdict is what should happen to an update under flood control
+type FloodVerdict int
+
+const (
+	FloodAllow FloodVerdict = iota // within the limit
+	FloodWarn                      // first update over the limit: tell the user to slow down
+	FloodDrop                      // over the limit and already told: ignore silently
+	FloodMute                      // the user was just muted: tell them once
+	FloodMuted                     // the user is muted: ignore silently
+)
+
+// FloodControl turns rate limit hits into a response. A user over their limit is told
+// to slow down once per limit window; one who hits the limit maxStrikes times within
+// strikeWindow is muted for muteDuration.
+type FloodControl struct {
+	limiter      *RateLimiter
+	maxStrikes   int
+	strikeWindow time.Duration
+	muteDuration time.Duration
+
+	offenders map[int64]*offender
+	mu        sync.Mutex
+}
+
+type offender struct {
+	limited     bool // over the limit right now and already told so
+	strikes     int
+	firstStrike time.Time
+	mutedUntil  time.Time
+}
+
+// NewFloodControl creates flood control on top of a rate limiter. A maxStrikes of 0
+// never mutes anyone.
+func NewFloodControl(limiter *RateLimiter, maxStrikes int, strikeWindow, muteDuration time.Duration) *FloodControl {
+	fc := &FloodControl{
+		limiter:      limiter,
+		maxStrikes:   maxStrikes,
+		strikeWindow: strikeWindow,
+		muteDuration: muteDuration,
+		offenders:    make(map[int64]*offender),
+	}
+
+	// Start cleanup goroutine
+	go fc.cleanup()
+
+	return fc
+}
+
+// Check counts an update of the given weight and returns what to do with it
+func (fc *FloodControl) Check(userID int64, weight int) FloodVerdict {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	now := time.Now()
+
+	o := fc.offenders[userID]
+	if o != nil && now.Before(o.mutedUntil) {
+		return FloodMuted
+	}
+
+	if fc.limiter.CheckUserWeight(userID, weight) {
+		if o != nil {
+			o.limited = false
+		}
+		return FloodAllow
+	}
+
+	if o == nil || now.Sub(o.firstStrike) > fc.strikeWindow {
+		o = &offender{firstStrike: now}
+		fc.offenders[userID] = o
+	}
+	if o.limited {
+		return FloodDrop
+	}
+
+	o.limited = true
+	o.strikes++
+	if fc.maxStrikes > 0 && o.strikes >= fc.maxStrikes {
+		fc.offenders[userID] = &offender{firstStrike: now, mutedUntil: now.Add(fc.muteDuration)}
+		return FloodMute
+	}
+	return FloodWarn
+}
+
+// cleanup removes offenders whose strikes and mute have run out
+func (fc *FloodControl) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		fc.mu.Lock()
+		now := time.Now()
+
+		for userID, o := range fc.offenders {
+			if now.After(o.mutedUntil) && now.Sub(o.firstStrike) > fc.strikeWindow {
+				delete(fc.offenders, userID)
+			}
+		}
+
+		fc.mu.Unlock()
+	}
+}
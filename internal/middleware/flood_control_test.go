@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_CheckUserWeight(t *testing.T) {
+	rl := NewRateLimiter(5, 0, time.Hour)
+
+	if !rl.CheckUserWeight(1, 3) {
+		t.Fatal("CheckUserWeight(3) with 5 left = false, want true")
+	}
+	if rl.CheckUserWeight(1, 3) {
+		t.Fatal("CheckUserWeight(3) with 2 left = true, want false")
+	}
+	// The denied request must not have used up the rest of the limit
+	if !rl.CheckUserLimit(1) || !rl.CheckUserLimit(1) {
+		t.Fatal("CheckUserLimit() with 2 left = false, want true")
+	}
+	if rl.CheckUserLimit(1) {
+		t.Fatal("CheckUserLimit() with 0 left = true, want false")
+	}
+	if got := rl.GetUserRemaining(1); got != 0 {
+		t.Errorf("GetUserRemaining() = %d, want 0", got)
+	}
+	if !rl.CheckUserWeight(2, 5) {
+		t.Error("CheckUserWeight() for another user = false, want true")
+	}
+}
+
+func TestFloodControl_Check(t *testing.T) {
+	fc := NewFloodControl(NewRateLimiter(3, 0, time.Hour), 2, time.Hour, 50*time.Millisecond)
+
+	steps := []struct {
+		weight int
+		want   FloodVerdict
+	}{
+		{2, FloodAllow},
+		{2, FloodWarn}, // over the limit: told once
+		{2, FloodDrop}, // still over it: ignored
+		{1, FloodAllow},
+		{1, FloodMute}, // second strike
+		{1, FloodMuted},
+	}
+	for i, step := range steps {
+		if got := fc.Check(1, step.weight); got != step.want {
+			t.Fatalf("step %d: Check(weight %d) = %v, want %v", i, step.weight, got, step.want)
+		}
+	}
+
+	if got := fc.Check(2, 1); got != FloodAllow {
+		t.Errorf("Check() for another user = %v, want FloodAllow", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := fc.Check(1, 1); got != FloodWarn {
+		t.Errorf("Check() after the mute = %v, want FloodWarn with a fresh strike count", got)
+	}
+}
+
+func TestFloodControl_NoMute(t *testing.T) {
+	fc := NewFloodControl(NewRateLimiter(1, 0, time.Hour), 0, time.Hour, time.Hour)
+
+	fc.Check(1, 1)
+	for i := 0; i < 5; i++ {
+		if got := fc.Check(1, 1); got == FloodMute || got == FloodMuted {
+			t.Fatalf("Check() with muting disabled = %v", got)
+		}
+	}
+}
@@ -44,6 +44,12 @@ func NewRateLimiter(userMaxRequests, ipMaxRequests int, window time.Duration) *R
 
 // CheckUserLimit checks if user has exceeded rate limit
 func (rl *RateLimiter) CheckUserLimit(userID int64) bool {
+	return rl.CheckUserWeight(userID, 1)
+}
+
+// CheckUserWeight checks a request that counts as weight requests against the user's limit.
+// Denied requests don't use up any of the limit.
+func (rl *RateLimiter) CheckUserWeight(userID int64, weight int) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -52,20 +58,17 @@ func (rl *RateLimiter) CheckUserLimit(userID int64) bool {
 	// Get or create user limit
 	limit, exists := rl.userLimits[userID]
 	if !exists || now.After(limit.resetTime) {
-		rl.userLimits[userID] = &userLimit{
-			requests:  1,
-			resetTime: now.Add(rl.window),
-		}
-		return true
+		limit = &userLimit{resetTime: now.Add(rl.window)}
+		rl.userLimits[userID] = limit
 	}
 
 	// Check if limit exceeded
-	if limit.requests >= rl.userMaxRequests {
+	if limit.requests+weight > rl.userMaxRequests {
 		return false
 	}
 
 	// Increment counter
-	limit.requests++
+	limit.requests += weight
 	return true
 }
 
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// UserBan restricts a user from part of the bot (or all of it) until ExpiresAt.
+// A nil ExpiresAt means the ban is permanent until lifted by an admin.
+type UserBan struct {
+	ID           uint       `gorm:"primaryKey"`
+	UserID       uint       `gorm:"not null;index:idx_ban_user_scope"`
+	User         User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Scope        string     `gorm:"type:varchar(20);not null;index:idx_ban_user_scope"`
+	Reason       string     `gorm:"type:varchar(255)"`
+	ExpiresAt    *time.Time `gorm:"index"`
+	IssuedByTgID int64      `gorm:"default:0"` // 0 = issued automatically by the system
+	CreatedAt    time.Time  `gorm:"autoCreateTime"`
+}
+
+func (UserBan) TableName() string {
+	return "user_bans"
+}
+
+// Ban scope constants
+const (
+	BanScopeGlobal = "global"
+	BanScopeChat   = "chat"
+	BanScopeTod    = "tod"
+	BanScopeQuiz   = "quiz"
+	BanScopeRooms  = "rooms"
+)
+
+// BanScopeTitles maps each scope to its user facing label
+var BanScopeTitles = map[string]string{
+	BanScopeGlobal: "تمام بخش‌های ربات",
+	BanScopeChat:   "چت ناشناس",
+	BanScopeTod:    "جرعت و حقیقت",
+	BanScopeQuiz:   "کوییز",
+	BanScopeRooms:  "روم‌ها",
+}
+
+// IsValidBanScope reports whether scope is one of the known ban scopes
+func IsValidBanScope(scope string) bool {
+	_, ok := BanScopeTitles[scope]
+	return ok
+}
+
+// IsActive reports whether the ban is still in effect
+func (b *UserBan) IsActive() bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(time.Now())
+}
+
+// IsPermanent reports whether the ban has no expiry
+func (b *UserBan) IsPermanent() bool {
+	return b.ExpiresAt == nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserBan_IsActive(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{
+			name:      "Permanent ban",
+			expiresAt: nil,
+			want:      true,
+		},
+		{
+			name:      "Expired ban",
+			expiresAt: &past,
+			want:      false,
+		},
+		{
+			name:      "Running ban",
+			expiresAt: &future,
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ban := &UserBan{Scope: BanScopeChat, ExpiresAt: tt.expiresAt}
+			if got := ban.IsActive(); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidBanScope(t *testing.T) {
+	for _, scope := range []string{BanScopeGlobal, BanScopeChat, BanScopeTod, BanScopeQuiz, BanScopeRooms} {
+		if !IsValidBanScope(scope) {
+			t.Errorf("IsValidBanScope(%q) = false, want true", scope)
+		}
+	}
+
+	if IsValidBanScope("village") {
+		t.Errorf("IsValidBanScope(%q) = true, want false", "village")
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// BetEscrow holds one player's stake for a 1v1 game until the game is settled
+type BetEscrow struct {
+	ID        uint   `gorm:"primaryKey"`
+	GameType  string `gorm:"type:varchar(20);not null;uniqueIndex:idx_bet_escrow_game_user"` // quiz, tod
+	GameID    uint   `gorm:"not null;uniqueIndex:idx_bet_escrow_game_user"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_bet_escrow_game_user;index"`
+	User      User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Amount    int64  `gorm:"not null"`
+	Status    string `gorm:"type:varchar(20);not null;default:'held';index"`
+	Payout    int64  `gorm:"default:0"`
+	SettledAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// Bet escrow status constants
+const (
+	BetStatusHeld     = "held"
+	BetStatusPaid     = "paid"
+	BetStatusLost     = "lost"
+	BetStatusSplit    = "split"
+	BetStatusRefunded = "refunded"
+)
+
+// BetStakeOptions are the stakes a player can choose in betting mode
+var BetStakeOptions = []int64{20, 50, 100, 200, 500}
+
+// IsValidBetStake checks whether amount is one of the offered stakes
+func IsValidBetStake(amount int64) bool {
+	for _, stake := range BetStakeOptions {
+		if stake == amount {
+			return true
+		}
+	}
+	return false
+}
+
+func (BetEscrow) TableName() string {
+	return "bet_escrows"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// UserBlock records that BlockerID has blocked BlockedID.
+// A block hides the pair from each other in every direction.
+type UserBlock struct {
+	ID        uint      `gorm:"primaryKey"`
+	BlockerID uint      `gorm:"not null;index:idx_user_block,unique"`
+	Blocker   User      `gorm:"foreignKey:BlockerID;constraint:OnDelete:CASCADE"`
+	BlockedID uint      `gorm:"not null;index:idx_user_block,unique;index"`
+	Blocked   User      `gorm:"foreignKey:BlockedID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (UserBlock) TableName() string {
+	return "user_blocks"
+}
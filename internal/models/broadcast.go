@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+)
+
+// Broadcast is an announcement an admin sends to a segment of users. Delivery walks the
+// segment in user ID order and LastUserID records how far it got, so a paused or
+// interrupted broadcast picks up where it stopped.
+type Broadcast struct {
+	ID            uint   `gorm:"primaryKey"`
+	CreatedByTgID int64  `gorm:"not null"`
+	Text          string `gorm:"type:text"`         // message text or media caption
+	Entities      string `gorm:"type:text"`         // JSON array of the Telegram entities formatting Text
+	MediaType     string `gorm:"type:varchar(20)"`  // one of the BroadcastMedia constants, empty for text
+	MediaFileID   string `gorm:"type:varchar(255)"` // Telegram file ID of the media
+	Buttons       string `gorm:"type:text"`         // JSON array of BroadcastButton
+
+	// Who receives it; only the fields of the chosen segment are set
+	Segment    string `gorm:"type:varchar(20);not null"`
+	Province   string `gorm:"type:varchar(100)"`
+	MinLevel   int    `gorm:"default:0"`
+	MaxLevel   int    `gorm:"default:0"`
+	ActiveDays int    `gorm:"default:0"`
+	VillageID  uint   `gorm:"default:0"`
+
+	Status            string `gorm:"type:varchar(20);default:'draft';index"`
+	ProgressMessageID int    `gorm:"default:0"` // admin's message showing live progress
+	LastUserID        uint   `gorm:"default:0"` // users up to this ID have been handled
+	Total             int64  `gorm:"default:0"` // recipients when the broadcast started
+	Delivered         int64  `gorm:"default:0"`
+	Failed            int64  `gorm:"default:0"`
+	Blocked           int64  `gorm:"default:0"` // recipients found to have blocked the bot
+
+	StartedAt  *time.Time `gorm:"default:NULL"`
+	FinishedAt *time.Time `gorm:"default:NULL"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (Broadcast) TableName() string {
+	return "broadcasts"
+}
+
+// BroadcastButton is a link button under a broadcast
+type BroadcastButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Broadcast status constants
+const (
+	BroadcastStatusDraft     = "draft"
+	BroadcastStatusRunning   = "running"
+	BroadcastStatusPaused    = "paused"
+	BroadcastStatusCancelled = "cancelled"
+	BroadcastStatusDone      = "done"
+)
+
+// Broadcast segment constants
+const (
+	BroadcastSegmentAll      = "all"
+	BroadcastSegmentProvince = "province"
+	BroadcastSegmentLevel    = "level"
+	BroadcastSegmentActive   = "active"
+	BroadcastSegmentVillage  = "village"
+)
+
+// Broadcast media constants
+const (
+	BroadcastMediaPhoto     = "photo"
+	BroadcastMediaVideo     = "video"
+	BroadcastMediaAnimation = "animation"
+	BroadcastMediaDocument  = "document"
+)
+
+// BroadcastSegments lists the segments in the order they are offered to admins
+var BroadcastSegments = []string{
+	BroadcastSegmentAll,
+	BroadcastSegmentProvince,
+	BroadcastSegmentLevel,
+	BroadcastSegmentActive,
+	BroadcastSegmentVillage,
+}
+
+// BroadcastSegmentTitles maps each segment to its admin facing label
+var BroadcastSegmentTitles = map[string]string{
+	BroadcastSegmentAll:      "همه کاربران",
+	BroadcastSegmentProvince: "استان",
+	BroadcastSegmentLevel:    "بازه سطح",
+	BroadcastSegmentActive:   "فعال در چند روز اخیر",
+	BroadcastSegmentVillage:  "اعضای دهکده",
+}
+
+// BroadcastStatusTitles maps each status to its admin facing label
+var BroadcastStatusTitles = map[string]string{
+	BroadcastStatusDraft:     "پیش‌نویس",
+	BroadcastStatusRunning:   "در حال ارسال",
+	BroadcastStatusPaused:    "متوقف",
+	BroadcastStatusCancelled: "لغو شده",
+	BroadcastStatusDone:      "تمام شده",
+}
+
+// IsValidBroadcastSegment reports whether segment is one of the known segments
+func IsValidBroadcastSegment(segment string) bool {
+	_, ok := BroadcastSegmentTitles[segment]
+	return ok
+}
+
+// IsFinished reports whether the broadcast will send nothing more
+func (b *Broadcast) IsFinished() bool {
+	return b.Status == BroadcastStatusCancelled || b.Status == BroadcastStatusDone
+}
+
+// Processed returns how many recipients have been handled so far
+func (b *Broadcast) Processed() int64 {
+	return b.Delivered + b.Failed + b.Blocked
+}
+
+// Progress returns the handled share of recipients as a percentage
+func (b *Broadcast) Progress() int {
+	if b.Total <= 0 {
+		if b.Status == BroadcastStatusDone {
+			return 100
+		}
+		return 0
+	}
+
+	percentage := int(b.Processed() * 100 / b.Total)
+	if percentage > 100 {
+		percentage = 100
+	}
+	return percentage
+}
+
+// ActiveSince returns the earliest last activity of a recipient in the active segment.
+// The window is counted from the start of the broadcast so resuming it later doesn't
+// change who receives it.
+func (b *Broadcast) ActiveSince(now time.Time) time.Time {
+	if b.StartedAt != nil {
+		now = *b.StartedAt
+	}
+	return now.AddDate(0, 0, -b.ActiveDays)
+}
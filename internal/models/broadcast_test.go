@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBroadcast_Progress(t *testing.T) {
+	tests := []struct {
+		name      string
+		broadcast Broadcast
+		want      int
+	}{
+		{
+			name:      "Not started",
+			broadcast: Broadcast{Status: BroadcastStatusDraft},
+			want:      0,
+		},
+		{
+			name:      "Halfway",
+			broadcast: Broadcast{Status: BroadcastStatusRunning, Total: 200, Delivered: 80, Failed: 5, Blocked: 15},
+			want:      50,
+		},
+		{
+			name:      "More handled than counted at start",
+			broadcast: Broadcast{Status: BroadcastStatusRunning, Total: 10, Delivered: 12},
+			want:      100,
+		},
+		{
+			name:      "Empty segment done",
+			broadcast: Broadcast{Status: BroadcastStatusDone},
+			want:      100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.broadcast.Progress(); got != tt.want {
+				t.Errorf("Progress() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBroadcast_ActiveSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	started := now.AddDate(0, 0, -2)
+
+	b := &Broadcast{ActiveDays: 7}
+	if got, want := b.ActiveSince(now), now.AddDate(0, 0, -7); !got.Equal(want) {
+		t.Errorf("ActiveSince() before start = %v, want %v", got, want)
+	}
+
+	b.StartedAt = &started
+	if got, want := b.ActiveSince(now), started.AddDate(0, 0, -7); !got.Equal(want) {
+		t.Errorf("ActiveSince() after start = %v, want %v", got, want)
+	}
+}
+
+func TestIsValidBroadcastSegment(t *testing.T) {
+	for _, segment := range BroadcastSegments {
+		if !IsValidBroadcastSegment(segment) {
+			t.Errorf("IsValidBroadcastSegment(%q) = false, want true", segment)
+		}
+	}
+
+	if IsValidBroadcastSegment("gender") {
+		t.Errorf("IsValidBroadcastSegment(%q) = true, want false", "gender")
+	}
+}
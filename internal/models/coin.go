@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,7 @@ type CoinTransaction struct {
 	Amount          int64     `gorm:"not null"`
 	TransactionType string    `gorm:"type:varchar(50);not null;index"`
 	Description     string    `gorm:"type:text"`
+	IdempotencyKey  *string   `gorm:"type:varchar(191);uniqueIndex"` // set when a replay of the operation must be a no-op
 	CreatedAt       time.Time `gorm:"autoCreateTime;index"`
 }
 
@@ -29,8 +32,39 @@ const (
 	TxTypeReferralReward  = "referral_reward"
 	TxTypeWelcomeBonus    = "welcome_bonus"
 	TxTypePenalty         = "penalty"
+	TxTypeBetEscrow       = "bet_escrow"
+	TxTypeBetPayout       = "bet_payout"
+	TxTypeBetRefund       = "bet_refund"
+	TxTypeCoinPurchase    = "coin_purchase"
+	TxTypeShopPurchase    = "shop_purchase"
+	TxTypeOpeningBalance  = "opening_balance" // balance an account started with, the base of reconciliation
 )
 
+// CoinOpKey builds the idempotency key of a coin operation from the IDs that identify it,
+// e.g. CoinOpKey(TxTypeGameReward, "tod", gameID, userID)
+func CoinOpKey(parts ...interface{}) string {
+	keys := make([]string, len(parts))
+	for i, part := range parts {
+		keys[i] = fmt.Sprint(part)
+	}
+	return strings.Join(keys, ":")
+}
+
+// StartingCoins is the gift balance every new account is opened with
+const StartingCoins int64 = 100
+
+// LedgerMismatch is a user whose stored balance differs from the sum of their ledger
+type LedgerMismatch struct {
+	UserID    uint
+	Balance   int64
+	LedgerSum int64
+}
+
+// Drift is the amount the ledger is missing to match the balance
+func (m LedgerMismatch) Drift() int64 {
+	return m.Balance - m.LedgerSum
+}
+
 func (CoinTransaction) TableName() string {
 	return "coin_transactions"
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestCoinOpKey(t *testing.T) {
+	got := CoinOpKey(TxTypeGameReward, "tod_game", uint(12), uint(7))
+	if want := "game_reward:tod_game:12:7"; got != want {
+		t.Errorf("CoinOpKey() = %q, want %q", got, want)
+	}
+
+	if CoinOpKey(TxTypeGameReward, uint(1), uint(23)) == CoinOpKey(TxTypeGameReward, uint(12), uint(3)) {
+		t.Error("CoinOpKey() must keep parts apart")
+	}
+}
+
+func TestLedgerMismatch_Drift(t *testing.T) {
+	m := LedgerMismatch{UserID: 1, Balance: 150, LedgerSum: 100}
+	if got := m.Drift(); got != 50 {
+		t.Errorf("Drift() = %d, want 50", got)
+	}
+
+	m = LedgerMismatch{UserID: 1, Balance: 80, LedgerSum: 100}
+	if got := m.Drift(); got != -20 {
+		t.Errorf("Drift() = %d, want -20", got)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// DiamondTransaction is an entry of the diamond ledger, kept apart from coin transactions
+type DiamondTransaction struct {
+	ID              uint      `gorm:"primaryKey"`
+	UserID          uint      `gorm:"not null;index"`
+	User            User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Amount          int64     `gorm:"not null"`
+	TransactionType string    `gorm:"type:varchar(50);not null;index"`
+	Description     string    `gorm:"type:text"`
+	IdempotencyKey  *string   `gorm:"type:varchar(191);uniqueIndex"` // set when a replay of the operation must be a no-op
+	CreatedAt       time.Time `gorm:"autoCreateTime;index"`
+}
+
+// Diamond transaction types. Admin adjustments, shop purchases and opening
+// balances reuse TxTypeAdminAdjustment, TxTypeShopPurchase and TxTypeOpeningBalance.
+const (
+	TxTypeDiamondPurchase = "diamond_purchase" // bought with Telegram Stars
+	TxTypeDiamondExchange = "diamond_exchange" // diamonds swapped for coins, logged in both ledgers
+)
+
+func (DiamondTransaction) TableName() string {
+	return "diamond_transactions"
+}
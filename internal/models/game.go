@@ -82,3 +82,41 @@ type GameParticipant struct {
 func (GameParticipant) TableName() string {
 	return "game_participants"
 }
+
+// Group quiz (Quiz of King) configuration
+const (
+	GroupQuizTotalQuestions      = 10
+	GroupQuizQuestionTimeSeconds = 15
+	GroupQuizCorrectPoints       = 100
+	GroupQuizMaxSpeedBonus       = 50
+	GroupQuizMinPlayers          = 2
+)
+
+// GroupQuizAnswer is a single answer stored in GameParticipant.Answers
+type GroupQuizAnswer struct {
+	QuestionID  uint `json:"question_id"`
+	AnswerIdx   int  `json:"answer_idx"`
+	IsCorrect   bool `json:"is_correct"`
+	TimeTakenMs int  `json:"time_taken_ms"`
+	Points      int  `json:"points"`
+}
+
+// CalculateGroupQuizPoints scores an answer by correctness and speed.
+// A correct answer earns the base points plus a bonus that shrinks
+// linearly to zero over the question time limit.
+func CalculateGroupQuizPoints(isCorrect bool, timeTakenMs int) int {
+	if !isCorrect {
+		return 0
+	}
+
+	limitMs := GroupQuizQuestionTimeSeconds * 1000
+	if timeTakenMs < 0 {
+		timeTakenMs = 0
+	}
+	if timeTakenMs >= limitMs {
+		return GroupQuizCorrectPoints
+	}
+
+	bonus := GroupQuizMaxSpeedBonus * (limitMs - timeTakenMs) / limitMs
+	return GroupQuizCorrectPoints + bonus
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestCalculateGroupQuizPoints(t *testing.T) {
+	limitMs := GroupQuizQuestionTimeSeconds * 1000
+
+	tests := []struct {
+		name        string
+		isCorrect   bool
+		timeTakenMs int
+		want        int
+	}{
+		{
+			name:        "Wrong answer",
+			isCorrect:   false,
+			timeTakenMs: 500,
+			want:        0,
+		},
+		{
+			name:        "Instant correct answer",
+			isCorrect:   true,
+			timeTakenMs: 0,
+			want:        GroupQuizCorrectPoints + GroupQuizMaxSpeedBonus,
+		},
+		{
+			name:        "Correct answer at half time",
+			isCorrect:   true,
+			timeTakenMs: limitMs / 2,
+			want:        GroupQuizCorrectPoints + GroupQuizMaxSpeedBonus/2,
+		},
+		{
+			name:        "Correct answer at the deadline",
+			isCorrect:   true,
+			timeTakenMs: limitMs,
+			want:        GroupQuizCorrectPoints,
+		},
+		{
+			name:        "Correct answer after the deadline",
+			isCorrect:   true,
+			timeTakenMs: limitMs + 2000,
+			want:        GroupQuizCorrectPoints,
+		},
+		{
+			name:        "Negative time is clamped",
+			isCorrect:   true,
+			timeTakenMs: -100,
+			want:        GroupQuizCorrectPoints + GroupQuizMaxSpeedBonus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateGroupQuizPoints(tt.isCorrect, tt.timeTakenMs)
+			if got != tt.want {
+				t.Errorf("CalculateGroupQuizPoints() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
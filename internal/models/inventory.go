@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+)
+
+// Games an inventory item is used in
+const (
+	ItemGameQuiz = "quiz"
+	ItemGameTod  = "tod"
+)
+
+// ItemTypeFreeze is the extra answer time item from the old JSON inventory
+const ItemTypeFreeze = "freeze"
+
+// ItemGameTitles maps each game to the heading used on the Backpack screen
+var ItemGameTitles = map[string]string{
+	ItemGameQuiz: "🧠 کوییز",
+	ItemGameTod:  "🔥 جرأت یا حقیقت",
+}
+
+// ItemGames lists the games in the order they are shown
+var ItemGames = []string{ItemGameQuiz, ItemGameTod}
+
+// ItemDef describes an item type that can be held in an inventory
+type ItemDef struct {
+	Type        string
+	Game        string
+	Title       string
+	Description string
+	Stackable   bool          // false = a user holds at most one
+	Expiry      time.Duration // 0 = never expires
+}
+
+// ItemRegistry lists every item type that can be held
+var ItemRegistry = []ItemDef{
+	{
+		Type: BoosterRemove2Options, Game: ItemGameQuiz, Stackable: true,
+		Title: "💣 حذف دو گزینه", Description: "حذف دو گزینه غلط سوال کوییز.",
+	},
+	{
+		Type: BoosterSecondChance, Game: ItemGameQuiz, Stackable: true,
+		Title: "🔁 شانس دوباره", Description: "یک بار دیگه جواب دادن بعد از جواب اشتباه.",
+	},
+	{
+		Type: ItemTypeFreeze, Game: ItemGameQuiz, Stackable: true,
+		Title: "⏳ زمان اضافه", Description: "۱۰ ثانیه وقت بیشتر برای پاسخ‌دهی.",
+	},
+	{
+		Type: ItemTypeShield, Game: ItemGameTod, Stackable: true,
+		Title: "🛡 سپر", Description: "رد نوبت بدون جریمه.",
+	},
+	{
+		Type: ItemTypeSwap, Game: ItemGameTod, Stackable: true,
+		Title: "🔄 تعویض", Description: "تغییر سوال به سوال دیگر.",
+	},
+	{
+		Type: ItemTypeMirror, Game: ItemGameTod, Stackable: true,
+		Title: "🪞 آینه", Description: "انتقال چالش به حریف.",
+	},
+}
+
+// TodStartingItems are granted once when a player starts their first Truth or Dare game
+var TodStartingItems = []string{ItemTypeShield, ItemTypeSwap, ItemTypeMirror}
+
+// GetItemDef finds an item type in the registry. Returns nil for unknown types.
+func GetItemDef(itemType string) *ItemDef {
+	for i := range ItemRegistry {
+		if ItemRegistry[i].Type == itemType {
+			return &ItemRegistry[i]
+		}
+	}
+	return nil
+}
+
+// InventoryItem is a stack of one item type held by a user
+type InventoryItem struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;uniqueIndex:idx_inventory_user_item"`
+	User      User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ItemType  string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_inventory_user_item"`
+	Quantity  int        `gorm:"not null;default:0"`
+	ExpiresAt *time.Time // nil = never expires
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (InventoryItem) TableName() string {
+	return "inventory_items"
+}
+
+// IsActive reports whether the stack holds usable items at the given time
+func (i *InventoryItem) IsActive(now time.Time) bool {
+	if i.Quantity <= 0 {
+		return false
+	}
+	return i.ExpiresAt == nil || now.Before(*i.ExpiresAt)
+}
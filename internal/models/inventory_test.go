@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventoryItem_IsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		item InventoryItem
+		want bool
+	}{
+		{name: "Held without expiry", item: InventoryItem{Quantity: 2}, want: true},
+		{name: "Used up", item: InventoryItem{Quantity: 0}, want: false},
+		{name: "Not expired yet", item: InventoryItem{Quantity: 1, ExpiresAt: &future}, want: true},
+		{name: "Expired", item: InventoryItem{Quantity: 1, ExpiresAt: &past}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.IsActive(now); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemRegistry_Valid(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, def := range ItemRegistry {
+		if seen[def.Type] {
+			t.Errorf("duplicate item type %q", def.Type)
+		}
+		seen[def.Type] = true
+
+		if _, ok := ItemGameTitles[def.Game]; !ok {
+			t.Errorf("item %q has unknown game %q", def.Type, def.Game)
+		}
+	}
+
+	for _, itemType := range TodStartingItems {
+		if GetItemDef(itemType) == nil {
+			t.Errorf("starting item %q is not registered", itemType)
+		}
+	}
+
+	for _, item := range ShopCatalog {
+		if item.Grant == ShopGrantInventory && GetItemDef(item.GrantKey) == nil {
+			t.Errorf("shop item %q grants unregistered item %q", item.ID, item.GrantKey)
+		}
+	}
+
+	if GetItemDef("missing") != nil {
+		t.Error("GetItemDef(missing) != nil")
+	}
+}
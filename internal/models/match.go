@@ -42,6 +42,7 @@ type MatchmakingQueue struct {
 	TargetProvinces string    `gorm:"type:text"`                             // Comma separated list of provinces
 	GameType        string    `gorm:"type:varchar(20);default:'chat';index"` // chat, quiz, tod
 	CoinsPaid       int64     `gorm:"default:5;index"`
+	BetAmount       int64     `gorm:"default:0;index"` // Stake for betting mode (0 = no bet)
 	CreatedAt       time.Time `gorm:"autoCreateTime;index"`
 }
 
@@ -71,4 +72,5 @@ type MatchFilters struct {
 	City      string
 	Provinces []string
 	GameType  string
+	BetAmount int64
 }
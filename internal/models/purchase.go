@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// CoinPackage is a coin bundle sold for Toman (receipt or gateway) or Telegram Stars
+type CoinPackage struct {
+	ID              uint
+	Coins           int64
+	PriceToman      int64
+	PriceStars      int
+	DiscountPercent int
+}
+
+// CoinPackages lists the packages in the order they are offered to users
+var CoinPackages = []CoinPackage{
+	{ID: 1, Coins: 200, PriceToman: 20000, PriceStars: 25},
+	{ID: 2, Coins: 500, PriceToman: 45000, PriceStars: 55, DiscountPercent: 10},
+	{ID: 3, Coins: 1000, PriceToman: 80000, PriceStars: 100, DiscountPercent: 20},
+	{ID: 4, Coins: 5000, PriceToman: 350000, PriceStars: 450, DiscountPercent: 30},
+}
+
+// GetCoinPackage finds a package by ID. Returns nil if there is no such package.
+func GetCoinPackage(id uint) *CoinPackage {
+	for i := range CoinPackages {
+		if CoinPackages[i].ID == id {
+			return &CoinPackages[i]
+		}
+	}
+	return nil
+}
+
+// PurchaseOrder is a coin purchase. Receipt orders (empty Provider) wait for admin review of
+// the receipt; gateway orders are approved once the provider verifies the payment.
+// Coins and PriceToman are copied from the package so later catalog changes don't affect the order.
+type PurchaseOrder struct {
+	ID            uint       `gorm:"primaryKey"`
+	UserID        uint       `gorm:"not null;index"`
+	User          User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PackageID     uint       `gorm:"not null"`
+	Coins         int64      `gorm:"not null"`
+	PriceToman    int64      `gorm:"not null"`
+	ReceiptFileID string     `gorm:"type:varchar(255)"`
+	Provider      string     `gorm:"type:varchar(20);default:'';index:idx_order_provider_authority"`
+	Authority     string     `gorm:"type:varchar(100);index:idx_order_provider_authority"`
+	RefID         string     `gorm:"type:varchar(100)"`
+	Status        string     `gorm:"type:varchar(20);default:'pending';index"`
+	ReviewerTgID  int64      `gorm:"default:0"`
+	ReviewedAt    *time.Time `gorm:"default:NULL"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime;index"`
+}
+
+// IsGateway reports whether the order is paid through an online payment provider
+func (o *PurchaseOrder) IsGateway() bool {
+	return o.Provider != ""
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// Purchase order status constants
+const (
+	OrderStatusPending  = "pending"
+	OrderStatusApproved = "approved"
+	OrderStatusRejected = "rejected"
+)
+
+// OrderStatusTitles maps each status to its user facing label
+var OrderStatusTitles = map[string]string{
+	OrderStatusPending:  "⏳ در انتظار بررسی",
+	OrderStatusApproved: "✅ تایید شده",
+	OrderStatusRejected: "❌ رد شده",
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestGetCoinPackage(t *testing.T) {
+	for _, pkg := range CoinPackages {
+		got := GetCoinPackage(pkg.ID)
+		if got == nil {
+			t.Fatalf("GetCoinPackage(%d) = nil, want package", pkg.ID)
+		}
+		if got.Coins != pkg.Coins || got.PriceToman != pkg.PriceToman {
+			t.Errorf("GetCoinPackage(%d) = %+v, want %+v", pkg.ID, *got, pkg)
+		}
+	}
+
+	if got := GetCoinPackage(0); got != nil {
+		t.Errorf("GetCoinPackage(0) = %+v, want nil", *got)
+	}
+}
+
+func TestCoinPackages_UniqueIDs(t *testing.T) {
+	seen := make(map[uint]bool)
+	for _, pkg := range CoinPackages {
+		if seen[pkg.ID] {
+			t.Errorf("duplicate coin package ID %d", pkg.ID)
+		}
+		seen[pkg.ID] = true
+
+		if pkg.Coins <= 0 || pkg.PriceToman <= 0 {
+			t.Errorf("package %d has non-positive coins or price", pkg.ID)
+		}
+	}
+}
@@ -77,21 +77,6 @@ func (QuizAnswer) TableName() string {
 	return "quiz_answers"
 }
 
-// UserBooster represents boosters owned by a user
-type UserBooster struct {
-	ID          uint      `gorm:"primaryKey"`
-	UserID      uint      `gorm:"not null;uniqueIndex:idx_user_booster_type"`
-	User        User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	BoosterType string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_booster_type"`
-	Quantity    int       `gorm:"default:0"`
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
-}
-
-func (UserBooster) TableName() string {
-	return "user_boosters"
-}
-
 // Quiz match states
 const (
 	QuizStateWaitingCategory  = "waiting_category"
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// ChatReport is a user's report against their partner in an anonymous chat.
+// Evidence holds the last relayed messages of the match as a JSON array of ReportEvidenceMessage.
+type ChatReport struct {
+	ID           uint       `gorm:"primaryKey"`
+	ReporterID   uint       `gorm:"not null;index:idx_report_reporter_match,unique"`
+	Reporter     User       `gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE"`
+	ReportedID   uint       `gorm:"not null;index"`
+	Reported     User       `gorm:"foreignKey:ReportedID;constraint:OnDelete:CASCADE"`
+	MatchID      uint       `gorm:"not null;index:idx_report_reporter_match,unique"`
+	Reason       string     `gorm:"type:varchar(20);not null"`
+	Evidence     string     `gorm:"type:text"`
+	Status       string     `gorm:"type:varchar(20);default:'pending';index"`
+	ReviewerTgID int64      `gorm:"default:0"`
+	ReviewedAt   *time.Time `gorm:"default:NULL"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime;index"`
+}
+
+func (ChatReport) TableName() string {
+	return "chat_reports"
+}
+
+// ReportEvidenceMessage is one relayed chat message kept as report evidence
+type ReportEvidenceMessage struct {
+	SenderID uint      `json:"sender_id"`
+	Kind     string    `json:"kind"`
+	Content  string    `json:"content"`
+	FileID   string    `json:"file_id,omitempty"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// Report status constants
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusConfirmed = "confirmed"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Report reason constants
+const (
+	ReportReasonSpam       = "spam"
+	ReportReasonHarassment = "harassment"
+	ReportReasonSexual     = "sexual"
+	ReportReasonScam       = "scam"
+	ReportReasonOther      = "other"
+)
+
+// ReportReasons lists the reasons in the order they are offered to users
+var ReportReasons = []string{
+	ReportReasonHarassment,
+	ReportReasonSexual,
+	ReportReasonSpam,
+	ReportReasonScam,
+	ReportReasonOther,
+}
+
+// ReportReasonTitles maps each reason to its user facing label
+var ReportReasonTitles = map[string]string{
+	ReportReasonHarassment: "🤬 توهین و آزار",
+	ReportReasonSexual:     "🔞 محتوای جنسی",
+	ReportReasonSpam:       "📢 اسپم و تبلیغات",
+	ReportReasonScam:       "💸 کلاهبرداری",
+	ReportReasonOther:      "❓ سایر موارد",
+}
+
+// IsValidReportReason reports whether reason is one of the known report reasons
+func IsValidReportReason(reason string) bool {
+	_, ok := ReportReasonTitles[reason]
+	return ok
+}
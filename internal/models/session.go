@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// ConversationSession is the stored conversation state of a Telegram user, so a
+// half-finished flow (registration, room creation, receipt upload, ...) survives restarts.
+// Users are keyed by Telegram ID because flows like registration start before a User row exists.
+type ConversationSession struct {
+	TelegramID int64      `gorm:"primaryKey;autoIncrement:false"`
+	State      string     `gorm:"type:varchar(64)"`
+	Data       string     `gorm:"type:text"` // typed JSON, see the session package
+	ExpiresAt  *time.Time `gorm:"index"`     // nil keeps the session until its flow ends
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+func (ConversationSession) TableName() string {
+	return "conversation_sessions"
+}
+
+// IsExpired reports whether the session's TTL has passed
+func (s *ConversationSession) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !s.ExpiresAt.After(now)
+}
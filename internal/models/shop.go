@@ -0,0 +1,160 @@
+package models
+
+import (
+	"time"
+)
+
+// Shop currencies
+const (
+	CurrencyCoins    = "coins"
+	CurrencyDiamonds = "diamonds"
+)
+
+// Shop grant kinds decide where a bought item is delivered
+const (
+	ShopGrantInventory = "inventory" // InventoryItem, GrantKey is the item type
+	ShopGrantAvatar    = "avatar"    // User.CustomAvatarID, set from a photo the buyer sends
+)
+
+// Shop categories
+const (
+	ShopCategoryBoosters  = "boosters"
+	ShopCategoryTod       = "tod"
+	ShopCategoryCosmetics = "cosmetics"
+)
+
+// ShopCategories lists the categories in the order they are shown
+var ShopCategories = []string{
+	ShopCategoryBoosters,
+	ShopCategoryTod,
+	ShopCategoryCosmetics,
+}
+
+// ShopCategoryTitles maps each category to its user facing label
+var ShopCategoryTitles = map[string]string{
+	ShopCategoryBoosters:  "🧠 بوسترهای کوییز",
+	ShopCategoryTod:       "🔥 آیتم‌های جرأت یا حقیقت",
+	ShopCategoryCosmetics: "🎨 ظاهری",
+}
+
+// ShopItem is an entry of the coin shop catalog
+type ShopItem struct {
+	ID          string
+	Category    string
+	Title       string
+	Description string
+	Grant       string
+	GrantKey    string
+	Quantity    int
+	Currency    string
+	Price       int64
+
+	// Offers
+	OriginalPrice  int64      // shown crossed out when higher than Price, 0 = no discount
+	Stock          int        // total units for sale, 0 = unlimited
+	AvailableFrom  *time.Time // nil = always
+	AvailableUntil *time.Time // nil = no end
+}
+
+// ShopCatalog lists every item in the order it is shown.
+// Premium items are priced in diamonds only.
+var ShopCatalog = []ShopItem{
+	{
+		ID: "booster_remove2", Category: ShopCategoryBoosters,
+		Title: "💣 حذف دو گزینه", Description: "دو گزینه غلط سوال کوییز رو حذف می‌کنه.",
+		Grant: ShopGrantInventory, GrantKey: BoosterRemove2Options, Quantity: 1,
+		Currency: CurrencyCoins, Price: BoosterRemove2OptionsCost,
+	},
+	{
+		ID: "booster_second_chance", Category: ShopCategoryBoosters,
+		Title: "🔁 شانس دوباره", Description: "اگه جواب اشتباه بدی، یه بار دیگه می‌تونی جواب بدی.",
+		Grant: ShopGrantInventory, GrantKey: BoosterSecondChance, Quantity: 1,
+		Currency: CurrencyCoins, Price: BoosterSecondChanceCost,
+	},
+	{
+		ID: "booster_remove2_pack", Category: ShopCategoryBoosters,
+		Title: "💣 بسته ۵ تایی حذف دو گزینه", Description: "۵ عدد بوستر حذف دو گزینه با تخفیف.",
+		Grant: ShopGrantInventory, GrantKey: BoosterRemove2Options, Quantity: 5,
+		Currency: CurrencyCoins, Price: 170, OriginalPrice: 5 * BoosterRemove2OptionsCost,
+	},
+	{
+		ID: "booster_second_chance_pack", Category: ShopCategoryBoosters,
+		Title: "🔁 بسته ۵ تایی شانس دوباره", Description: "۵ عدد بوستر شانس دوباره، فقط با الماس.",
+		Grant: ShopGrantInventory, GrantKey: BoosterSecondChance, Quantity: 5,
+		Currency: CurrencyDiamonds, Price: 4,
+	},
+	{
+		ID: "tod_shield", Category: ShopCategoryTod,
+		Title: "🛡 سپر", Description: "رد کردن نوبت بدون جریمه.",
+		Grant: ShopGrantInventory, GrantKey: ItemTypeShield, Quantity: 1,
+		Currency: CurrencyCoins, Price: 60,
+	},
+	{
+		ID: "tod_swap", Category: ShopCategoryTod,
+		Title: "🔄 تعویض", Description: "تغییر سوال به یه سوال دیگه.",
+		Grant: ShopGrantInventory, GrantKey: ItemTypeSwap, Quantity: 1,
+		Currency: CurrencyCoins, Price: 40,
+	},
+	{
+		ID: "tod_mirror", Category: ShopCategoryTod,
+		Title: "🪞 آینه", Description: "انتقال چالش به حریف.",
+		Grant: ShopGrantInventory, GrantKey: ItemTypeMirror, Quantity: 1,
+		Currency: CurrencyCoins, Price: 80,
+	},
+	{
+		ID: "tod_mirror_pack", Category: ShopCategoryTod,
+		Title: "🪞 بسته ۳ تایی آینه", Description: "۳ آینه برای بازیکن‌های حرفه‌ای، فقط تا پایان موجودی.",
+		Grant: ShopGrantInventory, GrantKey: ItemTypeMirror, Quantity: 3,
+		Currency: CurrencyDiamonds, Price: 5, Stock: 200,
+	},
+	{
+		ID: "avatar_custom", Category: ShopCategoryCosmetics,
+		Title: "🖼 آواتار اختصاصی", Description: "یه عکس دلخواه به جای عکس پروفایل نمایش داده می‌شه.",
+		Grant: ShopGrantAvatar, Quantity: 1,
+		Currency: CurrencyDiamonds, Price: 10,
+	},
+}
+
+// GetShopItem finds a catalog item by ID. Returns nil if there is no such item.
+func GetShopItem(id string) *ShopItem {
+	for i := range ShopCatalog {
+		if ShopCatalog[i].ID == id {
+			return &ShopCatalog[i]
+		}
+	}
+	return nil
+}
+
+// IsOnSale reports whether the item can be bought at the given time, ignoring stock
+func (i *ShopItem) IsOnSale(now time.Time) bool {
+	if i.AvailableFrom != nil && now.Before(*i.AvailableFrom) {
+		return false
+	}
+	if i.AvailableUntil != nil && !now.Before(*i.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+// IsLimited reports whether the item has a stock limit
+func (i *ShopItem) IsLimited() bool {
+	return i.Stock > 0
+}
+
+// ShopPurchase records an item bought from the shop
+type ShopPurchase struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ItemID    string    `gorm:"type:varchar(50);not null;index"`
+	Quantity  int       `gorm:"not null"`
+	Currency  string    `gorm:"type:varchar(20);not null"`
+	Price     int64     `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+
+	IdempotencyKey *string `gorm:"type:varchar(191);uniqueIndex"` // request that bought it, so a replay buys nothing
+}
+
+func (ShopPurchase) TableName() string {
+	return "shop_purchases"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShopItem_IsOnSale(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		from  *time.Time
+		until *time.Time
+		want  bool
+	}{
+		{name: "Always on sale", want: true},
+		{name: "Offer not started", from: &future, want: false},
+		{name: "Offer running", from: &past, until: &future, want: true},
+		{name: "Offer ended", until: &past, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &ShopItem{AvailableFrom: tt.from, AvailableUntil: tt.until}
+			if got := item.IsOnSale(now); got != tt.want {
+				t.Errorf("IsOnSale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShopCatalog_Valid(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, item := range ShopCatalog {
+		if seen[item.ID] {
+			t.Errorf("duplicate shop item ID %q", item.ID)
+		}
+		seen[item.ID] = true
+
+		if item.Price <= 0 || item.Quantity <= 0 {
+			t.Errorf("item %q has non-positive price or quantity", item.ID)
+		}
+		if item.Currency != CurrencyCoins && item.Currency != CurrencyDiamonds {
+			t.Errorf("item %q has unknown currency %q", item.ID, item.Currency)
+		}
+		if _, ok := ShopCategoryTitles[item.Category]; !ok {
+			t.Errorf("item %q has unknown category %q", item.ID, item.Category)
+		}
+		if GetShopItem(item.ID) == nil {
+			t.Errorf("GetShopItem(%q) = nil", item.ID)
+		}
+	}
+
+	if GetShopItem("missing") != nil {
+		t.Error("GetShopItem(missing) != nil")
+	}
+}
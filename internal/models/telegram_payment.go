@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramStarsCurrency is the currency of native invoices paid with Telegram Stars
+const TelegramStarsCurrency = "XTR"
+
+// Invoice product kinds
+const (
+	ProductKindCoins    = "coins"
+	ProductKindDiamonds = "diamonds"
+)
+
+// DiamondBundle is a diamond pack sold through Telegram invoices
+type DiamondBundle struct {
+	ID         uint
+	Diamonds   int64
+	PriceStars int
+	Stock      int // total units for sale, 0 = unlimited
+}
+
+// DiamondBundles lists the bundles in the order they are offered to users
+var DiamondBundles = []DiamondBundle{
+	{ID: 1, Diamonds: 10, PriceStars: 50},
+	{ID: 2, Diamonds: 60, PriceStars: 250},
+	{ID: 3, Diamonds: 150, PriceStars: 550},
+	{ID: 4, Diamonds: 500, PriceStars: 1500, Stock: 100},
+}
+
+// InvoiceProduct is anything sold through a native Telegram invoice
+type InvoiceProduct struct {
+	Kind       string
+	ID         uint
+	Amount     int64 // coins or diamonds credited
+	PriceStars int
+	Stock      int // 0 = unlimited
+}
+
+// GetInvoiceProduct finds a coin package or diamond bundle. Returns nil if there is no such product.
+func GetInvoiceProduct(kind string, id uint) *InvoiceProduct {
+	switch kind {
+	case ProductKindCoins:
+		if pkg := GetCoinPackage(id); pkg != nil && pkg.PriceStars > 0 {
+			return &InvoiceProduct{Kind: kind, ID: pkg.ID, Amount: pkg.Coins, PriceStars: pkg.PriceStars}
+		}
+	case ProductKindDiamonds:
+		for _, bundle := range DiamondBundles {
+			if bundle.ID == id {
+				return &InvoiceProduct{Kind: kind, ID: bundle.ID, Amount: bundle.Diamonds, PriceStars: bundle.PriceStars, Stock: bundle.Stock}
+			}
+		}
+	}
+	return nil
+}
+
+// InvoicePayload builds the payload of a Telegram invoice: "<kind>:<product id>:<user id>"
+func InvoicePayload(kind string, productID, userID uint) string {
+	return fmt.Sprintf("%s:%d:%d", kind, productID, userID)
+}
+
+// ParseInvoicePayload reverses InvoicePayload
+func ParseInvoicePayload(payload string) (kind string, productID, userID uint, err error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 {
+		return "", 0, 0, fmt.Errorf("invalid invoice payload %q", payload)
+	}
+
+	pid, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid product in invoice payload %q", payload)
+	}
+	uid, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid user in invoice payload %q", payload)
+	}
+
+	return parts[0], uint(pid), uint(uid), nil
+}
+
+// TelegramPayment is a successful native Telegram payment, kept for refunds and reconciliation.
+// The charge ID is unique, so a payment is credited at most once. A payment that couldn't be
+// credited is kept as needs_review; UserID is nil when the payer has no account.
+type TelegramPayment struct {
+	ID                      uint       `gorm:"primaryKey"`
+	TelegramPaymentChargeID string     `gorm:"type:varchar(255);uniqueIndex;not null"`
+	ProviderPaymentChargeID string     `gorm:"type:varchar(255)"`
+	UserID                  *uint      `gorm:"index"`
+	User                    User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PayerTgID               int64      `gorm:"not null;default:0"`
+	ProductKind             string     `gorm:"type:varchar(20);not null;index:idx_tg_payment_product"`
+	ProductID               uint       `gorm:"not null;index:idx_tg_payment_product"`
+	Amount                  int64      `gorm:"not null"`
+	Currency                string     `gorm:"type:varchar(10);not null"`
+	TotalAmount             int        `gorm:"not null"`
+	Payload                 string     `gorm:"type:varchar(128)"`
+	Status                  string     `gorm:"type:varchar(20);default:'paid';index"`
+	ReviewNote              string     `gorm:"type:varchar(255)"`
+	RefundedAt              *time.Time `gorm:"default:NULL"`
+	CreatedAt               time.Time  `gorm:"autoCreateTime;index"`
+}
+
+func (TelegramPayment) TableName() string {
+	return "telegram_payments"
+}
+
+// Telegram payment status constants
+const (
+	TelegramPaymentStatusPaid        = "paid"
+	TelegramPaymentStatusRefunded    = "refunded"
+	TelegramPaymentStatusNeedsReview = "needs_review"
+)
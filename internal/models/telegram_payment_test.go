@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestInvoicePayload_RoundTrip(t *testing.T) {
+	payload := InvoicePayload(ProductKindDiamonds, 3, 42)
+
+	kind, productID, userID, err := ParseInvoicePayload(payload)
+	if err != nil {
+		t.Fatalf("ParseInvoicePayload(%q) error = %v", payload, err)
+	}
+	if kind != ProductKindDiamonds || productID != 3 || userID != 42 {
+		t.Errorf("ParseInvoicePayload(%q) = %q, %d, %d; want %q, 3, 42", payload, kind, productID, userID, ProductKindDiamonds)
+	}
+}
+
+func TestParseInvoicePayload_Invalid(t *testing.T) {
+	for _, payload := range []string{"", "coins", "coins:1", "coins:x:1", "coins:1:-1", "coins:1:2:3"} {
+		if _, _, _, err := ParseInvoicePayload(payload); err == nil {
+			t.Errorf("ParseInvoicePayload(%q) expected error, got nil", payload)
+		}
+	}
+}
+
+func TestGetInvoiceProduct(t *testing.T) {
+	for _, pkg := range CoinPackages {
+		product := GetInvoiceProduct(ProductKindCoins, pkg.ID)
+		if product == nil {
+			t.Fatalf("GetInvoiceProduct(coins, %d) = nil", pkg.ID)
+		}
+		if product.Amount != pkg.Coins || product.PriceStars != pkg.PriceStars {
+			t.Errorf("GetInvoiceProduct(coins, %d) = %+v, want %d coins for %d stars", pkg.ID, *product, pkg.Coins, pkg.PriceStars)
+		}
+	}
+
+	for _, bundle := range DiamondBundles {
+		product := GetInvoiceProduct(ProductKindDiamonds, bundle.ID)
+		if product == nil {
+			t.Fatalf("GetInvoiceProduct(diamonds, %d) = nil", bundle.ID)
+		}
+		if product.Amount != bundle.Diamonds || product.Stock != bundle.Stock {
+			t.Errorf("GetInvoiceProduct(diamonds, %d) = %+v, want %+v", bundle.ID, *product, bundle)
+		}
+	}
+
+	if product := GetInvoiceProduct("boosters", 1); product != nil {
+		t.Errorf("GetInvoiceProduct(boosters, 1) = %+v, want nil", *product)
+	}
+}
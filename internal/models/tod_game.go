@@ -168,10 +168,7 @@ type TodPlayerStats struct {
 	UnfairJudgmentCount int     `gorm:"default:0"`     // Strikes for unfair judging
 
 	// Item Stats
-	ItemsUsed    int `gorm:"default:0"`
-	ShieldsOwned int `gorm:"default:1"` // Starting inventory
-	SwapsOwned   int `gorm:"default:1"`
-	MirrorsOwned int `gorm:"default:1"`
+	ItemsUsed int `gorm:"default:0"` // Items themselves are held in InventoryItem
 
 	// Timing Stats
 	AvgResponseTime int `gorm:"default:0"` // Seconds
@@ -206,7 +203,7 @@ type TodActionLog struct {
 	ID       uint   `gorm:"primaryKey"`
 	GameID   uint   `gorm:"not null;index"`
 	UserID   uint   `gorm:"not null;index"`
-	ActionID string `gorm:"type:varchar(100);not null;uniqueIndex"` // UUID for deduplication
+	ActionID string `gorm:"type:varchar(100);not null;uniqueIndex"` // Derived from the turn for deduplication
 	Action   string `gorm:"type:varchar(50);not null"`              // choice, proof, judgment, etc.
 
 	CreatedAt time.Time `gorm:"autoCreateTime"`
@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// ProviderMock is the name of the local mock gateway
+const ProviderMock = "mock"
+
+// MockProvider is an in-memory gateway for local development and tests.
+// Its pay URL points straight at the callback with a successful status, so opening it "pays" the invoice;
+// a callback with any other status cancels it.
+type MockProvider struct {
+	mu        sync.Mutex
+	nextID    int
+	invoices  map[string]int64 // authority -> amount in Toman
+	cancelled map[string]bool
+}
+
+func NewMockProvider() *MockProvider {
+	return &MockProvider{invoices: make(map[string]int64), cancelled: make(map[string]bool)}
+}
+
+func (p *MockProvider) Name() string {
+	return ProviderMock
+}
+
+func (p *MockProvider) CreateInvoice(ctx context.Context, req InvoiceRequest) (*Invoice, error) {
+	if req.AmountToman <= 0 {
+		return nil, fmt.Errorf("mock invoice amount must be positive")
+	}
+
+	p.mu.Lock()
+	p.nextID++
+	authority := fmt.Sprintf("MOCK%06d", p.nextID)
+	p.invoices[authority] = req.AmountToman
+	p.mu.Unlock()
+
+	query := url.Values{}
+	query.Set("Authority", authority)
+	query.Set("Status", "OK")
+
+	return &Invoice{
+		Authority: authority,
+		PayURL:    req.CallbackURL + "?" + query.Encode(),
+	}, nil
+}
+
+func (p *MockProvider) ParseCallback(query url.Values) (*Callback, error) {
+	authority := query.Get("Authority")
+	if authority == "" {
+		return nil, fmt.Errorf("mock callback without authority")
+	}
+	paid := query.Get("Status") == "OK"
+
+	p.mu.Lock()
+	if _, ok := p.invoices[authority]; ok {
+		p.cancelled[authority] = !paid
+	}
+	p.mu.Unlock()
+
+	return &Callback{
+		Authority: authority,
+		Paid:      paid,
+	}, nil
+}
+
+func (p *MockProvider) Verify(ctx context.Context, authority string, amountToman int64) (string, error) {
+	p.mu.Lock()
+	amount, ok := p.invoices[authority]
+	cancelled := p.cancelled[authority]
+	p.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown mock invoice %q", authority)
+	}
+	if cancelled {
+		return "", fmt.Errorf("mock invoice %q: %w", authority, ErrNotPaid)
+	}
+	if amount != amountToman {
+		return "", fmt.Errorf("mock invoice %q amount mismatch: %d != %d", authority, amount, amountToman)
+	}
+
+	return "REF-" + authority, nil
+}
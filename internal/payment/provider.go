@@ -0,0 +1,74 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/mroshb/game_bot/internal/config"
+)
+
+// CallbackPath is where payment gateways send the buyer back after paying
+const CallbackPath = "/payment/callback"
+
+// InvoiceRequest describes a payment to be opened on the gateway
+type InvoiceRequest struct {
+	OrderID     uint
+	AmountToman int64
+	Description string
+	CallbackURL string
+}
+
+// Invoice is an opened payment. Authority identifies it on the gateway and in callbacks.
+type Invoice struct {
+	Authority string
+	PayURL    string
+}
+
+// Callback is the gateway's report about an invoice, read from the buyer's redirect
+type Callback struct {
+	Authority string
+	Paid      bool
+}
+
+// ErrNotPaid is wrapped by Verify errors when the gateway says the invoice was never paid,
+// as opposed to failing to answer
+var ErrNotPaid = errors.New("invoice was not paid")
+
+// Provider is an online payment gateway.
+// A callback only says the buyer came back; Verify must succeed before coins are credited.
+type Provider interface {
+	// Name identifies the provider on stored orders
+	Name() string
+
+	// CreateInvoice opens a payment and returns the URL the buyer pays on
+	CreateInvoice(ctx context.Context, req InvoiceRequest) (*Invoice, error)
+
+	// ParseCallback reads the query string of a callback request
+	ParseCallback(query url.Values) (*Callback, error)
+
+	// Verify confirms with the gateway that the invoice was paid in full and returns its reference ID.
+	// Verifying an already verified invoice succeeds again. An unpaid or cancelled invoice
+	// fails with an error wrapping ErrNotPaid.
+	Verify(ctx context.Context, authority string, amountToman int64) (string, error)
+}
+
+// NewProvider creates the provider selected in the config. Returns nil if online payments are disabled.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch cfg.PaymentProvider {
+	case "":
+		return nil, nil
+	case ProviderZarinpal:
+		return NewZarinpalProvider(cfg.ZarinpalMerchantID, cfg.ZarinpalSandbox), nil
+	case ProviderMock:
+		return NewMockProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
+
+// CallbackURL builds the callback address for the given public base URL
+func CallbackURL(baseURL string) string {
+	return baseURL + CallbackPath
+}
@@ -0,0 +1,194 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/config"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "Disabled", provider: "", wantName: ""},
+		{name: "Zarinpal", provider: ProviderZarinpal, wantName: ProviderZarinpal},
+		{name: "Mock", provider: ProviderMock, wantName: ProviderMock},
+		{name: "Unknown", provider: "paypal", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(&config.Config{PaymentProvider: tt.provider, ZarinpalMerchantID: "merchant"})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("NewProvider() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewProvider() error = %v", err)
+			}
+
+			gotName := ""
+			if p != nil {
+				gotName = p.Name()
+			}
+			if gotName != tt.wantName {
+				t.Errorf("NewProvider() name = %q, want %q", gotName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMockProvider_Flow(t *testing.T) {
+	p := NewMockProvider()
+	ctx := context.Background()
+
+	invoice, err := p.CreateInvoice(ctx, InvoiceRequest{OrderID: 1, AmountToman: 20000, CallbackURL: "https://bot.example.com" + CallbackPath})
+	if err != nil {
+		t.Fatalf("CreateInvoice() error = %v", err)
+	}
+
+	payURL, err := url.Parse(invoice.PayURL)
+	if err != nil {
+		t.Fatalf("PayURL %q is not a URL: %v", invoice.PayURL, err)
+	}
+	if payURL.Path != CallbackPath {
+		t.Errorf("PayURL path = %q, want %q", payURL.Path, CallbackPath)
+	}
+
+	cb, err := p.ParseCallback(payURL.Query())
+	if err != nil {
+		t.Fatalf("ParseCallback() error = %v", err)
+	}
+	if cb.Authority != invoice.Authority || !cb.Paid {
+		t.Errorf("ParseCallback() = %+v, want paid callback for %q", cb, invoice.Authority)
+	}
+
+	refID, err := p.Verify(ctx, cb.Authority, 20000)
+	if err != nil || refID == "" {
+		t.Fatalf("Verify() = %q, %v; want reference ID", refID, err)
+	}
+
+	// Verifying again must succeed so retried callbacks stay harmless
+	if again, err := p.Verify(ctx, cb.Authority, 20000); err != nil || again != refID {
+		t.Errorf("second Verify() = %q, %v; want %q", again, err, refID)
+	}
+
+	if _, err := p.Verify(ctx, cb.Authority, 10000); err == nil {
+		t.Error("Verify() with wrong amount expected error, got nil")
+	}
+	if _, err := p.Verify(ctx, "MOCK999999", 20000); err == nil {
+		t.Error("Verify() with unknown authority expected error, got nil")
+	}
+}
+
+func TestMockProvider_CancelledCallback(t *testing.T) {
+	p := NewMockProvider()
+	ctx := context.Background()
+
+	invoice, err := p.CreateInvoice(ctx, InvoiceRequest{OrderID: 1, AmountToman: 20000})
+	if err != nil {
+		t.Fatalf("CreateInvoice() error = %v", err)
+	}
+
+	cb, err := p.ParseCallback(url.Values{"Authority": {invoice.Authority}, "Status": {"NOK"}})
+	if err != nil {
+		t.Fatalf("ParseCallback() error = %v", err)
+	}
+	if cb.Paid {
+		t.Error("ParseCallback() Paid = true for NOK status, want false")
+	}
+	if _, err := p.Verify(ctx, invoice.Authority, 20000); !errors.Is(err, ErrNotPaid) {
+		t.Errorf("Verify() of a cancelled invoice error = %v, want ErrNotPaid", err)
+	}
+
+	if _, err := p.ParseCallback(url.Values{}); err == nil {
+		t.Error("ParseCallback() without authority expected error, got nil")
+	}
+}
+
+// newZarinpalTestServer fakes the Zarinpal API; verify answers with verifyCode, negative codes in the errors envelope
+func newZarinpalTestServer(t *testing.T, verifyCode int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		if body["merchant_id"] != "merchant" {
+			t.Errorf("merchant_id = %v, want merchant", body["merchant_id"])
+		}
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/request.json"):
+			if body["amount"] != float64(200000) {
+				t.Errorf("amount = %v, want 200000 Rial", body["amount"])
+			}
+			w.Write([]byte(`{"data":{"code":100,"message":"Success","authority":"A000000000000000000000000000000000001"},"errors":[]}`))
+		case strings.HasSuffix(r.URL.Path, "/verify.json"):
+			if verifyCode < 0 {
+				w.Write([]byte(`{"data":[],"errors":{"code":-51,"message":"Session is not valid","validations":[]}}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"code":` + strconv.Itoa(verifyCode) + `,"message":"Verified","ref_id":201},"errors":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestZarinpalProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		verifyCode int
+		wantErr    bool
+	}{
+		{name: "Verified", verifyCode: zarinpalCodeSuccess},
+		{name: "Already verified", verifyCode: zarinpalCodeAlreadyVerified},
+		{name: "Not paid", verifyCode: zarinpalCodeNotPaid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newZarinpalTestServer(t, tt.verifyCode)
+			defer server.Close()
+
+			p := NewZarinpalProvider("merchant", false)
+			p.baseURL = server.URL
+			ctx := context.Background()
+
+			invoice, err := p.CreateInvoice(ctx, InvoiceRequest{OrderID: 7, AmountToman: 20000, CallbackURL: "https://bot.example.com" + CallbackPath})
+			if err != nil {
+				t.Fatalf("CreateInvoice() error = %v", err)
+			}
+			if !strings.HasSuffix(invoice.PayURL, "/pg/StartPay/"+invoice.Authority) {
+				t.Errorf("PayURL = %q, want StartPay URL for %q", invoice.PayURL, invoice.Authority)
+			}
+
+			refID, err := p.Verify(ctx, invoice.Authority, 20000)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotPaid) {
+					t.Errorf("Verify() error = %v, want ErrNotPaid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if refID != "201" {
+				t.Errorf("Verify() ref ID = %q, want %q", refID, "201")
+			}
+		})
+	}
+}
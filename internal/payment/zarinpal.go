@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ProviderZarinpal is the name of the Zarinpal gateway
+const ProviderZarinpal = "zarinpal"
+
+const (
+	zarinpalBaseURL        = "https://payment.zarinpal.com"
+	zarinpalSandboxBaseURL = "https://sandbox.zarinpal.com"
+
+	// Zarinpal result codes
+	zarinpalCodeSuccess         = 100
+	zarinpalCodeAlreadyVerified = 101
+	zarinpalCodeNotPaid         = -51
+)
+
+// ZarinpalProvider talks to the Zarinpal v4 REST API. Amounts are sent in Rial.
+type ZarinpalProvider struct {
+	merchantID string
+	baseURL    string
+	client     *http.Client
+}
+
+func NewZarinpalProvider(merchantID string, sandbox bool) *ZarinpalProvider {
+	baseURL := zarinpalBaseURL
+	if sandbox {
+		baseURL = zarinpalSandboxBaseURL
+	}
+	return &ZarinpalProvider{
+		merchantID: merchantID,
+		baseURL:    baseURL,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// zarinpalResponse is the envelope of every Zarinpal API response.
+// On failure data is an empty array and errors holds the code, so both are decoded lazily.
+type zarinpalResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors json.RawMessage `json:"errors"`
+}
+
+type zarinpalResult struct {
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Authority string `json:"authority"`
+	RefID     int64  `json:"ref_id"`
+}
+
+func (p *ZarinpalProvider) Name() string {
+	return ProviderZarinpal
+}
+
+func (p *ZarinpalProvider) CreateInvoice(ctx context.Context, req InvoiceRequest) (*Invoice, error) {
+	result, err := p.call(ctx, "/pg/v4/payment/request.json", map[string]interface{}{
+		"merchant_id":  p.merchantID,
+		"amount":       req.AmountToman * 10,
+		"callback_url": req.CallbackURL,
+		"description":  req.Description,
+		"metadata":     map[string]string{"order_id": strconv.FormatUint(uint64(req.OrderID), 10)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Code != zarinpalCodeSuccess || result.Authority == "" {
+		return nil, fmt.Errorf("zarinpal request failed: code %d (%s)", result.Code, result.Message)
+	}
+
+	return &Invoice{
+		Authority: result.Authority,
+		PayURL:    p.baseURL + "/pg/StartPay/" + result.Authority,
+	}, nil
+}
+
+func (p *ZarinpalProvider) ParseCallback(query url.Values) (*Callback, error) {
+	authority := query.Get("Authority")
+	if authority == "" {
+		return nil, fmt.Errorf("zarinpal callback without authority")
+	}
+	return &Callback{
+		Authority: authority,
+		Paid:      query.Get("Status") == "OK",
+	}, nil
+}
+
+func (p *ZarinpalProvider) Verify(ctx context.Context, authority string, amountToman int64) (string, error) {
+	result, err := p.call(ctx, "/pg/v4/payment/verify.json", map[string]interface{}{
+		"merchant_id": p.merchantID,
+		"amount":      amountToman * 10,
+		"authority":   authority,
+	})
+	if err != nil {
+		return "", err
+	}
+	if result.Code == zarinpalCodeNotPaid {
+		return "", fmt.Errorf("zarinpal verify: %w: code %d (%s)", ErrNotPaid, result.Code, result.Message)
+	}
+	if result.Code != zarinpalCodeSuccess && result.Code != zarinpalCodeAlreadyVerified {
+		return "", fmt.Errorf("zarinpal verify failed: code %d (%s)", result.Code, result.Message)
+	}
+
+	return strconv.FormatInt(result.RefID, 10), nil
+}
+
+// call posts a JSON request and returns the result from either data or errors
+func (p *ZarinpalProvider) call(ctx context.Context, path string, body interface{}) (*zarinpalResult, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("zarinpal request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope zarinpalResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("zarinpal response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	var result zarinpalResult
+	if json.Unmarshal(envelope.Data, &result) == nil && result.Code != 0 {
+		return &result, nil
+	}
+	if json.Unmarshal(envelope.Errors, &result) == nil && result.Code != 0 {
+		return &result, nil
+	}
+
+	return nil, fmt.Errorf("zarinpal response without result (HTTP %d)", resp.StatusCode)
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type BanRepository struct {
+	db *gorm.DB
+}
+
+func NewBanRepository(db *gorm.DB) *BanRepository {
+	return &BanRepository{db: db}
+}
+
+// activeBanCondition matches bans that have not expired yet
+const activeBanCondition = "expires_at IS NULL OR expires_at > ?"
+
+// BanUser bans a user from a scope. A zero duration bans permanently.
+func (r *BanRepository) BanUser(userID uint, scope string, duration time.Duration, reason string, issuedByTgID int64) (*models.UserBan, error) {
+	if !models.IsValidBanScope(scope) {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "invalid ban scope")
+	}
+
+	ban := &models.UserBan{
+		UserID:       userID,
+		Scope:        scope,
+		Reason:       reason,
+		IssuedByTgID: issuedByTgID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	if err := r.db.Create(ban).Error; err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to ban user")
+	}
+
+	return ban, nil
+}
+
+// GetActiveBan returns the longest running ban that keeps the user out of scope.
+// A global ban applies to every scope. Returns nil if the user is not banned.
+func (r *BanRepository) GetActiveBan(userID uint, scope string) (*models.UserBan, error) {
+	var ban models.UserBan
+	result := r.db.Where("user_id = ? AND scope IN ?", userID, []string{scope, models.BanScopeGlobal}).
+		Where(activeBanCondition, time.Now()).
+		Order("expires_at DESC NULLS FIRST").
+		Limit(1).
+		Find(&ban)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to check ban")
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &ban, nil
+}
+
+// GetActiveBans lists every running ban of a user
+func (r *BanRepository) GetActiveBans(userID uint) ([]models.UserBan, error) {
+	var bans []models.UserBan
+	result := r.db.Where("user_id = ?", userID).
+		Where(activeBanCondition, time.Now()).
+		Order("created_at DESC").
+		Find(&bans)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get bans")
+	}
+
+	return bans, nil
+}
+
+// LiftBans removes a user's running bans in a scope (or all scopes if scope is empty)
+func (r *BanRepository) LiftBans(userID uint, scope string) (int64, error) {
+	query := r.db.Where("user_id = ?", userID).Where(activeBanCondition, time.Now())
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+
+	result := query.Delete(&models.UserBan{})
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to lift bans")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// DeleteExpiredBans removes bans whose expiry has passed
+func (r *BanRepository) DeleteExpiredBans() (int64, error) {
+	result := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+		Delete(&models.UserBan{})
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to delete expired bans")
+	}
+
+	return result.RowsAffected, nil
+}
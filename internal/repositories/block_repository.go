@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockRepository struct {
+	db *gorm.DB
+}
+
+func NewBlockRepository(db *gorm.DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// blockedPairCondition matches a block between two users in either direction
+const blockedPairCondition = "(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)"
+
+// BlockUser blocks a user and drops any friendship or pending request between the pair
+func (r *BlockRepository) BlockUser(blockerID, blockedID uint) error {
+	if blockerID == blockedID {
+		return errors.New(errors.ErrCodeValidationFailed, "cannot block yourself")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		block := &models.UserBlock{
+			BlockerID: blockerID,
+			BlockedID: blockedID,
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(block)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to block user")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(errors.ErrCodeAlreadyExists, "user already blocked")
+		}
+
+		if err := tx.Where(
+			"(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+			blockerID, blockedID, blockedID, blockerID,
+		).Delete(&models.Friendship{}).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to remove friendship")
+		}
+
+		return nil
+	})
+}
+
+// UnblockUser removes a block
+func (r *BlockRepository) UnblockUser(blockerID, blockedID uint) error {
+	result := r.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Delete(&models.UserBlock{})
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to unblock user")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrCodeNotFound, "block not found")
+	}
+
+	return nil
+}
+
+// IsBlocked checks if either user has blocked the other
+func (r *BlockRepository) IsBlocked(user1ID, user2ID uint) (bool, error) {
+	return isBlockedPair(r.db, user1ID, user2ID)
+}
+
+// HasBlocked checks if blockerID has blocked blockedID
+func (r *BlockRepository) HasBlocked(blockerID, blockedID uint) (bool, error) {
+	var count int64
+	result := r.db.Model(&models.UserBlock{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to check block")
+	}
+
+	return count > 0, nil
+}
+
+// GetBlockedUsers retrieves a page of users blocked by blockerID along with the total count
+func (r *BlockRepository) GetBlockedUsers(blockerID uint, offset, limit int) ([]models.UserBlock, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.UserBlock{}).
+		Where("blocker_id = ?", blockerID).
+		Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrCodeInternalError, "failed to count blocked users")
+	}
+
+	var blocks []models.UserBlock
+	result := r.db.Where("blocker_id = ?", blockerID).
+		Preload("Blocked").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&blocks)
+
+	if result.Error != nil {
+		return nil, 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get blocked users")
+	}
+
+	return blocks, total, nil
+}
+
+// isBlockedPair is shared by repositories that must refuse interaction between blocked users
+func isBlockedPair(db *gorm.DB, user1ID, user2ID uint) (bool, error) {
+	var count int64
+	result := db.Model(&models.UserBlock{}).
+		Where(blockedPairCondition, user1ID, user2ID, user2ID, user1ID).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to check block")
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type BroadcastRepository struct {
+	db *gorm.DB
+}
+
+func NewBroadcastRepository(db *gorm.DB) *BroadcastRepository {
+	return &BroadcastRepository{db: db}
+}
+
+// BroadcastRecipient is the part of a user a broadcast needs
+type BroadcastRecipient struct {
+	ID         uint
+	TelegramID int64
+}
+
+// CreateBroadcast stores a new draft broadcast
+func (r *BroadcastRepository) CreateBroadcast(broadcast *models.Broadcast) error {
+	broadcast.Status = models.BroadcastStatusDraft
+	if err := r.db.Create(broadcast).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create broadcast")
+	}
+	return nil
+}
+
+// GetBroadcastByID retrieves a broadcast
+func (r *BroadcastRepository) GetBroadcastByID(id uint) (*models.Broadcast, error) {
+	var broadcast models.Broadcast
+	result := r.db.First(&broadcast, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeNotFound, "broadcast not found")
+		}
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get broadcast")
+	}
+	return &broadcast, nil
+}
+
+// UpdateDraft saves the content and targeting of a broadcast that hasn't started yet
+func (r *BroadcastRepository) UpdateDraft(broadcast *models.Broadcast) error {
+	result := r.db.Model(&models.Broadcast{}).
+		Where("id = ? AND status = ?", broadcast.ID, models.BroadcastStatusDraft).
+		Updates(map[string]interface{}{
+			"buttons":     broadcast.Buttons,
+			"segment":     broadcast.Segment,
+			"province":    broadcast.Province,
+			"min_level":   broadcast.MinLevel,
+			"max_level":   broadcast.MaxLevel,
+			"active_days": broadcast.ActiveDays,
+			"village_id":  broadcast.VillageID,
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to update broadcast")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrCodeValidationFailed, "broadcast already started")
+	}
+	return nil
+}
+
+// StartBroadcast moves a draft to running and records how many users it will reach.
+// Returns false if it was already started or deleted.
+func (r *BroadcastRepository) StartBroadcast(broadcast *models.Broadcast, progressMessageID int) (bool, error) {
+	now := time.Now()
+	broadcast.StartedAt = &now
+
+	total, err := r.CountRecipients(broadcast)
+	if err != nil {
+		return false, err
+	}
+
+	result := r.db.Model(&models.Broadcast{}).
+		Where("id = ? AND status = ?", broadcast.ID, models.BroadcastStatusDraft).
+		Updates(map[string]interface{}{
+			"status":              models.BroadcastStatusRunning,
+			"total":               total,
+			"progress_message_id": progressMessageID,
+			"started_at":          now,
+		})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to start broadcast")
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	broadcast.Status = models.BroadcastStatusRunning
+	broadcast.Total = total
+	broadcast.ProgressMessageID = progressMessageID
+	return true, nil
+}
+
+// SetStatus moves a broadcast to status if it is currently in one of from.
+// Returns false if it wasn't.
+func (r *BroadcastRepository) SetStatus(id uint, from []string, status string) (bool, error) {
+	updates := map[string]interface{}{"status": status}
+	if status == models.BroadcastStatusCancelled || status == models.BroadcastStatusDone {
+		updates["finished_at"] = time.Now()
+	}
+
+	result := r.db.Model(&models.Broadcast{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to update broadcast status")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SaveProgress records the counters and how far delivery got
+func (r *BroadcastRepository) SaveProgress(broadcast *models.Broadcast) error {
+	result := r.db.Model(&models.Broadcast{}).
+		Where("id = ?", broadcast.ID).
+		Updates(map[string]interface{}{
+			"last_user_id": broadcast.LastUserID,
+			"delivered":    broadcast.Delivered,
+			"failed":       broadcast.Failed,
+			"blocked":      broadcast.Blocked,
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to save broadcast progress")
+	}
+	return nil
+}
+
+// DeleteDraft removes a broadcast that was never started
+func (r *BroadcastRepository) DeleteDraft(id uint) error {
+	result := r.db.Where("id = ? AND status = ?", id, models.BroadcastStatusDraft).Delete(&models.Broadcast{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to delete broadcast")
+	}
+	return nil
+}
+
+// GetRecentBroadcasts retrieves the latest started broadcasts, newest first
+func (r *BroadcastRepository) GetRecentBroadcasts(limit int) ([]models.Broadcast, error) {
+	var broadcasts []models.Broadcast
+	result := r.db.Where("status <> ?", models.BroadcastStatusDraft).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&broadcasts)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get broadcasts")
+	}
+	return broadcasts, nil
+}
+
+// GetRunningBroadcasts retrieves the broadcasts that were delivering when the bot stopped
+func (r *BroadcastRepository) GetRunningBroadcasts() ([]models.Broadcast, error) {
+	var broadcasts []models.Broadcast
+	result := r.db.Where("status = ?", models.BroadcastStatusRunning).
+		Order("id ASC").
+		Find(&broadcasts)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get running broadcasts")
+	}
+	return broadcasts, nil
+}
+
+// CountRecipients counts the users in the broadcast's segment
+func (r *BroadcastRepository) CountRecipients(broadcast *models.Broadcast) (int64, error) {
+	var count int64
+	if err := r.recipients(broadcast).Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, errors.ErrCodeInternalError, "failed to count broadcast recipients")
+	}
+	return count, nil
+}
+
+// GetRecipients retrieves the next users of the broadcast's segment after afterUserID, in ID order
+func (r *BroadcastRepository) GetRecipients(broadcast *models.Broadcast, afterUserID uint, limit int) ([]BroadcastRecipient, error) {
+	var recipients []BroadcastRecipient
+	err := r.recipients(broadcast).
+		Select("users.id", "users.telegram_id").
+		Where("users.id > ?", afterUserID).
+		Order("users.id ASC").
+		Limit(limit).
+		Scan(&recipients).Error
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to get broadcast recipients")
+	}
+	return recipients, nil
+}
+
+// recipients selects the users a broadcast goes to. Users who blocked the bot or are
+// banned from all of it are left out.
+func (r *BroadcastRepository) recipients(broadcast *models.Broadcast) *gorm.DB {
+	query := r.db.Model(&models.User{}).
+		Where("users.unreachable_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM user_bans WHERE user_bans.user_id = users.id AND user_bans.scope = ? AND ("+activeBanCondition+"))",
+			models.BanScopeGlobal, time.Now())
+
+	switch broadcast.Segment {
+	case models.BroadcastSegmentProvince:
+		query = query.Where("users.province = ?", broadcast.Province)
+	case models.BroadcastSegmentLevel:
+		query = query.Where("users.level BETWEEN ? AND ?", broadcast.MinLevel, broadcast.MaxLevel)
+	case models.BroadcastSegmentActive:
+		query = query.Where("users.last_activity >= ?", broadcast.ActiveSince(time.Now()))
+	case models.BroadcastSegmentVillage:
+		query = query.Where("users.id IN (?)",
+			r.db.Model(&models.VillageMember{}).Select("user_id").Where("village_id = ?", broadcast.VillageID))
+	}
+
+	return query
+}
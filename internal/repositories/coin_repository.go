@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/mroshb/game_bot/internal/metrics"
 	"github.com/mroshb/game_bot/internal/models"
 	"github.com/mroshb/game_bot/pkg/errors"
 	"gorm.io/gorm"
@@ -17,75 +21,161 @@ func NewCoinRepository(db *gorm.DB) *CoinRepository {
 	return &CoinRepository{db: db}
 }
 
-// DeductCoins deducts coins from user's balance with transaction logging
-func (r *CoinRepository) DeductCoins(userID uint, amount int64, txType, description string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Get current balance
-		var user models.User
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return errors.New(errors.ErrCodeNotFound, "user not found")
-			}
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
-		}
+// DeductCoins deducts coins from user's balance with transaction logging.
+// A non-empty idempotencyKey makes the deduction happen at most once; repeating it
+// returns ErrCodeDuplicateRequest and leaves the balance alone.
+func (r *CoinRepository) DeductCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return coinTransaction(r.db, func(tx *gorm.DB) error {
+		return deductCoinsTx(tx, userID, amount, txType, description, idempotencyKey)
+	})
+}
 
-		// Check sufficient balance
-		if user.CoinBalance < amount {
-			return errors.New(errors.ErrCodeInsufficientFunds, fmt.Sprintf("insufficient coins: have %d, need %d", user.CoinBalance, amount))
-		}
+// AddCoins adds coins to user's balance with transaction logging.
+// idempotencyKey works as in DeductCoins.
+func (r *CoinRepository) AddCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return coinTransaction(r.db, func(tx *gorm.DB) error {
+		return addCoinsTx(tx, userID, amount, txType, description, idempotencyKey)
+	})
+}
 
-		// Update balance
-		newBalance := user.CoinBalance - amount
-		if err := tx.Model(&user).Update("coin_balance", newBalance).Error; err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update balance")
+// deductCoinsTx deducts coins inside an existing transaction, locking the user row
+func deductCoinsTx(tx *gorm.DB, userID uint, amount int64, txType, description, idempotencyKey string) error {
+	// Get current balance
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrCodeNotFound, "user not found")
 		}
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
+	}
 
-		// Create transaction record (negative amount for deduction)
-		transaction := &models.CoinTransaction{
-			UserID:          userID,
-			Amount:          -amount,
-			TransactionType: txType,
-			Description:     description,
-		}
-		if err := tx.Create(transaction).Error; err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create transaction")
+	if err := checkCoinKeyTx(tx, idempotencyKey); err != nil {
+		return err
+	}
+
+	// Check sufficient balance
+	if user.CoinBalance < amount {
+		return errors.New(errors.ErrCodeInsufficientFunds, fmt.Sprintf("insufficient coins: have %d, need %d", user.CoinBalance, amount))
+	}
+
+	// Update balance
+	newBalance := user.CoinBalance - amount
+	if err := tx.Model(&user).Update("coin_balance", newBalance).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update balance")
+	}
+
+	// Create transaction record (negative amount for deduction)
+	transaction := &models.CoinTransaction{
+		UserID:          userID,
+		Amount:          -amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	}
+	if err := createCoinTx(tx, transaction); err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create transaction")
+	}
+
+	return nil
+}
+
+// addCoinsTx adds coins inside an existing transaction, locking the user row
+func addCoinsTx(tx *gorm.DB, userID uint, amount int64, txType, description, idempotencyKey string) error {
+	// Get current balance
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrCodeNotFound, "user not found")
 		}
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
+	}
+
+	if err := checkCoinKeyTx(tx, idempotencyKey); err != nil {
+		return err
+	}
+
+	// Update balance
+	newBalance := user.CoinBalance + amount
+	if err := tx.Model(&user).Update("coin_balance", newBalance).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update balance")
+	}
+
+	// Create transaction record (positive amount for addition)
+	transaction := &models.CoinTransaction{
+		UserID:          userID,
+		Amount:          amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	}
+	if err := createCoinTx(tx, transaction); err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create transaction")
+	}
+
+	return nil
+}
 
+// checkCoinKeyTx fails with ErrCodeDuplicateRequest when an operation with the key was already
+// recorded. The unique index on idempotency_key backs this up against concurrent replays.
+func checkCoinKeyTx(tx *gorm.DB, idempotencyKey string) error {
+	if idempotencyKey == "" {
 		return nil
-	})
+	}
+
+	var count int64
+	if err := tx.Model(&models.CoinTransaction{}).Where("idempotency_key = ?", idempotencyKey).Count(&count).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to check idempotency key")
+	}
+	if count > 0 {
+		return errors.New(errors.ErrCodeDuplicateRequest, "coin operation already applied: "+idempotencyKey)
+	}
+
+	return nil
 }
 
-// AddCoins adds coins to user's balance with transaction logging
-func (r *CoinRepository) AddCoins(userID uint, amount int64, txType, description string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Get current balance
-		var user models.User
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return errors.New(errors.ErrCodeNotFound, "user not found")
-			}
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
-		}
+// coinMovesKey carries the *coinMoves of a database transaction in its context
+type coinMovesKey struct{}
 
-		// Update balance
-		newBalance := user.CoinBalance + amount
-		if err := tx.Model(&user).Update("coin_balance", newBalance).Error; err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update balance")
-		}
+// coinMoves are the ledger entries a database transaction wrote, counted in the
+// metrics only once it commits
+type coinMoves []models.CoinTransaction
 
-		// Create transaction record (positive amount for addition)
-		transaction := &models.CoinTransaction{
-			UserID:          userID,
-			Amount:          amount,
-			TransactionType: txType,
-			Description:     description,
-		}
-		if err := tx.Create(transaction).Error; err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create transaction")
-		}
+// coinTransaction runs fn in a database transaction like gorm.DB.Transaction and
+// counts the coins it minted and burned after it commits
+func coinTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	moves := &coinMoves{}
+	ctx := context.WithValue(db.Statement.Context, coinMovesKey{}, moves)
+	if err := db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+
+	for _, t := range *moves {
+		metrics.RecordCoins(t.TransactionType, t.Amount)
+	}
+	return nil
+}
+
+// createCoinTx writes a ledger entry. Entries written outside coinTransaction are
+// counted in the metrics right away.
+func createCoinTx(tx *gorm.DB, transaction *models.CoinTransaction) error {
+	if err := tx.Create(transaction).Error; err != nil {
+		return err
+	}
 
+	if moves, ok := tx.Statement.Context.Value(coinMovesKey{}).(*coinMoves); ok {
+		*moves = append(*moves, *transaction)
+	} else {
+		metrics.RecordCoins(transaction.TransactionType, transaction.Amount)
+	}
+	return nil
+}
+
+// coinKey stores an empty key as NULL so unkeyed transactions don't collide
+func coinKey(idempotencyKey string) *string {
+	if idempotencyKey == "" {
 		return nil
-	})
+	}
+	return &idempotencyKey
 }
 
 // GetBalance retrieves user's current coin balance
@@ -126,3 +216,238 @@ func (r *CoinRepository) HasSufficientBalance(userID uint, amount int64) (bool,
 	}
 	return balance >= amount, nil
 }
+
+// EscrowBet takes the same stake from every player of a game and holds it in escrow.
+// Either all stakes are taken or none; calling it again for the same game is a no-op.
+func (r *CoinRepository) EscrowBet(gameType string, gameID uint, userIDs []uint, amount int64) error {
+	if amount <= 0 {
+		return errors.New(errors.ErrCodeValidationFailed, "bet amount must be positive")
+	}
+
+	return coinTransaction(r.db, func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&models.BetEscrow{}).
+			Where("game_type = ? AND game_id = ?", gameType, gameID).
+			Count(&existing).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to check escrow")
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		// Lock users in a stable order to avoid deadlocks between concurrent escrows
+		sorted := append([]uint(nil), userIDs...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		description := fmt.Sprintf("Bet stake for %s game #%d", gameType, gameID)
+		for _, userID := range sorted {
+			key := models.CoinOpKey(models.TxTypeBetEscrow, gameType, gameID, userID)
+			if err := deductCoinsTx(tx, userID, amount, models.TxTypeBetEscrow, description, key); err != nil {
+				return err
+			}
+
+			escrow := &models.BetEscrow{
+				GameType: gameType,
+				GameID:   gameID,
+				UserID:   userID,
+				Amount:   amount,
+				Status:   models.BetStatusHeld,
+			}
+			if err := tx.Create(escrow).Error; err != nil {
+				return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create escrow")
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBetEscrows retrieves all escrow entries of a game
+func (r *CoinRepository) GetBetEscrows(gameType string, gameID uint) ([]models.BetEscrow, error) {
+	var escrows []models.BetEscrow
+	result := r.db.Where("game_type = ? AND game_id = ?", gameType, gameID).
+		Order("user_id ASC").
+		Find(&escrows)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get escrows")
+	}
+
+	return escrows, nil
+}
+
+// SettleBet pays the escrowed pot of a game to the winner, or splits it evenly when winnerID is 0.
+// Returns the settled escrows; an already settled game returns an empty slice.
+func (r *CoinRepository) SettleBet(gameType string, gameID, winnerID uint) ([]models.BetEscrow, error) {
+	return r.releaseEscrows(gameType, gameID, func(escrows []models.BetEscrow) {
+		var pot int64
+		hasWinner := false
+		for _, e := range escrows {
+			pot += e.Amount
+			if e.UserID == winnerID {
+				hasWinner = true
+			}
+		}
+
+		if winnerID != 0 && hasWinner {
+			for i := range escrows {
+				if escrows[i].UserID == winnerID {
+					escrows[i].Status = models.BetStatusPaid
+					escrows[i].Payout = pot
+				} else {
+					escrows[i].Status = models.BetStatusLost
+				}
+			}
+			return
+		}
+
+		// Draw: split the pot, any odd coin goes to the first player
+		share := pot / int64(len(escrows))
+		for i := range escrows {
+			escrows[i].Status = models.BetStatusSplit
+			escrows[i].Payout = share
+		}
+		escrows[0].Payout += pot - share*int64(len(escrows))
+	})
+}
+
+// RefundBet returns every held stake of a game to its owner
+func (r *CoinRepository) RefundBet(gameType string, gameID uint) ([]models.BetEscrow, error) {
+	return r.releaseEscrows(gameType, gameID, func(escrows []models.BetEscrow) {
+		for i := range escrows {
+			escrows[i].Status = models.BetStatusRefunded
+			escrows[i].Payout = escrows[i].Amount
+		}
+	})
+}
+
+// releaseEscrows locks the held escrows of a game, lets decide assign each status and payout, then credits them
+func (r *CoinRepository) releaseEscrows(gameType string, gameID uint, decide func([]models.BetEscrow)) ([]models.BetEscrow, error) {
+	var settled []models.BetEscrow
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		var escrows []models.BetEscrow
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("game_type = ? AND game_id = ? AND status = ?", gameType, gameID, models.BetStatusHeld).
+			Order("user_id ASC").
+			Find(&escrows).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to lock escrows")
+		}
+
+		// Nothing held: no bet on this game or it was already settled
+		if len(escrows) == 0 {
+			return nil
+		}
+		decide(escrows)
+
+		now := time.Now()
+		for _, e := range escrows {
+			if e.Payout > 0 {
+				txType := models.TxTypeBetPayout
+				if e.Status == models.BetStatusRefunded {
+					txType = models.TxTypeBetRefund
+				}
+				description := fmt.Sprintf("Bet %s for %s game #%d", e.Status, gameType, gameID)
+				key := models.CoinOpKey(txType, gameType, gameID, e.UserID)
+				if err := addCoinsTx(tx, e.UserID, e.Payout, txType, description, key); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(&models.BetEscrow{}).
+				Where("id = ?", e.ID).
+				Updates(map[string]interface{}{
+					"status":     e.Status,
+					"payout":     e.Payout,
+					"settled_at": now,
+				}).Error; err != nil {
+				return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update escrow")
+			}
+		}
+
+		settled = escrows
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return settled, nil
+}
+
+// FindLedgerMismatches lists users whose coin balance differs from the sum of their
+// transactions (opening balance included). userID limits the check to one user, 0 checks everyone.
+func (r *CoinRepository) FindLedgerMismatches(userID uint) ([]models.LedgerMismatch, error) {
+	var mismatches []models.LedgerMismatch
+	query := r.db.Table("users u").
+		Select("u.id AS user_id, u.coin_balance AS balance, COALESCE(SUM(t.amount), 0) AS ledger_sum").
+		Joins("LEFT JOIN coin_transactions t ON t.user_id = u.id").
+		Group("u.id, u.coin_balance").
+		Having("u.coin_balance <> COALESCE(SUM(t.amount), 0)").
+		Order("u.id ASC")
+	if userID != 0 {
+		query = query.Where("u.id = ?", userID)
+	}
+
+	if err := query.Scan(&mismatches).Error; err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to reconcile coin ledger")
+	}
+
+	return mismatches, nil
+}
+
+// ReconcileUser writes an admin adjustment that brings the user's ledger in line with
+// their balance. The check is repeated under the user row lock, so a balance that moved
+// since FindLedgerMismatches is handled correctly. The adjustment is keyed by runID and
+// the user, so repeating a run corrects each user at most once; a user the run already
+// corrected fails with ErrCodeDuplicateRequest. Returns nil if nothing was written.
+func (r *CoinRepository) ReconcileUser(userID uint, runID, description string) (*models.LedgerMismatch, error) {
+	var corrected *models.LedgerMismatch
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New(errors.ErrCodeNotFound, "user not found")
+			}
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
+		}
+
+		key := models.CoinOpKey(models.TxTypeAdminAdjustment, "reconcile", runID, userID)
+		if err := checkCoinKeyTx(tx, key); err != nil {
+			return err
+		}
+
+		var sum int64
+		if err := tx.Model(&models.CoinTransaction{}).Where("user_id = ?", userID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&sum).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to sum transactions")
+		}
+
+		mismatch := models.LedgerMismatch{UserID: userID, Balance: user.CoinBalance, LedgerSum: sum}
+		if mismatch.Drift() == 0 {
+			return nil
+		}
+
+		transaction := &models.CoinTransaction{
+			UserID:          userID,
+			Amount:          mismatch.Drift(),
+			TransactionType: models.TxTypeAdminAdjustment,
+			Description:     description,
+			IdempotencyKey:  coinKey(key),
+		}
+		if err := createCoinTx(tx, transaction); err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create transaction")
+		}
+
+		corrected = &mismatch
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return corrected, nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DiamondRepository struct {
+	db *gorm.DB
+}
+
+func NewDiamondRepository(db *gorm.DB) *DiamondRepository {
+	return &DiamondRepository{db: db}
+}
+
+// AddDiamonds adds diamonds to user's balance with transaction logging.
+// A non-empty idempotencyKey that was already used fails with ErrCodeDuplicateRequest.
+func (r *DiamondRepository) AddDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return addDiamondsTx(tx, userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// DeductDiamonds deducts diamonds from user's balance with transaction logging.
+// A non-empty idempotencyKey that was already used fails with ErrCodeDuplicateRequest.
+func (r *DiamondRepository) DeductDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return deductDiamondsTx(tx, userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// ExchangeForCoins swaps diamonds for coins at the given rate in one transaction.
+// Returns the number of coins credited. A replayed exchange, found by its idempotencyKey,
+// fails with ErrCodeDuplicateRequest and leaves the diamonds alone.
+func (r *DiamondRepository) ExchangeForCoins(userID uint, diamonds, coinsPerDiamond int64, idempotencyKey string) (int64, error) {
+	if diamonds <= 0 || coinsPerDiamond <= 0 {
+		return 0, errors.New(errors.ErrCodeValidation, "invalid exchange amount")
+	}
+
+	coins := diamonds * coinsPerDiamond
+	description := fmt.Sprintf("تبدیل %d الماس به %d سکه", diamonds, coins)
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		if err := deductDiamondsTx(tx, userID, diamonds, models.TxTypeDiamondExchange, description, ""); err != nil {
+			return err
+		}
+		return addCoinsTx(tx, userID, coins, models.TxTypeDiamondExchange, description, idempotencyKey)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return coins, nil
+}
+
+// GetTransactionHistory retrieves user's diamond transaction history
+func (r *DiamondRepository) GetTransactionHistory(userID uint, limit int) ([]models.DiamondTransaction, error) {
+	var transactions []models.DiamondTransaction
+	result := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&transactions)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get diamond transaction history")
+	}
+
+	return transactions, nil
+}
+
+// deductDiamondsTx deducts diamonds inside an existing transaction, locking the user row
+func deductDiamondsTx(tx *gorm.DB, userID uint, amount int64, txType, description, idempotencyKey string) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrCodeNotFound, "user not found")
+		}
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
+	}
+
+	if err := checkDiamondKeyTx(tx, idempotencyKey); err != nil {
+		return err
+	}
+
+	if user.Diamonds < amount {
+		return errors.New(errors.ErrCodeInsufficientFunds, fmt.Sprintf("insufficient diamonds: have %d, need %d", user.Diamonds, amount))
+	}
+
+	if err := tx.Model(&user).Update("diamonds", user.Diamonds-amount).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update diamonds")
+	}
+
+	transaction := &models.DiamondTransaction{
+		UserID:          userID,
+		Amount:          -amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	}
+	if err := tx.Create(transaction).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create diamond transaction")
+	}
+
+	return nil
+}
+
+// addDiamondsTx adds diamonds inside an existing transaction, locking the user row
+func addDiamondsTx(tx *gorm.DB, userID uint, amount int64, txType, description, idempotencyKey string) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrCodeNotFound, "user not found")
+		}
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get user")
+	}
+
+	if err := checkDiamondKeyTx(tx, idempotencyKey); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&user).Update("diamonds", user.Diamonds+amount).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update diamonds")
+	}
+
+	transaction := &models.DiamondTransaction{
+		UserID:          userID,
+		Amount:          amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	}
+	if err := tx.Create(transaction).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create diamond transaction")
+	}
+
+	return nil
+}
+
+// checkDiamondKeyTx fails with ErrCodeDuplicateRequest when an operation with the key was already recorded
+func checkDiamondKeyTx(tx *gorm.DB, idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.DiamondTransaction{}).Where("idempotency_key = ?", idempotencyKey).Count(&count).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to check idempotency key")
+	}
+	if count > 0 {
+		return errors.New(errors.ErrCodeDuplicateRequest, "diamond operation already applied: "+idempotencyKey)
+	}
+
+	return nil
+}
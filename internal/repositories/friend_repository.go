@@ -16,6 +16,15 @@ func NewFriendRepository(db *gorm.DB) *FriendRepository {
 
 // SendFriendRequest creates a new friend request
 func (r *FriendRepository) SendFriendRequest(requesterID, addresseeID uint) error {
+	// Blocked pairs can't send each other requests
+	blocked, err := isBlockedPair(r.db, requesterID, addresseeID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errors.New(errors.ErrCodeForbidden, "user is blocked")
+	}
+
 	// Check if already friends or request exists
 	var existing models.Friendship
 	result := r.db.Where(
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"encoding/json"
+
 	"github.com/mroshb/game_bot/internal/models"
 	"github.com/mroshb/game_bot/pkg/errors"
 	"gorm.io/gorm"
@@ -164,6 +166,30 @@ func (r *GameRepository) IncrementScore(gameSessionID, userID uint, points int)
 	return nil
 }
 
+// RecordAnswer appends an answer to a participant's answer log and adds its points
+func (r *GameRepository) RecordAnswer(gameSessionID, userID uint, answer models.GroupQuizAnswer) error {
+	answerJSON, err := json.Marshal([]models.GroupQuizAnswer{answer})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to encode answer")
+	}
+
+	result := r.db.Model(&models.GameParticipant{}).
+		Where("game_session_id = ? AND user_id = ?", gameSessionID, userID).
+		Updates(map[string]interface{}{
+			"answers": gorm.Expr("COALESCE(answers, '[]'::jsonb) || ?::jsonb", string(answerJSON)),
+			"score":   gorm.Expr("score + ?", answer.Points),
+		})
+
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to record answer")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrCodeNotFound, "participant not found")
+	}
+
+	return nil
+}
+
 // EndGame ends a game session
 func (r *GameRepository) EndGame(gameSessionID uint) error {
 	result := r.db.Model(&models.GameSession{}).
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetItems returns the user's usable items, skipping used up and expired stacks
+func (r *InventoryRepository) GetItems(userID uint) ([]models.InventoryItem, error) {
+	var items []models.InventoryItem
+	result := r.db.Where("user_id = ? AND quantity > 0 AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("item_type ASC").
+		Find(&items)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get inventory")
+	}
+
+	return items, nil
+}
+
+// GetQuantity returns how many usable items of a type the user holds
+func (r *InventoryRepository) GetQuantity(userID uint, itemType string) (int, error) {
+	var item models.InventoryItem
+	result := r.db.Where("user_id = ? AND item_type = ?", userID, itemType).First(&item)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get inventory item")
+	}
+
+	if !item.IsActive(time.Now()) {
+		return 0, nil
+	}
+	return item.Quantity, nil
+}
+
+// Grant adds items to the user's inventory
+func (r *InventoryRepository) Grant(userID uint, itemType string, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return grantItemTx(tx, userID, itemType, quantity)
+	})
+}
+
+// Consume takes items from the user's inventory, failing if they don't hold enough
+func (r *InventoryRepository) Consume(userID uint, itemType string, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return consumeItemTx(tx, userID, itemType, quantity)
+	})
+}
+
+// grantItemTx adds items inside an existing transaction. Expired stacks start
+// over from zero; expiring items get a fresh expiry (non-stackable ones are extended).
+func grantItemTx(tx *gorm.DB, userID uint, itemType string, quantity int) error {
+	def := models.GetItemDef(itemType)
+	if def == nil {
+		return errors.New(errors.ErrCodeValidation, "unknown item type: "+itemType)
+	}
+	if quantity <= 0 {
+		return errors.New(errors.ErrCodeValidation, "quantity must be positive")
+	}
+
+	item, err := lockItemTx(tx, userID, itemType, true)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !item.IsActive(now) {
+		item.Quantity = 0
+		item.ExpiresAt = nil
+	}
+
+	if def.Stackable {
+		item.Quantity += quantity
+	} else {
+		if item.Quantity > 0 && def.Expiry == 0 {
+			return errors.New(errors.ErrCodeAlreadyExists, "item already owned")
+		}
+		item.Quantity = 1
+	}
+
+	if def.Expiry > 0 {
+		base := now
+		if !def.Stackable && item.ExpiresAt != nil && item.ExpiresAt.After(now) {
+			base = *item.ExpiresAt
+		}
+		expiresAt := base.Add(def.Expiry)
+		item.ExpiresAt = &expiresAt
+	}
+
+	if err := tx.Model(item).Updates(map[string]interface{}{
+		"quantity":   item.Quantity,
+		"expires_at": item.ExpiresAt,
+	}).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to grant item")
+	}
+
+	return nil
+}
+
+// consumeItemTx takes items inside an existing transaction
+func consumeItemTx(tx *gorm.DB, userID uint, itemType string, quantity int) error {
+	if quantity <= 0 {
+		return errors.New(errors.ErrCodeValidation, "quantity must be positive")
+	}
+
+	item, err := lockItemTx(tx, userID, itemType, false)
+	if err != nil {
+		return err
+	}
+
+	if !item.IsActive(time.Now()) || item.Quantity < quantity {
+		return errors.New(errors.ErrCodeNotFound, "insufficient item quantity")
+	}
+
+	if err := tx.Model(item).Update("quantity", gorm.Expr("quantity - ?", quantity)).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to consume item")
+	}
+
+	return nil
+}
+
+// lockItemTx loads the user's stack of an item type FOR UPDATE,
+// creating an empty one first when create is set
+func lockItemTx(tx *gorm.DB, userID uint, itemType string, create bool) (*models.InventoryItem, error) {
+	if create {
+		empty := &models.InventoryItem{UserID: userID, ItemType: itemType}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(empty).Error; err != nil {
+			return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to create inventory item")
+		}
+	}
+
+	var item models.InventoryItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND item_type = ?", userID, itemType).
+		First(&item).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.New(errors.ErrCodeNotFound, "insufficient item quantity")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to get inventory item")
+	}
+
+	return &item, nil
+}
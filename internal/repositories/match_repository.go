@@ -69,6 +69,15 @@ func (r *MatchRepository) FindMatch(userID uint, filters *models.MatchFilters) (
 		query = query.Where("matchmaking_queue.game_type = ?", models.GameTypeChat)
 	}
 
+	// Never pair users who blocked each other
+	query = query.Where("NOT EXISTS (SELECT 1 FROM user_blocks WHERE (user_blocks.blocker_id = ? AND user_blocks.blocked_id = users.id) OR (user_blocks.blocker_id = users.id AND user_blocks.blocked_id = ?))", userID, userID)
+
+	// Skip anyone who has the bot blocked; they couldn't be told about the match
+	query = query.Where("users.unreachable_at IS NULL")
+
+	// Only pair players who chose the same stake
+	query = query.Where("matchmaking_queue.bet_amount = ?", filters.BetAmount)
+
 	// Apply filters
 	if filters.Gender != "" && filters.Gender != models.RequestedGenderAny {
 		query = query.Where("users.gender = ?", filters.Gender)
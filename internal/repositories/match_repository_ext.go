@@ -18,3 +18,36 @@ func (r *MatchRepository) GetMatchByID(id uint) (*models.MatchSession, error) {
 	}
 	return &session, nil
 }
+
+// CountActiveMatches counts anonymous chats that are still running
+func (r *MatchRepository) CountActiveMatches() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.MatchSession{}).
+		Where("status = ?", models.MatchStatusActive).
+		Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count active matches")
+	}
+	return count, nil
+}
+
+// GetQueueSizes counts waiting users per game type in the matchmaking queue
+func (r *MatchRepository) GetQueueSizes() (map[string]int64, error) {
+	var rows []struct {
+		GameType string
+		Count    int64
+	}
+	result := r.db.Model(&models.MatchmakingQueue{}).
+		Select("game_type, COUNT(*) AS count").
+		Group("game_type").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count queue")
+	}
+
+	sizes := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		sizes[row.GameType] = row.Count
+	}
+	return sizes, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type BanRepository struct {
+	db *DB
+}
+
+func NewBanRepository(db *DB) *BanRepository {
+	return &BanRepository{db: db}
+}
+
+// activeBan matches bans that have not expired yet
+func activeBan(b *models.UserBan, now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
+
+// BanUser bans a user from a scope. A zero duration bans permanently.
+func (r *BanRepository) BanUser(userID uint, scope string, duration time.Duration, reason string, issuedByTgID int64) (*models.UserBan, error) {
+	if !models.IsValidBanScope(scope) {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "invalid ban scope")
+	}
+
+	ban := &models.UserBan{
+		UserID:       userID,
+		Scope:        scope,
+		Reason:       reason,
+		IssuedByTgID: issuedByTgID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.bans.insert(ban)
+	return ban, nil
+}
+
+// GetActiveBan returns the longest running ban that keeps the user out of scope.
+// A global ban applies to every scope. Returns nil if the user is not banned.
+func (r *BanRepository) GetActiveBan(userID uint, scope string) (*models.UserBan, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	bans := r.db.bans.find(func(b *models.UserBan) bool {
+		return b.UserID == userID && (b.Scope == scope || b.Scope == models.BanScopeGlobal) && activeBan(b, now)
+	})
+	if len(bans) == 0 {
+		return nil, nil
+	}
+
+	// expires_at DESC NULLS FIRST
+	sort.SliceStable(bans, func(i, j int) bool {
+		if bans[i].ExpiresAt == nil || bans[j].ExpiresAt == nil {
+			return bans[i].ExpiresAt == nil && bans[j].ExpiresAt != nil
+		}
+		return bans[i].ExpiresAt.After(*bans[j].ExpiresAt)
+	})
+	return &bans[0], nil
+}
+
+// GetActiveBans lists every running ban of a user
+func (r *BanRepository) GetActiveBans(userID uint) ([]models.UserBan, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	bans := r.db.bans.find(func(b *models.UserBan) bool { return b.UserID == userID && activeBan(b, now) })
+	sort.SliceStable(bans, func(i, j int) bool { return bans[i].ID > bans[j].ID })
+	return bans, nil
+}
+
+// LiftBans removes a user's running bans in a scope (or all scopes if scope is empty)
+func (r *BanRepository) LiftBans(userID uint, scope string) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.bans.deleteWhere(func(b *models.UserBan) bool {
+		return b.UserID == userID && activeBan(b, now) && (scope == "" || b.Scope == scope)
+	}), nil
+}
+
+// DeleteExpiredBans removes bans whose expiry has passed
+func (r *BanRepository) DeleteExpiredBans() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.bans.deleteWhere(func(b *models.UserBan) bool { return !activeBan(b, now) }), nil
+}
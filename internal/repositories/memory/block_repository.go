@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type BlockRepository struct {
+	db *DB
+}
+
+func NewBlockRepository(db *DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// BlockUser blocks a user and drops any friendship or pending request between the pair
+func (r *BlockRepository) BlockUser(blockerID, blockedID uint) error {
+	if blockerID == blockedID {
+		return errors.New(errors.ErrCodeValidationFailed, "cannot block yourself")
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.blocks.count(func(b *models.UserBlock) bool {
+		return b.BlockerID == blockerID && b.BlockedID == blockedID
+	}) > 0 {
+		return errors.New(errors.ErrCodeAlreadyExists, "user already blocked")
+	}
+
+	r.db.blocks.insert(&models.UserBlock{BlockerID: blockerID, BlockedID: blockedID})
+	r.db.friendships.deleteWhere(func(f *models.Friendship) bool { return isPair(f.RequesterID, f.AddresseeID, blockerID, blockedID) })
+	return nil
+}
+
+// UnblockUser removes a block
+func (r *BlockRepository) UnblockUser(blockerID, blockedID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.blocks.deleteWhere(func(b *models.UserBlock) bool {
+		return b.BlockerID == blockerID && b.BlockedID == blockedID
+	}) == 0 {
+		return errors.New(errors.ErrCodeNotFound, "block not found")
+	}
+	return nil
+}
+
+// IsBlocked checks if either user has blocked the other
+func (r *BlockRepository) IsBlocked(user1ID, user2ID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.isBlockedPair(user1ID, user2ID), nil
+}
+
+// HasBlocked checks if blockerID has blocked blockedID
+func (r *BlockRepository) HasBlocked(blockerID, blockedID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.blocks.count(func(b *models.UserBlock) bool {
+		return b.BlockerID == blockerID && b.BlockedID == blockedID
+	}) > 0, nil
+}
+
+// GetBlockedUsers retrieves a page of users blocked by blockerID along with the total count
+func (r *BlockRepository) GetBlockedUsers(blockerID uint, offset, limit int) ([]models.UserBlock, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	blocks := r.db.blocks.find(func(b *models.UserBlock) bool { return b.BlockerID == blockerID })
+	total := int64(len(blocks))
+
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].ID > blocks[j].ID })
+	blocks = page(blocks, offset, limit)
+	for i := range blocks {
+		blocks[i].Blocked, _ = r.db.users.get(blocks[i].BlockedID)
+	}
+	return blocks, total, nil
+}
+
+// isBlockedPair is shared by repositories that must refuse interaction between blocked users
+func (db *DB) isBlockedPair(user1ID, user2ID uint) bool {
+	return db.blocks.count(func(b *models.UserBlock) bool {
+		return isPair(b.BlockerID, b.BlockedID, user1ID, user2ID)
+	}) > 0
+}
+
+// isPair reports whether a and b are the users x and y in either order
+func isPair(a, b, x, y uint) bool {
+	return (a == x && b == y) || (a == y && b == x)
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type BroadcastRepository struct {
+	db *DB
+}
+
+func NewBroadcastRepository(db *DB) *BroadcastRepository {
+	return &BroadcastRepository{db: db}
+}
+
+// CreateBroadcast stores a new draft broadcast
+func (r *BroadcastRepository) CreateBroadcast(broadcast *models.Broadcast) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	broadcast.Status = models.BroadcastStatusDraft
+	r.db.broadcasts.insert(broadcast)
+	return nil
+}
+
+// GetBroadcastByID retrieves a broadcast
+func (r *BroadcastRepository) GetBroadcastByID(id uint) (*models.Broadcast, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	broadcast, ok := r.db.broadcasts.get(id)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "broadcast not found")
+	}
+	return &broadcast, nil
+}
+
+// UpdateDraft saves the content and targeting of a broadcast that hasn't started yet
+func (r *BroadcastRepository) UpdateDraft(broadcast *models.Broadcast) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.broadcasts.updateWhere(func(b *models.Broadcast) bool {
+		return b.ID == broadcast.ID && b.Status == models.BroadcastStatusDraft
+	}, func(b *models.Broadcast) {
+		b.Buttons = broadcast.Buttons
+		b.Segment = broadcast.Segment
+		b.Province = broadcast.Province
+		b.MinLevel = broadcast.MinLevel
+		b.MaxLevel = broadcast.MaxLevel
+		b.ActiveDays = broadcast.ActiveDays
+		b.VillageID = broadcast.VillageID
+	}) == 0 {
+		return errors.New(errors.ErrCodeValidationFailed, "broadcast already started")
+	}
+	return nil
+}
+
+// StartBroadcast moves a draft to running and records how many users it will reach.
+// Returns false if it was already started or deleted.
+func (r *BroadcastRepository) StartBroadcast(broadcast *models.Broadcast, progressMessageID int) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	broadcast.StartedAt = &now
+	total := int64(len(r.db.recipients(broadcast)))
+
+	if r.db.broadcasts.updateWhere(func(b *models.Broadcast) bool {
+		return b.ID == broadcast.ID && b.Status == models.BroadcastStatusDraft
+	}, func(b *models.Broadcast) {
+		b.Status = models.BroadcastStatusRunning
+		b.Total = total
+		b.ProgressMessageID = progressMessageID
+		b.StartedAt = &now
+	}) == 0 {
+		return false, nil
+	}
+
+	broadcast.Status = models.BroadcastStatusRunning
+	broadcast.Total = total
+	broadcast.ProgressMessageID = progressMessageID
+	return true, nil
+}
+
+// SetStatus moves a broadcast to status if it is currently in one of from.
+// Returns false if it wasn't.
+func (r *BroadcastRepository) SetStatus(id uint, from []string, status string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.broadcasts.updateWhere(func(b *models.Broadcast) bool {
+		return b.ID == id && containsString(from, b.Status)
+	}, func(b *models.Broadcast) {
+		b.Status = status
+		if status == models.BroadcastStatusCancelled || status == models.BroadcastStatusDone {
+			b.FinishedAt = &now
+		}
+	}) > 0, nil
+}
+
+// SaveProgress records the counters and how far delivery got
+func (r *BroadcastRepository) SaveProgress(broadcast *models.Broadcast) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.broadcasts.update(broadcast.ID, func(b *models.Broadcast) {
+		b.LastUserID = broadcast.LastUserID
+		b.Delivered = broadcast.Delivered
+		b.Failed = broadcast.Failed
+		b.Blocked = broadcast.Blocked
+	})
+	return nil
+}
+
+// DeleteDraft removes a broadcast that was never started
+func (r *BroadcastRepository) DeleteDraft(id uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.broadcasts.deleteWhere(func(b *models.Broadcast) bool { return b.ID == id && b.Status == models.BroadcastStatusDraft })
+	return nil
+}
+
+// GetRecentBroadcasts retrieves the latest started broadcasts, newest first
+func (r *BroadcastRepository) GetRecentBroadcasts(limit int) ([]models.Broadcast, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	broadcasts := r.db.broadcasts.find(func(b *models.Broadcast) bool { return b.Status != models.BroadcastStatusDraft })
+	sort.SliceStable(broadcasts, func(i, j int) bool { return broadcasts[i].ID > broadcasts[j].ID })
+	return page(broadcasts, 0, limit), nil
+}
+
+// GetRunningBroadcasts retrieves the broadcasts that were delivering when the bot stopped
+func (r *BroadcastRepository) GetRunningBroadcasts() ([]models.Broadcast, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.broadcasts.find(func(b *models.Broadcast) bool { return b.Status == models.BroadcastStatusRunning }), nil
+}
+
+// CountRecipients counts the users in the broadcast's segment
+func (r *BroadcastRepository) CountRecipients(broadcast *models.Broadcast) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return int64(len(r.db.recipients(broadcast))), nil
+}
+
+// GetRecipients retrieves the next users of the broadcast's segment after afterUserID, in ID order
+func (r *BroadcastRepository) GetRecipients(broadcast *models.Broadcast, afterUserID uint, limit int) ([]repositories.BroadcastRecipient, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var recipients []repositories.BroadcastRecipient
+	for _, u := range r.db.recipients(broadcast) {
+		if u.ID > afterUserID {
+			recipients = append(recipients, repositories.BroadcastRecipient{ID: u.ID, TelegramID: u.TelegramID})
+		}
+	}
+	return page(recipients, 0, limit), nil
+}
+
+// recipients lists the users a broadcast goes to in ID order. Users who blocked the bot
+// or are banned from all of it are left out.
+func (db *DB) recipients(broadcast *models.Broadcast) []models.User {
+	now := time.Now()
+
+	var villagers map[uint]bool
+	if broadcast.Segment == models.BroadcastSegmentVillage {
+		villagers = make(map[uint]bool)
+		for _, m := range db.villageMembers.rows {
+			if m.VillageID == broadcast.VillageID {
+				villagers[m.UserID] = true
+			}
+		}
+	}
+
+	return db.users.find(func(u *models.User) bool {
+		if u.UnreachableAt != nil || db.bans.count(func(b *models.UserBan) bool {
+			return b.UserID == u.ID && b.Scope == models.BanScopeGlobal && activeBan(b, now)
+		}) > 0 {
+			return false
+		}
+
+		switch broadcast.Segment {
+		case models.BroadcastSegmentProvince:
+			return u.Province == broadcast.Province
+		case models.BroadcastSegmentLevel:
+			return u.Level >= broadcast.MinLevel && u.Level <= broadcast.MaxLevel
+		case models.BroadcastSegmentActive:
+			return !u.LastActivity.Before(broadcast.ActiveSince(now))
+		case models.BroadcastSegmentVillage:
+			return villagers[u.ID]
+		}
+		return true
+	})
+}
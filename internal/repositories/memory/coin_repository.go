@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type CoinRepository struct {
+	db *DB
+}
+
+func NewCoinRepository(db *DB) *CoinRepository {
+	return &CoinRepository{db: db}
+}
+
+// DeductCoins deducts coins from user's balance with transaction logging.
+// A non-empty idempotencyKey makes the deduction happen at most once; repeating it
+// returns ErrCodeDuplicateRequest and leaves the balance alone.
+func (r *CoinRepository) DeductCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.transaction(func() error {
+		return r.db.deductCoins(userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// AddCoins adds coins to user's balance with transaction logging.
+// idempotencyKey works as in DeductCoins.
+func (r *CoinRepository) AddCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.transaction(func() error {
+		return r.db.addCoins(userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// deductCoins deducts coins inside an existing transaction
+func (db *DB) deductCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	user, ok := db.users.get(userID)
+	if !ok {
+		return errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	if err := db.checkCoinKey(idempotencyKey); err != nil {
+		return err
+	}
+	if user.CoinBalance < amount {
+		return errors.New(errors.ErrCodeInsufficientFunds, fmt.Sprintf("insufficient coins: have %d, need %d", user.CoinBalance, amount))
+	}
+
+	db.users.update(userID, func(u *models.User) { u.CoinBalance -= amount })
+	db.insertCoinTx(&models.CoinTransaction{
+		UserID:          userID,
+		Amount:          -amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	})
+	return nil
+}
+
+// addCoins adds coins inside an existing transaction
+func (db *DB) addCoins(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	if _, ok := db.users.get(userID); !ok {
+		return errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	if err := db.checkCoinKey(idempotencyKey); err != nil {
+		return err
+	}
+
+	db.users.update(userID, func(u *models.User) { u.CoinBalance += amount })
+	db.insertCoinTx(&models.CoinTransaction{
+		UserID:          userID,
+		Amount:          amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	})
+	return nil
+}
+
+// checkCoinKey fails with ErrCodeDuplicateRequest when an operation with the key was already recorded
+func (db *DB) checkCoinKey(idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return nil
+	}
+	if db.coinTxs.count(func(t *models.CoinTransaction) bool {
+		return t.IdempotencyKey != nil && *t.IdempotencyKey == idempotencyKey
+	}) > 0 {
+		return errors.New(errors.ErrCodeDuplicateRequest, "coin operation already applied: "+idempotencyKey)
+	}
+	return nil
+}
+
+// coinKey stores an empty key as NULL so unkeyed transactions don't collide
+func coinKey(idempotencyKey string) *string {
+	if idempotencyKey == "" {
+		return nil
+	}
+	return &idempotencyKey
+}
+
+// GetBalance retrieves user's current coin balance
+func (r *CoinRepository) GetBalance(userID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users.get(userID)
+	if !ok {
+		return 0, errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	return user.CoinBalance, nil
+}
+
+// GetTransactionHistory retrieves user's transaction history
+func (r *CoinRepository) GetTransactionHistory(userID uint, limit int) ([]models.CoinTransaction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	txs := r.db.coinTxs.find(func(t *models.CoinTransaction) bool { return t.UserID == userID })
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].ID > txs[j].ID })
+	return page(txs, 0, limit), nil
+}
+
+// HasSufficientBalance checks if user has enough coins
+func (r *CoinRepository) HasSufficientBalance(userID uint, amount int64) (bool, error) {
+	balance, err := r.GetBalance(userID)
+	if err != nil {
+		return false, err
+	}
+	return balance >= amount, nil
+}
+
+// EscrowBet takes the same stake from every player of a game and holds it in escrow.
+// Either all stakes are taken or none; calling it again for the same game is a no-op.
+func (r *CoinRepository) EscrowBet(gameType string, gameID uint, userIDs []uint, amount int64) error {
+	if amount <= 0 {
+		return errors.New(errors.ErrCodeValidationFailed, "bet amount must be positive")
+	}
+
+	return r.db.transaction(func() error {
+		if r.db.escrows.count(func(e *models.BetEscrow) bool {
+			return e.GameType == gameType && e.GameID == gameID
+		}) > 0 {
+			return nil
+		}
+
+		sorted := append([]uint(nil), userIDs...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		description := fmt.Sprintf("Bet stake for %s game #%d", gameType, gameID)
+		for _, userID := range sorted {
+			key := models.CoinOpKey(models.TxTypeBetEscrow, gameType, gameID, userID)
+			if err := r.db.deductCoins(userID, amount, models.TxTypeBetEscrow, description, key); err != nil {
+				return err
+			}
+			r.db.escrows.insert(&models.BetEscrow{
+				GameType: gameType,
+				GameID:   gameID,
+				UserID:   userID,
+				Amount:   amount,
+				Status:   models.BetStatusHeld,
+			})
+		}
+		return nil
+	})
+}
+
+// GetBetEscrows retrieves all escrow entries of a game
+func (r *CoinRepository) GetBetEscrows(gameType string, gameID uint) ([]models.BetEscrow, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.gameEscrows(gameType, gameID, ""), nil
+}
+
+// gameEscrows lists a game's escrows by user ID, only those in status if it is set
+func (db *DB) gameEscrows(gameType string, gameID uint, status string) []models.BetEscrow {
+	escrows := db.escrows.find(func(e *models.BetEscrow) bool {
+		return e.GameType == gameType && e.GameID == gameID && (status == "" || e.Status == status)
+	})
+	sort.SliceStable(escrows, func(i, j int) bool { return escrows[i].UserID < escrows[j].UserID })
+	return escrows
+}
+
+// SettleBet pays the escrowed pot of a game to the winner, or splits it evenly when winnerID is 0.
+// Returns the settled escrows; an already settled game returns an empty slice.
+func (r *CoinRepository) SettleBet(gameType string, gameID, winnerID uint) ([]models.BetEscrow, error) {
+	return r.releaseEscrows(gameType, gameID, func(escrows []models.BetEscrow) {
+		var pot int64
+		hasWinner := false
+		for _, e := range escrows {
+			pot += e.Amount
+			if e.UserID == winnerID {
+				hasWinner = true
+			}
+		}
+
+		if winnerID != 0 && hasWinner {
+			for i := range escrows {
+				if escrows[i].UserID == winnerID {
+					escrows[i].Status = models.BetStatusPaid
+					escrows[i].Payout = pot
+				} else {
+					escrows[i].Status = models.BetStatusLost
+				}
+			}
+			return
+		}
+
+		share := pot / int64(len(escrows))
+		for i := range escrows {
+			escrows[i].Status = models.BetStatusSplit
+			escrows[i].Payout = share
+		}
+		escrows[0].Payout += pot - share*int64(len(escrows))
+	})
+}
+
+// RefundBet returns every held stake of a game to its owner
+func (r *CoinRepository) RefundBet(gameType string, gameID uint) ([]models.BetEscrow, error) {
+	return r.releaseEscrows(gameType, gameID, func(escrows []models.BetEscrow) {
+		for i := range escrows {
+			escrows[i].Status = models.BetStatusRefunded
+			escrows[i].Payout = escrows[i].Amount
+		}
+	})
+}
+
+// releaseEscrows lets decide assign each held escrow of a game a status and payout, then credits them
+func (r *CoinRepository) releaseEscrows(gameType string, gameID uint, decide func([]models.BetEscrow)) ([]models.BetEscrow, error) {
+	var settled []models.BetEscrow
+
+	err := r.db.transaction(func() error {
+		escrows := r.db.gameEscrows(gameType, gameID, models.BetStatusHeld)
+		if len(escrows) == 0 {
+			return nil
+		}
+		decide(escrows)
+
+		now := time.Now()
+		for _, e := range escrows {
+			if e.Payout > 0 {
+				txType := models.TxTypeBetPayout
+				if e.Status == models.BetStatusRefunded {
+					txType = models.TxTypeBetRefund
+				}
+				description := fmt.Sprintf("Bet %s for %s game #%d", e.Status, gameType, gameID)
+				key := models.CoinOpKey(txType, gameType, gameID, e.UserID)
+				if err := r.db.addCoins(e.UserID, e.Payout, txType, description, key); err != nil {
+					return err
+				}
+			}
+
+			r.db.escrows.update(e.ID, func(stored *models.BetEscrow) {
+				stored.Status = e.Status
+				stored.Payout = e.Payout
+				stored.SettledAt = &now
+			})
+		}
+
+		settled = escrows
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return settled, nil
+}
+
+// FindLedgerMismatches lists users whose coin balance differs from the sum of their
+// transactions. userID limits the check to one user, 0 checks everyone.
+func (r *CoinRepository) FindLedgerMismatches(userID uint) ([]models.LedgerMismatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	sums := make(map[uint]int64)
+	for _, t := range r.db.coinTxs.rows {
+		sums[t.UserID] += t.Amount
+	}
+
+	var mismatches []models.LedgerMismatch
+	for _, u := range r.db.users.find(func(u *models.User) bool { return userID == 0 || u.ID == userID }) {
+		if u.CoinBalance != sums[u.ID] {
+			mismatches = append(mismatches, models.LedgerMismatch{UserID: u.ID, Balance: u.CoinBalance, LedgerSum: sums[u.ID]})
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/metrics"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+func newTestUser(t *testing.T, users *UserRepository, telegramID int64, coins int64) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		TelegramID:  telegramID,
+		FullName:    "Player",
+		Gender:      models.GenderMale,
+		Age:         30,
+		Status:      models.UserStatusOnline,
+		CoinBalance: coins,
+	}
+	if err := users.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	return user
+}
+
+// hasCode reports whether err is an AppError with the given code
+func hasCode(err error, code string) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Code == code
+}
+
+func TestCoinRepository_IdempotencyKey(t *testing.T) {
+	db := NewDB()
+	coins := NewCoinRepository(db)
+	user := newTestUser(t, NewUserRepository(db), 1, 100)
+
+	if err := coins.DeductCoins(user.ID, 30, models.TxTypeMatchmaking, "search", "req-1"); err != nil {
+		t.Fatalf("DeductCoins() error = %v", err)
+	}
+	err := coins.DeductCoins(user.ID, 30, models.TxTypeMatchmaking, "search", "req-1")
+	if !hasCode(err, errors.ErrCodeDuplicateRequest) {
+		t.Errorf("repeated DeductCoins() error = %v, want %s", err, errors.ErrCodeDuplicateRequest)
+	}
+
+	// Unkeyed operations never collide
+	for i := 0; i < 2; i++ {
+		if err := coins.AddCoins(user.ID, 5, models.TxTypeDailyBonus, "bonus", ""); err != nil {
+			t.Fatalf("AddCoins() error = %v", err)
+		}
+	}
+
+	if balance, _ := coins.GetBalance(user.ID); balance != 80 {
+		t.Errorf("GetBalance() = %d, want 80", balance)
+	}
+	if mismatches, _ := coins.FindLedgerMismatches(0); len(mismatches) != 0 {
+		t.Errorf("FindLedgerMismatches() = %v, want none", mismatches)
+	}
+}
+
+func TestCoinRepository_EscrowBetIsAllOrNothing(t *testing.T) {
+	db := NewDB()
+	users := NewUserRepository(db)
+	coins := NewCoinRepository(db)
+	rich := newTestUser(t, users, 1, 100)
+	poor := newTestUser(t, users, 2, 10)
+
+	err := coins.EscrowBet(models.GameTypeQuiz, 7, []uint{rich.ID, poor.ID}, 50)
+	if !hasCode(err, errors.ErrCodeInsufficientFunds) {
+		t.Fatalf("EscrowBet() error = %v, want %s", err, errors.ErrCodeInsufficientFunds)
+	}
+
+	if balance, _ := coins.GetBalance(rich.ID); balance != 100 {
+		t.Errorf("rich balance after a failed escrow = %d, want 100", balance)
+	}
+	if escrows, _ := coins.GetBetEscrows(models.GameTypeQuiz, 7); len(escrows) != 0 {
+		t.Errorf("escrows after a failed escrow = %d, want 0", len(escrows))
+	}
+	if history, _ := coins.GetTransactionHistory(rich.ID, 10); len(history) != 1 {
+		t.Errorf("rich history = %d entries, want only the opening balance", len(history))
+	}
+}
+
+func TestCoinRepository_RefundBetReleasesOnce(t *testing.T) {
+	db := NewDB()
+	users := NewUserRepository(db)
+	coins := NewCoinRepository(db)
+	a := newTestUser(t, users, 1, 100)
+	b := newTestUser(t, users, 2, 100)
+
+	if err := coins.EscrowBet(models.GameTypeTod, 3, []uint{a.ID, b.ID}, 40); err != nil {
+		t.Fatalf("EscrowBet() error = %v", err)
+	}
+
+	released, err := coins.RefundBet(models.GameTypeTod, 3)
+	if err != nil {
+		t.Fatalf("RefundBet() error = %v", err)
+	}
+	if len(released) != 2 {
+		t.Errorf("RefundBet() released %d escrows, want 2", len(released))
+	}
+	if released, _ := coins.RefundBet(models.GameTypeTod, 3); len(released) != 0 {
+		t.Errorf("second RefundBet() released %d escrows, want 0", len(released))
+	}
+
+	for _, u := range []*models.User{a, b} {
+		if balance, _ := coins.GetBalance(u.ID); balance != 100 {
+			t.Errorf("balance of user %d = %d, want 100", u.ID, balance)
+		}
+	}
+}
+
+func TestCoinRepository_MetricsCountCommittedMoves(t *testing.T) {
+	db := NewDB()
+	users := NewUserRepository(db)
+	coins := NewCoinRepository(db)
+	rich := newTestUser(t, users, 1, 100)
+	poor := newTestUser(t, users, 2, 10)
+
+	burned := metrics.CoinsBurned.Value(models.TxTypeBetEscrow)
+	minted := metrics.CoinsMinted.Value(models.TxTypeBetRefund)
+
+	// The rich player's deduction is rolled back with the failed escrow, so it isn't counted
+	if err := coins.EscrowBet(models.GameTypeQuiz, 9, []uint{rich.ID, poor.ID}, 50); err == nil {
+		t.Fatal("EscrowBet() succeeded with an underfunded player")
+	}
+	if got := metrics.CoinsBurned.Value(models.TxTypeBetEscrow) - burned; got != 0 {
+		t.Errorf("coins burned by a failed escrow = %v, want 0", got)
+	}
+
+	if err := coins.EscrowBet(models.GameTypeQuiz, 9, []uint{rich.ID, poor.ID}, 10); err != nil {
+		t.Fatalf("EscrowBet() error = %v", err)
+	}
+	if _, err := coins.RefundBet(models.GameTypeQuiz, 9); err != nil {
+		t.Fatalf("RefundBet() error = %v", err)
+	}
+	if got := metrics.CoinsBurned.Value(models.TxTypeBetEscrow) - burned; got != 20 {
+		t.Errorf("coins burned by the escrow = %v, want 20", got)
+	}
+	if got := metrics.CoinsMinted.Value(models.TxTypeBetRefund) - minted; got != 20 {
+		t.Errorf("coins minted by the refund = %v, want 20", got)
+	}
+}
@@ -0,0 +1,354 @@
+// Package memory implements the repository stores in process memory. Each repository
+// mirrors its GORM counterpart, including column defaults, error codes and the
+// all-or-nothing behaviour of transactions, so handlers and services can be tested
+// without Postgres.
+package memory
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/metrics"
+	"github.com/mroshb/game_bot/internal/models"
+	"gorm.io/gorm/schema"
+)
+
+// DB holds every table. Repositories built on the same DB share its data and lock,
+// like GORM repositories sharing a connection.
+type DB struct {
+	mu     sync.Mutex
+	tables []snapshotter
+
+	// coinMoves are the ledger entries written by the running transaction, counted
+	// in the metrics once it succeeds
+	coinMoves []models.CoinTransaction
+
+	users      *table[models.User]
+	likes      *table[models.UserLike]
+	coinTxs    *table[models.CoinTransaction]
+	diamondTxs *table[models.DiamondTransaction]
+	escrows    *table[models.BetEscrow]
+
+	queue   *table[models.MatchmakingQueue]
+	matches *table[models.MatchSession]
+
+	friendships *table[models.Friendship]
+	blocks      *table[models.UserBlock]
+	reports     *table[models.ChatReport]
+	bans        *table[models.UserBan]
+
+	questions    *table[models.Question]
+	gameSessions *table[models.GameSession]
+	participants *table[models.GameParticipant]
+	rooms        *table[models.Room]
+	roomMembers  *table[models.RoomMember]
+
+	villages       *table[models.Village]
+	villageMembers *table[models.VillageMember]
+
+	quizMatches *table[models.QuizMatch]
+	quizRounds  *table[models.QuizRound]
+	quizAnswers *table[models.QuizAnswer]
+
+	todGames      *table[models.TodGame]
+	todTurns      *table[models.TodTurn]
+	todChallenges *table[models.TodChallenge]
+	todStats      *table[models.TodPlayerStats]
+	todJudgments  *table[models.TodJudgmentLog]
+	todActions    *table[models.TodActionLog]
+
+	inventory     *table[models.InventoryItem]
+	shopPurchases *table[models.ShopPurchase]
+	orders        *table[models.PurchaseOrder]
+	payments      *table[models.TelegramPayment]
+	broadcasts    *table[models.Broadcast]
+}
+
+// NewDB creates an empty database
+func NewDB() *DB {
+	db := &DB{}
+	db.users = newTable[models.User](db)
+	db.likes = newTable[models.UserLike](db)
+	db.coinTxs = newTable[models.CoinTransaction](db)
+	db.diamondTxs = newTable[models.DiamondTransaction](db)
+	db.escrows = newTable[models.BetEscrow](db)
+	db.queue = newTable[models.MatchmakingQueue](db)
+	db.matches = newTable[models.MatchSession](db)
+	db.friendships = newTable[models.Friendship](db)
+	db.blocks = newTable[models.UserBlock](db)
+	db.reports = newTable[models.ChatReport](db)
+	db.bans = newTable[models.UserBan](db)
+	db.questions = newTable[models.Question](db)
+	db.gameSessions = newTable[models.GameSession](db)
+	db.participants = newTable[models.GameParticipant](db)
+	db.rooms = newTable[models.Room](db)
+	db.roomMembers = newTable[models.RoomMember](db)
+	db.villages = newTable[models.Village](db)
+	db.villageMembers = newTable[models.VillageMember](db)
+	db.quizMatches = newTable[models.QuizMatch](db)
+	db.quizRounds = newTable[models.QuizRound](db)
+	db.quizAnswers = newTable[models.QuizAnswer](db)
+	db.todGames = newTable[models.TodGame](db)
+	db.todTurns = newTable[models.TodTurn](db)
+	db.todChallenges = newTable[models.TodChallenge](db)
+	db.todStats = newTable[models.TodPlayerStats](db)
+	db.todJudgments = newTable[models.TodJudgmentLog](db)
+	db.todActions = newTable[models.TodActionLog](db)
+	db.inventory = newTable[models.InventoryItem](db)
+	db.shopPurchases = newTable[models.ShopPurchase](db)
+	db.orders = newTable[models.PurchaseOrder](db)
+	db.payments = newTable[models.TelegramPayment](db)
+	db.broadcasts = newTable[models.Broadcast](db)
+	return db
+}
+
+// SeedQuestions inserts questions the way database.SeedQuestions fills Postgres
+func (db *DB) SeedQuestions(questions ...models.Question) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range questions {
+		db.questions.insert(&questions[i])
+	}
+}
+
+// SeedTodChallenges inserts Truth or Dare challenges
+func (db *DB) SeedTodChallenges(challenges ...models.TodChallenge) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range challenges {
+		db.todChallenges.insert(&challenges[i])
+	}
+}
+
+// transaction runs fn under the lock and undoes every write it made if it fails
+func (db *DB) transaction(fn func() error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	restores := make([]func(), len(db.tables))
+	for i, t := range db.tables {
+		restores[i] = t.snapshot()
+	}
+	db.coinMoves = nil
+
+	if err := fn(); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+
+	for _, t := range db.coinMoves {
+		metrics.RecordCoins(t.TransactionType, t.Amount)
+	}
+	return nil
+}
+
+// insertCoinTx writes a ledger entry inside a transaction
+func (db *DB) insertCoinTx(t *models.CoinTransaction) {
+	db.coinTxs.insert(t)
+	db.coinMoves = append(db.coinMoves, *t)
+}
+
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+var schemaCache sync.Map
+
+// table is the rows of one model keyed by primary key. Callers hold DB.mu.
+type table[T any] struct {
+	rows   map[uint]*T
+	nextID uint
+	schema *schema.Schema
+}
+
+func newTable[T any](db *DB) *table[T] {
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		panic("memory: " + err.Error())
+	}
+
+	t := &table[T]{rows: make(map[uint]*T), schema: s}
+	db.tables = append(db.tables, t)
+	return t
+}
+
+func (t *table[T]) snapshot() func() {
+	rows := make(map[uint]*T, len(t.rows))
+	for id, row := range t.rows {
+		copied := *row
+		rows[id] = &copied
+	}
+	nextID := t.nextID
+	return func() {
+		t.rows = rows
+		t.nextID = nextID
+	}
+}
+
+// insert fills the column defaults and timestamps the way a GORM Create does,
+// assigns the next ID and stores a copy of row without its associations
+func (t *table[T]) insert(row *T) {
+	ctx := context.Background()
+	rv := reflect.ValueOf(row).Elem()
+	now := time.Now()
+
+	for _, field := range t.schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, zero := field.ValueOf(ctx, rv); !zero {
+			continue
+		}
+		switch {
+		case field.DefaultValueInterface != nil:
+			field.Set(ctx, rv, field.DefaultValueInterface)
+		case field.AutoCreateTime > 0 || field.AutoUpdateTime > 0:
+			field.Set(ctx, rv, now)
+		case field.DataType == schema.Time && strings.EqualFold(field.DefaultValue, "CURRENT_TIMESTAMP"):
+			field.Set(ctx, rv, now)
+		}
+	}
+
+	t.nextID++
+	t.schema.PrioritizedPrimaryField.Set(ctx, rv, t.nextID)
+
+	stored := *row
+	t.clearRelations(&stored)
+	t.rows[t.nextID] = &stored
+}
+
+// update changes a stored row and bumps its autoUpdateTime fields. Returns false if there is no such row.
+func (t *table[T]) update(id uint, fn func(row *T)) bool {
+	row, ok := t.rows[id]
+	if !ok {
+		return false
+	}
+	fn(row)
+	t.touch(row)
+	return true
+}
+
+// updateWhere runs update on every row matching where and returns how many there were
+func (t *table[T]) updateWhere(where func(row *T) bool, fn func(row *T)) int64 {
+	var affected int64
+	for _, row := range t.rows {
+		if where(row) {
+			fn(row)
+			t.touch(row)
+			affected++
+		}
+	}
+	return affected
+}
+
+func (t *table[T]) touch(row *T) {
+	ctx := context.Background()
+	rv := reflect.ValueOf(row).Elem()
+	for _, field := range t.schema.Fields {
+		if field.AutoUpdateTime > 0 {
+			field.Set(ctx, rv, time.Now())
+		}
+	}
+}
+
+func (t *table[T]) clearRelations(row *T) {
+	rv := reflect.ValueOf(row).Elem()
+	for _, rel := range t.schema.Relationships.Relations {
+		f := rel.Field.ReflectValueOf(context.Background(), rv)
+		f.Set(reflect.Zero(f.Type()))
+	}
+}
+
+// get returns a copy of the row with the given ID
+func (t *table[T]) get(id uint) (T, bool) {
+	row, ok := t.rows[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return *row, true
+}
+
+// find returns copies of the rows matching where in ID order. A nil where matches every row.
+func (t *table[T]) find(where func(row *T) bool) []T {
+	ids := make([]uint, 0, len(t.rows))
+	for id, row := range t.rows {
+		if where == nil || where(row) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		rows[i] = *t.rows[id]
+	}
+	return rows
+}
+
+// first returns the matching row with the lowest ID
+func (t *table[T]) first(where func(row *T) bool) (T, bool) {
+	var (
+		found T
+		minID uint
+	)
+	for id, row := range t.rows {
+		if where(row) && (minID == 0 || id < minID) {
+			found, minID = *row, id
+		}
+	}
+	return found, minID != 0
+}
+
+// count counts the rows matching where
+func (t *table[T]) count(where func(row *T) bool) int64 {
+	var n int64
+	for _, row := range t.rows {
+		if where == nil || where(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// deleteWhere removes the rows matching where and returns how many there were
+func (t *table[T]) deleteWhere(where func(row *T) bool) int64 {
+	var n int64
+	for id, row := range t.rows {
+		if where(row) {
+			delete(t.rows, id)
+			n++
+		}
+	}
+	return n
+}
+
+// page applies OFFSET and LIMIT like SQL does; a negative limit means no limit
+func page[T any](rows []T, offset, limit int) []T {
+	if offset > 0 {
+		if offset >= len(rows) {
+			return rows[:0]
+		}
+		rows = rows[offset:]
+	}
+	if limit >= 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// pick returns n of rows in random order, standing in for ORDER BY RANDOM() LIMIT n
+func pick[T any](rows []T, n int) []T {
+	shuffled := append([]T(nil), rows...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return page(shuffled, 0, n)
+}
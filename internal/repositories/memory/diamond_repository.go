@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type DiamondRepository struct {
+	db *DB
+}
+
+func NewDiamondRepository(db *DB) *DiamondRepository {
+	return &DiamondRepository{db: db}
+}
+
+// AddDiamonds adds diamonds to user's balance with transaction logging.
+// A non-empty idempotencyKey that was already used fails with ErrCodeDuplicateRequest.
+func (r *DiamondRepository) AddDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.transaction(func() error {
+		return r.db.addDiamonds(userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// DeductDiamonds deducts diamonds from user's balance with transaction logging.
+// A non-empty idempotencyKey that was already used fails with ErrCodeDuplicateRequest.
+func (r *DiamondRepository) DeductDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	return r.db.transaction(func() error {
+		return r.db.deductDiamonds(userID, amount, txType, description, idempotencyKey)
+	})
+}
+
+// ExchangeForCoins swaps diamonds for coins at the given rate in one transaction.
+// Returns the number of coins credited. A replayed exchange, found by its idempotencyKey,
+// fails with ErrCodeDuplicateRequest and leaves the diamonds alone.
+func (r *DiamondRepository) ExchangeForCoins(userID uint, diamonds, coinsPerDiamond int64, idempotencyKey string) (int64, error) {
+	if diamonds <= 0 || coinsPerDiamond <= 0 {
+		return 0, errors.New(errors.ErrCodeValidation, "invalid exchange amount")
+	}
+
+	coins := diamonds * coinsPerDiamond
+	description := fmt.Sprintf("تبدیل %d الماس به %d سکه", diamonds, coins)
+
+	err := r.db.transaction(func() error {
+		if err := r.db.deductDiamonds(userID, diamonds, models.TxTypeDiamondExchange, description, ""); err != nil {
+			return err
+		}
+		return r.db.addCoins(userID, coins, models.TxTypeDiamondExchange, description, idempotencyKey)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return coins, nil
+}
+
+// GetTransactionHistory retrieves user's diamond transaction history
+func (r *DiamondRepository) GetTransactionHistory(userID uint, limit int) ([]models.DiamondTransaction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	txs := r.db.diamondTxs.find(func(t *models.DiamondTransaction) bool { return t.UserID == userID })
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].ID > txs[j].ID })
+	return page(txs, 0, limit), nil
+}
+
+// deductDiamonds deducts diamonds inside an existing transaction
+func (db *DB) deductDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	user, ok := db.users.get(userID)
+	if !ok {
+		return errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	if err := db.checkDiamondKey(idempotencyKey); err != nil {
+		return err
+	}
+	if user.Diamonds < amount {
+		return errors.New(errors.ErrCodeInsufficientFunds, fmt.Sprintf("insufficient diamonds: have %d, need %d", user.Diamonds, amount))
+	}
+
+	db.users.update(userID, func(u *models.User) { u.Diamonds -= amount })
+	db.diamondTxs.insert(&models.DiamondTransaction{
+		UserID:          userID,
+		Amount:          -amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	})
+	return nil
+}
+
+// addDiamonds adds diamonds inside an existing transaction
+func (db *DB) addDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error {
+	if _, ok := db.users.get(userID); !ok {
+		return errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	if err := db.checkDiamondKey(idempotencyKey); err != nil {
+		return err
+	}
+
+	db.users.update(userID, func(u *models.User) { u.Diamonds += amount })
+	db.diamondTxs.insert(&models.DiamondTransaction{
+		UserID:          userID,
+		Amount:          amount,
+		TransactionType: txType,
+		Description:     description,
+		IdempotencyKey:  coinKey(idempotencyKey),
+	})
+	return nil
+}
+
+// checkDiamondKey fails with ErrCodeDuplicateRequest when an operation with the key was already recorded
+func (db *DB) checkDiamondKey(idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return nil
+	}
+	if db.diamondTxs.count(func(t *models.DiamondTransaction) bool {
+		return t.IdempotencyKey != nil && *t.IdempotencyKey == idempotencyKey
+	}) > 0 {
+		return errors.New(errors.ErrCodeDuplicateRequest, "diamond operation already applied: "+idempotencyKey)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+func TestDiamondRepository_IdempotencyKey(t *testing.T) {
+	db := NewDB()
+	diamonds := NewDiamondRepository(db)
+	users := NewUserRepository(db)
+	user := newTestUser(t, users, 1, 100)
+
+	if err := diamonds.AddDiamonds(user.ID, 20, models.TxTypeAdminAdjustment, "gift", "req-1"); err != nil {
+		t.Fatalf("AddDiamonds() error = %v", err)
+	}
+	err := diamonds.AddDiamonds(user.ID, 20, models.TxTypeAdminAdjustment, "gift", "req-1")
+	if !hasCode(err, errors.ErrCodeDuplicateRequest) {
+		t.Errorf("repeated AddDiamonds() error = %v, want %s", err, errors.ErrCodeDuplicateRequest)
+	}
+
+	if err := diamonds.DeductDiamonds(user.ID, 5, models.TxTypeAdminAdjustment, "fix", "req-2"); err != nil {
+		t.Fatalf("DeductDiamonds() error = %v", err)
+	}
+	err = diamonds.DeductDiamonds(user.ID, 5, models.TxTypeAdminAdjustment, "fix", "req-2")
+	if !hasCode(err, errors.ErrCodeDuplicateRequest) {
+		t.Errorf("repeated DeductDiamonds() error = %v, want %s", err, errors.ErrCodeDuplicateRequest)
+	}
+
+	// Unkeyed operations never collide
+	for i := 0; i < 2; i++ {
+		if err := diamonds.AddDiamonds(user.ID, 1, models.TxTypeAdminAdjustment, "bonus", ""); err != nil {
+			t.Fatalf("AddDiamonds() error = %v", err)
+		}
+	}
+
+	got, err := users.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if got.Diamonds != 17 {
+		t.Errorf("Diamonds = %d, want 17", got.Diamonds)
+	}
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type FriendRepository struct {
+	db *DB
+}
+
+func NewFriendRepository(db *DB) *FriendRepository {
+	return &FriendRepository{db: db}
+}
+
+// SendFriendRequest creates a new friend request
+func (r *FriendRepository) SendFriendRequest(requesterID, addresseeID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.isBlockedPair(requesterID, addresseeID) {
+		return errors.New(errors.ErrCodeForbidden, "user is blocked")
+	}
+
+	if existing, ok := r.db.friendships.first(func(f *models.Friendship) bool {
+		return isPair(f.RequesterID, f.AddresseeID, requesterID, addresseeID)
+	}); ok {
+		if existing.Status == models.FriendshipStatusAccepted {
+			return errors.New(errors.ErrCodeAlreadyExists, "already friends")
+		}
+		return errors.New(errors.ErrCodeAlreadyExists, "friend request already exists")
+	}
+
+	r.db.friendships.insert(&models.Friendship{
+		RequesterID: requesterID,
+		AddresseeID: addresseeID,
+		Status:      models.FriendshipStatusPending,
+	})
+	return nil
+}
+
+// AcceptFriendRequest accepts a friend request
+func (r *FriendRepository) AcceptFriendRequest(requestID uint) error {
+	return r.answerRequest(requestID, models.FriendshipStatusAccepted)
+}
+
+// RejectFriendRequest rejects a friend request
+func (r *FriendRepository) RejectFriendRequest(requestID uint) error {
+	return r.answerRequest(requestID, models.FriendshipStatusRejected)
+}
+
+func (r *FriendRepository) answerRequest(requestID uint, status string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.friendships.updateWhere(func(f *models.Friendship) bool {
+		return f.ID == requestID && f.Status == models.FriendshipStatusPending
+	}, func(f *models.Friendship) {
+		f.Status = status
+	}) == 0 {
+		return errors.New(errors.ErrCodeNotFound, "friend request not found or already processed")
+	}
+	return nil
+}
+
+// GetFriends retrieves list of user's friends
+func (r *FriendRepository) GetFriends(userID uint) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	friendIDs := make(map[uint]bool)
+	for _, f := range r.db.friendships.rows {
+		if f.Status != models.FriendshipStatusAccepted {
+			continue
+		}
+		if f.RequesterID == userID {
+			friendIDs[f.AddresseeID] = true
+		} else if f.AddresseeID == userID {
+			friendIDs[f.RequesterID] = true
+		}
+	}
+
+	return r.db.users.find(func(u *models.User) bool { return friendIDs[u.ID] && u.ID != userID }), nil
+}
+
+// GetPendingRequests retrieves pending friend requests for a user
+func (r *FriendRepository) GetPendingRequests(userID uint) ([]models.Friendship, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	requests := r.db.friendships.find(func(f *models.Friendship) bool {
+		return f.AddresseeID == userID && f.Status == models.FriendshipStatusPending
+	})
+	for i := range requests {
+		requests[i].Requester, _ = r.db.users.get(requests[i].RequesterID)
+	}
+	return requests, nil
+}
+
+// RemoveFriend removes a friendship
+func (r *FriendRepository) RemoveFriend(user1ID, user2ID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.friendships.deleteWhere(func(f *models.Friendship) bool {
+		return isPair(f.RequesterID, f.AddresseeID, user1ID, user2ID) && f.Status == models.FriendshipStatusAccepted
+	}) == 0 {
+		return errors.New(errors.ErrCodeNotFound, "friendship not found")
+	}
+	return nil
+}
+
+// AreFriends checks if two users are friends
+func (r *FriendRepository) AreFriends(user1ID, user2ID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.friendships.count(func(f *models.Friendship) bool {
+		return isPair(f.RequesterID, f.AddresseeID, user1ID, user2ID) && f.Status == models.FriendshipStatusAccepted
+	}) > 0, nil
+}
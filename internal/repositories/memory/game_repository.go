@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type GameRepository struct {
+	db *DB
+}
+
+func NewGameRepository(db *DB) *GameRepository {
+	return &GameRepository{db: db}
+}
+
+// GetRandomQuestion retrieves a random question by type and optional category
+func (r *GameRepository) GetRandomQuestion(questionType, category string) (*models.Question, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	questions := pick(r.db.questions.find(func(q *models.Question) bool {
+		return q.QuestionType == questionType && (category == "" || q.Category == category)
+	}), 1)
+	if len(questions) == 0 {
+		return nil, errors.New(errors.ErrCodeNotFound, "no questions found")
+	}
+	return &questions[0], nil
+}
+
+// GetQuizQuestions retrieves multiple quiz questions
+func (r *GameRepository) GetQuizQuestions(count int) ([]models.Question, error) {
+	return r.GetQuestionsByCategoryExcluding("", count, nil)
+}
+
+// GetQuizCategories retrieves unique quiz categories
+func (r *GameRepository) GetQuizCategories(count int) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var categories []string
+	for _, q := range r.db.questions.find(func(q *models.Question) bool {
+		return q.QuestionType == models.QuestionTypeQuiz && q.Category != ""
+	}) {
+		if !seen[q.Category] {
+			seen[q.Category] = true
+			categories = append(categories, q.Category)
+		}
+	}
+	return pick(categories, count), nil
+}
+
+// GetQuestionsByCategoryExcluding retrieves random questions excluding some IDs
+func (r *GameRepository) GetQuestionsByCategoryExcluding(category string, count int, excludeIDs []uint) ([]models.Question, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	excluded := make(map[uint]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+
+	return pick(r.db.questions.find(func(q *models.Question) bool {
+		return q.QuestionType == models.QuestionTypeQuiz && (category == "" || q.Category == category) && !excluded[q.ID]
+	}), count), nil
+}
+
+// CreateGameSession creates a new game session
+func (r *GameRepository) CreateGameSession(roomID uint, gameType string) (*models.GameSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session := &models.GameSession{
+		RoomID:   roomID,
+		GameType: gameType,
+		Status:   models.GameStatusWaiting,
+	}
+	r.db.gameSessions.insert(session)
+	return session, nil
+}
+
+// SetTurnUserID sets the current user turn in a game session
+func (r *GameRepository) SetTurnUserID(gameSessionID, userID uint) error {
+	return r.updateSession(gameSessionID, func(s *models.GameSession) { s.TurnUserID = userID })
+}
+
+// AddParticipant adds a participant to a game session
+func (r *GameRepository) AddParticipant(gameSessionID, userID uint, turnOrder int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.participants.insert(&models.GameParticipant{
+		GameSessionID: gameSessionID,
+		UserID:        userID,
+		TurnOrder:     turnOrder,
+		Answers:       "[]",
+	})
+	return nil
+}
+
+// RecordAnswer appends an answer to a participant's answer log and adds its points
+func (r *GameRepository) RecordAnswer(gameSessionID, userID uint, answer models.GroupQuizAnswer) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var encodeErr error
+	affected := r.db.participants.updateWhere(func(p *models.GameParticipant) bool {
+		return p.GameSessionID == gameSessionID && p.UserID == userID
+	}, func(p *models.GameParticipant) {
+		var answers []models.GroupQuizAnswer
+		if p.Answers != "" {
+			_ = json.Unmarshal([]byte(p.Answers), &answers)
+		}
+		encoded, err := json.Marshal(append(answers, answer))
+		if err != nil {
+			encodeErr = err
+			return
+		}
+		p.Answers = string(encoded)
+		p.Score += answer.Points
+	})
+
+	if encodeErr != nil {
+		return errors.Wrap(encodeErr, errors.ErrCodeInternalError, "failed to encode answer")
+	}
+	if affected == 0 {
+		return errors.New(errors.ErrCodeNotFound, "participant not found")
+	}
+	return nil
+}
+
+// EndGame ends a game session
+func (r *GameRepository) EndGame(gameSessionID uint) error {
+	now := time.Now()
+	return r.updateSession(gameSessionID, func(s *models.GameSession) {
+		s.Status = models.GameStatusFinished
+		s.EndedAt = &now
+	})
+}
+
+// GetGameSession retrieves a game session by ID
+func (r *GameRepository) GetGameSession(gameSessionID uint) (*models.GameSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.gameSessions.get(gameSessionID)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "game session not found")
+	}
+	r.db.preloadSessionRoom(&session)
+	return &session, nil
+}
+
+func (db *DB) preloadSessionRoom(s *models.GameSession) {
+	s.Room, _ = db.rooms.get(s.RoomID)
+	s.Room.Host, _ = db.users.get(s.Room.HostID)
+}
+
+// GetParticipants retrieves all participants of a game session
+func (r *GameRepository) GetParticipants(gameSessionID uint) ([]models.GameParticipant, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	participants := r.db.participants.find(func(p *models.GameParticipant) bool { return p.GameSessionID == gameSessionID })
+	sort.SliceStable(participants, func(i, j int) bool { return participants[i].TurnOrder < participants[j].TurnOrder })
+	for i := range participants {
+		participants[i].User, _ = r.db.users.get(participants[i].UserID)
+	}
+	return participants, nil
+}
+
+// GetActiveGameSessionByRoomID retrieves an active game session for a room
+func (r *GameRepository) GetActiveGameSessionByRoomID(roomID uint) (*models.GameSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.gameSessions.first(func(s *models.GameSession) bool {
+		return s.RoomID == roomID && s.Status != models.GameStatusFinished
+	})
+	if !ok {
+		return nil, nil
+	}
+	r.db.preloadSessionRoom(&session)
+	return &session, nil
+}
+
+// StartGame starts a game session
+func (r *GameRepository) StartGame(gameSessionID uint) error {
+	now := time.Now()
+	return r.updateSession(gameSessionID, func(s *models.GameSession) {
+		s.Status = models.GameStatusInProgress
+		s.StartedAt = &now
+	})
+}
+
+// UpdateCurrentQuestion updates the current question in a game session
+func (r *GameRepository) UpdateCurrentQuestion(gameSessionID, questionID uint) error {
+	return r.updateSession(gameSessionID, func(s *models.GameSession) { s.CurrentQuestionID = &questionID })
+}
+
+// UpdateGameStatus updates the status of a game session
+func (r *GameRepository) UpdateGameStatus(gameSessionID uint, status string) error {
+	return r.updateSession(gameSessionID, func(s *models.GameSession) { s.Status = status })
+}
+
+func (r *GameRepository) updateSession(gameSessionID uint, fn func(s *models.GameSession)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.gameSessions.update(gameSessionID, fn)
+	return nil
+}
+
+// GetQuestionsByIDs retrieves multiple questions by their IDs
+func (r *GameRepository) GetQuestionsByIDs(ids []uint) ([]models.Question, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Keep the original order of IDs
+	var questions []models.Question
+	for _, id := range ids {
+		if q, ok := r.db.questions.get(id); ok {
+			questions = append(questions, q)
+		}
+	}
+	return questions, nil
+}
+
+// GetRecentGames retrieves recent games user participated in
+func (r *GameRepository) GetRecentGames(userID uint, limit int) ([]models.GameParticipant, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	participants := r.db.participants.find(func(p *models.GameParticipant) bool { return p.UserID == userID })
+	sort.SliceStable(participants, func(i, j int) bool { return participants[i].ID > participants[j].ID })
+	participants = page(participants, 0, limit)
+	for i := range participants {
+		participants[i].GameSession, _ = r.db.gameSessions.get(participants[i].GameSessionID)
+	}
+	return participants, nil
+}
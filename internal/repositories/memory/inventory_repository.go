@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type InventoryRepository struct {
+	db *DB
+}
+
+func NewInventoryRepository(db *DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// GetItems returns the user's usable items, skipping used up and expired stacks
+func (r *InventoryRepository) GetItems(userID uint) ([]models.InventoryItem, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	items := r.db.inventory.find(func(i *models.InventoryItem) bool {
+		return i.UserID == userID && i.Quantity > 0 && (i.ExpiresAt == nil || i.ExpiresAt.After(now))
+	})
+	sort.SliceStable(items, func(a, b int) bool { return items[a].ItemType < items[b].ItemType })
+	return items, nil
+}
+
+// GetQuantity returns how many usable items of a type the user holds
+func (r *InventoryRepository) GetQuantity(userID uint, itemType string) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	item, ok := r.db.inventory.first(func(i *models.InventoryItem) bool { return i.UserID == userID && i.ItemType == itemType })
+	if !ok || !item.IsActive(time.Now()) {
+		return 0, nil
+	}
+	return item.Quantity, nil
+}
+
+// Grant adds items to the user's inventory
+func (r *InventoryRepository) Grant(userID uint, itemType string, quantity int) error {
+	return r.db.transaction(func() error {
+		return r.db.grantItem(userID, itemType, quantity)
+	})
+}
+
+// Consume takes items from the user's inventory, failing if they don't hold enough
+func (r *InventoryRepository) Consume(userID uint, itemType string, quantity int) error {
+	return r.db.transaction(func() error {
+		return r.db.consumeItem(userID, itemType, quantity)
+	})
+}
+
+// grantItem adds items inside an existing transaction. Expired stacks start
+// over from zero; expiring items get a fresh expiry (non-stackable ones are extended).
+func (db *DB) grantItem(userID uint, itemType string, quantity int) error {
+	def := models.GetItemDef(itemType)
+	if def == nil {
+		return errors.New(errors.ErrCodeValidation, "unknown item type: "+itemType)
+	}
+	if quantity <= 0 {
+		return errors.New(errors.ErrCodeValidation, "quantity must be positive")
+	}
+
+	item := db.inventoryStack(userID, itemType)
+	if item == nil {
+		item = &models.InventoryItem{UserID: userID, ItemType: itemType}
+		db.inventory.insert(item)
+		item = db.inventory.rows[item.ID]
+	}
+
+	now := time.Now()
+	quantityAfter, expiresAt := item.Quantity, item.ExpiresAt
+	if !item.IsActive(now) {
+		quantityAfter, expiresAt = 0, nil
+	}
+
+	if def.Stackable {
+		quantityAfter += quantity
+	} else {
+		if quantityAfter > 0 && def.Expiry == 0 {
+			return errors.New(errors.ErrCodeAlreadyExists, "item already owned")
+		}
+		quantityAfter = 1
+	}
+
+	if def.Expiry > 0 {
+		base := now
+		if !def.Stackable && expiresAt != nil && expiresAt.After(now) {
+			base = *expiresAt
+		}
+		expiry := base.Add(def.Expiry)
+		expiresAt = &expiry
+	}
+
+	db.inventory.update(item.ID, func(i *models.InventoryItem) {
+		i.Quantity = quantityAfter
+		i.ExpiresAt = expiresAt
+	})
+	return nil
+}
+
+// consumeItem takes items inside an existing transaction
+func (db *DB) consumeItem(userID uint, itemType string, quantity int) error {
+	if quantity <= 0 {
+		return errors.New(errors.ErrCodeValidation, "quantity must be positive")
+	}
+
+	item := db.inventoryStack(userID, itemType)
+	if item == nil || !item.IsActive(time.Now()) || item.Quantity < quantity {
+		return errors.New(errors.ErrCodeNotFound, "insufficient item quantity")
+	}
+
+	db.inventory.update(item.ID, func(i *models.InventoryItem) { i.Quantity -= quantity })
+	return nil
+}
+
+// inventoryStack returns the stored stack of an item type, or nil if the user never held one
+func (db *DB) inventoryStack(userID uint, itemType string) *models.InventoryItem {
+	for _, item := range db.inventory.rows {
+		if item.UserID == userID && item.ItemType == itemType {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type MatchRepository struct {
+	db *DB
+}
+
+func NewMatchRepository(db *DB) *MatchRepository {
+	return &MatchRepository{db: db}
+}
+
+// AddToQueue adds a user to the matchmaking queue
+func (r *MatchRepository) AddToQueue(queue *models.MatchmakingQueue) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.queue.count(func(q *models.MatchmakingQueue) bool { return q.UserID == queue.UserID }) > 0 {
+		return errors.New(errors.ErrCodeAlreadyExists, "user already in matchmaking queue")
+	}
+
+	r.db.queue.insert(queue)
+	return nil
+}
+
+// RemoveFromQueue removes a user from the matchmaking queue
+func (r *MatchRepository) RemoveFromQueue(userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.queue.deleteWhere(func(q *models.MatchmakingQueue) bool { return q.UserID == userID })
+	return nil
+}
+
+// FindMatch finds the longest waiting queue entry compatible with the user, applying
+// the same filters as the SQL query of the GORM repository
+func (r *MatchRepository) FindMatch(userID uint, filters *models.MatchFilters) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	searchingUser, ok := r.db.users.get(userID)
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get user")
+	}
+
+	gameType := filters.GameType
+	if gameType == "" {
+		gameType = models.GameTypeChat
+	}
+
+	entries := r.db.queue.find(func(q *models.MatchmakingQueue) bool {
+		if q.UserID == userID || q.GameType != gameType || q.BetAmount != filters.BetAmount {
+			return false
+		}
+
+		candidate, ok := r.db.users.rows[q.UserID]
+		if !ok || candidate.UnreachableAt != nil || r.db.isBlockedPair(userID, candidate.ID) {
+			return false
+		}
+
+		if filters.Gender != "" && filters.Gender != models.RequestedGenderAny && candidate.Gender != filters.Gender {
+			return false
+		}
+		if filters.MinAge != nil && candidate.Age < *filters.MinAge {
+			return false
+		}
+		if filters.MaxAge != nil && candidate.Age > *filters.MaxAge {
+			return false
+		}
+		if filters.City != "" && candidate.City != filters.City && q.City != filters.City {
+			return false
+		}
+		if len(filters.Provinces) > 0 && !containsString(filters.Provinces, candidate.Province) {
+			return false
+		}
+
+		// The candidate must accept the searching user's province and gender too
+		if searchingUser.Province != "" && q.TargetProvinces != "" && !strings.Contains(q.TargetProvinces, searchingUser.Province) {
+			return false
+		}
+		return q.RequestedGender == searchingUser.Gender || q.RequestedGender == models.RequestedGenderAny
+	})
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	matchedUser, _ := r.db.users.get(entries[0].UserID)
+	return &matchedUser, nil
+}
+
+// CreateMatchSession creates a new match session
+func (r *MatchRepository) CreateMatchSession(user1ID, user2ID uint, timeoutDuration time.Duration) (*models.MatchSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session := &models.MatchSession{
+		User1ID:   user1ID,
+		User2ID:   user2ID,
+		StartedAt: time.Now(),
+		TimeoutAt: time.Now().Add(timeoutDuration),
+		Status:    models.MatchStatusActive,
+	}
+	r.db.matches.insert(session)
+	return session, nil
+}
+
+// GetActiveMatch retrieves active match for a user
+func (r *MatchRepository) GetActiveMatch(userID uint) (*models.MatchSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.matches.first(func(m *models.MatchSession) bool {
+		return (m.User1ID == userID || m.User2ID == userID) &&
+			(m.Status == models.MatchStatusActive || m.Status == models.MatchStatusTimeout)
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	r.db.preloadMatchUsers(&session)
+	return &session, nil
+}
+
+// preloadMatchUsers fills both players of a match session
+func (db *DB) preloadMatchUsers(m *models.MatchSession) {
+	m.User1, _ = db.users.get(m.User1ID)
+	m.User2, _ = db.users.get(m.User2ID)
+}
+
+// EndMatch ends a match session
+func (r *MatchRepository) EndMatch(sessionID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	r.db.matches.update(sessionID, func(m *models.MatchSession) {
+		m.Status = models.MatchStatusEnded
+		m.EndedAt = &now
+	})
+	return nil
+}
+
+// CheckAndHandleTimeouts marks active matches past their timeout as timed out and returns them
+func (r *MatchRepository) CheckAndHandleTimeouts() ([]models.MatchSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	timedOut := r.db.matches.find(func(m *models.MatchSession) bool {
+		return m.Status == models.MatchStatusActive && m.TimeoutAt.Before(now)
+	})
+
+	for i := range timedOut {
+		r.db.preloadMatchUsers(&timedOut[i])
+		r.db.matches.update(timedOut[i].ID, func(m *models.MatchSession) {
+			m.Status = models.MatchStatusTimeout
+			m.EndedAt = &now
+		})
+	}
+	return timedOut, nil
+}
+
+// IsUserInQueue checks if user is in matchmaking queue
+func (r *MatchRepository) IsUserInQueue(userID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.queue.count(func(q *models.MatchmakingQueue) bool { return q.UserID == userID }) > 0, nil
+}
+
+// GetQueueEntry retrieves user's queue entry
+func (r *MatchRepository) GetQueueEntry(userID uint) (*models.MatchmakingQueue, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	entry, ok := r.db.queue.first(func(q *models.MatchmakingQueue) bool { return q.UserID == userID })
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "queue entry not found")
+	}
+	return &entry, nil
+}
+
+// GetMatchByID retrieves a match session with both players
+func (r *MatchRepository) GetMatchByID(id uint) (*models.MatchSession, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	session, ok := r.db.matches.get(id)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "match not found")
+	}
+	r.db.preloadMatchUsers(&session)
+	return &session, nil
+}
+
+// CountActiveMatches counts anonymous chats that are still running
+func (r *MatchRepository) CountActiveMatches() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.matches.count(func(m *models.MatchSession) bool { return m.Status == models.MatchStatusActive }), nil
+}
+
+// GetQueueSizes counts waiting users per game type in the matchmaking queue
+func (r *MatchRepository) GetQueueSizes() (map[string]int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	sizes := make(map[string]int64)
+	for _, q := range r.db.queue.rows {
+		sizes[q.GameType]++
+	}
+	return sizes, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
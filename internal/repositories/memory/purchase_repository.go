@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type PurchaseRepository struct {
+	db *DB
+}
+
+func NewPurchaseRepository(db *DB) *PurchaseRepository {
+	return &PurchaseRepository{db: db}
+}
+
+// CreateOrder stores a new pending purchase order for a coin package
+func (r *PurchaseRepository) CreateOrder(userID uint, pkg *models.CoinPackage, receiptFileID string) (*models.PurchaseOrder, error) {
+	return r.createOrder(&models.PurchaseOrder{
+		UserID:        userID,
+		PackageID:     pkg.ID,
+		Coins:         pkg.Coins,
+		PriceToman:    pkg.PriceToman,
+		ReceiptFileID: receiptFileID,
+		Status:        models.OrderStatusPending,
+	}), nil
+}
+
+// CreateGatewayOrder stores a new pending order that will be paid through provider
+func (r *PurchaseRepository) CreateGatewayOrder(userID uint, pkg *models.CoinPackage, provider string) (*models.PurchaseOrder, error) {
+	return r.createOrder(&models.PurchaseOrder{
+		UserID:     userID,
+		PackageID:  pkg.ID,
+		Coins:      pkg.Coins,
+		PriceToman: pkg.PriceToman,
+		Provider:   provider,
+		Status:     models.OrderStatusPending,
+	}), nil
+}
+
+func (r *PurchaseRepository) createOrder(order *models.PurchaseOrder) *models.PurchaseOrder {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.orders.insert(order)
+	return order
+}
+
+// GetOrderByID retrieves an order with its buyer loaded
+func (r *PurchaseRepository) GetOrderByID(id uint) (*models.PurchaseOrder, error) {
+	return r.firstOrder(func(o *models.PurchaseOrder) bool { return o.ID == id })
+}
+
+// GetOrderByAuthority finds the gateway order of a provider invoice, with its buyer loaded
+func (r *PurchaseRepository) GetOrderByAuthority(provider, authority string) (*models.PurchaseOrder, error) {
+	return r.firstOrder(func(o *models.PurchaseOrder) bool { return o.Provider == provider && o.Authority == authority })
+}
+
+func (r *PurchaseRepository) firstOrder(where func(o *models.PurchaseOrder) bool) (*models.PurchaseOrder, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	order, ok := r.db.orders.first(where)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "purchase order not found")
+	}
+	order.User, _ = r.db.users.get(order.UserID)
+	return &order, nil
+}
+
+// GetUserOrders retrieves a user's latest orders, newest first
+func (r *PurchaseRepository) GetUserOrders(userID uint, limit int) ([]models.PurchaseOrder, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	orders := r.db.orders.find(func(o *models.PurchaseOrder) bool { return o.UserID == userID })
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	return page(orders, 0, limit), nil
+}
+
+// CountPendingOrders counts receipt orders waiting for admin review
+func (r *PurchaseRepository) CountPendingOrders() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.orders.count(func(o *models.PurchaseOrder) bool {
+		return o.Status == models.OrderStatusPending && o.Provider == ""
+	}), nil
+}
+
+// SetOrderAuthority stores the provider's invoice ID on an order
+func (r *PurchaseRepository) SetOrderAuthority(id uint, authority string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.orders.update(id, func(o *models.PurchaseOrder) { o.Authority = authority })
+	return nil
+}
+
+// ApproveOrder marks a pending receipt order approved and credits its coins in the same transaction.
+// Returns false if the order was already reviewed by someone else.
+func (r *PurchaseRepository) ApproveOrder(id uint, reviewerTgID int64) (*models.PurchaseOrder, bool, error) {
+	return r.approveOrder(id, func(o *models.PurchaseOrder) { o.ReviewerTgID = reviewerTgID })
+}
+
+// ConfirmPayment marks a pending gateway order approved with the provider's reference ID and
+// credits its coins in the same transaction. Confirming an order twice credits it only once;
+// the second call returns false.
+func (r *PurchaseRepository) ConfirmPayment(id uint, refID string) (*models.PurchaseOrder, bool, error) {
+	return r.approveOrder(id, func(o *models.PurchaseOrder) { o.RefID = refID })
+}
+
+// approveOrder approves the order if it is still pending and credits the buyer
+func (r *PurchaseRepository) approveOrder(id uint, fn func(o *models.PurchaseOrder)) (*models.PurchaseOrder, bool, error) {
+	var order models.PurchaseOrder
+	approved := false
+
+	err := r.db.transaction(func() error {
+		var ok bool
+		if order, ok = r.db.orders.get(id); !ok {
+			return errors.New(errors.ErrCodeNotFound, "purchase order not found")
+		}
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+
+		now := time.Now()
+		r.db.orders.update(id, func(o *models.PurchaseOrder) {
+			fn(o)
+			o.Status = models.OrderStatusApproved
+			o.ReviewedAt = &now
+		})
+
+		description := fmt.Sprintf("خرید پکیج %d سکه (سفارش #%d)", order.Coins, order.ID)
+		if err := r.db.addCoins(order.UserID, order.Coins, models.TxTypeCoinPurchase, description, models.CoinOpKey(models.TxTypeCoinPurchase, "order", order.ID)); err != nil {
+			return err
+		}
+
+		order, _ = r.db.orders.get(id)
+		approved = true
+		return nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &order, approved, nil
+}
+
+// RejectOrder marks a pending order rejected.
+// Returns false if the order was already reviewed by someone else.
+func (r *PurchaseRepository) RejectOrder(id uint, reviewerTgID int64) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.orders.updateWhere(func(o *models.PurchaseOrder) bool {
+		return o.ID == id && o.Status == models.OrderStatusPending
+	}, func(o *models.PurchaseOrder) {
+		o.Status = models.OrderStatusRejected
+		o.ReviewerTgID = reviewerTgID
+		o.ReviewedAt = &now
+	}) > 0, nil
+}
+
+// RecordTelegramPayment stores a successful Telegram payment and credits its coins or diamonds
+// in the same transaction. A charge ID that was already recorded is not credited again; false is returned.
+func (r *PurchaseRepository) RecordTelegramPayment(payment *models.TelegramPayment) (bool, error) {
+	if payment.UserID == nil {
+		return false, errors.New(errors.ErrCodeValidationFailed, "telegram payment has no user")
+	}
+	credited := false
+
+	err := r.db.transaction(func() error {
+		payment.Status = models.TelegramPaymentStatusPaid
+		if r.db.payments.count(func(p *models.TelegramPayment) bool {
+			return p.TelegramPaymentChargeID == payment.TelegramPaymentChargeID
+		}) > 0 {
+			return nil
+		}
+		r.db.payments.insert(payment)
+
+		switch payment.ProductKind {
+		case models.ProductKindCoins:
+			description := fmt.Sprintf("خرید پکیج %d سکه با استارز تلگرام", payment.Amount)
+			if err := r.db.addCoins(*payment.UserID, payment.Amount, models.TxTypeCoinPurchase, description, models.CoinOpKey(models.TxTypeCoinPurchase, "stars", payment.ID)); err != nil {
+				return err
+			}
+		case models.ProductKindDiamonds:
+			description := fmt.Sprintf("خرید بسته %d الماس با استارز تلگرام", payment.Amount)
+			if err := r.db.addDiamonds(*payment.UserID, payment.Amount, models.TxTypeDiamondPurchase, description, models.CoinOpKey(models.TxTypeDiamondPurchase, "stars", payment.ID)); err != nil {
+				return err
+			}
+		default:
+			return errors.New(errors.ErrCodeValidationFailed, "unknown product kind")
+		}
+
+		credited = true
+		return nil
+	})
+
+	return credited, err
+}
+
+// HoldTelegramPayment stores a payment that couldn't be credited as needs_review, so an admin
+// can credit or refund it later. Returns false if the charge ID was already recorded.
+func (r *PurchaseRepository) HoldTelegramPayment(payment *models.TelegramPayment) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.payments.count(func(p *models.TelegramPayment) bool {
+		return p.TelegramPaymentChargeID == payment.TelegramPaymentChargeID
+	}) > 0 {
+		return false, nil
+	}
+
+	payment.ID = 0
+	payment.Status = models.TelegramPaymentStatusNeedsReview
+	r.db.payments.insert(payment)
+	return true, nil
+}
+
+// CountProductSales counts paid Telegram payments of a product, for stock checks
+func (r *PurchaseRepository) CountProductSales(kind string, productID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.payments.count(func(p *models.TelegramPayment) bool {
+		return p.ProductKind == kind && p.ProductID == productID && p.Status == models.TelegramPaymentStatusPaid
+	}), nil
+}
+
+// GetTelegramPayment retrieves a payment by its Telegram charge ID
+func (r *PurchaseRepository) GetTelegramPayment(chargeID string) (*models.TelegramPayment, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	payment, ok := r.db.payments.first(func(p *models.TelegramPayment) bool {
+		return p.TelegramPaymentChargeID == chargeID
+	})
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "telegram payment not found")
+	}
+	return &payment, nil
+}
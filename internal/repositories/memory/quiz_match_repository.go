@@ -0,0 +1,368 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type QuizMatchRepository struct {
+	db *DB
+}
+
+func NewQuizMatchRepository(db *DB) *QuizMatchRepository {
+	return &QuizMatchRepository{db: db}
+}
+
+// CreateQuizMatch creates a new quiz match between two users
+func (r *QuizMatchRepository) CreateQuizMatch(user1ID, user2ID uint) (*models.QuizMatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	match := &models.QuizMatch{
+		User1ID:        user1ID,
+		User2ID:        user2ID,
+		CurrentRound:   1,
+		State:          models.QuizStateWaitingCategory,
+		TurnUserID:     &user1ID, // User1 chooses first category
+		TimeoutAt:      time.Now().Add(time.Duration(models.QuizTimeoutDays) * 24 * time.Hour),
+		LastActivityAt: time.Now(),
+	}
+	r.db.quizMatches.insert(match)
+	r.db.preloadQuizUsers(match)
+	return match, nil
+}
+
+func (db *DB) preloadQuizUsers(m *models.QuizMatch) {
+	m.User1, _ = db.users.get(m.User1ID)
+	m.User2, _ = db.users.get(m.User2ID)
+}
+
+// quizActive matches quiz matches that are neither finished nor timed out
+func quizActive(m *models.QuizMatch) bool {
+	return m.State != models.QuizStateGameFinished && m.State != models.QuizStateTimeout
+}
+
+// GetQuizMatch retrieves a quiz match by ID with all relations
+func (r *QuizMatchRepository) GetQuizMatch(matchID uint) (*models.QuizMatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	match, ok := r.db.quizMatches.get(matchID)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "quiz match not found")
+	}
+	r.db.preloadQuizUsers(&match)
+	return &match, nil
+}
+
+// GetAllActiveQuizMatchesByUser retrieves all active quiz matches for a user, those waiting on them first
+func (r *QuizMatchRepository) GetAllActiveQuizMatchesByUser(userID uint) ([]models.QuizMatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	matches := r.db.quizMatches.find(func(m *models.QuizMatch) bool {
+		return (m.User1ID == userID || m.User2ID == userID) && quizActive(m)
+	})
+
+	myTurn := func(m models.QuizMatch) bool { return m.TurnUserID != nil && *m.TurnUserID == userID }
+	sort.SliceStable(matches, func(i, j int) bool {
+		if myTurn(matches[i]) != myTurn(matches[j]) {
+			return myTurn(matches[i])
+		}
+		return matches[i].LastActivityAt.After(matches[j].LastActivityAt)
+	})
+	for i := range matches {
+		r.db.preloadQuizUsers(&matches[i])
+	}
+	return matches, nil
+}
+
+// GetFinishedQuizMatchesByUser retrieves finished quiz matches for a user
+func (r *QuizMatchRepository) GetFinishedQuizMatchesByUser(userID uint, limit int) ([]models.QuizMatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	matches := r.db.quizMatches.find(func(m *models.QuizMatch) bool {
+		return (m.User1ID == userID || m.User2ID == userID) && m.State == models.QuizStateGameFinished
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].FinishedAt == nil || matches[j].FinishedAt == nil {
+			return matches[i].FinishedAt == nil && matches[j].FinishedAt != nil
+		}
+		return matches[i].FinishedAt.After(*matches[j].FinishedAt)
+	})
+	matches = page(matches, 0, limit)
+	for i := range matches {
+		r.db.preloadQuizUsers(&matches[i])
+	}
+	return matches, nil
+}
+
+// UpdateQuizMatchState updates the state of a quiz match
+func (r *QuizMatchRepository) UpdateQuizMatchState(matchID uint, state string) error {
+	return r.updateMatch(matchID, func(m *models.QuizMatch) {
+		m.State = state
+		m.LastActivityAt = time.Now()
+	})
+}
+
+// UpdateQuizMatchStateAtomic updates the state only if current state matches one of the expected ones
+func (r *QuizMatchRepository) UpdateQuizMatchStateAtomic(matchID uint, expectedStates []string, newState string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.quizMatches.updateWhere(func(m *models.QuizMatch) bool {
+		return m.ID == matchID && containsString(expectedStates, m.State)
+	}, func(m *models.QuizMatch) {
+		m.State = newState
+		m.LastActivityAt = time.Now()
+	}) > 0, nil
+}
+
+// UpdateCurrentQuestion updates the current question number
+func (r *QuizMatchRepository) UpdateCurrentQuestion(matchID uint, questionNum int) error {
+	return r.updateMatch(matchID, func(m *models.QuizMatch) {
+		m.CurrentQuestion = questionNum
+		m.LastActivityAt = time.Now()
+	})
+}
+
+// UpdateLightsMessageID updates the lights message ID for a user
+func (r *QuizMatchRepository) UpdateLightsMessageID(matchID, userID uint, messageID int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if !r.db.quizMatches.update(matchID, func(m *models.QuizMatch) {
+		if m.User2ID == userID {
+			m.User2LightsMsgID = messageID
+		} else {
+			m.User1LightsMsgID = messageID
+		}
+	}) {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get match")
+	}
+	return nil
+}
+
+// CreateQuizRound creates a new round in a quiz match, returning the existing one if it was already created
+func (r *QuizMatchRepository) CreateQuizRound(matchID uint, roundNum int, category string, chosenBy uint, questionIDs string) (*models.QuizRound, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if existing, ok := r.db.quizRounds.first(func(q *models.QuizRound) bool {
+		return q.MatchID == matchID && q.RoundNumber == roundNum
+	}); ok {
+		return &existing, nil
+	}
+
+	round := &models.QuizRound{
+		MatchID:        matchID,
+		RoundNumber:    roundNum,
+		Category:       category,
+		ChosenByUserID: chosenBy,
+		QuestionIDs:    questionIDs,
+	}
+	r.db.quizRounds.insert(round)
+	return round, nil
+}
+
+// GetQuizRound retrieves a specific round
+func (r *QuizMatchRepository) GetQuizRound(matchID uint, roundNum int) (*models.QuizRound, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	round, ok := r.db.quizRounds.first(func(q *models.QuizRound) bool {
+		return q.MatchID == matchID && q.RoundNumber == roundNum
+	})
+	if !ok {
+		return nil, nil
+	}
+	return &round, nil
+}
+
+// GetAllQuizRounds retrieves all rounds for a match
+func (r *QuizMatchRepository) GetAllQuizRounds(matchID uint) ([]models.QuizRound, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rounds := r.db.quizRounds.find(func(q *models.QuizRound) bool { return q.MatchID == matchID })
+	sort.SliceStable(rounds, func(i, j int) bool { return rounds[i].RoundNumber < rounds[j].RoundNumber })
+	return rounds, nil
+}
+
+// RecordAnswer records a user's answer to a question; answering the same question twice is a no-op
+func (r *QuizMatchRepository) RecordAnswer(matchID, roundID, userID, questionID uint, questionNum, answerIdx, timeMs int, isCorrect bool, booster string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.quizAnswers.count(func(a *models.QuizAnswer) bool {
+		return a.MatchID == matchID && a.RoundID == roundID && a.UserID == userID && a.QuestionNumber == questionNum
+	}) > 0 {
+		return nil
+	}
+
+	r.db.quizAnswers.insert(&models.QuizAnswer{
+		MatchID:        matchID,
+		RoundID:        roundID,
+		UserID:         userID,
+		QuestionID:     questionID,
+		QuestionNumber: questionNum,
+		AnswerIndex:    &answerIdx,
+		IsCorrect:      isCorrect,
+		TimeTakenMs:    timeMs,
+		BoosterUsed:    booster,
+	})
+	r.db.quizMatches.update(matchID, func(m *models.QuizMatch) { m.LastActivityAt = time.Now() })
+	return nil
+}
+
+// DeleteUserAnswer deletes a specific answer (used for Retry booster)
+func (r *QuizMatchRepository) DeleteUserAnswer(matchID, roundID, userID uint, questionNum int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.quizAnswers.deleteWhere(func(a *models.QuizAnswer) bool {
+		return a.MatchID == matchID && a.RoundID == roundID && a.UserID == userID && a.QuestionNumber == questionNum
+	})
+	return nil
+}
+
+// GetUserAnswers retrieves all answers for a user in a round
+func (r *QuizMatchRepository) GetUserAnswers(matchID, roundID, userID uint) ([]models.QuizAnswer, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	answers := r.db.quizAnswers.find(func(a *models.QuizAnswer) bool {
+		return a.MatchID == matchID && a.RoundID == roundID && a.UserID == userID
+	})
+	sort.SliceStable(answers, func(i, j int) bool { return answers[i].QuestionNumber < answers[j].QuestionNumber })
+	return answers, nil
+}
+
+// UpdateQuizMatchScore updates the total score and time for a user
+func (r *QuizMatchRepository) UpdateQuizMatchScore(matchID, userID uint, correctCount int, timeMs int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if !r.db.quizMatches.update(matchID, func(m *models.QuizMatch) {
+		if m.User1ID == userID {
+			m.User1TotalCorrect = correctCount
+			m.User1TotalTimeMs = timeMs
+		} else {
+			m.User2TotalCorrect = correctCount
+			m.User2TotalTimeMs = timeMs
+		}
+		m.LastActivityAt = time.Now()
+	}) {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get match")
+	}
+	return nil
+}
+
+// UpdateRoundStats updates the round statistics
+func (r *QuizMatchRepository) UpdateRoundStats(roundID, userID uint, correctCount, timeMs int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	round, ok := r.db.quizRounds.get(roundID)
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get round")
+	}
+	match, ok := r.db.quizMatches.get(round.MatchID)
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get match")
+	}
+
+	r.db.quizRounds.update(roundID, func(q *models.QuizRound) {
+		if match.User1ID == userID {
+			q.User1CorrectCount = correctCount
+			q.User1TimeMs = timeMs
+		} else {
+			q.User2CorrectCount = correctCount
+			q.User2TimeMs = timeMs
+		}
+	})
+	return nil
+}
+
+// FinishQuizMatch marks a match as finished and sets the winner
+func (r *QuizMatchRepository) FinishQuizMatch(matchID, winnerID uint) error {
+	now := time.Now()
+	return r.updateMatch(matchID, func(m *models.QuizMatch) {
+		m.State = models.QuizStateGameFinished
+		m.FinishedAt = &now
+		if winnerID > 0 {
+			m.WinnerID = &winnerID
+		}
+	})
+}
+
+// TimeoutQuizMatch marks a match as timed out
+func (r *QuizMatchRepository) TimeoutQuizMatch(matchID uint) error {
+	now := time.Now()
+	return r.updateMatch(matchID, func(m *models.QuizMatch) {
+		m.State = models.QuizStateTimeout
+		m.FinishedAt = &now
+	})
+}
+
+// GetTimeoutMatches retrieves matches that have exceeded the timeout period
+func (r *QuizMatchRepository) GetTimeoutMatches() ([]models.QuizMatch, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	matches := r.db.quizMatches.find(func(m *models.QuizMatch) bool { return m.TimeoutAt.Before(now) && quizActive(m) })
+	for i := range matches {
+		r.db.preloadQuizUsers(&matches[i])
+	}
+	return matches, nil
+}
+
+// SwitchTurn switches the turn to the other user
+func (r *QuizMatchRepository) SwitchTurn(matchID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if !r.db.quizMatches.update(matchID, func(m *models.QuizMatch) {
+		next := m.User1ID
+		if m.TurnUserID != nil && *m.TurnUserID == m.User1ID {
+			next = m.User2ID
+		}
+		m.TurnUserID = &next
+		m.LastActivityAt = time.Now()
+	}) {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get match")
+	}
+	return nil
+}
+
+// AdvanceRound advances to the next round
+func (r *QuizMatchRepository) AdvanceRound(matchID uint) error {
+	return r.updateMatch(matchID, func(m *models.QuizMatch) {
+		m.CurrentRound++
+		m.CurrentQuestion = 0
+		m.State = models.QuizStateWaitingCategory
+		m.LastActivityAt = time.Now()
+	})
+}
+
+// CountActiveQuizMatches counts quiz matches that are not finished or timed out
+func (r *QuizMatchRepository) CountActiveQuizMatches() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.quizMatches.count(quizActive), nil
+}
+
+func (r *QuizMatchRepository) updateMatch(matchID uint, fn func(m *models.QuizMatch)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.quizMatches.update(matchID, fn)
+	return nil
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type ReportRepository struct {
+	db *DB
+}
+
+func NewReportRepository(db *DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// CreateReport stores a new pending report; a user can report each match only once
+func (r *ReportRepository) CreateReport(report *models.ChatReport) error {
+	if report.ReporterID == report.ReportedID {
+		return errors.New(errors.ErrCodeValidationFailed, "cannot report yourself")
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	report.Status = models.ReportStatusPending
+	if r.db.reports.count(func(c *models.ChatReport) bool {
+		return c.ReporterID == report.ReporterID && c.MatchID == report.MatchID
+	}) > 0 {
+		return errors.New(errors.ErrCodeAlreadyExists, "match already reported")
+	}
+
+	r.db.reports.insert(report)
+	return nil
+}
+
+// GetReportByID retrieves a report with both users loaded
+func (r *ReportRepository) GetReportByID(id uint) (*models.ChatReport, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	report, ok := r.db.reports.get(id)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "report not found")
+	}
+	r.db.preloadReportUsers(&report)
+	return &report, nil
+}
+
+func (db *DB) preloadReportUsers(report *models.ChatReport) {
+	report.Reporter, _ = db.users.get(report.ReporterID)
+	report.Reported, _ = db.users.get(report.ReportedID)
+}
+
+// GetPendingReports retrieves a page of the moderation queue, oldest first, along with the total count
+func (r *ReportRepository) GetPendingReports(offset, limit int) ([]models.ChatReport, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	reports := r.db.reports.find(func(c *models.ChatReport) bool { return c.Status == models.ReportStatusPending })
+	total := int64(len(reports))
+
+	reports = page(reports, offset, limit)
+	for i := range reports {
+		r.db.preloadReportUsers(&reports[i])
+	}
+	return reports, total, nil
+}
+
+// CountPendingReports counts reports waiting for review
+func (r *ReportRepository) CountPendingReports() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.reports.count(func(c *models.ChatReport) bool { return c.Status == models.ReportStatusPending }), nil
+}
+
+// ReviewReport moves a pending report to confirmed or dismissed.
+// Returns false if the report was already reviewed by someone else.
+func (r *ReportRepository) ReviewReport(id uint, status string, reviewerTgID int64) (bool, error) {
+	if status != models.ReportStatusConfirmed && status != models.ReportStatusDismissed {
+		return false, errors.New(errors.ErrCodeValidationFailed, "invalid report status")
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.reports.updateWhere(func(c *models.ChatReport) bool {
+		return c.ID == id && c.Status == models.ReportStatusPending
+	}, func(c *models.ChatReport) {
+		c.Status = status
+		c.ReviewerTgID = reviewerTgID
+		c.ReviewedAt = &now
+	}) > 0, nil
+}
+
+// CountConfirmedReports counts confirmed reports against a user
+func (r *ReportRepository) CountConfirmedReports(userID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.reports.count(func(c *models.ChatReport) bool {
+		return c.ReportedID == userID && c.Status == models.ReportStatusConfirmed
+	}), nil
+}
+
+// HasReported checks if a user already reported a match
+func (r *ReportRepository) HasReported(reporterID, matchID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.reports.count(func(c *models.ChatReport) bool {
+		return c.ReporterID == reporterID && c.MatchID == matchID
+	}) > 0, nil
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type RoomRepository struct {
+	db *DB
+}
+
+func NewRoomRepository(db *DB) *RoomRepository {
+	return &RoomRepository{db: db}
+}
+
+// CreateRoom creates a new room
+func (r *RoomRepository) CreateRoom(room *models.Room) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := room.BeforeCreate(nil); err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create room")
+	}
+	if r.db.rooms.count(func(rm *models.Room) bool { return rm.InviteCode == room.InviteCode }) > 0 {
+		return errors.Wrap(gorm.ErrDuplicatedKey, errors.ErrCodeInternalError, "failed to create room")
+	}
+
+	r.db.rooms.insert(room)
+	return nil
+}
+
+// GetRoomByID retrieves a room by ID
+func (r *RoomRepository) GetRoomByID(roomID uint) (*models.Room, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	room, ok := r.db.rooms.get(roomID)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "room not found")
+	}
+	room.Host, _ = r.db.users.get(room.HostID)
+	return &room, nil
+}
+
+// GetPublicRooms retrieves all public rooms that are waiting or in progress and not full
+func (r *RoomRepository) GetPublicRooms() ([]models.Room, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	rooms := r.db.rooms.find(func(rm *models.Room) bool {
+		return rm.RoomType == models.RoomTypePublic &&
+			(rm.Status == models.RoomStatusWaiting || rm.Status == models.RoomStatusInProgress) &&
+			int(r.db.activeMemberCount(rm.ID)) < rm.MaxPlayers
+	})
+	sort.SliceStable(rooms, func(i, j int) bool { return rooms[i].ID > rooms[j].ID })
+	for i := range rooms {
+		rooms[i].Host, _ = r.db.users.get(rooms[i].HostID)
+	}
+	return rooms, nil
+}
+
+// activeMemberCount counts the members of a room that were not kicked
+func (db *DB) activeMemberCount(roomID uint) int64 {
+	return db.roomMembers.count(func(m *models.RoomMember) bool { return m.RoomID == roomID && !m.IsKicked })
+}
+
+// GetRoomByInviteCode retrieves a room by invite code
+func (r *RoomRepository) GetRoomByInviteCode(inviteCode string) (*models.Room, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	room, ok := r.db.rooms.first(func(rm *models.Room) bool { return rm.InviteCode == inviteCode })
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "room not found")
+	}
+	room.Host, _ = r.db.users.get(room.HostID)
+	return &room, nil
+}
+
+// AddMember adds a member to a room
+func (r *RoomRepository) AddMember(roomID, userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	room, ok := r.db.rooms.get(roomID)
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, errors.ErrCodeInternalError, "failed to get room")
+	}
+	if int(r.db.activeMemberCount(roomID)) >= room.MaxPlayers {
+		return errors.New(errors.ErrCodeValidationFailed, "room is full")
+	}
+	if r.db.roomMembers.count(func(m *models.RoomMember) bool { return m.RoomID == roomID && m.UserID == userID }) > 0 {
+		return errors.New(errors.ErrCodeAlreadyExists, "user already in room")
+	}
+
+	r.db.roomMembers.insert(&models.RoomMember{RoomID: roomID, UserID: userID})
+	return nil
+}
+
+// RemoveMember removes a member from a room
+func (r *RoomRepository) RemoveMember(roomID, userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.roomMembers.deleteWhere(func(m *models.RoomMember) bool { return m.RoomID == roomID && m.UserID == userID }) == 0 {
+		return errors.New(errors.ErrCodeNotFound, "member not found")
+	}
+	return nil
+}
+
+// GetRoomMembers retrieves all members of a room
+func (r *RoomRepository) GetRoomMembers(roomID uint) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var members []models.User
+	for _, m := range r.db.roomMembers.find(func(m *models.RoomMember) bool { return m.RoomID == roomID && !m.IsKicked }) {
+		if user, ok := r.db.users.get(m.UserID); ok {
+			members = append(members, user)
+		}
+	}
+	return members, nil
+}
+
+// GetMemberCount returns the number of members in a room
+func (r *RoomRepository) GetMemberCount(roomID uint) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return int(r.db.activeMemberCount(roomID)), nil
+}
+
+// IsHost checks if a user is the host of a room
+func (r *RoomRepository) IsHost(roomID, userID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	room, ok := r.db.rooms.get(roomID)
+	if !ok {
+		return false, errors.New(errors.ErrCodeNotFound, "room not found")
+	}
+	return room.HostID == userID, nil
+}
+
+// IsMember checks if a user is a member of a room
+func (r *RoomRepository) IsMember(roomID, userID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.roomMembers.count(func(m *models.RoomMember) bool {
+		return m.RoomID == roomID && m.UserID == userID && !m.IsKicked
+	}) > 0, nil
+}
+
+// CloseRoom closes a room
+func (r *RoomRepository) CloseRoom(roomID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.rooms.update(roomID, func(rm *models.Room) { rm.Status = models.RoomStatusClosed })
+	return nil
+}
+
+// KickMember kicks a member from a room
+func (r *RoomRepository) KickMember(roomID, userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.roomMembers.updateWhere(func(m *models.RoomMember) bool {
+		return m.RoomID == roomID && m.UserID == userID
+	}, func(m *models.RoomMember) {
+		m.IsKicked = true
+	}) == 0 {
+		return errors.New(errors.ErrCodeNotFound, "member not found")
+	}
+	return nil
+}
+
+// GetUserRooms retrieves all rooms a user is a member of
+func (r *RoomRepository) GetUserRooms(userID uint) ([]models.Room, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var rooms []models.Room
+	for _, m := range r.db.roomMembers.find(func(m *models.RoomMember) bool { return m.UserID == userID && !m.IsKicked }) {
+		room, ok := r.db.rooms.get(m.RoomID)
+		if !ok || room.Status == models.RoomStatusClosed {
+			continue
+		}
+		room.Host, _ = r.db.users.get(room.HostID)
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+)
+
+type ShopRepository struct {
+	db *DB
+}
+
+func NewShopRepository(db *DB) *ShopRepository {
+	return &ShopRepository{db: db}
+}
+
+// Purchase charges the buyer and grants a catalog item in a single transaction.
+// avatarFileID is the photo used for avatar items and ignored otherwise.
+func (r *ShopRepository) Purchase(userID uint, item *models.ShopItem, avatarFileID, idempotencyKey string) (*models.ShopPurchase, error) {
+	if !item.IsOnSale(time.Now()) {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "item is not on sale")
+	}
+	if item.Grant == models.ShopGrantAvatar && avatarFileID == "" {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "avatar photo is required")
+	}
+
+	purchase := &models.ShopPurchase{
+		UserID:   userID,
+		ItemID:   item.ID,
+		Quantity: item.Quantity,
+		Currency: item.Currency,
+		Price:    item.Price,
+	}
+	if idempotencyKey != "" {
+		purchase.IdempotencyKey = &idempotencyKey
+	}
+
+	err := r.db.transaction(func() error {
+		if idempotencyKey != "" && r.db.shopPurchases.count(func(p *models.ShopPurchase) bool {
+			return p.IdempotencyKey != nil && *p.IdempotencyKey == idempotencyKey
+		}) > 0 {
+			return errors.New(errors.ErrCodeDuplicateRequest, "shop purchase already made: "+idempotencyKey)
+		}
+
+		if item.IsLimited() && r.db.countItemSales(item.ID) >= int64(item.Stock) {
+			return errors.New(errors.ErrCodeOutOfStock, "item is out of stock")
+		}
+
+		if err := r.db.charge(userID, item, idempotencyKey); err != nil {
+			return err
+		}
+		if err := r.db.grant(userID, item, avatarFileID); err != nil {
+			return err
+		}
+
+		r.db.shopPurchases.insert(purchase)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// CountItemSales counts units of an item sold so far, for stock checks
+func (r *ShopRepository) CountItemSales(itemID string) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.countItemSales(itemID), nil
+}
+
+func (db *DB) countItemSales(itemID string) int64 {
+	return db.shopPurchases.count(func(p *models.ShopPurchase) bool { return p.ItemID == itemID })
+}
+
+// charge takes the item's price from the buyer in its currency
+func (db *DB) charge(userID uint, item *models.ShopItem, idempotencyKey string) error {
+	description := fmt.Sprintf("خرید %s از فروشگاه", item.Title)
+	switch item.Currency {
+	case models.CurrencyCoins:
+		return db.deductCoins(userID, item.Price, models.TxTypeShopPurchase, description, idempotencyKey)
+	case models.CurrencyDiamonds:
+		return db.deductDiamonds(userID, item.Price, models.TxTypeShopPurchase, description, "")
+	default:
+		return errors.New(errors.ErrCodeValidationFailed, "unknown currency")
+	}
+}
+
+// grant delivers a bought item to wherever the game keeps it
+func (db *DB) grant(userID uint, item *models.ShopItem, avatarFileID string) error {
+	switch item.Grant {
+	case models.ShopGrantInventory:
+		return db.grantItem(userID, item.GrantKey, item.Quantity)
+	case models.ShopGrantAvatar:
+		db.users.update(userID, func(u *models.User) { u.CustomAvatarID = avatarFileID })
+		return nil
+	default:
+		return errors.New(errors.ErrCodeValidationFailed, "unknown grant kind")
+	}
+}
@@ -0,0 +1,23 @@
+package memory
+
+import "github.com/mroshb/game_bot/internal/repositories"
+
+var (
+	_ repositories.UserStore      = (*UserRepository)(nil)
+	_ repositories.CoinStore      = (*CoinRepository)(nil)
+	_ repositories.DiamondStore   = (*DiamondRepository)(nil)
+	_ repositories.MatchStore     = (*MatchRepository)(nil)
+	_ repositories.FriendStore    = (*FriendRepository)(nil)
+	_ repositories.GameStore      = (*GameRepository)(nil)
+	_ repositories.RoomStore      = (*RoomRepository)(nil)
+	_ repositories.VillageStore   = (*VillageRepository)(nil)
+	_ repositories.QuizMatchStore = (*QuizMatchRepository)(nil)
+	_ repositories.TodStore       = (*TodRepository)(nil)
+	_ repositories.BlockStore     = (*BlockRepository)(nil)
+	_ repositories.ReportStore    = (*ReportRepository)(nil)
+	_ repositories.BanStore       = (*BanRepository)(nil)
+	_ repositories.PurchaseStore  = (*PurchaseRepository)(nil)
+	_ repositories.ShopStore      = (*ShopRepository)(nil)
+	_ repositories.InventoryStore = (*InventoryRepository)(nil)
+	_ repositories.BroadcastStore = (*BroadcastRepository)(nil)
+)
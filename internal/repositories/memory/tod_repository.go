@@ -0,0 +1,666 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// TodRepository returns raw gorm errors like its GORM counterpart does
+type TodRepository struct {
+	db *DB
+}
+
+func NewTodRepository(db *DB) *TodRepository {
+	return &TodRepository{db: db}
+}
+
+// ========================================
+// GAME CRUD OPERATIONS
+// ========================================
+
+// CreateGame creates a new ToD game linked to a match
+func (r *TodRepository) CreateGame(matchID uint, player1ID, player2ID uint) (*models.TodGame, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.todGames.count(func(g *models.TodGame) bool { return g.MatchID == matchID }) > 0 {
+		return nil, gorm.ErrDuplicatedKey
+	}
+
+	now := time.Now()
+	deadline := now.Add(60 * time.Second)
+
+	game := &models.TodGame{
+		MatchID:         matchID,
+		State:           models.TodStateMatchmaking,
+		ActivePlayerID:  player1ID,
+		PassivePlayerID: player2ID,
+		CurrentRound:    1,
+		MaxRounds:       10,
+		TurnStartedAt:   &now,
+		TurnDeadline:    &deadline,
+		AllowItems:      true,
+		DifficultyLevel: "normal",
+		StartedAt:       &now,
+	}
+	r.db.todGames.insert(game)
+	r.db.preloadTodMatch(game)
+	return game, nil
+}
+
+// preloadTodMatch fills the match of a game together with its players
+func (db *DB) preloadTodMatch(g *models.TodGame) {
+	g.Match, _ = db.matches.get(g.MatchID)
+	db.preloadMatchUsers(&g.Match)
+}
+
+// GetGameByID retrieves a game by ID with preloaded relationships
+func (r *TodRepository) GetGameByID(gameID uint) (*models.TodGame, error) {
+	return r.firstGame(func(g *models.TodGame) bool { return g.ID == gameID })
+}
+
+// GetGameByMatchID retrieves a game by match ID
+func (r *TodRepository) GetGameByMatchID(matchID uint) (*models.TodGame, error) {
+	return r.firstGame(func(g *models.TodGame) bool { return g.MatchID == matchID })
+}
+
+// GetActiveGameForUser retrieves active game for a user
+func (r *TodRepository) GetActiveGameForUser(userID uint) (*models.TodGame, error) {
+	return r.firstGame(func(g *models.TodGame) bool {
+		return (g.ActivePlayerID == userID || g.PassivePlayerID == userID) && todActive(g)
+	})
+}
+
+func (r *TodRepository) firstGame(where func(g *models.TodGame) bool) (*models.TodGame, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	game, ok := r.db.todGames.first(where)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	r.db.preloadTodMatch(&game)
+	return &game, nil
+}
+
+// todActive matches games that have not ended
+func todActive(g *models.TodGame) bool {
+	return g.State != models.TodStateGameEnd && g.State != models.TodStateForfeit
+}
+
+// UpdateGameState updates the game state
+func (r *TodRepository) UpdateGameState(gameID uint, newState string) error {
+	return r.updateGame(gameID, func(g *models.TodGame) { g.State = newState })
+}
+
+// UpdateGameStateAtomic moves a game to newState only if it is still in one of expectedStates.
+// Returns false if another handler changed the state first.
+func (r *TodRepository) UpdateGameStateAtomic(gameID uint, expectedStates []string, newState string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.todGames.updateWhere(func(g *models.TodGame) bool {
+		return g.ID == gameID && containsString(expectedStates, g.State)
+	}, func(g *models.TodGame) {
+		g.State = newState
+	}) > 0, nil
+}
+
+// SetPlayerOrder decides who plays the first turn
+func (r *TodRepository) SetPlayerOrder(gameID, activePlayerID, passivePlayerID uint) error {
+	return r.updateGame(gameID, func(g *models.TodGame) {
+		g.ActivePlayerID = activePlayerID
+		g.PassivePlayerID = passivePlayerID
+	})
+}
+
+func (r *TodRepository) updateGame(gameID uint, fn func(g *models.TodGame)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.todGames.update(gameID, fn)
+	return nil
+}
+
+// ========================================
+// TURN MANAGEMENT
+// ========================================
+
+// CreateTurn creates a new turn
+func (r *TodRepository) CreateTurn(gameID uint, playerID, judgeID uint, roundNum int) (*models.TodTurn, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	turn := &models.TodTurn{
+		GameID:      gameID,
+		RoundNumber: roundNum,
+		PlayerID:    playerID,
+		JudgeID:     judgeID,
+	}
+	r.db.todTurns.insert(turn)
+
+	// Update game's current_turn_id
+	r.db.todGames.update(gameID, func(g *models.TodGame) { g.CurrentTurnID = turn.ID })
+	return turn, nil
+}
+
+// GetCurrentTurn retrieves the current turn for a game
+func (r *TodRepository) GetCurrentTurn(gameID uint) (*models.TodTurn, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	game, ok := r.db.todGames.get(gameID)
+	if !ok || game.CurrentTurnID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	turn, ok := r.db.todTurns.get(game.CurrentTurnID)
+	if !ok {
+		return &turn, gorm.ErrRecordNotFound
+	}
+	if turn.ChallengeID != nil {
+		if challenge, ok := r.db.todChallenges.get(*turn.ChallengeID); ok {
+			turn.Challenge = &challenge
+		}
+	}
+	return &turn, nil
+}
+
+// GetTurns retrieves every turn of a game
+func (r *TodRepository) GetTurns(gameID uint) ([]models.TodTurn, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.todTurns.find(func(t *models.TodTurn) bool { return t.GameID == gameID }), nil
+}
+
+// UpdateTurnChoice updates the choice made in a turn
+func (r *TodRepository) UpdateTurnChoice(turnID uint, choice string) error {
+	now := time.Now()
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.Choice = choice
+		t.ChosenAt = &now
+	})
+}
+
+// UpdateTurnChallenge updates the challenge for a turn
+func (r *TodRepository) UpdateTurnChallenge(turnID uint, challengeID uint, challengeText string) error {
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.ChallengeID = &challengeID
+		t.ChallengeText = challengeText
+	})
+}
+
+// UpdateTurnProof updates the proof submission
+func (r *TodRepository) UpdateTurnProof(turnID uint, proofType, proofData string) error {
+	now := time.Now()
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.ProofType = proofType
+		t.ProofData = proofData
+		t.ProofSubmittedAt = &now
+	})
+}
+
+// UpdateTurnJudgment updates the judgment result
+func (r *TodRepository) UpdateTurnJudgment(turnID uint, result, reason string) error {
+	now := time.Now()
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.JudgmentResult = result
+		t.JudgmentReason = reason
+		t.JudgedAt = &now
+	})
+}
+
+// CompleteTurn marks a turn as completed
+func (r *TodRepository) CompleteTurn(turnID uint) error {
+	now := time.Now()
+	return r.updateTurn(turnID, func(t *models.TodTurn) { t.CompletedAt = &now })
+}
+
+// UpdateTurnRewards updates XP and coins awarded
+func (r *TodRepository) UpdateTurnRewards(turnID uint, xp, coins int) error {
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.XPAwarded = xp
+		t.CoinsAwarded = coins
+	})
+}
+
+// RecordItemUse notes the item played during a turn
+func (r *TodRepository) RecordItemUse(turnID uint, itemType string) error {
+	now := time.Now()
+	return r.updateTurn(turnID, func(t *models.TodTurn) {
+		t.ItemUsed = itemType
+		t.ItemUsedAt = &now
+	})
+}
+
+func (r *TodRepository) updateTurn(turnID uint, fn func(t *models.TodTurn)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.todTurns.update(turnID, fn)
+	return nil
+}
+
+// ========================================
+// CHALLENGE SELECTION
+// ========================================
+
+// GetRandomChallenge retrieves a random challenge based on filters. Like the GORM
+// version it ignores category and relation, and falls back to any active challenge of the type.
+func (r *TodRepository) GetRandomChallenge(challengeType, difficulty, category, gender, relation string) (*models.TodChallenge, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	ofType := func(c *models.TodChallenge) bool { return c.Type == challengeType && c.IsActive }
+
+	challenges := pick(r.db.todChallenges.find(func(c *models.TodChallenge) bool {
+		if !ofType(c) || (difficulty != "" && c.Difficulty != difficulty) {
+			return false
+		}
+		return gender == "" || gender == "all" || c.GenderTarget == gender || c.GenderTarget == "all"
+	}), 1)
+	if len(challenges) == 0 {
+		challenges = pick(r.db.todChallenges.find(ofType), 1)
+	}
+	if len(challenges) == 0 {
+		return &models.TodChallenge{}, gorm.ErrRecordNotFound
+	}
+	return &challenges[0], nil
+}
+
+// IncrementChallengeUsage increments the usage counter
+func (r *TodRepository) IncrementChallengeUsage(challengeID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// UpdateColumn leaves updated_at alone
+	if c, ok := r.db.todChallenges.rows[challengeID]; ok {
+		c.TimesUsed++
+	}
+	return nil
+}
+
+// UpdateChallengeAcceptanceRate updates the acceptance rate
+func (r *TodRepository) UpdateChallengeAcceptanceRate(challengeID uint, wasAccepted bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if !r.db.todChallenges.update(challengeID, func(c *models.TodChallenge) {
+		totalJudgments := c.TimesUsed
+		if totalJudgments == 0 {
+			totalJudgments = 1
+		}
+
+		currentAccepted := c.AcceptanceRate * float64(totalJudgments-1)
+		if wasAccepted {
+			currentAccepted++
+		}
+		c.AcceptanceRate = currentAccepted / float64(totalJudgments)
+	}) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ========================================
+// TIMER & TIMEOUT
+// ========================================
+
+// todWaiting matches games waiting on a player with a running turn timer
+func todWaiting(g *models.TodGame) bool {
+	switch g.State {
+	case models.TodStateWaitingChoice, models.TodStateWaitingProof, models.TodStateWaitingJudgment:
+		return g.TurnDeadline != nil
+	}
+	return false
+}
+
+// GetGamesNearingTimeout retrieves games approaching 30s warning
+func (r *TodRepository) GetGamesNearingTimeout() ([]*models.TodGame, error) {
+	warningTime := time.Now().Add(30 * time.Second)
+	return r.findGames(func(g *models.TodGame) bool {
+		return todWaiting(g) && !g.TurnDeadline.After(warningTime) && g.WarningShownAt == nil
+	}), nil
+}
+
+// GetTimedOutGames retrieves games that have exceeded deadline
+func (r *TodRepository) GetTimedOutGames() ([]*models.TodGame, error) {
+	now := time.Now()
+	return r.findGames(func(g *models.TodGame) bool { return todWaiting(g) && g.TurnDeadline.Before(now) }), nil
+}
+
+func (r *TodRepository) findGames(where func(g *models.TodGame) bool) []*models.TodGame {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var games []*models.TodGame
+	for _, game := range r.db.todGames.find(where) {
+		game := game
+		r.db.preloadTodMatch(&game)
+		games = append(games, &game)
+	}
+	return games
+}
+
+// MarkWarningShown marks that warning has been shown
+func (r *TodRepository) MarkWarningShown(gameID uint) error {
+	now := time.Now()
+	return r.updateGame(gameID, func(g *models.TodGame) { g.WarningShownAt = &now })
+}
+
+// HandleTimeout handles game timeout
+func (r *TodRepository) HandleTimeout(gameID uint) error {
+	now := time.Now()
+	return r.updateGame(gameID, func(g *models.TodGame) {
+		g.State = models.TodStateForfeit
+		g.EndedAt = &now
+		g.EndReason = "timeout"
+	})
+}
+
+// ========================================
+// PLAYER STATS
+// ========================================
+
+// GetOrCreatePlayerStats retrieves or creates player stats
+func (r *TodRepository) GetOrCreatePlayerStats(userID uint) (*models.TodPlayerStats, error) {
+	var stats models.TodPlayerStats
+
+	err := r.db.transaction(func() error {
+		var ok bool
+		if stats, ok = r.db.todStats.first(func(s *models.TodPlayerStats) bool { return s.UserID == userID }); ok {
+			return nil
+		}
+
+		// First game: create the stats row together with the starting items
+		stats = models.TodPlayerStats{UserID: userID, JudgeScore: 100.0}
+		r.db.todStats.insert(&stats)
+		for _, itemType := range models.TodStartingItems {
+			if err := r.db.grantItem(userID, itemType, 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// updatePlayerStats changes the stats row of a user if there is one, like UpdatePlayerStats
+func (r *TodRepository) updatePlayerStats(userID uint, fn func(s *models.TodPlayerStats)) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.updatePlayerStats(userID, fn)
+	return nil
+}
+
+func (db *DB) updatePlayerStats(userID uint, fn func(s *models.TodPlayerStats)) {
+	db.todStats.updateWhere(func(s *models.TodPlayerStats) bool { return s.UserID == userID }, fn)
+}
+
+// IncrementGamesPlayed increments games played counter
+func (r *TodRepository) IncrementGamesPlayed(userID uint, won bool) error {
+	return r.updatePlayerStats(userID, func(s *models.TodPlayerStats) {
+		s.GamesPlayed++
+		if won {
+			s.GamesWon++
+		} else {
+			s.GamesLost++
+		}
+	})
+}
+
+// IncrementChallengeCompleted increments challenge stats
+func (r *TodRepository) IncrementChallengeCompleted(userID uint, choiceType string, wasAccepted bool) error {
+	return r.updatePlayerStats(userID, func(s *models.TodPlayerStats) {
+		if choiceType == models.TodTypeTruth {
+			s.TruthsChosen++
+		} else if choiceType == models.TodTypeDare {
+			s.DaresChosen++
+		}
+
+		if wasAccepted {
+			s.ChallengesCompleted++
+		} else {
+			s.ChallengesFailed++
+		}
+	})
+}
+
+// IncrementTimeoutCount counts a turn the player let run out
+func (r *TodRepository) IncrementTimeoutCount(userID uint) error {
+	return r.updatePlayerStats(userID, func(s *models.TodPlayerStats) { s.TimeoutCount++ })
+}
+
+// UpdateJudgeScore updates judge score
+func (r *TodRepository) UpdateJudgeScore(userID uint, newScore float64) error {
+	return r.updatePlayerStats(userID, func(s *models.TodPlayerStats) { s.JudgeScore = newScore })
+}
+
+// UseItem spends one of the player's items on a turn in one transaction; a second use in
+// the same turn fails with ErrCodeDuplicateRequest
+func (r *TodRepository) UseItem(gameID, turnID, userID uint, itemType string) error {
+	actionID := fmt.Sprintf("use_item:%d", turnID)
+	return r.db.transaction(func() error {
+		if r.db.todActions.count(func(a *models.TodActionLog) bool { return a.ActionID == actionID }) > 0 {
+			return errors.New(errors.ErrCodeDuplicateRequest, "an item was already used this turn")
+		}
+		r.db.todActions.insert(&models.TodActionLog{
+			GameID:   gameID,
+			UserID:   userID,
+			ActionID: actionID,
+			Action:   "use_item_" + itemType,
+		})
+
+		if err := r.db.consumeItem(userID, itemType, 1); err != nil {
+			return err
+		}
+		r.db.updatePlayerStats(userID, func(s *models.TodPlayerStats) { s.ItemsUsed++ })
+
+		now := time.Now()
+		r.db.todTurns.update(turnID, func(t *models.TodTurn) {
+			t.ItemUsed = itemType
+			t.ItemUsedAt = &now
+		})
+		return nil
+	})
+}
+
+// ========================================
+// JUDGMENT & ANTI-ABUSE
+// ========================================
+
+// LogJudgment creates a judgment log entry
+func (r *TodRepository) LogJudgment(turnID, judgeID, playerID uint, result string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.todJudgments.insert(&models.TodJudgmentLog{
+		TurnID:   turnID,
+		JudgeID:  judgeID,
+		PlayerID: playerID,
+		Result:   result,
+	})
+
+	// Update judge stats
+	r.db.updatePlayerStats(judgeID, func(s *models.TodPlayerStats) {
+		s.JudgmentsMade++
+		if result == "accepted" {
+			s.JudgmentsAccepted++
+		} else {
+			s.JudgmentsRejected++
+		}
+	})
+	return nil
+}
+
+// recentJudgments retrieves the latest judgments by a judge, newest first
+func (r *TodRepository) recentJudgments(judgeID uint, limit int) []models.TodJudgmentLog {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	logs := r.db.todJudgments.find(func(l *models.TodJudgmentLog) bool { return l.JudgeID == judgeID })
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].ID > logs[j].ID })
+	return page(logs, 0, limit)
+}
+
+// DetectUnfairJudgment detects unfair judgment patterns
+func (r *TodRepository) DetectUnfairJudgment(judgeID uint) (bool, string, error) {
+	logs := r.recentJudgments(judgeID, 10)
+	if len(logs) < 5 {
+		return false, "", nil // Not enough data
+	}
+
+	// Pattern 1: Rejected last 8 out of 10
+	rejectedCount := 0
+	for _, log := range logs {
+		if log.Result == "rejected" {
+			rejectedCount++
+		}
+	}
+	if rejectedCount >= 8 {
+		return true, "رد متوالی بیش از حد", nil
+	}
+
+	// Pattern 2: All rejections in last 5 games
+	for _, log := range logs[:5] {
+		if log.Result == "accepted" {
+			return false, "", nil
+		}
+	}
+	return true, "رد ۵ مورد متوالی", nil
+}
+
+// IncrementUnfairJudgmentCount increments unfair judgment counter
+func (r *TodRepository) IncrementUnfairJudgmentCount(judgeID uint) error {
+	return r.updatePlayerStats(judgeID, func(s *models.TodPlayerStats) { s.UnfairJudgmentCount++ })
+}
+
+// CalculateJudgeScore calculates judge score based on recent judgments
+func (r *TodRepository) CalculateJudgeScore(judgeID uint) (float64, error) {
+	logs := r.recentJudgments(judgeID, 20)
+	if len(logs) < 5 {
+		return 100.0, nil // Not enough data
+	}
+
+	acceptedCount := 0
+	for _, log := range logs {
+		if log.Result == "accepted" {
+			acceptedCount++
+		}
+	}
+
+	acceptanceRate := float64(acceptedCount) / float64(len(logs))
+
+	// Score based on acceptance rate
+	switch {
+	case acceptanceRate < 0.3:
+		return 20.0, nil // Very suspicious
+	case acceptanceRate < 0.5:
+		return 50.0, nil // Suspicious
+	case acceptanceRate >= 0.7 && acceptanceRate <= 0.9:
+		return 100.0, nil // Perfect
+	case acceptanceRate > 0.9:
+		return 80.0, nil // Too lenient
+	default:
+		return 70.0, nil // Acceptable
+	}
+}
+
+// ========================================
+// GAME END
+// ========================================
+
+// EndGame ends a game and sets winner
+func (r *TodRepository) EndGame(gameID uint, winnerID uint, reason string) error {
+	now := time.Now()
+	return r.updateGame(gameID, func(g *models.TodGame) {
+		g.State = models.TodStateGameEnd
+		g.EndedAt = &now
+		g.EndReason = reason
+		if winnerID > 0 {
+			g.WinnerID = &winnerID
+		}
+	})
+}
+
+// SwitchTurn switches active and passive players
+func (r *TodRepository) SwitchTurn(gameID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	deadline := now.Add(60 * time.Second)
+
+	if !r.db.todGames.update(gameID, func(g *models.TodGame) {
+		g.ActivePlayerID, g.PassivePlayerID = g.PassivePlayerID, g.ActivePlayerID
+		g.TurnStartedAt = &now
+		g.TurnDeadline = &deadline
+		g.WarningShownAt = nil
+	}) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IncrementRound increments the current round
+func (r *TodRepository) IncrementRound(gameID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// UpdateColumn leaves updated_at alone
+	if g, ok := r.db.todGames.rows[gameID]; ok {
+		g.CurrentRound++
+	}
+	return nil
+}
+
+// ========================================
+// IDEMPOTENCY
+// ========================================
+
+// ClaimAction records an action and reports whether this call was the first to do so
+func (r *TodRepository) ClaimAction(gameID uint, userID uint, actionID, action string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.todActions.count(func(a *models.TodActionLog) bool { return a.ActionID == actionID }) > 0 {
+		return false, nil
+	}
+
+	r.db.todActions.insert(&models.TodActionLog{
+		GameID:   gameID,
+		UserID:   userID,
+		ActionID: actionID,
+		Action:   action,
+	})
+	return true, nil
+}
+
+// CleanupOldActions removes old action logs (older than 24 hours)
+func (r *TodRepository) CleanupOldActions() error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	r.db.todActions.deleteWhere(func(a *models.TodActionLog) bool { return a.CreatedAt.Before(cutoff) })
+	return nil
+}
+
+// CountActiveGames counts Truth or Dare games that have not ended
+func (r *TodRepository) CountActiveGames() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.todGames.count(todActive), nil
+}
@@ -0,0 +1,384 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"github.com/mroshb/game_bot/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// CreateUser creates a new user and records its starting balance in the ledger
+func (r *UserRepository) CreateUser(user *models.User) error {
+	if user.PublicID == "" {
+		user.PublicID = utils.GenerateRandomID(8)
+	}
+
+	err := r.db.transaction(func() error {
+		if err := user.BeforeSave(nil); err != nil {
+			return err
+		}
+		if r.db.users.count(func(u *models.User) bool {
+			return u.TelegramID == user.TelegramID || u.PublicID == user.PublicID
+		}) > 0 {
+			return gorm.ErrDuplicatedKey
+		}
+
+		r.db.users.insert(user)
+		if user.CoinBalance == 0 {
+			return nil
+		}
+		r.db.insertCoinTx(&models.CoinTransaction{
+			UserID:          user.ID,
+			Amount:          user.CoinBalance,
+			TransactionType: models.TxTypeOpeningBalance,
+			Description:     "هدیه عضویت",
+		})
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create user")
+	}
+	return nil
+}
+
+// GetUserByPublicID retrieves a user by Public ID
+func (r *UserRepository) GetUserByPublicID(publicID string) (*models.User, error) {
+	return r.getUser(func(u *models.User) bool { return u.PublicID == publicID })
+}
+
+// GetUserByTelegramID retrieves a user by Telegram ID
+func (r *UserRepository) GetUserByTelegramID(telegramID int64) (*models.User, error) {
+	return r.getUser(func(u *models.User) bool { return u.TelegramID == telegramID })
+}
+
+// GetUserByID retrieves a user by ID
+func (r *UserRepository) GetUserByID(id uint) (*models.User, error) {
+	return r.getUser(func(u *models.User) bool { return u.ID == id })
+}
+
+func (r *UserRepository) getUser(where func(u *models.User) bool) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users.first(where)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "user not found")
+	}
+	return &user, nil
+}
+
+// UpdateUser saves every field of the user except the balances, which only change through the ledgers
+func (r *UserRepository) UpdateUser(user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := user.BeforeSave(nil); err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to update user")
+	}
+
+	stored, ok := r.db.users.rows[user.ID]
+	if !ok {
+		r.db.users.insert(user)
+		return nil
+	}
+
+	saved := *user
+	saved.CoinBalance = stored.CoinBalance
+	saved.Diamonds = stored.Diamonds
+	if saved.CreatedAt.IsZero() {
+		saved.CreatedAt = stored.CreatedAt
+	}
+	r.db.users.update(user.ID, func(u *models.User) { *u = saved })
+	user.UpdatedAt = r.db.users.rows[user.ID].UpdatedAt
+	return nil
+}
+
+// UpdateUserStatus updates user status
+func (r *UserRepository) UpdateUserStatus(userID uint, status string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.users.update(userID, func(u *models.User) { u.Status = status })
+	return nil
+}
+
+// CountUsers counts all registered users
+func (r *UserRepository) CountUsers() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.users.count(nil), nil
+}
+
+// CountOnlineUsers counts users that are not offline
+func (r *UserRepository) CountOnlineUsers() (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.users.count(func(u *models.User) bool { return u.Status != models.UserStatusOffline }), nil
+}
+
+// UpdateLastActivity updates user's last activity timestamp
+func (r *UserRepository) UpdateLastActivity(userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.users.update(userID, func(u *models.User) { u.LastActivity = time.Now() })
+	return nil
+}
+
+// GetLeaderboard returns the top users by XP for game categories and by coins otherwise
+func (r *UserRepository) GetLeaderboard(category string, period string, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(nil)
+	switch category {
+	case "quiz", "truth_dare":
+		sort.SliceStable(users, func(i, j int) bool { return users[i].XP > users[j].XP })
+	default:
+		sort.SliceStable(users, func(i, j int) bool { return users[i].CoinBalance > users[j].CoinBalance })
+	}
+	return page(users, 0, limit), nil
+}
+
+// GetUserRank returns user's rank based on coin balance
+func (r *UserRepository) GetUserRank(userID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users.get(userID)
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	rank := r.db.users.count(func(u *models.User) bool { return u.CoinBalance > user.CoinBalance })
+	return rank + 1, nil
+}
+
+// FindRecentChatUsers returns the users the current user has finished chats with, latest first
+func (r *UserRepository) FindRecentChatUsers(userID uint, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	lastChat := make(map[uint]time.Time)
+	for _, m := range r.db.matches.find(func(m *models.MatchSession) bool {
+		return (m.User1ID == userID || m.User2ID == userID) && m.EndedAt != nil
+	}) {
+		other := m.User1ID
+		if other == userID {
+			other = m.User2ID
+		}
+		if m.EndedAt.After(lastChat[other]) {
+			lastChat[other] = *m.EndedAt
+		}
+	}
+
+	users := r.db.users.find(func(u *models.User) bool {
+		_, ok := lastChat[u.ID]
+		return ok
+	})
+	sort.SliceStable(users, func(i, j int) bool { return lastChat[users[i].ID].After(lastChat[users[j].ID]) })
+	return page(users, 0, limit), nil
+}
+
+// FindUsersByProvince returns users in the same province
+func (r *UserRepository) FindUsersByProvince(userID uint, province string, limit int) ([]models.User, error) {
+	return r.findRecentlyActive(limit, func(u *models.User) bool {
+		return u.Province == province && u.ID != userID
+	}), nil
+}
+
+// FindUsersByAge returns users with similar age (+/- 2 years)
+func (r *UserRepository) FindUsersByAge(userID uint, age int, limit int) ([]models.User, error) {
+	return r.findRecentlyActive(limit, func(u *models.User) bool {
+		return u.Age >= age-2 && u.Age <= age+2 && u.ID != userID
+	}), nil
+}
+
+func (r *UserRepository) findRecentlyActive(limit int, where func(u *models.User) bool) []models.User {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(where)
+	sort.SliceStable(users, func(i, j int) bool { return users[i].LastActivity.After(users[j].LastActivity) })
+	return page(users, 0, limit)
+}
+
+// FindNewUsers returns most recently registered users
+func (r *UserRepository) FindNewUsers(userID uint, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(func(u *models.User) bool { return u.ID != userID })
+	sortNewestFirst(users)
+	return page(users, 0, limit), nil
+}
+
+// FindUsersWithNoChats returns the newest users who were never matched
+func (r *UserRepository) FindUsersWithNoChats(userID uint, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(func(u *models.User) bool {
+		return u.ID != userID && r.db.matches.count(func(m *models.MatchSession) bool {
+			return m.User1ID == u.ID || m.User2ID == u.ID
+		}) == 0
+	})
+	sortNewestFirst(users)
+	return page(users, 0, limit), nil
+}
+
+func sortNewestFirst(users []models.User) {
+	sort.SliceStable(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+}
+
+// UpdateLocation updates user's latitude and longitude
+func (r *UserRepository) UpdateLocation(userID uint, lat, lon float64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.users.update(userID, func(u *models.User) {
+		u.Latitude = lat
+		u.Longitude = lon
+	})
+	return nil
+}
+
+// FindNearbyUsers returns users who shared a location, nearest first
+func (r *UserRepository) FindNearbyUsers(userID uint, lat, lon float64, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(func(u *models.User) bool {
+		return u.ID != userID && u.Latitude != 0 && u.Longitude != 0
+	})
+	for i := range users {
+		users[i].Distance = haversineKm(lat, lon, users[i].Latitude, users[i].Longitude)
+	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].Distance < users[j].Distance })
+	return page(users, 0, limit), nil
+}
+
+// haversineKm is the distance formula FindNearbyUsers runs in SQL
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	cos := math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Cos(lon2*rad-lon1*rad) +
+		math.Sin(lat1*rad)*math.Sin(lat2*rad)
+	return 6371 * math.Acos(math.Min(1, math.Max(-1, cos)))
+}
+
+// HasLiked checks if a user has already liked another user
+func (r *UserRepository) HasLiked(likerID, likedID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.likes.count(func(l *models.UserLike) bool {
+		return l.LikerID == likerID && l.LikedID == likedID
+	}) > 0, nil
+}
+
+// AddLike adds a like from one user to another
+func (r *UserRepository) AddLike(likerID, likedID uint) error {
+	return r.db.transaction(func() error {
+		if r.db.likes.count(func(l *models.UserLike) bool {
+			return l.LikerID == likerID && l.LikedID == likedID
+		}) > 0 {
+			return gorm.ErrDuplicatedKey
+		}
+
+		r.db.likes.insert(&models.UserLike{LikerID: likerID, LikedID: likedID})
+		r.db.users.update(likedID, func(u *models.User) { u.Likes++ })
+		return nil
+	})
+}
+
+// MarkInactiveUsersOffline marks users as offline if they haven't been active
+func (r *UserRepository) MarkInactiveUsersOffline(timeout time.Duration) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	cutoff := time.Now().Add(-timeout)
+	return r.db.users.updateWhere(func(u *models.User) bool {
+		return u.LastActivity.Before(cutoff) && u.Status != models.UserStatusOffline
+	}, func(u *models.User) {
+		u.Status = models.UserStatusOffline
+	}), nil
+}
+
+// MarkUnreachable flags a user Telegram won't deliver messages to. Returns false if
+// the user was already flagged.
+func (r *UserRepository) MarkUnreachable(userID uint) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	return r.db.users.updateWhere(func(u *models.User) bool {
+		return u.ID == userID && u.UnreachableAt == nil
+	}, func(u *models.User) {
+		u.UnreachableAt = &now
+	}) > 0, nil
+}
+
+// MarkReachable clears the unreachable flag of a user
+func (r *UserRepository) MarkReachable(telegramID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.users.updateWhere(func(u *models.User) bool {
+		return u.TelegramID == telegramID && u.UnreachableAt != nil
+	}, func(u *models.User) {
+		u.UnreachableAt = nil
+	})
+	return nil
+}
+
+// GetUnreachableTelegramIDs returns the Telegram IDs of all users flagged unreachable
+func (r *UserRepository) GetUnreachableTelegramIDs() ([]int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var ids []int64
+	for _, u := range r.db.users.find(func(u *models.User) bool { return u.UnreachableAt != nil }) {
+		ids = append(ids, u.TelegramID)
+	}
+	return ids, nil
+}
+
+// AddXP adds experience points to a user
+func (r *UserRepository) AddXP(userID uint, xp int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.users.update(userID, func(u *models.User) { u.XP += int64(xp) })
+	return nil
+}
+
+// GetReferralCount returns the number of users referred by a specific user
+func (r *UserRepository) GetReferralCount(userID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	return r.db.users.count(func(u *models.User) bool { return u.ReferrerID == userID }), nil
+}
+
+// GetReferredUsers returns a list of users referred by a specific user
+func (r *UserRepository) GetReferredUsers(userID uint, limit int) ([]models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := r.db.users.find(func(u *models.User) bool { return u.ReferrerID == userID })
+	sortNewestFirst(users)
+	return page(users, 0, limit), nil
+}
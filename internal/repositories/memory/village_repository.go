@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type VillageRepository struct {
+	db *DB
+}
+
+func NewVillageRepository(db *DB) *VillageRepository {
+	return &VillageRepository{db: db}
+}
+
+func (r *VillageRepository) CreateVillage(village *models.Village, creatorID uint) error {
+	return r.db.transaction(func() error {
+		if r.db.villages.count(func(v *models.Village) bool { return v.Name == village.Name }) > 0 {
+			return errors.Wrap(gorm.ErrDuplicatedKey, errors.ErrCodeInternalError, "failed to create village")
+		}
+		r.db.villages.insert(village)
+
+		r.db.villageMembers.insert(&models.VillageMember{
+			VillageID: village.ID,
+			UserID:    creatorID,
+			Role:      models.VillageRoleLeader,
+		})
+		return nil
+	})
+}
+
+func (r *VillageRepository) GetVillageByID(id uint) (*models.Village, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	village, ok := r.db.villages.get(id)
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "village not found")
+	}
+	village.Creator, _ = r.db.users.get(village.CreatorID)
+	return &village, nil
+}
+
+func (r *VillageRepository) GetVillageByName(name string) (*models.Village, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	village, ok := r.db.villages.first(func(v *models.Village) bool { return v.Name == name })
+	if !ok {
+		return nil, errors.New(errors.ErrCodeNotFound, "village not found")
+	}
+	return &village, nil
+}
+
+func (r *VillageRepository) GetUserVillage(userID uint) (*models.Village, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	member, ok := r.db.villageMembers.first(func(m *models.VillageMember) bool { return m.UserID == userID })
+	if !ok {
+		return nil, nil // User not in a village
+	}
+	village, _ := r.db.villages.get(member.VillageID)
+	return &village, nil
+}
+
+func (r *VillageRepository) AddMember(villageID, userID uint, role string) error {
+	return r.db.transaction(func() error {
+		r.db.villageMembers.insert(&models.VillageMember{
+			VillageID: villageID,
+			UserID:    userID,
+			Role:      role,
+		})
+		r.db.villages.update(villageID, func(v *models.Village) { v.MemberCount++ })
+		return nil
+	})
+}
+
+func (r *VillageRepository) RemoveMember(villageID, userID uint) error {
+	return r.db.transaction(func() error {
+		r.db.villageMembers.deleteWhere(func(m *models.VillageMember) bool { return m.VillageID == villageID && m.UserID == userID })
+		r.db.villages.update(villageID, func(v *models.Village) { v.MemberCount-- })
+		return nil
+	})
+}
+
+func (r *VillageRepository) GetVillageMembers(villageID uint) ([]models.VillageMember, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	members := r.db.villageMembers.find(func(m *models.VillageMember) bool { return m.VillageID == villageID })
+	for i := range members {
+		members[i].User, _ = r.db.users.get(members[i].UserID)
+	}
+	return members, nil
+}
+
+func (r *VillageRepository) UpdateVillageXP(villageID uint, xp int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.villages.update(villageID, func(v *models.Village) { v.XP += xp })
+	return nil
+}
+
+func (r *VillageRepository) GetVillageLeaderboard(limit int) ([]models.Village, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	villages := r.db.villages.find(nil)
+	sort.SliceStable(villages, func(i, j int) bool { return villages[i].Score > villages[j].Score })
+	return page(villages, 0, limit), nil
+}
+
+func (r *VillageRepository) GetVillageRank(villageID uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	village, ok := r.db.villages.get(villageID)
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return r.db.villages.count(func(v *models.Village) bool { return v.Score > village.Score }) + 1, nil
+}
+
+func (r *VillageRepository) UpdateVillageStats(villageID uint, level int, xp, score int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.villages.update(villageID, func(v *models.Village) {
+		v.Level = level
+		v.XP = xp
+		v.Score = score
+	})
+	return nil
+}
@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PurchaseRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseRepository(db *gorm.DB) *PurchaseRepository {
+	return &PurchaseRepository{db: db}
+}
+
+// CreateOrder stores a new pending purchase order for a coin package
+func (r *PurchaseRepository) CreateOrder(userID uint, pkg *models.CoinPackage, receiptFileID string) (*models.PurchaseOrder, error) {
+	order := &models.PurchaseOrder{
+		UserID:        userID,
+		PackageID:     pkg.ID,
+		Coins:         pkg.Coins,
+		PriceToman:    pkg.PriceToman,
+		ReceiptFileID: receiptFileID,
+		Status:        models.OrderStatusPending,
+	}
+
+	if err := r.db.Create(order).Error; err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to create purchase order")
+	}
+
+	return order, nil
+}
+
+// GetOrderByID retrieves an order with its buyer loaded
+func (r *PurchaseRepository) GetOrderByID(id uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	result := r.db.Preload("User").First(&order, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeNotFound, "purchase order not found")
+		}
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get purchase order")
+	}
+	return &order, nil
+}
+
+// GetUserOrders retrieves a user's latest orders, newest first
+func (r *PurchaseRepository) GetUserOrders(userID uint, limit int) ([]models.PurchaseOrder, error) {
+	var orders []models.PurchaseOrder
+	result := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&orders)
+
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get purchase orders")
+	}
+
+	return orders, nil
+}
+
+// CountPendingOrders counts receipt orders waiting for admin review
+func (r *PurchaseRepository) CountPendingOrders() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.PurchaseOrder{}).
+		Where("status = ? AND provider = ''", models.OrderStatusPending).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count pending orders")
+	}
+
+	return count, nil
+}
+
+// CreateGatewayOrder stores a new pending order that will be paid through provider
+func (r *PurchaseRepository) CreateGatewayOrder(userID uint, pkg *models.CoinPackage, provider string) (*models.PurchaseOrder, error) {
+	order := &models.PurchaseOrder{
+		UserID:     userID,
+		PackageID:  pkg.ID,
+		Coins:      pkg.Coins,
+		PriceToman: pkg.PriceToman,
+		Provider:   provider,
+		Status:     models.OrderStatusPending,
+	}
+
+	if err := r.db.Create(order).Error; err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to create purchase order")
+	}
+
+	return order, nil
+}
+
+// SetOrderAuthority stores the provider's invoice ID on an order
+func (r *PurchaseRepository) SetOrderAuthority(id uint, authority string) error {
+	result := r.db.Model(&models.PurchaseOrder{}).Where("id = ?", id).Update("authority", authority)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to set order authority")
+	}
+	return nil
+}
+
+// GetOrderByAuthority finds the gateway order of a provider invoice, with its buyer loaded
+func (r *PurchaseRepository) GetOrderByAuthority(provider, authority string) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	result := r.db.Preload("User").
+		Where("provider = ? AND authority = ?", provider, authority).
+		First(&order)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeNotFound, "purchase order not found")
+		}
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get purchase order")
+	}
+	return &order, nil
+}
+
+// ApproveOrder marks a pending receipt order approved and credits its coins in the same transaction.
+// Returns false if the order was already reviewed by someone else.
+func (r *PurchaseRepository) ApproveOrder(id uint, reviewerTgID int64) (*models.PurchaseOrder, bool, error) {
+	return r.approveOrder(id, map[string]interface{}{
+		"reviewer_tg_id": reviewerTgID,
+	})
+}
+
+// ConfirmPayment marks a pending gateway order approved with the provider's reference ID and
+// credits its coins in the same transaction. Confirming an order twice credits it only once;
+// the second call returns false.
+func (r *PurchaseRepository) ConfirmPayment(id uint, refID string) (*models.PurchaseOrder, bool, error) {
+	return r.approveOrder(id, map[string]interface{}{
+		"ref_id": refID,
+	})
+}
+
+// approveOrder locks the order, approves it if it is still pending and credits the buyer
+func (r *PurchaseRepository) approveOrder(id uint, updates map[string]interface{}) (*models.PurchaseOrder, bool, error) {
+	var order models.PurchaseOrder
+	approved := false
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New(errors.ErrCodeNotFound, "purchase order not found")
+			}
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get purchase order")
+		}
+
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+
+		updates["status"] = models.OrderStatusApproved
+		updates["reviewed_at"] = time.Now()
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to approve purchase order")
+		}
+
+		description := fmt.Sprintf("خرید پکیج %d سکه (سفارش #%d)", order.Coins, order.ID)
+		if err := addCoinsTx(tx, order.UserID, order.Coins, models.TxTypeCoinPurchase, description, models.CoinOpKey(models.TxTypeCoinPurchase, "order", order.ID)); err != nil {
+			return err
+		}
+
+		approved = true
+		return nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &order, approved, nil
+}
+
+// RejectOrder marks a pending order rejected.
+// Returns false if the order was already reviewed by someone else.
+func (r *PurchaseRepository) RejectOrder(id uint, reviewerTgID int64) (bool, error) {
+	result := r.db.Model(&models.PurchaseOrder{}).
+		Where("id = ? AND status = ?", id, models.OrderStatusPending).
+		Updates(map[string]interface{}{
+			"status":         models.OrderStatusRejected,
+			"reviewer_tg_id": reviewerTgID,
+			"reviewed_at":    time.Now(),
+		})
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to reject purchase order")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RecordTelegramPayment stores a successful Telegram payment and credits its coins or diamonds
+// in the same transaction. A charge ID that was already recorded is not credited again; false is returned.
+func (r *PurchaseRepository) RecordTelegramPayment(payment *models.TelegramPayment) (bool, error) {
+	if payment.UserID == nil {
+		return false, errors.New(errors.ErrCodeValidationFailed, "telegram payment has no user")
+	}
+	credited := false
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		payment.Status = models.TelegramPaymentStatusPaid
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(payment)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to record telegram payment")
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		switch payment.ProductKind {
+		case models.ProductKindCoins:
+			description := fmt.Sprintf("خرید پکیج %d سکه با استارز تلگرام", payment.Amount)
+			if err := addCoinsTx(tx, *payment.UserID, payment.Amount, models.TxTypeCoinPurchase, description, models.CoinOpKey(models.TxTypeCoinPurchase, "stars", payment.ID)); err != nil {
+				return err
+			}
+		case models.ProductKindDiamonds:
+			description := fmt.Sprintf("خرید بسته %d الماس با استارز تلگرام", payment.Amount)
+			if err := addDiamondsTx(tx, *payment.UserID, payment.Amount, models.TxTypeDiamondPurchase, description, models.CoinOpKey(models.TxTypeDiamondPurchase, "stars", payment.ID)); err != nil {
+				return err
+			}
+		default:
+			return errors.New(errors.ErrCodeValidationFailed, "unknown product kind")
+		}
+
+		credited = true
+		return nil
+	})
+
+	return credited, err
+}
+
+// HoldTelegramPayment stores a payment that couldn't be credited as needs_review, so an admin
+// can credit or refund it later. Returns false if the charge ID was already recorded.
+func (r *PurchaseRepository) HoldTelegramPayment(payment *models.TelegramPayment) (bool, error) {
+	payment.ID = 0
+	payment.Status = models.TelegramPaymentStatusNeedsReview
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(payment)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to hold telegram payment")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountProductSales counts paid Telegram payments of a product, for stock checks
+func (r *PurchaseRepository) CountProductSales(kind string, productID uint) (int64, error) {
+	var count int64
+	result := r.db.Model(&models.TelegramPayment{}).
+		Where("product_kind = ? AND product_id = ? AND status = ?", kind, productID, models.TelegramPaymentStatusPaid).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count product sales")
+	}
+
+	return count, nil
+}
+
+// GetTelegramPayment retrieves a payment by its Telegram charge ID
+func (r *PurchaseRepository) GetTelegramPayment(chargeID string) (*models.TelegramPayment, error) {
+	var payment models.TelegramPayment
+	result := r.db.Where("telegram_payment_charge_id = ?", chargeID).First(&payment)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeNotFound, "telegram payment not found")
+		}
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get telegram payment")
+	}
+	return &payment, nil
+}
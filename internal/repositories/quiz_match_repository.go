@@ -445,86 +445,6 @@ func (r *QuizMatchRepository) GetTimeoutMatches() ([]models.QuizMatch, error) {
 	return matches, nil
 }
 
-// GetUserBooster retrieves a user's booster
-func (r *QuizMatchRepository) GetUserBooster(userID uint, boosterType string) (*models.UserBooster, error) {
-	var booster models.UserBooster
-	result := r.db.Where("user_id = ? AND booster_type = ?", userID, boosterType).First(&booster)
-
-	if result.Error == gorm.ErrRecordNotFound {
-		return &models.UserBooster{
-			UserID:      userID,
-			BoosterType: boosterType,
-			Quantity:    0,
-		}, nil
-	}
-	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get user booster")
-	}
-
-	return &booster, nil
-}
-
-// UseBooster decrements a user's booster quantity
-func (r *QuizMatchRepository) UseBooster(userID uint, boosterType string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var booster models.UserBooster
-		err := tx.Where("user_id = ? AND booster_type = ?", userID, boosterType).
-			First(&booster).Error
-
-		if err == gorm.ErrRecordNotFound {
-			return errors.New(errors.ErrCodeNotFound, "booster not found")
-		}
-		if err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get booster")
-		}
-
-		if booster.Quantity <= 0 {
-			return errors.New(errors.ErrCodeNotFound, "insufficient booster quantity")
-		}
-
-		result := tx.Model(&booster).Update("quantity", gorm.Expr("quantity - 1"))
-		if result.Error != nil {
-			return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to use booster")
-		}
-
-		return nil
-	})
-}
-
-// AddBooster adds boosters to a user's inventory
-func (r *QuizMatchRepository) AddBooster(userID uint, boosterType string, quantity int) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var booster models.UserBooster
-		err := tx.Where("user_id = ? AND booster_type = ?", userID, boosterType).
-			First(&booster).Error
-
-		if err == gorm.ErrRecordNotFound {
-			// Create new booster entry
-			booster = models.UserBooster{
-				UserID:      userID,
-				BoosterType: boosterType,
-				Quantity:    quantity,
-			}
-			if err := tx.Create(&booster).Error; err != nil {
-				return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create booster")
-			}
-			return nil
-		}
-
-		if err != nil {
-			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to get booster")
-		}
-
-		// Update existing booster
-		result := tx.Model(&booster).Update("quantity", gorm.Expr("quantity + ?", quantity))
-		if result.Error != nil {
-			return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to add booster")
-		}
-
-		return nil
-	})
-}
-
 // SwitchTurn switches the turn to the other user
 func (r *QuizMatchRepository) SwitchTurn(matchID uint) error {
 	var match models.QuizMatch
@@ -570,3 +490,15 @@ func (r *QuizMatchRepository) AdvanceRound(matchID uint) error {
 
 	return nil
 }
+
+// CountActiveQuizMatches counts quiz matches that are not finished or timed out
+func (r *QuizMatchRepository) CountActiveQuizMatches() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.QuizMatch{}).
+		Where("state NOT IN (?, ?)", models.QuizStateGameFinished, models.QuizStateTimeout).
+		Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count active quiz matches")
+	}
+	return count, nil
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// CreateReport stores a new pending report; a user can report each match only once
+func (r *ReportRepository) CreateReport(report *models.ChatReport) error {
+	if report.ReporterID == report.ReportedID {
+		return errors.New(errors.ErrCodeValidationFailed, "cannot report yourself")
+	}
+
+	report.Status = models.ReportStatusPending
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to create report")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(errors.ErrCodeAlreadyExists, "match already reported")
+	}
+
+	return nil
+}
+
+// GetReportByID retrieves a report with both users loaded
+func (r *ReportRepository) GetReportByID(id uint) (*models.ChatReport, error) {
+	var report models.ChatReport
+	result := r.db.Preload("Reporter").Preload("Reported").First(&report, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeNotFound, "report not found")
+		}
+		return nil, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get report")
+	}
+	return &report, nil
+}
+
+// GetPendingReports retrieves a page of the moderation queue, oldest first, along with the total count
+func (r *ReportRepository) GetPendingReports(offset, limit int) ([]models.ChatReport, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.ChatReport{}).
+		Where("status = ?", models.ReportStatusPending).
+		Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, errors.ErrCodeInternalError, "failed to count pending reports")
+	}
+
+	var reports []models.ChatReport
+	result := r.db.Where("status = ?", models.ReportStatusPending).
+		Preload("Reporter").
+		Preload("Reported").
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&reports)
+
+	if result.Error != nil {
+		return nil, 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to get pending reports")
+	}
+
+	return reports, total, nil
+}
+
+// CountPendingReports counts reports waiting for review
+func (r *ReportRepository) CountPendingReports() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.ChatReport{}).
+		Where("status = ?", models.ReportStatusPending).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count pending reports")
+	}
+
+	return count, nil
+}
+
+// ReviewReport moves a pending report to confirmed or dismissed.
+// Returns false if the report was already reviewed by someone else.
+func (r *ReportRepository) ReviewReport(id uint, status string, reviewerTgID int64) (bool, error) {
+	if status != models.ReportStatusConfirmed && status != models.ReportStatusDismissed {
+		return false, errors.New(errors.ErrCodeValidationFailed, "invalid report status")
+	}
+
+	result := r.db.Model(&models.ChatReport{}).
+		Where("id = ? AND status = ?", id, models.ReportStatusPending).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewer_tg_id": reviewerTgID,
+			"reviewed_at":    time.Now(),
+		})
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to review report")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountConfirmedReports counts confirmed reports against a user
+func (r *ReportRepository) CountConfirmedReports(userID uint) (int64, error) {
+	var count int64
+	result := r.db.Model(&models.ChatReport{}).
+		Where("reported_id = ? AND status = ?", userID, models.ReportStatusConfirmed).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count confirmed reports")
+	}
+
+	return count, nil
+}
+
+// HasReported checks if a user already reported a match
+func (r *ReportRepository) HasReported(reporterID, matchID uint) (bool, error) {
+	var count int64
+	result := r.db.Model(&models.ChatReport{}).
+		Where("reporter_id = ? AND match_id = ?", reporterID, matchID).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to check report")
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ShopRepository struct {
+	db *gorm.DB
+}
+
+func NewShopRepository(db *gorm.DB) *ShopRepository {
+	return &ShopRepository{db: db}
+}
+
+// Purchase charges the buyer and grants a catalog item in a single transaction.
+// avatarFileID is the photo used for avatar items and ignored otherwise. A purchase whose
+// idempotencyKey was already used fails with ErrCodeDuplicateRequest and charges nothing.
+func (r *ShopRepository) Purchase(userID uint, item *models.ShopItem, avatarFileID, idempotencyKey string) (*models.ShopPurchase, error) {
+	if !item.IsOnSale(time.Now()) {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "item is not on sale")
+	}
+	if item.Grant == models.ShopGrantAvatar && avatarFileID == "" {
+		return nil, errors.New(errors.ErrCodeValidationFailed, "avatar photo is required")
+	}
+
+	purchase := &models.ShopPurchase{
+		UserID:   userID,
+		ItemID:   item.ID,
+		Quantity: item.Quantity,
+		Currency: item.Currency,
+		Price:    item.Price,
+	}
+	if idempotencyKey != "" {
+		purchase.IdempotencyKey = &idempotencyKey
+	}
+
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		if err := checkPurchaseKeyTx(tx, idempotencyKey); err != nil {
+			return err
+		}
+
+		if item.IsLimited() {
+			// Serialize buyers of the same limited item so the stock can't be oversold
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "shop:"+item.ID).Error; err != nil {
+				return errors.Wrap(err, errors.ErrCodeInternalError, "failed to lock item stock")
+			}
+
+			sold, err := countItemSalesTx(tx, item.ID)
+			if err != nil {
+				return err
+			}
+			if sold >= int64(item.Stock) {
+				return errors.New(errors.ErrCodeOutOfStock, "item is out of stock")
+			}
+		}
+
+		if err := chargeTx(tx, userID, item, idempotencyKey); err != nil {
+			return err
+		}
+
+		if err := grantTx(tx, userID, item, avatarFileID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(purchase).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to record shop purchase")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// CountItemSales counts units of an item sold so far, for stock checks
+func (r *ShopRepository) CountItemSales(itemID string) (int64, error) {
+	return countItemSalesTx(r.db, itemID)
+}
+
+func countItemSalesTx(tx *gorm.DB, itemID string) (int64, error) {
+	var count int64
+	result := tx.Model(&models.ShopPurchase{}).Where("item_id = ?", itemID).Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count item sales")
+	}
+	return count, nil
+}
+
+// checkPurchaseKeyTx fails with ErrCodeDuplicateRequest when a purchase with the key was already made
+func checkPurchaseKeyTx(tx *gorm.DB, idempotencyKey string) error {
+	if idempotencyKey == "" {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.ShopPurchase{}).Where("idempotency_key = ?", idempotencyKey).Count(&count).Error; err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to check idempotency key")
+	}
+	if count > 0 {
+		return errors.New(errors.ErrCodeDuplicateRequest, "shop purchase already made: "+idempotencyKey)
+	}
+
+	return nil
+}
+
+// chargeTx takes the item's price from the buyer in its currency
+func chargeTx(tx *gorm.DB, userID uint, item *models.ShopItem, idempotencyKey string) error {
+	description := fmt.Sprintf("خرید %s از فروشگاه", item.Title)
+	switch item.Currency {
+	case models.CurrencyCoins:
+		return deductCoinsTx(tx, userID, item.Price, models.TxTypeShopPurchase, description, idempotencyKey)
+	case models.CurrencyDiamonds:
+		return deductDiamondsTx(tx, userID, item.Price, models.TxTypeShopPurchase, description, "")
+	default:
+		return errors.New(errors.ErrCodeValidationFailed, "unknown currency")
+	}
+}
+
+// grantTx delivers a bought item to wherever the game keeps it
+func grantTx(tx *gorm.DB, userID uint, item *models.ShopItem, avatarFileID string) error {
+	switch item.Grant {
+	case models.ShopGrantInventory:
+		return grantItemTx(tx, userID, item.GrantKey, item.Quantity)
+	case models.ShopGrantAvatar:
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("custom_avatar_id", avatarFileID).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to set avatar")
+		}
+		return nil
+	default:
+		return errors.New(errors.ErrCodeValidationFailed, "unknown grant kind")
+	}
+}
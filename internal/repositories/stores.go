@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+)
+
+// The stores below are what handlers and services need from each repository. The
+// GORM repositories implement them against Postgres and the memory package implements
+// them in process for tests.
+
+// UserStore is implemented by UserRepository
+type UserStore interface {
+	CreateUser(user *models.User) error
+	GetUserByPublicID(publicID string) (*models.User, error)
+	GetUserByTelegramID(telegramID int64) (*models.User, error)
+	GetUserByID(id uint) (*models.User, error)
+	UpdateUser(user *models.User) error
+	UpdateUserStatus(userID uint, status string) error
+	CountUsers() (int64, error)
+	CountOnlineUsers() (int64, error)
+	UpdateLastActivity(userID uint) error
+	GetLeaderboard(category string, period string, limit int) ([]models.User, error)
+	GetUserRank(userID uint) (int64, error)
+	FindRecentChatUsers(userID uint, limit int) ([]models.User, error)
+	FindUsersByProvince(userID uint, province string, limit int) ([]models.User, error)
+	FindUsersByAge(userID uint, age int, limit int) ([]models.User, error)
+	FindNewUsers(userID uint, limit int) ([]models.User, error)
+	FindUsersWithNoChats(userID uint, limit int) ([]models.User, error)
+	UpdateLocation(userID uint, lat, lon float64) error
+	FindNearbyUsers(userID uint, lat, lon float64, limit int) ([]models.User, error)
+	HasLiked(likerID, likedID uint) (bool, error)
+	AddLike(likerID, likedID uint) error
+	MarkInactiveUsersOffline(timeout time.Duration) (int64, error)
+	MarkUnreachable(userID uint) (bool, error)
+	MarkReachable(telegramID int64) error
+	AddXP(userID uint, xp int) error
+	GetReferralCount(userID uint) (int64, error)
+	GetReferredUsers(userID uint, limit int) ([]models.User, error)
+}
+
+// CoinStore is implemented by CoinRepository
+type CoinStore interface {
+	DeductCoins(userID uint, amount int64, txType, description, idempotencyKey string) error
+	AddCoins(userID uint, amount int64, txType, description, idempotencyKey string) error
+	GetTransactionHistory(userID uint, limit int) ([]models.CoinTransaction, error)
+	HasSufficientBalance(userID uint, amount int64) (bool, error)
+	EscrowBet(gameType string, gameID uint, userIDs []uint, amount int64) error
+	SettleBet(gameType string, gameID, winnerID uint) ([]models.BetEscrow, error)
+	RefundBet(gameType string, gameID uint) ([]models.BetEscrow, error)
+	FindLedgerMismatches(userID uint) ([]models.LedgerMismatch, error)
+}
+
+// DiamondStore is implemented by DiamondRepository
+type DiamondStore interface {
+	AddDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error
+	DeductDiamonds(userID uint, amount int64, txType, description, idempotencyKey string) error
+	ExchangeForCoins(userID uint, diamonds, coinsPerDiamond int64, idempotencyKey string) (int64, error)
+	GetTransactionHistory(userID uint, limit int) ([]models.DiamondTransaction, error)
+}
+
+// MatchStore is implemented by MatchRepository
+type MatchStore interface {
+	AddToQueue(queue *models.MatchmakingQueue) error
+	RemoveFromQueue(userID uint) error
+	FindMatch(userID uint, filters *models.MatchFilters) (*models.User, error)
+	CreateMatchSession(user1ID, user2ID uint, timeoutDuration time.Duration) (*models.MatchSession, error)
+	GetActiveMatch(userID uint) (*models.MatchSession, error)
+	EndMatch(sessionID uint) error
+	CheckAndHandleTimeouts() ([]models.MatchSession, error)
+	IsUserInQueue(userID uint) (bool, error)
+	GetQueueEntry(userID uint) (*models.MatchmakingQueue, error)
+	GetMatchByID(id uint) (*models.MatchSession, error)
+	CountActiveMatches() (int64, error)
+	GetQueueSizes() (map[string]int64, error)
+}
+
+// FriendStore is implemented by FriendRepository
+type FriendStore interface {
+	SendFriendRequest(requesterID, addresseeID uint) error
+	AcceptFriendRequest(requestID uint) error
+	RejectFriendRequest(requestID uint) error
+	GetFriends(userID uint) ([]models.User, error)
+	GetPendingRequests(userID uint) ([]models.Friendship, error)
+	RemoveFriend(user1ID, user2ID uint) error
+	AreFriends(user1ID, user2ID uint) (bool, error)
+}
+
+// GameStore is implemented by GameRepository
+type GameStore interface {
+	GetRandomQuestion(questionType, category string) (*models.Question, error)
+	GetQuizQuestions(count int) ([]models.Question, error)
+	GetQuizCategories(count int) ([]string, error)
+	GetQuestionsByCategoryExcluding(category string, count int, excludeIDs []uint) ([]models.Question, error)
+	CreateGameSession(roomID uint, gameType string) (*models.GameSession, error)
+	SetTurnUserID(gameSessionID, userID uint) error
+	AddParticipant(gameSessionID, userID uint, turnOrder int) error
+	RecordAnswer(gameSessionID, userID uint, answer models.GroupQuizAnswer) error
+	EndGame(gameSessionID uint) error
+	GetGameSession(gameSessionID uint) (*models.GameSession, error)
+	GetParticipants(gameSessionID uint) ([]models.GameParticipant, error)
+	GetActiveGameSessionByRoomID(roomID uint) (*models.GameSession, error)
+	StartGame(gameSessionID uint) error
+	UpdateCurrentQuestion(gameSessionID, questionID uint) error
+	UpdateGameStatus(gameSessionID uint, status string) error
+	GetQuestionsByIDs(ids []uint) ([]models.Question, error)
+	GetRecentGames(userID uint, limit int) ([]models.GameParticipant, error)
+}
+
+// RoomStore is implemented by RoomRepository
+type RoomStore interface {
+	CreateRoom(room *models.Room) error
+	GetRoomByID(roomID uint) (*models.Room, error)
+	GetPublicRooms() ([]models.Room, error)
+	GetRoomByInviteCode(inviteCode string) (*models.Room, error)
+	AddMember(roomID, userID uint) error
+	RemoveMember(roomID, userID uint) error
+	GetRoomMembers(roomID uint) ([]models.User, error)
+	GetMemberCount(roomID uint) (int, error)
+	IsHost(roomID, userID uint) (bool, error)
+	IsMember(roomID, userID uint) (bool, error)
+	CloseRoom(roomID uint) error
+	KickMember(roomID, userID uint) error
+	GetUserRooms(userID uint) ([]models.Room, error)
+}
+
+// VillageStore is implemented by VillageRepository
+type VillageStore interface {
+	CreateVillage(village *models.Village, creatorID uint) error
+	GetVillageByID(id uint) (*models.Village, error)
+	GetVillageByName(name string) (*models.Village, error)
+	GetUserVillage(userID uint) (*models.Village, error)
+	AddMember(villageID, userID uint, role string) error
+	RemoveMember(villageID, userID uint) error
+	GetVillageMembers(villageID uint) ([]models.VillageMember, error)
+	UpdateVillageXP(villageID uint, xp int64) error
+	GetVillageLeaderboard(limit int) ([]models.Village, error)
+	GetVillageRank(villageID uint) (int64, error)
+	UpdateVillageStats(villageID uint, level int, xp, score int64) error
+}
+
+// QuizMatchStore is implemented by QuizMatchRepository
+type QuizMatchStore interface {
+	CreateQuizMatch(user1ID, user2ID uint) (*models.QuizMatch, error)
+	GetQuizMatch(matchID uint) (*models.QuizMatch, error)
+	GetAllActiveQuizMatchesByUser(userID uint) ([]models.QuizMatch, error)
+	GetFinishedQuizMatchesByUser(userID uint, limit int) ([]models.QuizMatch, error)
+	UpdateQuizMatchState(matchID uint, state string) error
+	UpdateQuizMatchStateAtomic(matchID uint, expectedStates []string, newState string) (bool, error)
+	UpdateCurrentQuestion(matchID uint, questionNum int) error
+	UpdateLightsMessageID(matchID, userID uint, messageID int) error
+	CreateQuizRound(matchID uint, roundNum int, category string, chosenBy uint, questionIDs string) (*models.QuizRound, error)
+	GetQuizRound(matchID uint, roundNum int) (*models.QuizRound, error)
+	GetAllQuizRounds(matchID uint) ([]models.QuizRound, error)
+	RecordAnswer(matchID, roundID, userID, questionID uint, questionNum, answerIdx, timeMs int, isCorrect bool, booster string) error
+	DeleteUserAnswer(matchID, roundID, userID uint, questionNum int) error
+	GetUserAnswers(matchID, roundID, userID uint) ([]models.QuizAnswer, error)
+	UpdateQuizMatchScore(matchID, userID uint, correctCount int, timeMs int64) error
+	UpdateRoundStats(roundID, userID uint, correctCount, timeMs int) error
+	FinishQuizMatch(matchID, winnerID uint) error
+	TimeoutQuizMatch(matchID uint) error
+	GetTimeoutMatches() ([]models.QuizMatch, error)
+	SwitchTurn(matchID uint) error
+	AdvanceRound(matchID uint) error
+	CountActiveQuizMatches() (int64, error)
+}
+
+// TodStore is implemented by TodRepository
+type TodStore interface {
+	CreateGame(matchID uint, player1ID, player2ID uint) (*models.TodGame, error)
+	GetGameByID(gameID uint) (*models.TodGame, error)
+	GetGameByMatchID(matchID uint) (*models.TodGame, error)
+	GetActiveGameForUser(userID uint) (*models.TodGame, error)
+	UpdateGameState(gameID uint, newState string) error
+	UpdateGameStateAtomic(gameID uint, expectedStates []string, newState string) (bool, error)
+	SetPlayerOrder(gameID, activePlayerID, passivePlayerID uint) error
+	CreateTurn(gameID uint, playerID, judgeID uint, roundNum int) (*models.TodTurn, error)
+	GetCurrentTurn(gameID uint) (*models.TodTurn, error)
+	GetTurns(gameID uint) ([]models.TodTurn, error)
+	UpdateTurnChoice(turnID uint, choice string) error
+	UpdateTurnChallenge(turnID uint, challengeID uint, challengeText string) error
+	UpdateTurnProof(turnID uint, proofType, proofData string) error
+	UpdateTurnJudgment(turnID uint, result, reason string) error
+	CompleteTurn(turnID uint) error
+	UpdateTurnRewards(turnID uint, xp, coins int) error
+	RecordItemUse(turnID uint, itemType string) error
+	GetRandomChallenge(challengeType, difficulty, category, gender, relation string) (*models.TodChallenge, error)
+	IncrementChallengeUsage(challengeID uint) error
+	UpdateChallengeAcceptanceRate(challengeID uint, wasAccepted bool) error
+	GetGamesNearingTimeout() ([]*models.TodGame, error)
+	GetTimedOutGames() ([]*models.TodGame, error)
+	MarkWarningShown(gameID uint) error
+	HandleTimeout(gameID uint) error
+	GetOrCreatePlayerStats(userID uint) (*models.TodPlayerStats, error)
+	IncrementGamesPlayed(userID uint, won bool) error
+	IncrementChallengeCompleted(userID uint, choiceType string, wasAccepted bool) error
+	IncrementTimeoutCount(userID uint) error
+	UpdateJudgeScore(userID uint, newScore float64) error
+	UseItem(gameID, turnID, userID uint, itemType string) error
+	LogJudgment(turnID, judgeID, playerID uint, result string) error
+	DetectUnfairJudgment(judgeID uint) (bool, string, error)
+	IncrementUnfairJudgmentCount(judgeID uint) error
+	CalculateJudgeScore(judgeID uint) (float64, error)
+	EndGame(gameID uint, winnerID uint, reason string) error
+	SwitchTurn(gameID uint) error
+	IncrementRound(gameID uint) error
+	ClaimAction(gameID uint, userID uint, actionID, action string) (bool, error)
+	CleanupOldActions() error
+	CountActiveGames() (int64, error)
+}
+
+// BlockStore is implemented by BlockRepository
+type BlockStore interface {
+	BlockUser(blockerID, blockedID uint) error
+	UnblockUser(blockerID, blockedID uint) error
+	IsBlocked(user1ID, user2ID uint) (bool, error)
+	HasBlocked(blockerID, blockedID uint) (bool, error)
+	GetBlockedUsers(blockerID uint, offset, limit int) ([]models.UserBlock, int64, error)
+}
+
+// ReportStore is implemented by ReportRepository
+type ReportStore interface {
+	CreateReport(report *models.ChatReport) error
+	GetReportByID(id uint) (*models.ChatReport, error)
+	GetPendingReports(offset, limit int) ([]models.ChatReport, int64, error)
+	CountPendingReports() (int64, error)
+	ReviewReport(id uint, status string, reviewerTgID int64) (bool, error)
+	CountConfirmedReports(userID uint) (int64, error)
+	HasReported(reporterID, matchID uint) (bool, error)
+}
+
+// BanStore is implemented by BanRepository
+type BanStore interface {
+	BanUser(userID uint, scope string, duration time.Duration, reason string, issuedByTgID int64) (*models.UserBan, error)
+	GetActiveBan(userID uint, scope string) (*models.UserBan, error)
+	GetActiveBans(userID uint) ([]models.UserBan, error)
+	LiftBans(userID uint, scope string) (int64, error)
+	DeleteExpiredBans() (int64, error)
+}
+
+// PurchaseStore is implemented by PurchaseRepository
+type PurchaseStore interface {
+	CreateOrder(userID uint, pkg *models.CoinPackage, receiptFileID string) (*models.PurchaseOrder, error)
+	GetOrderByID(id uint) (*models.PurchaseOrder, error)
+	GetUserOrders(userID uint, limit int) ([]models.PurchaseOrder, error)
+	CountPendingOrders() (int64, error)
+	CreateGatewayOrder(userID uint, pkg *models.CoinPackage, provider string) (*models.PurchaseOrder, error)
+	SetOrderAuthority(id uint, authority string) error
+	GetOrderByAuthority(provider, authority string) (*models.PurchaseOrder, error)
+	ApproveOrder(id uint, reviewerTgID int64) (*models.PurchaseOrder, bool, error)
+	ConfirmPayment(id uint, refID string) (*models.PurchaseOrder, bool, error)
+	RejectOrder(id uint, reviewerTgID int64) (bool, error)
+	RecordTelegramPayment(payment *models.TelegramPayment) (bool, error)
+	HoldTelegramPayment(payment *models.TelegramPayment) (bool, error)
+	GetTelegramPayment(chargeID string) (*models.TelegramPayment, error)
+	CountProductSales(kind string, productID uint) (int64, error)
+}
+
+// ShopStore is implemented by ShopRepository
+type ShopStore interface {
+	Purchase(userID uint, item *models.ShopItem, avatarFileID, idempotencyKey string) (*models.ShopPurchase, error)
+	CountItemSales(itemID string) (int64, error)
+}
+
+// InventoryStore is implemented by InventoryRepository
+type InventoryStore interface {
+	GetItems(userID uint) ([]models.InventoryItem, error)
+	GetQuantity(userID uint, itemType string) (int, error)
+	Consume(userID uint, itemType string, quantity int) error
+}
+
+// BroadcastStore is implemented by BroadcastRepository
+type BroadcastStore interface {
+	CreateBroadcast(broadcast *models.Broadcast) error
+	GetBroadcastByID(id uint) (*models.Broadcast, error)
+	UpdateDraft(broadcast *models.Broadcast) error
+	StartBroadcast(broadcast *models.Broadcast, progressMessageID int) (bool, error)
+	SetStatus(id uint, from []string, status string) (bool, error)
+	SaveProgress(broadcast *models.Broadcast) error
+	DeleteDraft(id uint) error
+	GetRecentBroadcasts(limit int) ([]models.Broadcast, error)
+	GetRunningBroadcasts() ([]models.Broadcast, error)
+	CountRecipients(broadcast *models.Broadcast) (int64, error)
+	GetRecipients(broadcast *models.Broadcast, afterUserID uint, limit int) ([]BroadcastRecipient, error)
+}
+
+var (
+	_ UserStore      = (*UserRepository)(nil)
+	_ CoinStore      = (*CoinRepository)(nil)
+	_ DiamondStore   = (*DiamondRepository)(nil)
+	_ MatchStore     = (*MatchRepository)(nil)
+	_ FriendStore    = (*FriendRepository)(nil)
+	_ GameStore      = (*GameRepository)(nil)
+	_ RoomStore      = (*RoomRepository)(nil)
+	_ VillageStore   = (*VillageRepository)(nil)
+	_ QuizMatchStore = (*QuizMatchRepository)(nil)
+	_ TodStore       = (*TodRepository)(nil)
+	_ BlockStore     = (*BlockRepository)(nil)
+	_ ReportStore    = (*ReportRepository)(nil)
+	_ BanStore       = (*BanRepository)(nil)
+	_ PurchaseStore  = (*PurchaseRepository)(nil)
+	_ ShopStore      = (*ShopRepository)(nil)
+	_ InventoryStore = (*InventoryRepository)(nil)
+	_ BroadcastStore = (*BroadcastRepository)(nil)
+)
@@ -5,8 +5,10 @@ import (
 	"time"
 
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/errors"
 	"github.com/mroshb/game_bot/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TodRepository struct {
@@ -92,6 +94,32 @@ func (r *TodRepository) UpdateGameState(gameID uint, newState string) error {
 		Update("updated_at", time.Now()).Error
 }
 
+// UpdateGameStateAtomic moves a game to newState only if it is still in one of expectedStates.
+// Returns false if another handler changed the state first.
+func (r *TodRepository) UpdateGameStateAtomic(gameID uint, expectedStates []string, newState string) (bool, error) {
+	result := r.db.Model(&models.TodGame{}).
+		Where("id = ? AND state IN ?", gameID, expectedStates).
+		Updates(map[string]interface{}{
+			"state":      newState,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to update tod game state atomically")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// SetPlayerOrder decides who plays the first turn
+func (r *TodRepository) SetPlayerOrder(gameID, activePlayerID, passivePlayerID uint) error {
+	return r.db.Model(&models.TodGame{}).Where("id = ?", gameID).
+		Updates(map[string]interface{}{
+			"active_player_id":  activePlayerID,
+			"passive_player_id": passivePlayerID,
+		}).Error
+}
+
 // ========================================
 // TURN MANAGEMENT
 // ========================================
@@ -132,6 +160,13 @@ func (r *TodRepository) GetCurrentTurn(gameID uint) (*models.TodTurn, error) {
 	return &turn, err
 }
 
+// GetTurns retrieves every turn of a game
+func (r *TodRepository) GetTurns(gameID uint) ([]models.TodTurn, error) {
+	var turns []models.TodTurn
+	err := r.db.Where("game_id = ?", gameID).Order("id ASC").Find(&turns).Error
+	return turns, err
+}
+
 // UpdateTurnChoice updates the choice made in a turn
 func (r *TodRepository) UpdateTurnChoice(turnID uint, choice string) error {
 	now := time.Now()
@@ -189,6 +224,15 @@ func (r *TodRepository) UpdateTurnRewards(turnID uint, xp, coins int) error {
 		}).Error
 }
 
+// RecordItemUse notes the item played during a turn
+func (r *TodRepository) RecordItemUse(turnID uint, itemType string) error {
+	return r.db.Model(&models.TodTurn{}).Where("id = ?", turnID).
+		Updates(map[string]interface{}{
+			"item_used":    itemType,
+			"item_used_at": time.Now(),
+		}).Error
+}
+
 // ========================================
 // CHALLENGE SELECTION
 // ========================================
@@ -333,13 +377,22 @@ func (r *TodRepository) GetOrCreatePlayerStats(userID uint) (*models.TodPlayerSt
 
 	if err == gorm.ErrRecordNotFound {
 		stats = models.TodPlayerStats{
-			UserID:       userID,
-			JudgeScore:   100.0,
-			ShieldsOwned: 1,
-			SwapsOwned:   1,
-			MirrorsOwned: 1,
+			UserID:     userID,
+			JudgeScore: 100.0,
 		}
-		if err := r.db.Create(&stats).Error; err != nil {
+		// First game: create the stats row together with the starting items
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&stats).Error; err != nil {
+				return err
+			}
+			for _, itemType := range models.TodStartingItems {
+				if err := grantItemTx(tx, userID, itemType, 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			return nil, err
 		}
 	} else if err != nil {
@@ -389,70 +442,54 @@ func (r *TodRepository) IncrementChallengeCompleted(userID uint, choiceType stri
 	return r.UpdatePlayerStats(userID, updates)
 }
 
-// UpdateJudgeScore updates judge score
-func (r *TodRepository) UpdateJudgeScore(userID uint, newScore float64) error {
+// IncrementTimeoutCount counts a turn the player let run out
+func (r *TodRepository) IncrementTimeoutCount(userID uint) error {
 	return r.UpdatePlayerStats(userID, map[string]interface{}{
-		"judge_score": newScore,
+		"timeout_count": gorm.Expr("timeout_count + 1"),
 	})
 }
 
-// UseItem decrements item count
-func (r *TodRepository) UseItem(userID uint, itemType string) error {
-	var field string
-	switch itemType {
-	case models.ItemTypeShield:
-		field = "shields_owned"
-	case models.ItemTypeSwap:
-		field = "swaps_owned"
-	case models.ItemTypeMirror:
-		field = "mirrors_owned"
-	default:
-		return fmt.Errorf("invalid item type: %s", itemType)
-	}
-
-	// Check if user has item
-	var stats models.TodPlayerStats
-	if err := r.db.Where("user_id = ?", userID).First(&stats).Error; err != nil {
-		return err
-	}
-
-	var currentCount int
-	switch itemType {
-	case models.ItemTypeShield:
-		currentCount = stats.ShieldsOwned
-	case models.ItemTypeSwap:
-		currentCount = stats.SwapsOwned
-	case models.ItemTypeMirror:
-		currentCount = stats.MirrorsOwned
-	}
-
-	if currentCount <= 0 {
-		return fmt.Errorf("insufficient items")
-	}
-
-	// Decrement
+// UpdateJudgeScore updates judge score
+func (r *TodRepository) UpdateJudgeScore(userID uint, newScore float64) error {
 	return r.UpdatePlayerStats(userID, map[string]interface{}{
-		field:        gorm.Expr(field + " - 1"),
-		"items_used": gorm.Expr("items_used + 1"),
+		"judge_score": newScore,
 	})
 }
 
-// AddItem adds items to inventory
-func (r *TodRepository) AddItem(userID uint, itemType string, count int) error {
-	var field string
-	switch itemType {
-	case models.ItemTypeShield:
-		field = "shields_owned"
-	case models.ItemTypeSwap:
-		field = "swaps_owned"
-	case models.ItemTypeMirror:
-		field = "mirrors_owned"
-	default:
-		return fmt.Errorf("invalid item type: %s", itemType)
-	}
+// UseItem spends one of the player's items on a turn. Claiming the turn's item, taking it
+// from the inventory and recording it on the turn happen in one transaction, so a failed
+// use leaves the turn free and a second use in the same turn fails with ErrCodeDuplicateRequest.
+func (r *TodRepository) UseItem(gameID, turnID, userID uint, itemType string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		claim := &models.TodActionLog{
+			GameID:   gameID,
+			UserID:   userID,
+			ActionID: fmt.Sprintf("use_item:%d", turnID),
+			Action:   "use_item_" + itemType,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(claim)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to claim item use")
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(errors.ErrCodeDuplicateRequest, "an item was already used this turn")
+		}
 
-	return r.UpdatePlayerStats(userID, map[string]interface{}{
-		field: gorm.Expr(fmt.Sprintf("%s + %d", field, count)),
+		if err := consumeItemTx(tx, userID, itemType, 1); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TodPlayerStats{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"items_used": gorm.Expr("items_used + 1"),
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternalError, "failed to count item use")
+		}
+		return tx.Model(&models.TodTurn{}).Where("id = ?", turnID).
+			Updates(map[string]interface{}{
+				"item_used":    itemType,
+				"item_used_at": time.Now(),
+			}).Error
 	})
 }
 
@@ -642,24 +679,21 @@ func (r *TodRepository) IncrementRound(gameID uint) error {
 // IDEMPOTENCY
 // ========================================
 
-// IsActionProcessed checks if action has been processed
-func (r *TodRepository) IsActionProcessed(gameID uint, actionID string) bool {
-	var count int64
-	r.db.Model(&models.TodActionLog{}).
-		Where("game_id = ? AND action_id = ?", gameID, actionID).
-		Count(&count)
-	return count > 0
-}
-
-// MarkActionProcessed marks an action as processed
-func (r *TodRepository) MarkActionProcessed(gameID uint, userID uint, actionID, action string) error {
+// ClaimAction records an action and reports whether this call was the first to do so.
+// actionID must identify the action itself (e.g. the turn it belongs to), so a repeated
+// callback maps to the same ID and is rejected by the unique index.
+func (r *TodRepository) ClaimAction(gameID uint, userID uint, actionID, action string) (bool, error) {
 	log := &models.TodActionLog{
 		GameID:   gameID,
 		UserID:   userID,
 		ActionID: actionID,
 		Action:   action,
 	}
-	return r.db.Create(log).Error
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
 // CleanupOldActions removes old action logs (older than 24 hours)
@@ -667,3 +701,12 @@ func (r *TodRepository) CleanupOldActions() error {
 	cutoff := time.Now().Add(-24 * time.Hour)
 	return r.db.Where("created_at < ?", cutoff).Delete(&models.TodActionLog{}).Error
 }
+
+// CountActiveGames counts Truth or Dare games that have not ended
+func (r *TodRepository) CountActiveGames() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TodGame{}).
+		Where("state NOT IN (?, ?)", models.TodStateGameEnd, models.TodStateForfeit).
+		Count(&count).Error
+	return count, err
+}
@@ -23,9 +23,23 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 		user.PublicID = utils.GenerateRandomID(8)
 	}
 
-	result := r.db.Create(user)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to create user")
+	// Record the starting balance in the ledger so it can be reconciled later
+	err := coinTransaction(r.db, func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if user.CoinBalance == 0 {
+			return nil
+		}
+		return createCoinTx(tx, &models.CoinTransaction{
+			UserID:          user.ID,
+			Amount:          user.CoinBalance,
+			TransactionType: models.TxTypeOpeningBalance,
+			Description:     "هدیه عضویت",
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternalError, "failed to create user")
 	}
 	return nil
 }
@@ -77,7 +91,8 @@ func (r *UserRepository) GetUserByID(id uint) (*models.User, error) {
 
 // UpdateUser updates user information
 func (r *UserRepository) UpdateUser(user *models.User) error {
-	result := r.db.Save(user)
+	// Balances only change through the coin and diamond ledgers; a stale copy must not overwrite them
+	result := r.db.Omit("coin_balance", "diamonds").Save(user)
 	if result.Error != nil {
 		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to update user")
 	}
@@ -93,6 +108,26 @@ func (r *UserRepository) UpdateUserStatus(userID uint, status string) error {
 	return nil
 }
 
+// CountUsers counts all registered users
+func (r *UserRepository) CountUsers() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.User{}).Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count users")
+	}
+	return count, nil
+}
+
+// CountOnlineUsers counts users that are not offline
+func (r *UserRepository) CountOnlineUsers() (int64, error) {
+	var count int64
+	result := r.db.Model(&models.User{}).Where("status <> ?", models.UserStatusOffline).Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to count online users")
+	}
+	return count, nil
+}
+
 // UpdateLastActivity updates user's last activity timestamp
 func (r *UserRepository) UpdateLastActivity(userID uint) error {
 	result := r.db.Model(&models.User{}).Where("id = ?", userID).Update("last_activity", gorm.Expr("CURRENT_TIMESTAMP"))
@@ -397,6 +432,41 @@ func (r *UserRepository) MarkInactiveUsersOffline(timeout time.Duration) (int64,
 	return result.RowsAffected, result.Error
 }
 
+// MarkUnreachable flags a user Telegram won't deliver messages to. Returns false if
+// the user was already flagged.
+func (r *UserRepository) MarkUnreachable(userID uint) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND unreachable_at IS NULL", userID).
+		UpdateColumn("unreachable_at", time.Now())
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to mark user unreachable")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkReachable clears the unreachable flag of a user
+func (r *UserRepository) MarkReachable(telegramID int64) error {
+	result := r.db.Model(&models.User{}).
+		Where("telegram_id = ? AND unreachable_at IS NOT NULL", telegramID).
+		UpdateColumn("unreachable_at", nil)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, errors.ErrCodeInternalError, "failed to mark user reachable")
+	}
+	return nil
+}
+
+// GetUnreachableTelegramIDs returns the Telegram IDs of all users flagged unreachable
+func (r *UserRepository) GetUnreachableTelegramIDs() ([]int64, error) {
+	var ids []int64
+	err := r.db.Model(&models.User{}).
+		Where("unreachable_at IS NOT NULL").
+		Pluck("telegram_id", &ids).Error
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternalError, "failed to get unreachable users")
+	}
+	return ids, nil
+}
+
 // AddXP adds experience points to a user and handles leveling up
 func (r *UserRepository) AddXP(userID uint, xp int) error {
 	result := r.db.Model(&models.User{}).
@@ -0,0 +1,62 @@
+package sender
+
+import "time"
+
+// bucket is a token bucket: it holds up to burst tokens and refills at rate tokens a second
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	heldUntil time.Time // no tokens are handed out before this
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// delay returns how long until a token is available, 0 if one is available now
+func (b *bucket) delay(now time.Time) time.Duration {
+	if now.Before(b.heldUntil) {
+		return b.heldUntil.Sub(now)
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// take uses up a token; call it only after delay returned 0
+func (b *bucket) take() {
+	b.tokens--
+}
+
+// hold hands out no tokens until the given time, then exactly one before refilling again
+func (b *bucket) hold(until time.Time) {
+	if until.After(b.heldUntil) {
+		b.heldUntil = until
+	}
+	b.tokens = 1
+	b.last = b.heldUntil
+}
+
+// full reports whether the bucket has refilled completely, so forgetting it changes nothing
+func (b *bucket) full(now time.Time) bool {
+	if now.Before(b.heldUntil) {
+		return false
+	}
+	b.refill(now)
+	return b.tokens >= b.burst
+}
@@ -0,0 +1,462 @@
+package sender
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Priority decides which queued message goes out first; a lower value wins
+type Priority int
+
+const (
+	PriorityGame   Priority = iota // timers, questions and turns of games in progress
+	PriorityNormal                 // replies to what a user just did
+	PriorityBulk                   // fan-out to many chats: room and village chat, announcements
+	numPriorities
+)
+
+// Defaults for zero Options fields, a little under Telegram's documented limits
+const (
+	defaultGlobalPerSecond = 25
+	defaultChatPerSecond   = 1
+	defaultChatBurst       = 3
+	defaultRetryBackoff    = time.Second
+	defaultWorkers         = 8
+	defaultMaxRetryAfter   = time.Minute
+	maxRetryBackoff        = 30 * time.Second
+	chatSweepInterval      = time.Minute
+)
+
+// ErrUnreachable is returned for messages to a chat that blocked the bot or no longer exists
+var ErrUnreachable = errors.New("chat is unreachable")
+
+// unreachableErrors are the descriptions of 403s Telegram gives for users that can't be messaged
+var unreachableErrors = []string{"bot was blocked by the user", "user is deactivated"}
+
+// API is the part of the Telegram client the sender needs; *tgbotapi.BotAPI implements it
+type API interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// Failure describes a message the sender gave up on
+type Failure struct {
+	ChatID   int64
+	Priority Priority
+	Attempts int
+	Err      error
+}
+
+// Options configures a Sender. Zero rates, burst, backoff and workers take the defaults.
+type Options struct {
+	GlobalPerSecond int           // messages per second across all chats
+	ChatPerSecond   int           // messages per second to one chat
+	ChatBurst       int           // messages a quiet chat may get back to back
+	MaxRetries      int           // retries after network and 5xx errors
+	RetryBackoff    time.Duration // wait before the first retry, doubled on each one after
+	MaxRetryAfter   time.Duration // longest a message may be held by 429s in total before it is given up
+	Workers         int           // requests to Telegram in flight at once
+
+	// OnFailure is called once for every message the sender tried and gave up on,
+	// including those queued with SendAsync; messages to a chat already known to be
+	// unreachable fail without it
+	OnFailure func(Failure)
+}
+
+// Sender delivers outgoing messages through one queue so the bot stays within
+// Telegram's global and per-chat limits. Messages wait in a lane per priority;
+// messages to the same chat are sent one at a time and in the order queued.
+// Once a chat turns out to be unreachable its messages fail with ErrUnreachable
+// without being sent, until MarkReachable is called for it.
+type Sender struct {
+	api  API
+	opts Options
+
+	mu        sync.Mutex
+	lanes     [numPriorities][]*job
+	global    *bucket
+	chats     map[int64]*bucket
+	inFlight  map[int64]bool // chats with a request on the wire; their next message waits for it
+	lastSweep time.Time
+
+	unreachable map[int64]bool
+
+	wake  chan struct{}
+	slots chan struct{}
+}
+
+type job struct {
+	chatID    int64
+	msg       tgbotapi.Chattable
+	priority  Priority
+	attempts  int
+	notBefore time.Time     // set while waiting to be retried
+	heldFor   time.Duration // total wait Telegram asked for in 429s
+	done      chan result
+}
+
+type result struct {
+	msg tgbotapi.Message
+	err error
+}
+
+// New creates a sender and starts its dispatcher
+func New(api API, opts Options) *Sender {
+	if opts.GlobalPerSecond <= 0 {
+		opts.GlobalPerSecond = defaultGlobalPerSecond
+	}
+	if opts.ChatPerSecond <= 0 {
+		opts.ChatPerSecond = defaultChatPerSecond
+	}
+	if opts.ChatBurst <= 0 {
+		opts.ChatBurst = defaultChatBurst
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.MaxRetryAfter <= 0 {
+		opts.MaxRetryAfter = defaultMaxRetryAfter
+	}
+
+	now := time.Now()
+	s := &Sender{
+		api:       api,
+		opts:      opts,
+		global:    newBucket(float64(opts.GlobalPerSecond), opts.GlobalPerSecond, now),
+		chats:     make(map[int64]*bucket),
+		inFlight:  make(map[int64]bool),
+		lastSweep: now,
+		wake:      make(chan struct{}, 1),
+		slots:     make(chan struct{}, opts.Workers),
+
+		unreachable: make(map[int64]bool),
+	}
+
+	go s.dispatch()
+
+	return s
+}
+
+// Send queues a message to chatID and waits until it is delivered or given up on
+func (s *Sender) Send(chatID int64, c tgbotapi.Chattable, priority Priority) (tgbotapi.Message, error) {
+	j, err := s.enqueue(chatID, c, priority)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	r := <-j.done
+	return r.msg, r.err
+}
+
+// SendAsync queues a message to chatID and returns without waiting for it. It is for
+// fan-out to many chats, where waiting on each one would hold up the caller; a message
+// the sender gives up on is reported to OnFailure. Returns ErrUnreachable if the chat is
+// already known to be unreachable.
+func (s *Sender) SendAsync(chatID int64, c tgbotapi.Chattable, priority Priority) error {
+	_, err := s.enqueue(chatID, c, priority)
+	return err
+}
+
+// enqueue adds a message to its lane and wakes the dispatcher
+func (s *Sender) enqueue(chatID int64, c tgbotapi.Chattable, priority Priority) (*job, error) {
+	if priority < 0 || priority >= numPriorities {
+		priority = PriorityNormal
+	}
+
+	// done is buffered so finishing a job never waits for a reader that SendAsync doesn't have
+	j := &job{chatID: chatID, msg: c, priority: priority, done: make(chan result, 1)}
+
+	s.mu.Lock()
+	if s.unreachable[chatID] {
+		s.mu.Unlock()
+		return nil, ErrUnreachable
+	}
+	s.lanes[priority] = append(s.lanes[priority], j)
+	s.mu.Unlock()
+	s.signal()
+
+	return j, nil
+}
+
+// MarkUnreachable makes messages to chatID fail with ErrUnreachable and drops those
+// already queued for it
+func (s *Sender) MarkUnreachable(chatID int64) {
+	s.mu.Lock()
+	s.unreachable[chatID] = true
+	dropped := s.dropQueued(chatID)
+	s.mu.Unlock()
+
+	for _, j := range dropped {
+		j.done <- result{err: ErrUnreachable}
+	}
+}
+
+// MarkReachable lets messages to chatID through again. Returns false if the chat
+// wasn't marked unreachable.
+func (s *Sender) MarkReachable(chatID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.unreachable[chatID] {
+		return false
+	}
+	delete(s.unreachable, chatID)
+	return true
+}
+
+// dropQueued removes and returns every queued message to chatID. Caller must hold s.mu.
+func (s *Sender) dropQueued(chatID int64) []*job {
+	var dropped []*job
+	for p, lane := range s.lanes {
+		kept := lane[:0]
+		for _, j := range lane {
+			if j.chatID == chatID {
+				dropped = append(dropped, j)
+			} else {
+				kept = append(kept, j)
+			}
+		}
+		for i := len(kept); i < len(lane); i++ {
+			lane[i] = nil
+		}
+		s.lanes[p] = kept
+	}
+	return dropped
+}
+
+// signal wakes the dispatcher if it is waiting
+func (s *Sender) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch hands queued messages to workers as fast as the limits allow
+func (s *Sender) dispatch() {
+	for {
+		// Take a worker slot first so the pick below sees every request in flight
+		s.slots <- struct{}{}
+
+		s.mu.Lock()
+		j, wait := s.next(time.Now())
+		s.mu.Unlock()
+
+		if j != nil {
+			go s.deliver(j)
+			continue
+		}
+		<-s.slots
+
+		if wait == 0 {
+			<-s.wake
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// next removes and returns the message to send now. If none can go yet it returns how
+// long until one might, or 0 if only a new message or a finished request can change that.
+// Caller must hold s.mu.
+func (s *Sender) next(now time.Time) (*job, time.Duration) {
+	s.sweep(now)
+
+	if d := s.global.delay(now); d > 0 {
+		return nil, d
+	}
+
+	var wait time.Duration
+	waitFor := func(d time.Duration) {
+		if wait == 0 || d < wait {
+			wait = d
+		}
+	}
+
+	for p := range s.lanes {
+		lane := s.lanes[p]
+		var waiting map[int64]bool // chats with an earlier message in this lane that can't go yet
+
+		for i, j := range lane {
+			if waiting[j.chatID] {
+				continue
+			}
+
+			ready := !s.inFlight[j.chatID]
+			if ready && now.Before(j.notBefore) {
+				ready = false
+				waitFor(j.notBefore.Sub(now))
+			}
+			var chat *bucket
+			if ready {
+				chat = s.chatBucket(j.chatID, now)
+				if d := chat.delay(now); d > 0 {
+					ready = false
+					waitFor(d)
+				}
+			}
+			if !ready {
+				if waiting == nil {
+					waiting = make(map[int64]bool)
+				}
+				waiting[j.chatID] = true
+				continue
+			}
+
+			chat.take()
+			s.global.take()
+			s.inFlight[j.chatID] = true
+
+			copy(lane[i:], lane[i+1:])
+			lane[len(lane)-1] = nil
+			s.lanes[p] = lane[:len(lane)-1]
+			return j, 0
+		}
+	}
+
+	return nil, wait
+}
+
+// chatBucket returns the rate limit bucket of a chat. Caller must hold s.mu.
+func (s *Sender) chatBucket(chatID int64, now time.Time) *bucket {
+	b, ok := s.chats[chatID]
+	if !ok {
+		b = newBucket(float64(s.opts.ChatPerSecond), s.opts.ChatBurst, now)
+		s.chats[chatID] = b
+	}
+	return b
+}
+
+// sweep forgets chats that have been quiet long enough to refill. Caller must hold s.mu.
+func (s *Sender) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < chatSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for chatID, b := range s.chats {
+		if !s.inFlight[chatID] && b.full(now) {
+			delete(s.chats, chatID)
+		}
+	}
+}
+
+// deliver sends one message and either finishes it or queues it again for a retry
+func (s *Sender) deliver(j *job) {
+	defer func() {
+		<-s.slots
+		s.signal()
+	}()
+
+	j.attempts++
+	sent, err := s.api.Send(j.msg)
+
+	if err != nil && s.retry(j, err) {
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.inFlight, j.chatID)
+	s.mu.Unlock()
+
+	if IsUnreachable(err) {
+		s.MarkUnreachable(j.chatID)
+	}
+	if err != nil && s.opts.OnFailure != nil {
+		s.opts.OnFailure(Failure{ChatID: j.chatID, Priority: j.priority, Attempts: j.attempts, Err: err})
+	}
+	j.done <- result{msg: sent, err: err}
+}
+
+// retry puts a failed message back at the front of its lane if the error is worth
+// retrying. A 429 pauses the whole chat for as long as Telegram asks; a message held
+// longer than MaxRetryAfter in total is given up on.
+func (s *Sender) retry(j *job, err error) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if wait, ok := retryAfter(err); ok {
+		s.chatBucket(j.chatID, now).hold(now.Add(wait))
+		if j.heldFor+wait > s.opts.MaxRetryAfter {
+			return false
+		}
+		// Telegram told us exactly when to come back, so this isn't counted as an attempt
+		j.attempts--
+		j.heldFor += wait
+	} else if isTransient(err) && j.attempts <= s.opts.MaxRetries {
+		j.notBefore = now.Add(s.backoff(j.attempts))
+	} else {
+		return false
+	}
+
+	delete(s.inFlight, j.chatID)
+	s.lanes[j.priority] = append([]*job{j}, s.lanes[j.priority]...)
+	return true
+}
+
+// backoff returns the wait before retry number attempt
+func (s *Sender) backoff(attempt int) time.Duration {
+	d := s.opts.RetryBackoff
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d
+}
+
+// retryAfter returns the wait Telegram asked for if err is a 429
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if apiErr.RetryAfter <= 0 {
+		return time.Second, true
+	}
+	return time.Duration(apiErr.RetryAfter) * time.Second, true
+}
+
+// IsUnreachable reports whether err means the chat can't be messaged: the user blocked
+// the bot or deleted their account
+func IsUnreachable(err error) bool {
+	if errors.Is(err, ErrUnreachable) {
+		return true
+	}
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	description := strings.ToLower(apiErr.Message)
+	for _, e := range unreachableErrors {
+		if strings.Contains(description, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient reports whether err may go away on its own: a 5xx from Telegram,
+// a network error or a response that isn't Telegram's JSON
+func isTransient(err error) bool {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,372 @@
+package sender
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeAPI records the text of every message sent and fails the first sends with errs
+type fakeAPI struct {
+	mu    sync.Mutex
+	sent  []string
+	calls int
+	errs  []error
+	gate  chan struct{} // when set, each send waits for a value from it
+}
+
+func (f *fakeAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if f.gate != nil {
+		<-f.gate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+	f.sent = append(f.sent, c.(tgbotapi.MessageConfig).Text)
+	return tgbotapi.Message{MessageID: f.calls}, nil
+}
+
+func (f *fakeAPI) snapshot() ([]string, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...), f.calls
+}
+
+func TestBucket_Delay(t *testing.T) {
+	now := time.Now()
+	b := newBucket(2, 2, now)
+
+	for i := 0; i < 2; i++ {
+		if d := b.delay(now); d != 0 {
+			t.Fatalf("delay() with a full bucket = %v, want 0", d)
+		}
+		b.take()
+	}
+	if d := b.delay(now); d != 500*time.Millisecond {
+		t.Errorf("delay() with an empty bucket = %v, want 500ms", d)
+	}
+	if d := b.delay(now.Add(500 * time.Millisecond)); d != 0 {
+		t.Errorf("delay() after refilling one token = %v, want 0", d)
+	}
+
+	b.hold(now.Add(3 * time.Second))
+	if d := b.delay(now.Add(time.Second)); d != 2*time.Second {
+		t.Errorf("delay() while held = %v, want 2s", d)
+	}
+	if b.full(now.Add(time.Second)) {
+		t.Error("full() while held = true, want false")
+	}
+	if d := b.delay(now.Add(3 * time.Second)); d != 0 {
+		t.Errorf("delay() when the hold ends = %v, want 0", d)
+	}
+}
+
+func TestSender_PriorityLanes(t *testing.T) {
+	api := &fakeAPI{gate: make(chan struct{})}
+	s := New(api, Options{Workers: 1, GlobalPerSecond: 1000, ChatPerSecond: 1000, ChatBurst: 10})
+
+	var wg sync.WaitGroup
+	send := func(chatID int64, text string, p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Send(chatID, tgbotapi.NewMessage(chatID, text), p); err != nil {
+				t.Errorf("Send(%q) error = %v", text, err)
+			}
+		}()
+	}
+
+	// The first message holds the only worker while the rest queue up behind it
+	send(1, "first", PriorityBulk)
+	waitFor(t, func() bool { s.mu.Lock(); defer s.mu.Unlock(); return s.inFlight[1] })
+	send(2, "bulk", PriorityBulk)
+	waitFor(t, func() bool { return queued(s, PriorityBulk) == 1 })
+	send(3, "normal", PriorityNormal)
+	waitFor(t, func() bool { return queued(s, PriorityNormal) == 1 })
+	send(4, "game", PriorityGame)
+	waitFor(t, func() bool { return queued(s, PriorityGame) == 1 })
+
+	for i := 0; i < 4; i++ {
+		api.gate <- struct{}{}
+	}
+	wg.Wait()
+
+	sent, _ := api.snapshot()
+	want := []string{"first", "game", "normal", "bulk"}
+	for i := range want {
+		if i >= len(sent) || sent[i] != want[i] {
+			t.Fatalf("sent = %v, want %v", sent, want)
+		}
+	}
+}
+
+func TestSender_ChatOrderAndRate(t *testing.T) {
+	api := &fakeAPI{}
+	s := New(api, Options{GlobalPerSecond: 1000, ChatPerSecond: 20, ChatBurst: 1})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i, text := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Send(1, tgbotapi.NewMessage(1, text), PriorityNormal)
+		}()
+		waitFor(t, func() bool { _, calls := api.snapshot(); return calls+queued(s, PriorityNormal) == i+1 })
+	}
+	wg.Wait()
+
+	sent, _ := api.snapshot()
+	if len(sent) != 3 || sent[0] != "a" || sent[1] != "b" || sent[2] != "c" {
+		t.Errorf("sent = %v, want [a b c]", sent)
+	}
+	// One message per 50ms to the same chat: the third goes out no sooner than 100ms in
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 messages to one chat took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestSender_Retries(t *testing.T) {
+	serverErr := &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}
+	blocked := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantErr      bool
+		wantCalls    int
+		wantFailures int
+	}{
+		{"network error then success", []error{errors.New("connection reset by peer")}, false, 2, 0},
+		{"5xx then success", []error{serverErr, serverErr}, false, 3, 0},
+		{"5xx until retries run out", []error{serverErr, serverErr, serverErr}, true, 3, 1},
+		{"403 is not retried", []error{blocked}, true, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{errs: tt.errs}
+			var failures []Failure
+			var mu sync.Mutex
+			s := New(api, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, OnFailure: func(f Failure) {
+				mu.Lock()
+				failures = append(failures, f)
+				mu.Unlock()
+			}})
+
+			_, err := s.Send(7, tgbotapi.NewMessage(7, "hi"), PriorityNormal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, calls := api.snapshot(); calls != tt.wantCalls {
+				t.Errorf("API calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(failures) != tt.wantFailures {
+				t.Fatalf("OnFailure calls = %d, want %d", len(failures), tt.wantFailures)
+			}
+			if tt.wantFailures > 0 && (failures[0].ChatID != 7 || failures[0].Attempts != tt.wantCalls || failures[0].Err != err) {
+				t.Errorf("OnFailure got %+v, want chat 7 after %d attempts with %v", failures[0], tt.wantCalls, err)
+			}
+		})
+	}
+}
+
+func TestSender_RetryAfter(t *testing.T) {
+	tooMany := &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+	api := &fakeAPI{errs: []error{tooMany}}
+	s := New(api, Options{MaxRetries: 0})
+
+	start := time.Now()
+	if _, err := s.Send(1, tgbotapi.NewMessage(1, "hi"), PriorityNormal); err != nil {
+		t.Fatalf("Send() after a 429 error = %v, want it retried", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Send() retried after %v, want at least the 1s Telegram asked for", elapsed)
+	}
+
+	// Other chats aren't held up by one chat's flood wait
+	api.mu.Lock()
+	api.errs = []error{tooMany}
+	api.mu.Unlock()
+	go s.Send(3, tgbotapi.NewMessage(3, "held"), PriorityNormal)
+	waitFor(t, func() bool { _, calls := api.snapshot(); return calls == 3 })
+
+	start = time.Now()
+	if _, err := s.Send(2, tgbotapi.NewMessage(2, "other"), PriorityNormal); err != nil {
+		t.Fatalf("Send() to another chat error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Send() to another chat took %v while chat 3 was held", elapsed)
+	}
+}
+
+func TestSender_RetryAfterCap(t *testing.T) {
+	tooMany := &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5}}
+	api := &fakeAPI{errs: []error{tooMany}}
+	var failures []Failure
+	var mu sync.Mutex
+	s := New(api, Options{MaxRetryAfter: time.Second, OnFailure: func(f Failure) {
+		mu.Lock()
+		failures = append(failures, f)
+		mu.Unlock()
+	}})
+
+	// Waiting 5s would go past the 1s cap, so the message is given up on right away
+	start := time.Now()
+	if _, err := s.Send(1, tgbotapi.NewMessage(1, "hi"), PriorityNormal); err != tooMany {
+		t.Fatalf("Send() error = %v, want the 429", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Send() gave up after %v, want at once", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failures) != 1 || failures[0].ChatID != 1 {
+		t.Errorf("OnFailure calls = %+v, want one for chat 1", failures)
+	}
+}
+
+func TestSender_SendAsync(t *testing.T) {
+	blocked := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	api := &fakeAPI{errs: []error{blocked}, gate: make(chan struct{})}
+	failed := make(chan Failure, 1)
+	s := New(api, Options{Workers: 1, GlobalPerSecond: 1000, ChatPerSecond: 1000, ChatBurst: 10, OnFailure: func(f Failure) {
+		failed <- f
+	}})
+
+	// The API is stuck on the first message, yet queuing the rest doesn't wait for it
+	done := make(chan struct{})
+	go func() {
+		for chatID := int64(1); chatID <= 3; chatID++ {
+			if err := s.SendAsync(chatID, tgbotapi.NewMessage(chatID, "fan-out"), PriorityBulk); err != nil {
+				t.Errorf("SendAsync() error = %v", err)
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SendAsync() waited for delivery")
+	}
+
+	for i := 0; i < 3; i++ {
+		api.gate <- struct{}{}
+	}
+	select {
+	case f := <-failed:
+		if f.ChatID != 1 || !IsUnreachable(f.Err) {
+			t.Errorf("OnFailure got %+v, want chat 1 unreachable", f)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a failed SendAsync message wasn't reported to OnFailure")
+	}
+	waitFor(t, func() bool { sent, _ := api.snapshot(); return len(sent) == 2 })
+
+	if err := s.SendAsync(1, tgbotapi.NewMessage(1, "later"), PriorityBulk); err != ErrUnreachable {
+		t.Errorf("SendAsync() to a chat marked unreachable error = %v, want ErrUnreachable", err)
+	}
+}
+
+func TestSender_Unreachable(t *testing.T) {
+	blocked := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	api := &fakeAPI{errs: []error{blocked}, gate: make(chan struct{})}
+	var failures int
+	var mu sync.Mutex
+	s := New(api, Options{GlobalPerSecond: 1000, ChatPerSecond: 1000, ChatBurst: 10, OnFailure: func(Failure) {
+		mu.Lock()
+		failures++
+		mu.Unlock()
+	}})
+
+	errs := make(chan error, 2)
+	go func() { _, err := s.Send(1, tgbotapi.NewMessage(1, "first"), PriorityNormal); errs <- err }()
+	waitFor(t, func() bool { s.mu.Lock(); defer s.mu.Unlock(); return s.inFlight[1] })
+	go func() { _, err := s.Send(1, tgbotapi.NewMessage(1, "queued"), PriorityBulk); errs <- err }()
+	waitFor(t, func() bool { return queued(s, PriorityBulk) == 1 })
+
+	// The first message finds the chat blocked and takes the one queued behind it down too
+	api.gate <- struct{}{}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !IsUnreachable(err) {
+			t.Errorf("Send() to a blocked chat error = %v, want unreachable", err)
+		}
+	}
+	if _, err := s.Send(1, tgbotapi.NewMessage(1, "later"), PriorityNormal); err != ErrUnreachable {
+		t.Errorf("Send() to a chat marked unreachable error = %v, want ErrUnreachable", err)
+	}
+	if _, calls := api.snapshot(); calls != 1 {
+		t.Errorf("API calls = %d, want 1", calls)
+	}
+	mu.Lock()
+	if failures != 1 {
+		t.Errorf("OnFailure calls = %d, want 1", failures)
+	}
+	mu.Unlock()
+
+	if !s.MarkReachable(1) {
+		t.Error("MarkReachable() = false for a chat marked unreachable")
+	}
+	if s.MarkReachable(1) {
+		t.Error("MarkReachable() = true for a chat already reachable")
+	}
+	go func() { api.gate <- struct{}{} }()
+	if _, err := s.Send(1, tgbotapi.NewMessage(1, "back"), PriorityNormal); err != nil {
+		t.Errorf("Send() after MarkReachable error = %v", err)
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}, true},
+		{&tgbotapi.Error{Code: 403, Message: "Forbidden: user is deactivated"}, true},
+		{&tgbotapi.Error{Code: 403, Message: "Forbidden: bot can't initiate conversation with a user"}, false},
+		{&tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}, false},
+		{ErrUnreachable, true},
+		{errors.New("connection reset by peer"), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUnreachable(tt.err); got != tt.want {
+			t.Errorf("IsUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// queued returns how many messages wait in a lane
+func queued(s *Sender, p Priority) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.lanes[p])
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
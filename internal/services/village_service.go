@@ -7,11 +7,11 @@ import (
 )
 
 type VillageService struct {
-	repo     *repositories.VillageRepository
-	userRepo *repositories.UserRepository
+	repo     repositories.VillageStore
+	userRepo repositories.UserStore
 }
 
-func NewVillageService(repo *repositories.VillageRepository, userRepo *repositories.UserRepository) *VillageService {
+func NewVillageService(repo repositories.VillageStore, userRepo repositories.UserStore) *VillageService {
 	return &VillageService{
 		repo:     repo,
 		userRepo: userRepo,
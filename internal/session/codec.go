@@ -0,0 +1,155 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Session data is stored as JSON with the Go type of every value next to it, because
+// handlers read values back with type assertions (.(uint), .(int), ...) that plain JSON
+// would break by turning every number into a float64.
+
+// typedValue is one stored entry of Session.Data
+type typedValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// Value types the codec can store
+const (
+	typeString      = "string"
+	typeBool        = "bool"
+	typeInt         = "int"
+	typeInt64       = "int64"
+	typeUint        = "uint"
+	typeFloat64     = "float64"
+	typeTime        = "time"
+	typeStrings     = "[]string"
+	typeInts        = "[]int"
+	typeStringBools = "map[string]bool"
+)
+
+// typeOf returns the codec type of a value, or "" if it can't be stored
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return typeString
+	case bool:
+		return typeBool
+	case int:
+		return typeInt
+	case int64:
+		return typeInt64
+	case uint:
+		return typeUint
+	case float64:
+		return typeFloat64
+	case time.Time:
+		return typeTime
+	case []string:
+		return typeStrings
+	case []int:
+		return typeInts
+	case map[string]bool:
+		return typeStringBools
+	default:
+		return ""
+	}
+}
+
+// encodeData serializes session data. Values of unsupported types fail the whole
+// encoding, so a handler storing one is caught instead of silently losing state.
+func encodeData(data map[string]interface{}) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	entries := make(map[string]typedValue, len(data))
+	for key, v := range data {
+		typ := typeOf(v)
+		if typ == "" {
+			return "", fmt.Errorf("session key %q has unsupported type %T", key, v)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode session key %q: %w", key, err)
+		}
+		entries[key] = typedValue{Type: typ, Value: raw}
+	}
+
+	out, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// decodeData restores session data with the types it was stored with
+func decodeData(encoded string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if encoded == "" {
+		return data, nil
+	}
+
+	var entries map[string]typedValue
+	if err := json.Unmarshal([]byte(encoded), &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode session data: %w", err)
+	}
+
+	for key, entry := range entries {
+		v, err := decodeValue(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode session key %q: %w", key, err)
+		}
+		data[key] = v
+	}
+	return data, nil
+}
+
+func decodeValue(entry typedValue) (interface{}, error) {
+	switch entry.Type {
+	case typeString:
+		var v string
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeBool:
+		var v bool
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeInt:
+		var v int
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeInt64:
+		var v int64
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeUint:
+		var v uint
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeFloat64:
+		var v float64
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeTime:
+		var v time.Time
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeStrings:
+		var v []string
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeInts:
+		var v []int
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	case typeStringBools:
+		var v map[string]bool
+		err := json.Unmarshal(entry.Value, &v)
+		return v, err
+	default:
+		return nil, fmt.Errorf("unknown type %q", entry.Type)
+	}
+}
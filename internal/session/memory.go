@@ -0,0 +1,89 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process memory. They are lost on restart, so it is
+// meant for development and tests; it encodes sessions like PostgresStore does, so a
+// value the database can't hold fails here too.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	sessions map[int64]memoryEntry
+}
+
+type memoryEntry struct {
+	state     string
+	data      string
+	expiresAt time.Time // zero when the session doesn't expire
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:      ttl,
+		sessions: make(map[int64]memoryEntry),
+	}
+}
+
+func (m *MemoryStore) Load(userID int64) (*Session, error) {
+	m.mu.Lock()
+	entry, ok := m.sessions[userID]
+	m.mu.Unlock()
+
+	if !ok || entry.expired(time.Now()) {
+		return nil, nil
+	}
+
+	data, err := decodeData(entry.data)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{State: entry.state, Data: data}, nil
+}
+
+func (m *MemoryStore) Save(userID int64, s *Session) error {
+	data, err := encodeData(s.Data)
+	if err != nil {
+		return err
+	}
+
+	entry := memoryEntry{state: s.State, data: data}
+	if m.ttl > 0 {
+		entry.expiresAt = time.Now().Add(m.ttl)
+	}
+
+	m.mu.Lock()
+	m.sessions[userID] = entry
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) Delete(userID int64) error {
+	m.mu.Lock()
+	delete(m.sessions, userID)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) Cleanup() (int64, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	for userID, entry := range m.sessions {
+		if entry.expired(now) {
+			delete(m.sessions, userID)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !e.expiresAt.After(now)
+}
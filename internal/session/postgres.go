@@ -0,0 +1,71 @@
+package session
+
+import (
+	"time"
+
+	"github.com/mroshb/game_bot/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresStore keeps sessions in the conversation_sessions table
+type PostgresStore struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+func NewPostgresStore(db *gorm.DB, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, ttl: ttl}
+}
+
+func (p *PostgresStore) Load(userID int64) (*Session, error) {
+	var row models.ConversationSession
+	err := p.db.Where("telegram_id = ?", userID).First(&row).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if row.IsExpired(time.Now()) {
+		return nil, nil
+	}
+
+	data, err := decodeData(row.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{State: row.State, Data: data}, nil
+}
+
+func (p *PostgresStore) Save(userID int64, s *Session) error {
+	data, err := encodeData(s.Data)
+	if err != nil {
+		return err
+	}
+
+	row := &models.ConversationSession{
+		TelegramID: userID,
+		State:      s.State,
+		Data:       data,
+	}
+	if p.ttl > 0 {
+		expiresAt := time.Now().Add(p.ttl)
+		row.ExpiresAt = &expiresAt
+	}
+
+	return p.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "telegram_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "data", "expires_at", "updated_at"}),
+	}).Create(row).Error
+}
+
+func (p *PostgresStore) Delete(userID int64) error {
+	return p.db.Where("telegram_id = ?", userID).Delete(&models.ConversationSession{}).Error
+}
+
+func (p *PostgresStore) Cleanup() (int64, error) {
+	result := p.db.Where("expires_at <= ?", time.Now()).Delete(&models.ConversationSession{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/mroshb/game_bot/internal/config"
+	"gorm.io/gorm"
+)
+
+// Store names accepted in SESSION_STORE
+const (
+	StorePostgres = "postgres"
+	StoreMemory   = "memory"
+)
+
+// Session is a user's conversation state: the flow they are in and the data collected so far.
+// Data values must be of a type the codec knows (see codec.go) or the session is not saved.
+type Session struct {
+	State string
+	Data  map[string]interface{}
+}
+
+// New returns an empty session
+func New() *Session {
+	return &Session{Data: make(map[string]interface{})}
+}
+
+// IsEmpty reports whether the session holds nothing worth storing
+func (s *Session) IsEmpty() bool {
+	return s.State == "" && len(s.Data) == 0
+}
+
+// Store keeps sessions between updates and across restarts
+type Store interface {
+	// Load returns the user's session, or nil if there is none or it expired
+	Load(userID int64) (*Session, error)
+
+	// Save stores the session and restarts its TTL
+	Save(userID int64, s *Session) error
+
+	// Delete drops the user's session
+	Delete(userID int64) error
+
+	// Cleanup drops expired sessions and returns how many were removed
+	Cleanup() (int64, error)
+}
+
+// NewStore creates the store selected in the config
+func NewStore(cfg *config.Config, db *gorm.DB) (Store, error) {
+	ttl := cfg.GetSessionTTL()
+
+	switch cfg.SessionStore {
+	case "", StorePostgres:
+		return NewPostgresStore(db, ttl), nil
+	case StoreMemory:
+		return NewMemoryStore(ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", cfg.SessionStore)
+	}
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/config"
+)
+
+func TestCodec_RoundTripKeepsTypes(t *testing.T) {
+	data := map[string]interface{}{
+		"name":                   "Sara",
+		"age":                    24,
+		"entry_fee":              int64(50),
+		"purchase_package_id":    uint(3),
+		"search_provinces":       []string{"تهران", "البرز"},
+		"adv_selected_provinces": map[string]bool{"تهران": true},
+		"confirmed":              true,
+		"started_at":             time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	encoded, err := encodeData(data)
+	if err != nil {
+		t.Fatalf("encodeData() error = %v", err)
+	}
+	decoded, err := decodeData(encoded)
+	if err != nil {
+		t.Fatalf("decodeData() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, data) {
+		t.Errorf("decodeData() = %#v, want %#v", decoded, data)
+	}
+	if _, ok := decoded["purchase_package_id"].(uint); !ok {
+		t.Errorf("purchase_package_id decoded as %T, want uint", decoded["purchase_package_id"])
+	}
+}
+
+func TestCodec_RejectsUnsupportedTypes(t *testing.T) {
+	if _, err := encodeData(map[string]interface{}{"bad": struct{}{}}); err == nil {
+		t.Error("encodeData() with a struct value expected error, got nil")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+
+	if s, err := store.Load(1); err != nil || s != nil {
+		t.Fatalf("Load() of unknown user = %v, %v; want nil, nil", s, err)
+	}
+
+	saved := &Session{State: "room_name", Data: map[string]interface{}{"room_type": "public"}}
+	if err := store.Save(1, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Changing the saved session must not change the stored copy
+	saved.Data["room_type"] = "private"
+
+	loaded, err := store.Load(1)
+	if err != nil || loaded == nil {
+		t.Fatalf("Load() = %v, %v; want session", loaded, err)
+	}
+	if loaded.State != "room_name" || loaded.Data["room_type"] != "public" {
+		t.Errorf("Load() = %+v, want stored session", loaded)
+	}
+
+	if err := store.Delete(1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if s, _ := store.Load(1); s != nil {
+		t.Errorf("Load() after Delete() = %+v, want nil", s)
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	if err := store.Save(1, &Session{State: "edit_name", Data: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Pretend the TTL has passed
+	entry := store.sessions[1]
+	entry.expiresAt = time.Now().Add(-time.Minute)
+	store.sessions[1] = entry
+
+	if s, _ := store.Load(1); s != nil {
+		t.Errorf("Load() of expired session = %+v, want nil", s)
+	}
+	if removed, _ := store.Cleanup(); removed != 1 {
+		t.Errorf("Cleanup() removed %d, want 1", removed)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if _, err := NewStore(&config.Config{SessionStore: StoreMemory}, nil); err != nil {
+		t.Errorf("NewStore(memory) error = %v", err)
+	}
+	if _, err := NewStore(&config.Config{SessionStore: "redis"}, nil); err == nil {
+		t.Error("NewStore(redis) expected error, got nil")
+	}
+}
@@ -0,0 +1,527 @@
+// Package telegramtest runs a local stand-in for the Telegram Bot API. It serves the
+// methods the bot uses over HTTP, keeps every chat in memory and lets tests act as users,
+// so the real update loop can be exercised without network access or a bot token.
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Message is a message as it shows in a chat
+type Message struct {
+	ID      int
+	FromBot bool
+	Method  string // Bot API method that sent it, e.g. "sendPhoto"; empty for users' messages
+	Text    string // text, or the caption of media
+	File    string // file_id of media, or the name of an uploaded file
+	Inline  [][]tgbotapi.InlineKeyboardButton
+	Edited  bool
+	Deleted bool
+}
+
+// CallbackAnswer is a call to answerCallbackQuery
+type CallbackAnswer struct {
+	QueryID   string
+	Text      string
+	ShowAlert bool
+}
+
+// chat is one chat with the bot; message IDs count up per chat like in private chats
+type chat struct {
+	messages []*Message
+	keyboard [][]tgbotapi.KeyboardButton // reply keyboard currently shown
+	nextID   int
+}
+
+func (c *chat) find(messageID int) *Message {
+	for _, m := range c.messages {
+		if m.ID == messageID && !m.Deleted {
+			return m
+		}
+	}
+	return nil
+}
+
+// replyMarkup is any keyboard accepted in reply_markup
+type replyMarkup struct {
+	InlineKeyboard [][]tgbotapi.InlineKeyboardButton `json:"inline_keyboard"`
+	Keyboard       [][]tgbotapi.KeyboardButton       `json:"keyboard"`
+	RemoveKeyboard bool                              `json:"remove_keyboard"`
+}
+
+// apiError is a failed Bot API call, reported the way Telegram does
+type apiError struct {
+	code        int
+	description string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s", e.code, e.description)
+}
+
+var errBlocked = &apiError{http.StatusForbidden, "Forbidden: bot was blocked by the user"}
+
+func errBadRequest(description string) error {
+	return &apiError{http.StatusBadRequest, "Bad Request: " + description}
+}
+
+// mediaFields maps the media send methods to the parameter that carries the file
+var mediaFields = map[string]string{
+	"sendPhoto":     "photo",
+	"sendVideo":     "video",
+	"sendVoice":     "voice",
+	"sendAudio":     "audio",
+	"sendDocument":  "document",
+	"sendSticker":   "sticker",
+	"sendAnimation": "animation",
+	"sendVideoNote": "video_note",
+}
+
+// Server is a Bot API stand-in listening on a local port. Point the bot at it with
+// BOT_API_URL=<URL> (or Config.BotAPIURL) and the bot token passed to NewServer.
+type Server struct {
+	URL   string
+	Token string
+	Bot   tgbotapi.User
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	chats    map[int64]*chat
+	blocked  map[int64]bool
+	answers  []CallbackAnswer
+	updates  []tgbotapi.Update // not yet confirmed by a getUpdates offset
+	lastID   int
+	newData  chan struct{} // closed and replaced whenever an update is queued
+	closed   chan struct{}
+	closeOne sync.Once
+}
+
+// NewServer starts a server for a bot with the given token
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:   token,
+		Bot:     tgbotapi.User{ID: 1000, IsBot: true, FirstName: "Test Bot", UserName: "test_bot"},
+		chats:   make(map[int64]*chat),
+		blocked: make(map[int64]bool),
+		newData: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close ends pending long polls and shuts the server down
+func (s *Server) Close() {
+	s.closeOne.Do(func() { close(s.closed) })
+	s.srv.Close()
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requests look like /bot<token>/<method>
+	path := strings.TrimPrefix(r.URL.Path, "/bot")
+	token, method, ok := strings.Cut(path, "/")
+	if !ok || path == r.URL.Path {
+		writeResponse(w, nil, &apiError{http.StatusNotFound, "Not Found"})
+		return
+	}
+	if token != s.Token {
+		writeResponse(w, nil, &apiError{http.StatusUnauthorized, "Unauthorized"})
+		return
+	}
+
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		err = r.ParseMultipartForm(32 << 20)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		writeResponse(w, nil, errBadRequest("can't parse request"))
+		return
+	}
+
+	result, err := s.call(r, method)
+	writeResponse(w, result, err)
+}
+
+// call runs one Bot API method
+func (s *Server) call(r *http.Request, method string) (interface{}, error) {
+	switch method {
+	case "getMe":
+		return s.Bot, nil
+	case "getUpdates":
+		return s.getUpdates(r)
+	case "sendMessage":
+		return s.send(r, method, r.FormValue("text"), "")
+	case "editMessageText":
+		text := r.FormValue("text")
+		return s.edit(r, &text)
+	case "editMessageCaption":
+		caption := r.FormValue("caption")
+		return s.edit(r, &caption)
+	case "editMessageReplyMarkup":
+		return s.edit(r, nil)
+	case "deleteMessage":
+		return s.deleteMessage(r)
+	case "answerCallbackQuery":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.answers = append(s.answers, CallbackAnswer{
+			QueryID:   r.FormValue("callback_query_id"),
+			Text:      r.FormValue("text"),
+			ShowAlert: r.FormValue("show_alert") == "true",
+		})
+		return true, nil
+	case "setWebhook", "deleteWebhook":
+		return true, nil
+	}
+
+	if field, ok := mediaFields[method]; ok {
+		file := r.FormValue(field)
+		if file == "" && r.MultipartForm != nil && len(r.MultipartForm.File[field]) > 0 {
+			file = r.MultipartForm.File[field][0].Filename
+		}
+		if file == "" {
+			return nil, errBadRequest("there is no " + field + " in the request")
+		}
+		return s.send(r, method, r.FormValue("caption"), file)
+	}
+	return nil, &apiError{http.StatusNotFound, "Not Found"}
+}
+
+func writeResponse(w http.ResponseWriter, result interface{}, err error) {
+	resp := map[string]interface{}{"ok": err == nil}
+	status := http.StatusOK
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = &apiError{http.StatusInternalServerError, err.Error()}
+		}
+		status = apiErr.code
+		resp["error_code"] = apiErr.code
+		resp["description"] = apiErr.description
+	} else {
+		resp["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// getUpdates returns the queued updates, waiting up to timeout seconds for one to arrive.
+// Updates below offset are confirmed and dropped, as on Telegram.
+func (s *Server) getUpdates(r *http.Request) (interface{}, error) {
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	timeout, _ := strconv.Atoi(r.FormValue("timeout"))
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		for len(s.updates) > 0 && s.updates[0].UpdateID < offset {
+			s.updates = s.updates[1:]
+		}
+		if len(s.updates) > 0 || timeout <= 0 {
+			batch := append([]tgbotapi.Update{}, s.updates[:min(limit, len(s.updates))]...)
+			s.mu.Unlock()
+			return batch, nil
+		}
+		newData := s.newData
+		s.mu.Unlock()
+
+		select {
+		case <-newData:
+		case <-deadline.C:
+			return []tgbotapi.Update{}, nil
+		case <-s.closed:
+			return []tgbotapi.Update{}, nil
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}, nil
+		}
+	}
+}
+
+// send posts a new bot message to the chat in chat_id
+func (s *Server) send(r *http.Request, method, text, file string) (interface{}, error) {
+	chatID, err := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	if err != nil {
+		return nil, errBadRequest("chat not found")
+	}
+	markup, err := parseMarkup(r.FormValue("reply_markup"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.blocked[chatID] {
+		return nil, errBlocked
+	}
+	c := s.chat(chatID)
+	c.nextID++
+	m := &Message{ID: c.nextID, FromBot: true, Method: method, Text: text, File: file, Inline: markup.InlineKeyboard}
+	c.messages = append(c.messages, m)
+	if markup.Keyboard != nil {
+		c.keyboard = markup.Keyboard
+	} else if markup.RemoveKeyboard {
+		c.keyboard = nil
+	}
+	return s.apiMessage(chatID, m), nil
+}
+
+// edit changes a bot message in place; like Telegram, an edit without a keyboard removes it
+func (s *Server) edit(r *http.Request, text *string) (interface{}, error) {
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(r.FormValue("message_id"))
+	markup, err := parseMarkup(r.FormValue("reply_markup"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.chat(chatID).find(messageID)
+	if m == nil || !m.FromBot {
+		return nil, errBadRequest("message to edit not found")
+	}
+	if text != nil {
+		m.Text = *text
+	}
+	m.Inline = markup.InlineKeyboard
+	m.Edited = true
+	return s.apiMessage(chatID, m), nil
+}
+
+func (s *Server) deleteMessage(r *http.Request) (interface{}, error) {
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(r.FormValue("message_id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.chat(chatID).find(messageID)
+	if m == nil {
+		return nil, errBadRequest("message to delete not found")
+	}
+	m.Deleted = true
+	return true, nil
+}
+
+func parseMarkup(raw string) (replyMarkup, error) {
+	var markup replyMarkup
+	if raw == "" {
+		return markup, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &markup); err != nil {
+		return markup, errBadRequest("can't parse reply keyboard markup JSON object")
+	}
+	return markup, nil
+}
+
+// chat returns the chat with chatID, creating it on first use. s.mu must be held.
+func (s *Server) chat(chatID int64) *chat {
+	c, ok := s.chats[chatID]
+	if !ok {
+		c = &chat{}
+		s.chats[chatID] = c
+	}
+	return c
+}
+
+// apiMessage renders m the way the Bot API returns it
+func (s *Server) apiMessage(chatID int64, m *Message) tgbotapi.Message {
+	msg := tgbotapi.Message{
+		MessageID: m.ID,
+		Date:      int(time.Now().Unix()),
+		Chat:      apiChat(chatID),
+	}
+	if m.FromBot {
+		bot := s.Bot
+		msg.From = &bot
+	}
+	if m.Inline != nil {
+		msg.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: m.Inline}
+	}
+	if m.Method == "" || m.Method == "sendMessage" {
+		msg.Text = m.Text
+		return msg
+	}
+
+	msg.Caption = m.Text
+	switch m.Method {
+	case "sendPhoto":
+		msg.Photo = []tgbotapi.PhotoSize{{FileID: m.File, FileUniqueID: m.File}}
+	case "sendVideo":
+		msg.Video = &tgbotapi.Video{FileID: m.File, FileUniqueID: m.File}
+	case "sendVoice":
+		msg.Voice = &tgbotapi.Voice{FileID: m.File, FileUniqueID: m.File}
+	case "sendAudio":
+		msg.Audio = &tgbotapi.Audio{FileID: m.File, FileUniqueID: m.File}
+	case "sendDocument":
+		msg.Document = &tgbotapi.Document{FileID: m.File, FileUniqueID: m.File}
+	case "sendSticker":
+		msg.Sticker = &tgbotapi.Sticker{FileID: m.File, FileUniqueID: m.File}
+	case "sendAnimation":
+		msg.Animation = &tgbotapi.Animation{FileID: m.File, FileUniqueID: m.File}
+	case "sendVideoNote":
+		msg.VideoNote = &tgbotapi.VideoNote{FileID: m.File, FileUniqueID: m.File}
+	}
+	return msg
+}
+
+func apiChat(chatID int64) *tgbotapi.Chat {
+	if chatID < 0 {
+		return &tgbotapi.Chat{ID: chatID, Type: "supergroup"}
+	}
+	return &tgbotapi.Chat{ID: chatID, Type: "private"}
+}
+
+// AddUpdate queues an update for getUpdates and returns the update ID it was given
+func (s *Server) AddUpdate(update tgbotapi.Update) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUpdate(update)
+}
+
+// addUpdate queues an update and wakes pending long polls. s.mu must be held.
+func (s *Server) addUpdate(update tgbotapi.Update) int {
+	s.lastID++
+	update.UpdateID = s.lastID
+	s.updates = append(s.updates, update)
+	close(s.newData)
+	s.newData = make(chan struct{})
+	return update.UpdateID
+}
+
+// SendText has a user write text to the bot in their private chat; a leading slash
+// makes it a command. It returns the ID of the user's message.
+func (s *Server) SendText(from tgbotapi.User, text string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.chat(from.ID)
+	c.nextID++
+	c.messages = append(c.messages, &Message{ID: c.nextID, Text: text})
+
+	msg := &tgbotapi.Message{
+		MessageID: c.nextID,
+		From:      &from,
+		Chat:      apiChat(from.ID),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	// Telegram stops reporting a block as soon as the user writes again
+	delete(s.blocked, from.ID)
+	s.addUpdate(tgbotapi.Update{Message: msg})
+	return msg.MessageID
+}
+
+// PressButton has a user press an inline button of a bot message in their private chat
+// and returns the callback query ID
+func (s *Server) PressButton(from tgbotapi.User, messageID int, data string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var message *tgbotapi.Message
+	if m := s.chat(from.ID).find(messageID); m != nil {
+		msg := s.apiMessage(from.ID, m)
+		message = &msg
+	}
+	queryID := fmt.Sprintf("%d-%d", from.ID, s.lastID+1)
+	s.addUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:           queryID,
+		From:         &from,
+		Message:      message,
+		ChatInstance: strconv.FormatInt(from.ID, 10),
+		Data:         data,
+	}})
+	return queryID
+}
+
+// InlineButton finds the newest visible bot message in chatID with a callback button
+// labelled label, and returns the message ID and the button's callback data
+func (s *Server) InlineButton(chatID int64, label string) (messageID int, data string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := s.chat(chatID).messages
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Deleted || !msgs[i].FromBot {
+			continue
+		}
+		for _, row := range msgs[i].Inline {
+			for _, b := range row {
+				if b.Text == label && b.CallbackData != nil {
+					return msgs[i].ID, *b.CallbackData, true
+				}
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// Messages returns copies of all messages in chatID, oldest first, including deleted ones
+func (s *Server) Messages(chatID int64) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var msgs []Message
+	for _, m := range s.chat(chatID).messages {
+		msgs = append(msgs, *m)
+	}
+	return msgs
+}
+
+// BotMessages returns copies of the messages the bot sent to chatID, oldest first
+func (s *Server) BotMessages(chatID int64) []Message {
+	var msgs []Message
+	for _, m := range s.Messages(chatID) {
+		if m.FromBot {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// ReplyKeyboard returns the reply keyboard chatID currently shows
+func (s *Server) ReplyKeyboard(chatID int64) [][]tgbotapi.KeyboardButton {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chat(chatID).keyboard
+}
+
+// CallbackAnswers returns the answerCallbackQuery calls so far
+func (s *Server) CallbackAnswers() []CallbackAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CallbackAnswer{}, s.answers...)
+}
+
+// Block makes sends to chatID fail as if the user blocked the bot, until they write again
+func (s *Server) Block(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocked[chatID] = true
+}
@@ -0,0 +1,157 @@
+package telegramtest
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/sender"
+)
+
+const testToken = "123:test"
+
+func newTestClient(t *testing.T) (*Server, *tgbotapi.BotAPI) {
+	t.Helper()
+
+	s := NewServer(testToken)
+	t.Cleanup(s.Close)
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(testToken, s.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+	}
+	return s, api
+}
+
+func TestServer_GetMe(t *testing.T) {
+	s, api := newTestClient(t)
+	if api.Self.UserName != s.Bot.UserName {
+		t.Errorf("Self.UserName = %q, want %q", api.Self.UserName, s.Bot.UserName)
+	}
+
+	if _, err := tgbotapi.NewBotAPIWithAPIEndpoint("456:wrong", s.URL+"/bot%s/%s"); err == nil {
+		t.Error("NewBotAPIWithAPIEndpoint() with a wrong token succeeded")
+	}
+}
+
+func TestServer_SendEditDelete(t *testing.T) {
+	s, api := newTestClient(t)
+
+	msg := tgbotapi.NewMessage(7, "pick one")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Yes", "btn:yes"),
+	))
+	sent, err := api.Send(msg)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if sent.MessageID != 1 || sent.Chat.ID != 7 {
+		t.Errorf("Send() = message %d in chat %d, want message 1 in chat 7", sent.MessageID, sent.Chat.ID)
+	}
+	if id, data, ok := s.InlineButton(7, "Yes"); !ok || id != sent.MessageID || data != "btn:yes" {
+		t.Errorf("InlineButton() = %d, %q, %v", id, data, ok)
+	}
+
+	// An edit without a keyboard removes it
+	if _, err := api.Send(tgbotapi.NewEditMessageText(7, sent.MessageID, "done")); err != nil {
+		t.Fatalf("editMessageText error = %v", err)
+	}
+	got := s.BotMessages(7)
+	if len(got) != 1 || got[0].Text != "done" || !got[0].Edited || got[0].Inline != nil {
+		t.Errorf("BotMessages() after edit = %+v", got)
+	}
+
+	photo := tgbotapi.NewPhoto(7, tgbotapi.FileID("photo-1"))
+	photo.Caption = "look"
+	if sent, err := api.Send(photo); err != nil || len(sent.Photo) == 0 || sent.Photo[0].FileID != "photo-1" {
+		t.Errorf("sendPhoto = %+v, %v", sent.Photo, err)
+	}
+
+	if _, err := api.Request(tgbotapi.NewDeleteMessage(7, 1)); err != nil {
+		t.Fatalf("deleteMessage error = %v", err)
+	}
+	if _, err := api.Request(tgbotapi.NewDeleteMessage(7, 1)); err == nil {
+		t.Error("deleting a deleted message succeeded")
+	}
+	got = s.BotMessages(7)
+	if len(got) != 2 || !got[0].Deleted || got[1].Method != "sendPhoto" || got[1].Text != "look" {
+		t.Errorf("BotMessages() = %+v", got)
+	}
+}
+
+func TestServer_ReplyKeyboard(t *testing.T) {
+	s, api := newTestClient(t)
+
+	msg := tgbotapi.NewMessage(7, "menu")
+	msg.ReplyMarkup = tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("Play")))
+	if _, err := api.Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if kb := s.ReplyKeyboard(7); len(kb) != 1 || kb[0][0].Text != "Play" {
+		t.Errorf("ReplyKeyboard() = %v, want [[Play]]", kb)
+	}
+
+	msg = tgbotapi.NewMessage(7, "bye")
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	if _, err := api.Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if kb := s.ReplyKeyboard(7); kb != nil {
+		t.Errorf("ReplyKeyboard() after removal = %v, want none", kb)
+	}
+}
+
+func TestServer_GetUpdates(t *testing.T) {
+	s, api := newTestClient(t)
+	user := tgbotapi.User{ID: 7, FirstName: "Alice"}
+
+	s.SendText(user, "/start")
+	sent, err := api.Send(tgbotapi.NewMessage(7, "hi"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	queryID := s.PressButton(user, sent.MessageID, "btn:go")
+
+	updates, err := api.GetUpdates(tgbotapi.NewUpdate(0))
+	if err != nil {
+		t.Fatalf("GetUpdates() error = %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("GetUpdates() = %d updates, want 2", len(updates))
+	}
+	if !updates[0].Message.IsCommand() || updates[0].Message.Command() != "start" {
+		t.Errorf("first update = %+v, want the /start command", updates[0].Message)
+	}
+	cb := updates[1].CallbackQuery
+	if cb == nil || cb.ID != queryID || cb.Data != "btn:go" || cb.Message.MessageID != sent.MessageID {
+		t.Errorf("second update = %+v, want the button press", cb)
+	}
+
+	// A higher offset confirms what was received
+	updates, err = api.GetUpdates(tgbotapi.NewUpdate(updates[1].UpdateID + 1))
+	if err != nil || len(updates) != 0 {
+		t.Errorf("GetUpdates() after confirming = %d updates, %v; want none", len(updates), err)
+	}
+
+	if _, err := api.Request(tgbotapi.NewCallback(queryID, "ok")); err != nil {
+		t.Fatalf("answerCallbackQuery error = %v", err)
+	}
+	if answers := s.CallbackAnswers(); len(answers) != 1 || answers[0].QueryID != queryID {
+		t.Errorf("CallbackAnswers() = %+v", answers)
+	}
+}
+
+func TestServer_BlockedChat(t *testing.T) {
+	s, api := newTestClient(t)
+	s.Block(7)
+
+	_, err := api.Send(tgbotapi.NewMessage(7, "hello?"))
+	if !sender.IsUnreachable(err) {
+		t.Errorf("Send() to a blocked chat error = %v, want an unreachable error", err)
+	}
+
+	// Writing to the bot again unblocks it
+	s.SendText(tgbotapi.User{ID: 7}, "I'm back")
+	if _, err := api.Send(tgbotapi.NewMessage(7, "welcome back")); err != nil {
+		t.Errorf("Send() after the user wrote again error = %v", err)
+	}
+}
@@ -41,4 +41,6 @@ const (
 	ErrCodeInsufficientFunds = "INSUFFICIENT_FUNDS"
 	ErrCodeAlreadyExists     = "ALREADY_EXISTS"
 	ErrCodeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	ErrCodeOutOfStock        = "OUT_OF_STOCK"
+	ErrCodeDuplicateRequest  = "DUPLICATE_REQUEST"
 )
@@ -1,6 +1,9 @@
 package utils
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 // NormalizePersianNumbers converts Persian and Arabic numerals to English numerals
 func NormalizePersianNumbers(input string) string {
@@ -20,3 +23,25 @@ func NormalizePersianText(input string) string {
 	)
 	return strings.TrimSpace(replacer.Replace(input))
 }
+
+// FormatPersianNumber renders n with Persian digits and thousands separators (e.g. ۲۰،۰۰۰)
+func FormatPersianNumber(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString("،")
+		}
+		grouped.WriteRune(d)
+	}
+
+	replacer := strings.NewReplacer(
+		"0", "۰", "1", "۱", "2", "۲", "3", "۳", "4", "۴", "5", "۵", "6", "۶", "7", "۷", "8", "۸", "9", "۹",
+	)
+	return sign + replacer.Replace(grouped.String())
+}
@@ -2,33 +2,66 @@ package telegram
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/mroshb/game_bot/internal/config"
 	"github.com/mroshb/game_bot/internal/handlers"
+	"github.com/mroshb/game_bot/internal/middleware"
 	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/payment"
 	"github.com/mroshb/game_bot/internal/repositories"
+	"github.com/mroshb/game_bot/internal/sender"
 	"github.com/mroshb/game_bot/internal/services"
+	"github.com/mroshb/game_bot/internal/session"
 	"github.com/mroshb/game_bot/pkg/logger"
 	"github.com/mroshb/game_bot/pkg/utils"
 	"gorm.io/gorm"
 )
 
+// API is the part of the Telegram Bot API the bot uses; *tgbotapi.BotAPI implements it
+// and tests swap in a fake
+type API interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+	HandleUpdate(r *http.Request) (*tgbotapi.Update, error)
+}
+
 type Bot struct {
-	api      *tgbotapi.BotAPI
+	api      API
+	username string
 	config   *config.Config
 	db       *gorm.DB
 	handlers *handlers.HandlerManager
 
-	// User sessions for conversation state
-	sessions map[int64]*handlers.UserSession
-	mu       sync.RWMutex
+	// User sessions for conversation state. The store keeps them across restarts;
+	// sessions holds the ones loaded for updates being handled right now.
+	sessions     map[int64]*cachedSession
+	sessionStore session.Store
+	mu           sync.RWMutex
 
 	// Worker pool for parallel processing
 	workerChans []chan tgbotapi.Update
+
+	// Every outgoing message goes through the sender to stay within Telegram's limits
+	sender *sender.Sender
+
+	// Per-user rate limiting with slow-down notices and flood mutes; nil when disabled
+	floodControl *middleware.FloodControl
+
+	// HTTP server for health checks, metrics, the webhook and payment callbacks
+	httpServer *http.Server
+
+	// ready is set while updates are being received, for /readyz
+	ready   atomic.Bool
+	stopped atomic.Bool
 }
 
 // Session states
@@ -57,12 +90,13 @@ const (
 	StateRoomMaxPlayers  = "room_max_players"
 	StateRoomEntryFee    = "room_entry_fee"
 	StateAwaitingReceipt = "awaiting_receipt"
+	StateShopAvatar      = "shop_avatar"
 	StateVillageName     = "village_name"
 	StateVillageDesc     = "village_desc"
 )
 
 func InitBot(cfg *config.Config, db *gorm.DB) (*Bot, error) {
-	api, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.BotToken, cfg.GetBotAPIEndpoint())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
@@ -76,6 +110,7 @@ func InitBot(cfg *config.Config, db *gorm.DB) (*Bot, error) {
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	coinRepo := repositories.NewCoinRepository(db)
+	diamondRepo := repositories.NewDiamondRepository(db)
 	matchRepo := repositories.NewMatchRepository(db)
 	friendRepo := repositories.NewFriendRepository(db)
 	gameRepo := repositories.NewGameRepository(db)
@@ -83,73 +118,164 @@ func InitBot(cfg *config.Config, db *gorm.DB) (*Bot, error) {
 	villageRepo := repositories.NewVillageRepository(db)
 	quizMatchRepo := repositories.NewQuizMatchRepository(db)
 	todRepo := repositories.NewTodRepository(db)
+	blockRepo := repositories.NewBlockRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
+	banRepo := repositories.NewBanRepository(db)
+	purchaseRepo := repositories.NewPurchaseRepository(db)
+	shopRepo := repositories.NewShopRepository(db)
+	inventoryRepo := repositories.NewInventoryRepository(db)
+	broadcastRepo := repositories.NewBroadcastRepository(db)
 	villageSvc := services.NewVillageService(villageRepo, userRepo)
 
+	paymentProvider, err := payment.NewProvider(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment provider: %w", err)
+	}
+
+	sessionStore, err := session.NewStore(cfg, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session store: %w", err)
+	}
+
 	// Initialize handler manager
-	handlerMgr := handlers.NewHandlerManager(cfg, db, userRepo, coinRepo, matchRepo, friendRepo, gameRepo, roomRepo, villageRepo, quizMatchRepo, todRepo, villageSvc)
+	handlerMgr := handlers.NewHandlerManager(cfg, userRepo, coinRepo, diamondRepo, matchRepo, friendRepo, gameRepo, roomRepo, villageRepo, quizMatchRepo, todRepo, blockRepo, reportRepo, banRepo, purchaseRepo, shopRepo, inventoryRepo, broadcastRepo, paymentProvider, villageSvc)
 
-	bot := &Bot{
-		api:         api,
-		config:      cfg,
-		db:          db,
-		handlers:    handlerMgr,
-		sessions:    make(map[int64]*handlers.UserSession),
-		workerChans: make([]chan tgbotapi.Update, 10), // 10 workers
+	bot := newBot(cfg, api, api.Self.UserName, handlerMgr, sessionStore)
+	bot.db = db
+
+	// Users who blocked the bot before a restart stay skipped until they write again
+	if unreachable, err := userRepo.GetUnreachableTelegramIDs(); err != nil {
+		logger.Error("Failed to load unreachable users", "error", err)
+	} else {
+		for _, id := range unreachable {
+			bot.sender.MarkUnreachable(id)
+		}
 	}
 
+	if err := bot.start(); err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+// start runs the worker pool and the HTTP server, begins receiving updates and starts the background jobs
+func (b *Bot) start() error {
 	// Start workers
-	for i := 0; i < 10; i++ {
-		bot.workerChans[i] = make(chan tgbotapi.Update, 100)
-		go bot.startWorker(bot.workerChans[i])
+	for i := range b.workerChans {
+		b.workerChans[i] = make(chan tgbotapi.Update, 100)
+		go b.startWorker(b.workerChans[i])
 	}
 
-	// Start update listener
-	go bot.startUpdateListener()
+	// Gauges read on every scrape of /metrics
+	b.registerMetrics()
+
+	// Start HTTP server (health checks, metrics, webhook, payment callbacks)
+	b.startHTTPServer()
+
+	// Start receiving updates
+	if b.config.IsWebhookMode() {
+		if err := b.setWebhook(); err != nil {
+			b.stopHTTPServer()
+			return err
+		}
+		b.ready.Store(true)
+	} else {
+		go b.startUpdateListener()
+	}
 
 	// Start background jobs
-	go bot.startBackgroundJobs()
+	go b.startBackgroundJobs()
 
 	// Start Truth or Dare background jobs
-	go bot.StartTodBackgroundJobs()
+	go b.StartTodBackgroundJobs()
 
-	return bot, nil
+	// Pick up admin broadcasts that were delivering when the bot stopped
+	b.handlers.ResumeBroadcasts(b)
+
+	// Start coin ledger checks
+	if b.config.LedgerCheckHours > 0 {
+		go b.startLedgerCheck(time.Duration(b.config.LedgerCheckHours) * time.Hour)
+	}
+
+	return nil
+}
+
+// newBot wires a bot around the given API without receiving updates or starting jobs
+func newBot(cfg *config.Config, api API, username string, handlerMgr *handlers.HandlerManager, sessionStore session.Store) *Bot {
+	// Every Bot API call, sent through the sender or directly, is counted in the metrics
+	api = meteredAPI{api}
+
+	bot := &Bot{
+		api:          api,
+		username:     username,
+		config:       cfg,
+		handlers:     handlerMgr,
+		sessions:     make(map[int64]*cachedSession),
+		sessionStore: sessionStore,
+		workerChans:  make([]chan tgbotapi.Update, 10), // 10 workers
+	}
+
+	bot.sender = sender.New(api, sender.Options{
+		GlobalPerSecond: cfg.SendGlobalPerSecond,
+		ChatPerSecond:   cfg.SendChatPerSecond,
+		MaxRetries:      cfg.SendMaxRetries,
+		MaxRetryAfter:   cfg.GetSendMaxRetryAfter(),
+		OnFailure:       bot.onSendFailure,
+	})
+
+	if cfg.RateLimitPerUser > 0 {
+		limiter := middleware.NewRateLimiter(cfg.RateLimitPerUser, cfg.RateLimitPerIP, rateLimitWindow)
+		bot.floodControl = middleware.NewFloodControl(limiter, cfg.FloodMaxStrikes, floodStrikeWindow, cfg.GetFloodMute())
+	}
+
+	return bot
 }
 
 func (b *Bot) startUpdateListener() {
+	// getUpdates is refused while a webhook is set, e.g. after switching modes
+	if err := b.deleteWebhook(); err != nil {
+		logger.Error("Failed to delete webhook before polling", "error", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	for {
 		logger.Info("Starting update listener...")
 		updates := b.api.GetUpdatesChan(u)
+		b.ready.Store(true)
 
 		for update := range updates {
-			// Find userID for hashing
-			var userID int64
-			if update.Message != nil {
-				userID = update.Message.From.ID
-			} else if update.CallbackQuery != nil {
-				userID = update.CallbackQuery.From.ID
-			}
-
-			if userID != 0 {
-				// Hashed dispatch to workers to ensure per-user ordered processing
-				workerIdx := userID % int64(len(b.workerChans))
-				if workerIdx < 0 {
-					workerIdx = -workerIdx
-				}
-				b.workerChans[workerIdx] <- update
-			} else {
-				// Non-user related update (if any), process normally
-				go b.handleUpdate(update)
-			}
+			b.dispatchUpdate(update)
 		}
 
+		b.ready.Store(false)
+		if b.stopped.Load() {
+			return
+		}
 		logger.Warn("Update channel closed. Restarting in 5 seconds...")
 		time.Sleep(5 * time.Second)
 	}
 }
 
+// dispatchUpdate hands an update to a worker, whichever way it was received
+func (b *Bot) dispatchUpdate(update tgbotapi.Update) {
+	// Find userID for hashing
+	userID := updateUserID(update)
+
+	if userID != 0 {
+		// Hashed dispatch to workers to ensure per-user ordered processing
+		workerIdx := userID % int64(len(b.workerChans))
+		if workerIdx < 0 {
+			workerIdx = -workerIdx
+		}
+		b.workerChans[workerIdx] <- update
+	} else {
+		// Non-user related update (if any), process normally
+		go b.handleUpdate(update)
+	}
+}
+
 func (b *Bot) startBackgroundJobs() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
@@ -170,6 +296,23 @@ func (b *Bot) startBackgroundJobs() {
 		// Check Quiz Match Timeouts (3 days)
 		b.handlers.CheckQuizTimeouts(b)
 
+		// Remove bans whose time is up
+		if count := b.handlers.CleanupExpiredBans(); count > 0 {
+			logger.Debug("Cleaned up expired bans", "count", count)
+		}
+
+		// Drop chat transcripts that can no longer be reported
+		if count := b.handlers.CleanupChatTranscripts(); count > 0 {
+			logger.Debug("Cleaned up chat transcripts", "count", count)
+		}
+
+		// Drop conversation sessions idle for longer than their TTL
+		if count, err := b.sessionStore.Cleanup(); err != nil {
+			logger.Error("Failed to clean up sessions", "error", err)
+		} else if count > 0 {
+			logger.Debug("Cleaned up expired sessions", "count", count)
+		}
+
 		// Mark inactive users offline (e.g. 10 minutes)
 		if count, err := b.handlers.UserRepo.MarkInactiveUsersOffline(10 * time.Minute); err == nil && count > 0 {
 			logger.Debug("Marked inactive users offline", "count", count)
@@ -177,20 +320,112 @@ func (b *Bot) startBackgroundJobs() {
 	}
 }
 
+// startLedgerCheck periodically reconciles balances with the coin ledger
+func (b *Bot) startLedgerCheck(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.handlers.CheckCoinLedger(b)
+	}
+}
+
+// updateUserID returns the user an update came from, or 0 if it has none
+func updateUserID(update tgbotapi.Update) int64 {
+	if update.Message != nil {
+		return update.Message.From.ID
+	} else if update.CallbackQuery != nil {
+		return update.CallbackQuery.From.ID
+	} else if update.PreCheckoutQuery != nil {
+		return update.PreCheckoutQuery.From.ID
+	}
+	return 0
+}
+
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	defer observeUpdate(update, time.Now())
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error("Panic in handleUpdate", "error", r)
 		}
 	}()
 
+	if update.MyChatMember != nil {
+		b.handleMyChatMember(update.MyChatMember)
+		return
+	}
+
+	// Whatever the update did to the user's session is stored once it is handled
+	if userID := updateUserID(update); userID != 0 {
+		defer b.saveSession(userID)
+
+		// Anyone talking to the bot can be messaged again
+		if b.sender.MarkReachable(userID) {
+			b.handlers.MarkReachable(userID)
+		}
+	}
+
+	// Payments are settled before anything else; money already taken must always be credited
+	if update.PreCheckoutQuery != nil {
+		b.handlePreCheckoutQuery(update.PreCheckoutQuery)
+		return
+	}
+	if update.Message != nil && update.Message.SuccessfulPayment != nil {
+		b.handlers.HandleSuccessfulPayment(update.Message, b)
+		return
+	}
+
+	// Flooding users are slowed down and eventually muted
+	if userID := updateUserID(update); userID != 0 && !b.allowUpdate(userID, update) {
+		return
+	}
+
+	// Globally banned users can't use the bot until the ban ends
 	if update.Message != nil {
+		if b.handlers.RejectBanned(update.Message.From.ID, models.BanScopeGlobal, b) {
+			return
+		}
 		b.handleMessage(update.Message)
 	} else if update.CallbackQuery != nil {
+		if b.handlers.RejectBanned(update.CallbackQuery.From.ID, models.BanScopeGlobal, b) {
+			b.api.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
+			return
+		}
 		b.handleCallbackQuery(update.CallbackQuery)
 	}
 }
 
+// handleMyChatMember follows users blocking and unblocking the bot in their private chat
+func (b *Bot) handleMyChatMember(member *tgbotapi.ChatMemberUpdated) {
+	if !member.Chat.IsPrivate() {
+		return
+	}
+
+	switch member.NewChatMember.Status {
+	case "kicked":
+		logger.Info("User blocked the bot", "chat_id", member.Chat.ID)
+		b.sender.MarkUnreachable(member.Chat.ID)
+		b.handlers.HandleUnreachable(member.Chat.ID, b)
+	case "member":
+		if b.sender.MarkReachable(member.Chat.ID) {
+			b.handlers.MarkReachable(member.Chat.ID)
+		}
+	}
+}
+
+// handlePreCheckoutQuery answers Telegram's last check before a payment is taken
+func (b *Bot) handlePreCheckoutQuery(query *tgbotapi.PreCheckoutQuery) {
+	ok, errorMessage := b.handlers.ValidatePreCheckout(query)
+	answer := tgbotapi.PreCheckoutConfig{
+		PreCheckoutQueryID: query.ID,
+		OK:                 ok,
+		ErrorMessage:       errorMessage,
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		logger.Error("Failed to answer pre-checkout query", "error", err, "query_id", query.ID)
+	}
+}
+
 func (b *Bot) handleMessage(message *tgbotapi.Message) {
 	userID := message.From.ID
 
@@ -245,7 +480,7 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		b.handlers.UserRepo.UpdateLastActivity(user.ID)
 
 		b.clearSession(userID)
-		b.sendMessage(userID, MsgCancel, MainMenuKeyboard(false))
+		b.sendMessage(userID, MsgCancel, MainMenuKeyboard(b.isAdmin(userID)))
 		return
 	}
 
@@ -273,6 +508,18 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		return
 	}
 
+	// Handle shop avatar photo
+	if session.State == handlers.StateShopAvatar {
+		handlerSession := &handlers.UserSession{
+			State: session.State,
+			Data:  session.Data,
+		}
+		b.handlers.HandleShopAvatarPhoto(userID, message, handlerSession, b)
+		session.State = handlerSession.State
+		session.Data = handlerSession.Data
+		return
+	}
+
 	// Handle button presses (allows switching context)
 	if message.Text != "" {
 		if b.handleButtonPress(message, user, isRegistered) {
@@ -280,6 +527,12 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		}
 	}
 
+	// Handle admin panel input
+	if strings.HasPrefix(session.State, "admin_") {
+		b.handlers.HandleAdminInput(message, session, b)
+		return
+	}
+
 	// Handle Location
 	if isRegistered && (message.Location != nil || message.Venue != nil) {
 		var lat, lon float64
@@ -379,6 +632,19 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 				return
 			}
 
+			// Block the partner and end the chat at once
+			if normalizeButton(message.Text) == normalizeButton(BtnBlockUser) {
+				b.handlers.BlockFromChat(userID, b)
+				session.State = StateNone
+				return
+			}
+
+			// Report the partner while the chat goes on
+			if normalizeButton(message.Text) == normalizeButton(BtnReportUser) {
+				b.handlers.StartChatReport(userID, b)
+				return
+			}
+
 			// Intercept Main Menu buttons during chat
 			switch normalizeButton(message.Text) {
 			case normalizeButton(BtnPlayGame), normalizeButton(BtnProfile), normalizeButton(BtnLeaderboard), normalizeButton(BtnFriends),
@@ -401,25 +667,12 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		// Check if user is in a room and send message
 		activeRooms, _ := b.handlers.RoomRepo.GetUserRooms(user.ID)
 		if len(activeRooms) > 0 {
+			// The session remembers the room the user entered last; with a single room there is nothing to choose
 			roomID, ok := session.Data["current_room_id"].(uint)
-			if !ok || roomID == 0 {
-				// If session is lost (restart), try to find the "best" room to route to
-				if len(activeRooms) == 1 {
-					roomID = activeRooms[0].ID
-					session.Data["current_room_id"] = roomID
-					ok = true
-				} else {
-					// Multiple rooms: Check if user is in an active game in one of them
-					for _, r := range activeRooms {
-						game, _ := b.handlers.GameRepo.GetActiveGameSessionByRoomID(r.ID)
-						if game != nil {
-							roomID = r.ID
-							session.Data["current_room_id"] = roomID
-							ok = true
-							break
-						}
-					}
-				}
+			if (!ok || roomID == 0) && len(activeRooms) == 1 {
+				roomID = activeRooms[0].ID
+				session.Data["current_room_id"] = roomID
+				ok = true
 			}
 
 			if ok && roomID > 0 {
@@ -445,7 +698,7 @@ func (b *Bot) handleMessage(message *tgbotapi.Message) {
 		b.sendMessage(userID, "👋 سلام! برای شروع ثبت نام لطفاً دستور /start را بزنید.", nil)
 	} else {
 		// If registered but unknown input -> Main Menu
-		b.sendMessage(userID, MsgMainMenu, MainMenuKeyboard(false))
+		b.sendMessage(userID, MsgMainMenu, MainMenuKeyboard(b.isAdmin(userID)))
 	}
 }
 
@@ -488,7 +741,7 @@ func (b *Bot) handleCommand(message *tgbotapi.Message, isRegistered bool) {
 				return
 			}
 
-			b.sendMessage(userID, MsgWelcomeBack, MainMenuKeyboard(false))
+			b.sendMessage(userID, MsgWelcomeBack, MainMenuKeyboard(b.isAdmin(userID)))
 		} else {
 			// Step 1: Start and Gender (Inline)
 			session := b.getSession(userID)
@@ -497,6 +750,12 @@ func (b *Bot) handleCommand(message *tgbotapi.Message, isRegistered bool) {
 			session.Data["last_bot_msg_id"] = msgID
 		}
 
+	case "reports":
+		b.handlers.ShowReportQueue(userID, 0, b)
+
+	case "admin":
+		b.handlers.ShowAdminPanel(userID, b)
+
 	case "help":
 		user, _ := b.handlers.UserRepo.GetUserByTelegramID(userID)
 		isAdmin := user != nil && user.TelegramID == b.config.SuperAdminTgID
@@ -518,7 +777,7 @@ func (b *Bot) handleCommand(message *tgbotapi.Message, isRegistered bool) {
 	case "join":
 		args := message.CommandArguments()
 		if args != "" {
-			b.handlers.JoinRoomByCode(userID, args, b)
+			b.handlers.JoinRoomByCode(userID, args, handlers.MessageRequestID(message), b)
 		} else {
 			b.sendMessage(userID, "🔑 لطفاً کد دعوت را هم بفرستید. مثال: /join CODE123", nil)
 		}
@@ -600,7 +859,7 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		clearState()
 		// If we know they came from ToD menu, maybe we should start ToD matchmaking?
 		// For now, let's stick to the callback "btn:tod_new_game" for specific ToD matchmaking.
-		b.handlers.StartMatchmaking(userID, models.RequestedGenderAny, b.getSession(userID), b)
+		b.handlers.StartMatchmaking(userID, models.RequestedGenderAny, handlers.MessageRequestID(message), b.getSession(userID), b)
 
 	case normalizeButton(BtnOneVsOneRandom):
 		user, _ := b.handlers.UserRepo.GetUserByTelegramID(userID)
@@ -618,13 +877,12 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 			}
 		} else {
 			// Start matchmaking for 1v1
-			b.handlers.StartMatchmaking(userID, models.RequestedGenderAny, b.getSession(userID), b)
+			b.handlers.StartMatchmaking(userID, models.RequestedGenderAny, handlers.MessageRequestID(message), b.getSession(userID), b)
 		}
 
 	case normalizeButton(BtnPlayWithFriends):
 		// Generate share link
-		botUser, _ := b.api.GetMe()
-		shareLink := fmt.Sprintf("https://t.me/%s?start=join_group", botUser.UserName)
+		shareLink := fmt.Sprintf("https://t.me/%s?start=join_group", b.username)
 		msgText := "بیا با هم بازی کنیم! بزن روی لینک زیر:\n" + shareLink
 
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
@@ -635,14 +893,16 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		b.sendMessage(userID, msgText, keyboard)
 
 	case normalizeButton(BtnBetting):
-		b.sendMessage(userID, "💰 مبلغ شرط‌بندی رو انتخاب کن (به زودی...)", nil)
+		clearState()
+		b.handlers.ShowBettingMenu(userID, b)
 
 	case normalizeButton(BtnProfile):
 		clearState()
 		b.handlers.ShowProfile(userID, user, b)
 
 	case normalizeButton(BtnCoinShop):
-		b.sendMessage(userID, "🛍 فروشگاه سکه بزودی راه اندازی می شود.", nil)
+		clearState()
+		b.handlers.ShowShop(userID, b)
 
 	case normalizeButton(BtnDailyBonus), "✅ " + normalizeButton(BtnDailyBonus):
 		b.handlers.HandleDailyBonus(userID, "", b)
@@ -657,7 +917,8 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		b.handlers.HandleFilterProvince(userID, b) // Reuse province selection or dedicated edit loc
 
 	case normalizeButton(BtnBlocks):
-		b.sendMessage(userID, "🚫 لیست بلاک شده‌های شما (به زودی...)", nil)
+		clearState()
+		b.handlers.ShowBlockedUsers(userID, 0, b)
 
 	case normalizeButton(BtnSettings):
 		clearState()
@@ -700,8 +961,7 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		b.handlers.HandleVillageInvite(userID, b)
 
 	case normalizeButton(BtnInviteLink):
-		botUser, _ := b.api.GetMe()
-		inviteLink := fmt.Sprintf("https://t.me/%s?start=ref_%d", botUser.UserName, userID)
+		inviteLink := fmt.Sprintf("https://t.me/%s?start=ref_%d", b.username, userID)
 		b.sendMessage(userID, "🔗 لینک دعوت اختصاصی شما:\n\n"+inviteLink, nil)
 
 	case normalizeButton(BtnFriendList):
@@ -739,7 +999,7 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		} else if btn == normalizeButton(BtnMale) {
 			gender = models.GenderMale
 		}
-		b.handlers.StartMatchmaking(userID, gender, b.getSession(userID), b)
+		b.handlers.StartMatchmaking(userID, gender, handlers.MessageRequestID(message), b.getSession(userID), b)
 
 	case normalizeButton(BtnSkip):
 		if b.getSession(userID).State == handlers.StateSearchAge || b.getSession(userID).State == handlers.StateSearchCity {
@@ -765,8 +1025,7 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 		// Calculate total rewards earned from referrals (100 coins per referral)
 		totalRewards := referralCount * 100
 
-		botUser, _ := b.api.GetMe()
-		inviteLink := fmt.Sprintf("https://t.me/%s?start=ref_%d", botUser.UserName, userID)
+		inviteLink := fmt.Sprintf("https://t.me/%s?start=ref_%d", b.username, userID)
 
 		// Enhanced message with statistics
 		referralMsg := fmt.Sprintf(
@@ -797,12 +1056,7 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 
 	case normalizeButton(BtnIncreaseCoins):
 		clearState()
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData(BtnIHavePaid, "btn:"+BtnIHavePaid),
-			),
-		)
-		b.sendMessage(userID, MsgCoinPurchasePlans, keyboard)
+		b.handlers.HandleBuyCoins(userID, 0, b)
 
 	case normalizeButton(BtnShowBalance):
 		clearState()
@@ -835,7 +1089,20 @@ func (b *Bot) handleButtonPress(message *tgbotapi.Message, user *models.User, is
 			b.handlers.UserRepo.UpdateUserStatus(user.ID, models.UserStatusOnline)
 		}
 		b.clearSession(userID)
-		b.sendMessage(userID, MsgCancel, MainMenuKeyboard(false))
+		b.sendMessage(userID, MsgCancel, MainMenuKeyboard(b.isAdmin(userID)))
+
+	case normalizeButton(BtnAdminPanel):
+		if !b.isAdmin(userID) {
+			return false
+		}
+		clearState()
+		b.handlers.ShowAdminPanel(userID, b)
+
+	case normalizeButton(BtnUserManagement):
+		if !b.isAdmin(userID) {
+			return false
+		}
+		b.handlers.StartAdminLookup(userID, b.getSession(userID), b)
 
 	default:
 		return false
@@ -871,6 +1138,17 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		return
 	}
 
+	// Betting stake selection
+	if strings.HasPrefix(data, "bet_") {
+		var amount int64
+		if _, err := fmt.Sscanf(data, "bet_quiz_%d", &amount); err == nil {
+			b.handlers.HandleBetSelection(userID, models.GameTypeQuiz, amount, b)
+		} else if _, err := fmt.Sscanf(data, "bet_tod_%d", &amount); err == nil {
+			b.handlers.HandleBetSelection(userID, models.GameTypeTod, amount, b)
+		}
+		return
+	}
+
 	// Registration callbacks
 	if strings.HasPrefix(data, "reg_") {
 		session := b.getSession(userID)
@@ -916,7 +1194,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		if query.Message != nil {
 			msgID = query.Message.MessageID
 		}
-		b.handlers.HandleAdvancedSearchProvince(userID, data, msgID, handlerSession, b)
+		b.handlers.HandleAdvancedSearchProvince(userID, data, msgID, handlers.CallbackRequestID(query.ID), handlerSession, b)
 		session.State = handlerSession.State
 		session.Data = handlerSession.Data
 		return
@@ -944,7 +1222,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	if len(data) > 10 && data[:10] == "room_join_" {
 		var roomID uint
 		fmt.Sscanf(data, "room_join_%d", &roomID)
-		b.handlers.JoinRoom(userID, roomID, b)
+		b.handlers.JoinRoom(userID, roomID, handlers.CallbackRequestID(query.ID), b)
 
 		// Set current room in session
 		session := b.getSession(userID)
@@ -961,7 +1239,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		return
 	}
 	if data == "room_quick_join" {
-		b.handlers.QuickJoinRoom(userID, b)
+		b.handlers.QuickJoinRoom(userID, handlers.CallbackRequestID(query.ID), b)
 		return
 	}
 	if data == "room_join_code" {
@@ -1016,7 +1294,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		} else if data[:17] == "match_add_friend_" {
 			var matchID uint
 			fmt.Sscanf(data, "match_add_friend_%d", &matchID)
-			b.handlers.HandleAddFriendFromMatch(userID, matchID, b)
+			b.handlers.HandleAddFriendFromMatch(userID, matchID, handlers.CallbackRequestID(query.ID), b)
 		} else if data[:10] == "match_end_" {
 			b.handlers.EndChat(userID, b)
 		}
@@ -1119,7 +1397,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	if len(data) > 14 && data[:14] == "gt_accept_inv_" {
 		var roomID uint
 		fmt.Sscanf(data, "gt_accept_inv_%d", &roomID)
-		b.handlers.JoinRoom(userID, roomID, b)
+		b.handlers.JoinRoom(userID, roomID, handlers.CallbackRequestID(query.ID), b)
 
 		session := b.getSession(userID)
 		session.Data["current_room_id"] = roomID
@@ -1138,7 +1416,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		if len(parts) >= 5 {
 			fmt.Sscanf(parts[2], "%d", &matchID)
 			category = strings.Join(parts[3:], "_")
-			b.handlers.HandleMatchTruthOrDareCategorySelection(userID, matchID, category, b)
+			b.handlers.HandleMatchTruthOrDareCategorySelection(userID, matchID, category, handlers.CallbackRequestID(query.ID), b)
 		}
 		return
 	}
@@ -1183,16 +1461,88 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		return
 	}
 	if data == "paid_coins" {
+		// Older purchase messages had a single "I have paid" button; ask which package it was
+		b.handlers.HandleBuyCoins(userID, 0, b)
+		return
+	}
+	if strings.HasPrefix(data, "buy_pkg_") {
+		var packageID uint
+		fmt.Sscanf(data, "buy_pkg_%d", &packageID)
+		session := b.getSession(userID)
+		handlerSession := &handlers.UserSession{
+			State: session.State,
+			Data:  session.Data,
+		}
+		b.handlers.HandleSelectCoinPackage(userID, packageID, handlerSession, b)
+		session.State = handlerSession.State
+		session.Data = handlerSession.Data
+		return
+	}
+	if strings.HasPrefix(data, "pay_pkg_") {
+		var packageID uint
+		fmt.Sscanf(data, "pay_pkg_%d", &packageID)
+		b.handlers.HandleOnlinePayment(userID, packageID, b)
+		return
+	}
+	if data == "stars_shop" {
+		b.handlers.ShowStarsShop(userID, b)
+		return
+	}
+	if strings.HasPrefix(data, "stars_buy_") {
+		// stars_buy_{kind}_{productID}
+		parts := strings.Split(strings.TrimPrefix(data, "stars_buy_"), "_")
+		if len(parts) == 2 {
+			var productID uint
+			fmt.Sscanf(parts[1], "%d", &productID)
+			b.handlers.SendProductInvoice(userID, parts[0], productID, b)
+		}
+		return
+	}
+	if data == "shop_open" {
+		b.handlers.ShowShop(userID, b)
+		return
+	}
+	if strings.HasPrefix(data, "shop_item_") {
+		b.handlers.ShowShopItem(userID, strings.TrimPrefix(data, "shop_item_"), b)
+		return
+	}
+	if strings.HasPrefix(data, "shop_buy_") {
 		session := b.getSession(userID)
 		handlerSession := &handlers.UserSession{
 			State: session.State,
 			Data:  session.Data,
 		}
-		b.handlers.HandlePaid(userID, handlerSession, b)
+		b.handlers.HandleShopBuy(userID, strings.TrimPrefix(data, "shop_buy_"), handlers.CallbackRequestID(query.ID), handlerSession, b)
 		session.State = handlerSession.State
 		session.Data = handlerSession.Data
 		return
 	}
+	if data == "diamonds" {
+		b.handlers.ShowDiamonds(userID, b)
+		return
+	}
+	if strings.HasPrefix(data, "dia_exch_") {
+		var diamonds int64
+		fmt.Sscanf(data, "dia_exch_%d", &diamonds)
+		b.handlers.HandleDiamondExchange(userID, diamonds, handlers.CallbackRequestID(query.ID), b)
+		return
+	}
+	if data == "my_orders" {
+		b.handlers.ShowMyOrders(userID, b)
+		return
+	}
+	if strings.HasPrefix(data, "order_approve_") {
+		var orderID uint
+		fmt.Sscanf(data, "order_approve_%d", &orderID)
+		b.handlers.HandleOrderReview(userID, orderID, true, b)
+		return
+	}
+	if strings.HasPrefix(data, "order_reject_") {
+		var orderID uint
+		fmt.Sscanf(data, "order_reject_%d", &orderID)
+		b.handlers.HandleOrderReview(userID, orderID, false, b)
+		return
+	}
 
 	// Edit Profile Callbacks
 	if data == "edit_profile" {
@@ -1228,7 +1578,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		return
 	}
 	if data == "shop_items" {
-		b.sendMessage(userID, "🛍 فروشگاه آیتم‌ها بزودی باز می‌شود!", nil)
+		b.handlers.ShowShop(userID, b)
 		return
 	}
 
@@ -1236,7 +1586,7 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	if len(data) > 11 && data[:11] == "friend_add_" {
 		var targetUserID uint
 		fmt.Sscanf(data, "friend_add_%d", &targetUserID)
-		b.handlers.HandleAddFriend(userID, targetUserID, b)
+		b.handlers.HandleAddFriend(userID, targetUserID, handlers.CallbackRequestID(query.ID), b)
 		return
 	}
 	if len(data) > 13 && data[:14] == "friend_accept_" {
@@ -1257,6 +1607,75 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 		b.handlers.HandleRemoveFriend(userID, friendID, b)
 		return
 	}
+	if strings.HasPrefix(data, "blocks_page_") {
+		var page int
+		fmt.Sscanf(data, "blocks_page_%d", &page)
+		b.handlers.ShowBlockedUsers(userID, page, b)
+		return
+	}
+	if strings.HasPrefix(data, "block_") {
+		var targetUserID uint
+		fmt.Sscanf(data, "block_%d", &targetUserID)
+		b.handlers.HandleBlockUser(userID, targetUserID, b)
+		return
+	}
+	if strings.HasPrefix(data, "unblock_") {
+		var targetUserID uint
+		var page int
+		fmt.Sscanf(data, "unblock_%d_%d", &targetUserID, &page)
+		b.handlers.HandleUnblock(userID, targetUserID, page, b)
+		return
+	}
+
+	// Admin panel
+	if strings.HasPrefix(data, "adm_bc_") {
+		b.handleBroadcastCallback(userID, data)
+		return
+	}
+	if strings.HasPrefix(data, "adm_") {
+		b.handleAdminCallback(userID, data)
+		return
+	}
+
+	// Abuse reports
+	if strings.HasPrefix(data, "reports_page_") {
+		var page int
+		fmt.Sscanf(data, "reports_page_%d", &page)
+		b.handlers.ShowReportQueue(userID, page, b)
+		return
+	}
+	if strings.HasPrefix(data, "report_reason_") {
+		var matchID uint
+		var reason string
+		fmt.Sscanf(data, "report_reason_%d_%s", &matchID, &reason)
+		b.handlers.HandleReportReason(userID, matchID, reason, b)
+		return
+	}
+	if strings.HasPrefix(data, "report_view_") {
+		var reportID uint
+		fmt.Sscanf(data, "report_view_%d", &reportID)
+		b.handlers.ShowReportDetails(userID, reportID, b)
+		return
+	}
+	if strings.HasPrefix(data, "report_approve_") {
+		var reportID uint
+		fmt.Sscanf(data, "report_approve_%d", &reportID)
+		b.handlers.HandleReportReview(userID, reportID, true, b)
+		return
+	}
+	if strings.HasPrefix(data, "report_dismiss_") {
+		var reportID uint
+		fmt.Sscanf(data, "report_dismiss_%d", &reportID)
+		b.handlers.HandleReportReview(userID, reportID, false, b)
+		return
+	}
+	if strings.HasPrefix(data, "report_") {
+		var matchID uint
+		fmt.Sscanf(data, "report_%d", &matchID)
+		b.handlers.HandleReportStart(userID, matchID, b)
+		return
+	}
+
 	if len(data) > 5 && data[:5] == "like_" {
 		var likedUserID uint
 		fmt.Sscanf(data, "like_%d", &likedUserID)
@@ -1267,17 +1686,99 @@ func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
 	// Fallback: Button Label Emulation
 	if strings.HasPrefix(data, "btn:") {
 		btnText := strings.TrimPrefix(data, "btn:")
-		// Simulate a message to trigger full message handling (including states)
+		// Simulate a message to trigger full message handling (including states).
+		// It carries the ID of the message the button was on, so paid actions it starts
+		// are keyed to that one-shot keyboard.
 		fakeMsg := &tgbotapi.Message{
-			From: query.From,
-			Chat: query.Message.Chat,
-			Text: btnText,
+			MessageID: query.Message.MessageID,
+			From:      query.From,
+			Chat:      query.Message.Chat,
+			Text:      btnText,
 		}
 		b.handleMessage(fakeMsg)
 		return
 	}
 }
 
+func (b *Bot) handleAdminCallback(userID int64, data string) {
+	var targetUserID uint
+	var hours int
+	var scope string
+
+	switch {
+	case data == "adm_stats":
+		b.handlers.ShowAdminPanel(userID, b)
+	case data == "adm_lookup":
+		b.handlers.StartAdminLookup(userID, b.getSession(userID), b)
+	case strings.HasPrefix(data, "adm_user_"):
+		fmt.Sscanf(data, "adm_user_%d", &targetUserID)
+		b.handlers.ShowAdminUserCard(userID, targetUserID, b)
+	case strings.HasPrefix(data, "adm_profile_"):
+		fmt.Sscanf(data, "adm_profile_%d", &targetUserID)
+		b.handlers.ShowAdminUserProfile(userID, targetUserID, b)
+	case strings.HasPrefix(data, "adm_tx_"):
+		fmt.Sscanf(data, "adm_tx_%d", &targetUserID)
+		b.handlers.ShowAdminTransactions(userID, targetUserID, b)
+	case strings.HasPrefix(data, "adm_coins_"):
+		fmt.Sscanf(data, "adm_coins_%d", &targetUserID)
+		b.handlers.StartAdminCoinAdjustment(userID, targetUserID, b.getSession(userID), b)
+	case strings.HasPrefix(data, "adm_dia_"):
+		fmt.Sscanf(data, "adm_dia_%d", &targetUserID)
+		b.handlers.StartAdminDiamondAdjustment(userID, targetUserID, b.getSession(userID), b)
+	case strings.HasPrefix(data, "adm_bscope_"):
+		fmt.Sscanf(data, "adm_bscope_%d_%s", &targetUserID, &scope)
+		b.handlers.ShowAdminBanDurations(userID, targetUserID, scope, b)
+	case strings.HasPrefix(data, "adm_bdur_"):
+		fmt.Sscanf(data, "adm_bdur_%d_%d_%s", &targetUserID, &hours, &scope)
+		b.handlers.HandleAdminBan(userID, targetUserID, scope, hours, b)
+	case strings.HasPrefix(data, "adm_ban_"):
+		fmt.Sscanf(data, "adm_ban_%d", &targetUserID)
+		b.handlers.ShowAdminBanScopes(userID, targetUserID, b)
+	case strings.HasPrefix(data, "adm_unban_"):
+		fmt.Sscanf(data, "adm_unban_%d", &targetUserID)
+		b.handlers.HandleAdminUnban(userID, targetUserID, b)
+	}
+}
+
+func (b *Bot) handleBroadcastCallback(userID int64, data string) {
+	var broadcastID uint
+	var segment string
+
+	switch {
+	case data == "adm_bc_new":
+		b.handlers.StartBroadcastCompose(userID, b.getSession(userID), b)
+	case data == "adm_bc_list":
+		b.handlers.ShowBroadcastList(userID, b)
+	case strings.HasPrefix(data, "adm_bc_nobtn_"):
+		fmt.Sscanf(data, "adm_bc_nobtn_%d", &broadcastID)
+		b.handlers.SkipBroadcastButtons(userID, broadcastID, b.getSession(userID), b)
+	case strings.HasPrefix(data, "adm_bc_seg_"):
+		fmt.Sscanf(data, "adm_bc_seg_%d_%s", &broadcastID, &segment)
+		b.handlers.HandleBroadcastSegment(userID, broadcastID, segment, b.getSession(userID), b)
+	case strings.HasPrefix(data, "adm_bc_target_"):
+		fmt.Sscanf(data, "adm_bc_target_%d", &broadcastID)
+		b.handlers.ShowBroadcastSegments(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_start_"):
+		fmt.Sscanf(data, "adm_bc_start_%d", &broadcastID)
+		b.handlers.HandleBroadcastStart(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_del_"):
+		fmt.Sscanf(data, "adm_bc_del_%d", &broadcastID)
+		b.handlers.HandleBroadcastDelete(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_pause_"):
+		fmt.Sscanf(data, "adm_bc_pause_%d", &broadcastID)
+		b.handlers.HandleBroadcastPause(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_resume_"):
+		fmt.Sscanf(data, "adm_bc_resume_%d", &broadcastID)
+		b.handlers.HandleBroadcastResume(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_cancel_"):
+		fmt.Sscanf(data, "adm_bc_cancel_%d", &broadcastID)
+		b.handlers.HandleBroadcastCancel(userID, broadcastID, b)
+	case strings.HasPrefix(data, "adm_bc_view_"):
+		fmt.Sscanf(data, "adm_bc_view_%d", &broadcastID)
+		b.handlers.ShowBroadcastStatus(userID, broadcastID, b)
+	}
+}
+
 func (b *Bot) handleChatMessage(message *tgbotapi.Message, user *models.User) {
 	b.handlers.HandleChatMessage(message, user, b)
 }
@@ -1322,7 +1823,7 @@ func (b *Bot) startSearchFlow(userID int64) {
 	inQueue, _ := b.handlers.MatchRepo.IsUserInQueue(user.ID)
 	if inQueue {
 		// Instead of blocking, trigger the resume logic in StartMatchmaking
-		b.handlers.StartMatchmaking(userID, "", b.getSession(userID), b)
+		b.handlers.StartMatchmaking(userID, "", "", b.getSession(userID), b)
 		return
 	}
 
@@ -1337,33 +1838,87 @@ func (b *Bot) startSearchFlow(userID int64) {
 	b.sendMessage(userID, MsgSelectGender, SearchGenderFilterKeyboard())
 }
 
-func (b *Bot) getSession(userID int64) *handlers.UserSession {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+// cachedSession is a session loaded for the update being handled
+type cachedSession struct {
+	session *handlers.UserSession
+	stored  bool // the store has a copy that must be replaced or deleted
+}
 
-	if session, exists := b.sessions[userID]; exists {
-		return session
+// getSession returns the user's session, loading it from the store on first use in an update.
+// Updates of one user are handled by a single worker, so the cached session isn't shared.
+func (b *Bot) getSession(userID int64) *handlers.UserSession {
+	b.mu.RLock()
+	cached, exists := b.sessions[userID]
+	b.mu.RUnlock()
+	if exists {
+		return cached.session
 	}
 
-	session := &handlers.UserSession{
-		State: StateNone,
-		Data:  make(map[string]interface{}),
+	cached = &cachedSession{session: session.New()}
+	loaded, err := b.sessionStore.Load(userID)
+	if err != nil {
+		logger.Error("Failed to load session", "user_id", userID, "error", err)
+	} else if loaded != nil {
+		cached = &cachedSession{session: loaded, stored: true}
 	}
-	b.sessions[userID] = session
-	return session
+
+	b.mu.Lock()
+	b.sessions[userID] = cached
+	b.mu.Unlock()
+	return cached.session
 }
 
 func (b *Bot) clearSession(userID int64) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.sessions[userID] = &handlers.UserSession{
-		State: StateNone,
-		Data:  make(map[string]interface{}),
+	cached, exists := b.sessions[userID]
+	if !exists {
+		// Not loaded in this update; make sure a stored copy gets deleted too
+		cached = &cachedSession{stored: true}
+		b.sessions[userID] = cached
+	}
+	cached.session = session.New()
+}
+
+// saveSession writes the user's session back to the store and drops it from the cache.
+// Empty sessions aren't kept, so users outside any flow cost no storage.
+func (b *Bot) saveSession(userID int64) {
+	b.mu.Lock()
+	cached, exists := b.sessions[userID]
+	delete(b.sessions, userID)
+	b.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	var err error
+	if cached.session.IsEmpty() {
+		if cached.stored {
+			err = b.sessionStore.Delete(userID)
+		}
+	} else {
+		err = b.sessionStore.Save(userID, cached.session)
+	}
+	if err != nil {
+		logger.Error("Failed to save session", "user_id", userID, "state", cached.session.State, "error", err)
 	}
 }
 
 func (b *Bot) sendMessage(chatID int64, text string, keyboard interface{}) int {
+	return b.sendMessageWithPriority(chatID, text, keyboard, sender.PriorityNormal)
+}
+
+func (b *Bot) sendMessageWithPriority(chatID int64, text string, keyboard interface{}, priority sender.Priority) int {
+	sentMsg, err := b.sender.Send(chatID, newTextMessage(chatID, text, keyboard), priority)
+	if err != nil {
+		return 0 // Logged by onSendFailure
+	}
+	return sentMsg.MessageID
+}
+
+// newTextMessage builds an HTML text message with the keyboard attached
+func newTextMessage(chatID int64, text string, keyboard interface{}) tgbotapi.MessageConfig {
 	// Add RTL mark for Persian support
 	rtlText := "\u200f" + text
 	msg := tgbotapi.NewMessage(chatID, rtlText)
@@ -1377,31 +1932,45 @@ func (b *Bot) sendMessage(chatID int64, text string, keyboard interface{}) int {
 	case tgbotapi.ReplyKeyboardRemove:
 		msg.ReplyMarkup = kb
 	}
-
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		sentMsg, err := b.api.Send(msg)
-		if err != nil {
-			logger.Error("Failed to send message", "error", err, "chat_id", chatID, "attempt", i+1)
-
-			// If it's a network error, wait and retry
-			if strings.Contains(err.Error(), "connection reset") ||
-				strings.Contains(err.Error(), "timeout") ||
-				strings.Contains(err.Error(), "network is unreachable") {
-				time.Sleep(time.Duration(i+1) * time.Second)
-				continue
-			}
-			return 0 // Non-network error, don't retry
-		}
-		return sentMsg.MessageID // Success
-	}
-	return 0 // All retries failed
+	return msg
 }
 
 func (b *Bot) SendMessage(chatID int64, text string, keyboard interface{}) int {
 	return b.sendMessage(chatID, text, keyboard)
 }
 
+// SendMessageWithPriority sends a text message ahead of or behind other queued messages
+func (b *Bot) SendMessageWithPriority(chatID int64, text string, keyboard interface{}, priority sender.Priority) int {
+	return b.sendMessageWithPriority(chatID, text, keyboard, priority)
+}
+
+// Send delivers any message through the outbound queue
+func (b *Bot) Send(chatID int64, c tgbotapi.Chattable, priority sender.Priority) (tgbotapi.Message, error) {
+	return b.sender.Send(chatID, c, priority)
+}
+
+// SendAsync queues any message without waiting for it to be delivered. Fan-out loops use
+// it so one slow chat doesn't hold up the update worker; failures reach onSendFailure.
+func (b *Bot) SendAsync(chatID int64, c tgbotapi.Chattable, priority sender.Priority) error {
+	return b.sender.SendAsync(chatID, c, priority)
+}
+
+// SendMessageAsync queues a text message without waiting for it to be delivered
+func (b *Bot) SendMessageAsync(chatID int64, text string, keyboard interface{}, priority sender.Priority) error {
+	return b.sender.SendAsync(chatID, newTextMessage(chatID, text, keyboard), priority)
+}
+
+// onSendFailure is called for every message the sender gave up on
+func (b *Bot) onSendFailure(f sender.Failure) {
+	if sender.IsUnreachable(f.Err) {
+		logger.Info("User unreachable", "chat_id", f.ChatID, "error", f.Err)
+		// Off the sender's worker: cleaning up sends messages to the user's opponents
+		go b.handlers.HandleUnreachable(f.ChatID, b)
+		return
+	}
+	logger.Error("Failed to send message", "chat_id", f.ChatID, "priority", f.Priority, "attempts", f.Attempts, "error", f.Err)
+}
+
 func (b *Bot) DeleteMessage(chatID int64, messageID int) {
 	if messageID == 0 {
 		return
@@ -1424,17 +1993,20 @@ func (b *Bot) EditMessage(chatID int64, messageID int, text string, keyboard int
 		}
 	}
 
-	if _, err := b.api.Send(msg); err != nil {
-		logger.Error("Failed to edit message", "error", err, "chat_id", chatID, "message_id", messageID)
-	}
+	b.sender.Send(chatID, msg, sender.PriorityNormal)
 }
 
-func (b *Bot) SendMainMenu(chatID int64, _ bool) {
-	b.sendMessage(chatID, MsgMainMenu, MainMenuKeyboard(false))
+func (b *Bot) SendMainMenu(chatID int64, isAdmin bool) {
+	b.sendMessage(chatID, MsgMainMenu, MainMenuKeyboard(isAdmin))
 }
 
-func (b *Bot) GetMainMenuKeyboard(_ bool) interface{} {
-	return MainMenuKeyboard(false)
+func (b *Bot) GetMainMenuKeyboard(isAdmin bool) interface{} {
+	return MainMenuKeyboard(isAdmin)
+}
+
+// isAdmin reports whether a Telegram user is the super admin
+func (b *Bot) isAdmin(userID int64) bool {
+	return b.config.SuperAdminTgID != 0 && userID == b.config.SuperAdminTgID
 }
 
 func (b *Bot) GetGenderKeyboard() interface{} {
@@ -1465,8 +2037,8 @@ func (b *Bot) GetEditProfileFieldsKeyboard() interface{} {
 	return EditProfileFieldsKeyboard()
 }
 
-func (b *Bot) GetAPI() interface{} {
-	return b.api
+func (b *Bot) BotUsername() string {
+	return b.username
 }
 
 func (b *Bot) GetConfig() interface{} {
@@ -1474,8 +2046,15 @@ func (b *Bot) GetConfig() interface{} {
 }
 
 func (b *Bot) Stop() {
-	b.api.StopReceivingUpdates()
+	b.stopped.Store(true)
+	b.ready.Store(false)
+	// The webhook is left in place: during a rolling deploy it already points at the new
+	// instance, and Telegram keeps updates it can't deliver until someone is listening
+	if !b.config.IsWebhookMode() {
+		b.api.StopReceivingUpdates()
+	}
 	logger.Info("Bot stopped receiving updates")
+	b.stopHTTPServer()
 }
 func (b *Bot) EditMessageReplyMarkup(chatID int64, messageID int, keyboard interface{}) {
 	var kb tgbotapi.InlineKeyboardMarkup
@@ -1519,21 +2098,11 @@ func (b *Bot) sendPhoto(chatID int64, photoID string, caption string, keyboard i
 		photo.ReplyMarkup = kb
 	}
 
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		sentMsg, err := b.api.Send(photo)
-		if err != nil {
-			logger.Error("Failed to send photo", "error", err, "chat_id", chatID, "attempt", i+1)
-			if strings.Contains(err.Error(), "connection reset") ||
-				strings.Contains(err.Error(), "timeout") {
-				time.Sleep(time.Duration(i+1) * time.Second)
-				continue
-			}
-			return 0
-		}
-		return sentMsg.MessageID
+	sentMsg, err := b.sender.Send(chatID, photo, sender.PriorityNormal)
+	if err != nil {
+		return 0 // Logged by onSendFailure
 	}
-	return 0
+	return sentMsg.MessageID
 }
 
 func (b *Bot) startWorker(ch chan tgbotapi.Update) {
@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+	"github.com/mroshb/game_bot/internal/session"
+)
+
+const (
+	btnBoy  = "🙍‍♂️ پسر"
+	btnGirl = "🙍‍♀️ دختر"
+)
+
+// balance reads a user's coins straight from the store
+func (s *scenario) balance(u *simUser) int64 {
+	s.t.Helper()
+
+	user, err := s.bot.handlers.UserRepo.GetUserByTelegramID(u.id)
+	if err != nil {
+		s.t.Fatalf("GetUserByTelegramID(%d) error = %v", u.id, err)
+	}
+	return user.CoinBalance
+}
+
+func TestScenario_RegisterMatchChatAndEnd(t *testing.T) {
+	sc := newScenario(t)
+	alice := sc.register(101, "Alice", btnGirl)
+	bob := sc.register(102, "Bob", btnBoy)
+
+	alice.tap(BtnChatNow)
+	alice.press(BtnRandomMatch)
+	// Bob joins the queue after Alice so her search is the one that pairs them
+	time.Sleep(time.Second)
+	bob.tap(BtnChatNow)
+	bob.press(BtnRandomMatch)
+
+	for _, u := range []*simUser{alice, bob} {
+		u.expect("✅ پیدا شد")
+		u.expectKeyboard(BtnEndChat)
+		if got := sc.balance(u); got != models.StartingCoins-5 {
+			t.Errorf("%s balance = %d, want %d", u.firstName, got, models.StartingCoins-5)
+		}
+	}
+
+	alice.send("سلام! خوبی؟")
+	bob.expect("سلام! خوبی؟")
+	bob.send("مرسی، تو چطوری؟")
+	alice.expect("مرسی، تو چطوری؟")
+
+	bob.tap(BtnEndChat)
+	alice.expect("طرف مقابل چت را ترک کرد")
+	alice.expectKeyboard(BtnChatNow)
+	bob.expectKeyboard(BtnChatNow)
+
+	// Chatting is over: text no longer reaches the old partner
+	alice.send("هنوز اینجایی؟")
+	if _, ok := bob.awaitFor("هنوز اینجایی؟", 500*time.Millisecond); ok {
+		t.Error("a message was forwarded after the chat ended")
+	}
+}
+
+func TestScenario_QuizMatch(t *testing.T) {
+	sc := newScenario(t)
+	for _, category := range []string{"History", "Science", "Sports"} {
+		for i := 1; i <= models.QuizQuestionsPerRound; i++ {
+			sc.db.SeedQuestions(models.Question{
+				QuestionText:  fmt.Sprintf("%s question %d", category, i),
+				QuestionType:  models.QuestionTypeQuiz,
+				Category:      category,
+				CorrectAnswer: "Right",
+				Options:       `["Right","Wrong","Maybe","Never"]`,
+			})
+		}
+	}
+	alice := sc.register(201, "Alice", btnGirl)
+	bob := sc.register(202, "Bob", btnBoy)
+
+	// Bob waits in the queue first, so Alice is the one who finds a match and picks the first category
+	bob.tap(BtnPlayGame)
+	bob.press(BtnQuiz)
+	bob.press("➕ 🎮 بازی جدید")
+	bob.expect("هنوز حریفی پیدا نشد")
+
+	alice.tap(BtnPlayGame)
+	alice.press(BtnQuiz)
+	alice.press("➕ 🎮 بازی جدید")
+	alice.expect("حریف پیدا شد")
+	bob.expect("حریف پیدا شد")
+
+	alice.press("🏁 انتخاب موضوع")
+	alice.press("History")
+	alice.expect("سؤال 1 از 4")
+	alice.press("Right")
+	alice.expect("✅ صحیح")
+	alice.expect("سؤال 2 از 4")
+
+	bob.expect("Alice موضوع را انتخاب کرد")
+	bob.press("🏁 شروع بازی")
+	question := bob.expect("سؤال 1 از 4")
+	if !strings.Contains(question.text, "History question") {
+		t.Errorf("Bob's question = %q, want one from the chosen category", question.text)
+	}
+	bob.press("Wrong")
+	bob.expect("❌ غلط! پاسخ صحیح: Right")
+}
+
+func TestScenario_TruthOrDareTurn(t *testing.T) {
+	sc := newScenario(t)
+	sc.db.SeedTodChallenges(models.TodChallenge{
+		Type:         models.TodTypeTruth,
+		Text:         "Tell us a secret",
+		Difficulty:   "easy",
+		GenderTarget: "all",
+		ProofType:    models.ProofTypeText,
+		CoinReward:   10,
+	})
+	alice := sc.register(301, "Alice", btnGirl)
+	bob := sc.register(302, "Bob", btnBoy)
+
+	bob.tap(BtnPlayGame)
+	bob.press(BtnTruthDare)
+	bob.press(BtnRandomMatch)
+	bob.expect("هنوز حریفی پیدا نشد")
+
+	alice.tap(BtnPlayGame)
+	alice.press(BtnTruthDare)
+	alice.press(BtnRandomMatch)
+	alice.expect("حریف پیدا شد")
+	bob.expect("حریف پیدا شد")
+
+	// The coin flip decides who goes first
+	flip := alice.expect("نتیجه قرعه‌کشی")
+	bob.expect("نتیجه قرعه‌کشی")
+	active, passive := alice, bob
+	if !strings.Contains(flip.text, "نوبت اول: Alice") {
+		active, passive = bob, alice
+	}
+
+	alice.press("▶️ ادامه")
+	active.expect("نوبت شما")
+	passive.expect("حریف در حال انتخاب")
+
+	active.press("🔵 حقیقت")
+	active.expect("Tell us a secret")
+	passive.expect("حریف در حال انجام چالش")
+
+	active.send("I still sleep with a night light")
+	active.press("✅ انجام دادم")
+	passive.expect("داوری با توئه")
+	passive.expect("I still sleep with a night light")
+	passive.press("✅ قبوله")
+
+	active.expect("داور قبول کرد")
+	if got := sc.balance(active); got != models.StartingCoins+10 {
+		t.Errorf("%s balance after an accepted challenge = %d, want %d", active.firstName, got, models.StartingCoins+10)
+	}
+
+	// Turns swap for the next round
+	passive.expect("نوبت شما")
+}
+
+func TestAllowUpdate_LimitBelowPurchaseWeight(t *testing.T) {
+	cfg := &config.Config{RateLimitPerUser: weightPurchase - 2, SendGlobalPerSecond: 1000, SendChatPerSecond: 1000}
+	bot := newBot(cfg, newFakeAPI(), "test_bot", newTestHandlers(cfg, memory.NewDB()), session.NewMemoryStore(time.Hour))
+
+	buy := tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "1", Data: "shop_buy_coins_100"}}
+	if !bot.allowUpdate(501, buy) {
+		t.Fatal("a purchase within a fresh window was refused")
+	}
+	if bot.allowUpdate(501, buy) {
+		t.Error("a second purchase in the same window was allowed")
+	}
+}
@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatMessage is a message as it shows in a user's chat
+type chatMessage struct {
+	id      int
+	fromBot bool
+	text    string
+	inline  [][]tgbotapi.InlineKeyboardButton
+	deleted bool
+}
+
+// fakeChat is one user's private chat with the bot
+type fakeChat struct {
+	messages []*chatMessage
+	keyboard [][]tgbotapi.KeyboardButton // reply keyboard currently shown
+	nextID   int
+}
+
+func (c *fakeChat) find(messageID int) *chatMessage {
+	for _, m := range c.messages {
+		if m.id == messageID {
+			return m
+		}
+	}
+	return nil
+}
+
+// replyMarkup is any keyboard Telegram accepts in reply_markup
+type replyMarkup struct {
+	InlineKeyboard [][]tgbotapi.InlineKeyboardButton `json:"inline_keyboard"`
+	Keyboard       [][]tgbotapi.KeyboardButton       `json:"keyboard"`
+	RemoveKeyboard bool                              `json:"remove_keyboard"`
+}
+
+// fakeAPI stands in for the Bot API: it applies every request to in-memory chats
+// the way a Telegram client would display them
+type fakeAPI struct {
+	mu    sync.Mutex
+	chats map[int64]*fakeChat
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{chats: make(map[int64]*fakeChat)}
+}
+
+func (f *fakeAPI) chat(chatID int64) *fakeChat {
+	c, ok := f.chats[chatID]
+	if !ok {
+		c = &fakeChat{}
+		f.chats[chatID] = c
+	}
+	return c
+}
+
+func (f *fakeAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return f.apply(c)
+}
+
+func (f *fakeAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if _, err := f.apply(c); err != nil {
+		return nil, err
+	}
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}, nil
+}
+
+func (f *fakeAPI) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}, nil
+}
+
+func (f *fakeAPI) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return make(chan tgbotapi.Update)
+}
+
+func (f *fakeAPI) StopReceivingUpdates() {}
+
+func (f *fakeAPI) HandleUpdate(r *http.Request) (*tgbotapi.Update, error) {
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// apply performs a request on the chat it targets
+func (f *fakeAPI) apply(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch req := c.(type) {
+	case tgbotapi.EditMessageTextConfig:
+		return f.edit(req.BaseEdit, &req.Text)
+	case tgbotapi.EditMessageCaptionConfig:
+		return f.edit(req.BaseEdit, &req.Caption)
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return f.edit(req.BaseEdit, nil)
+	case tgbotapi.DeleteMessageConfig:
+		if m := f.chat(req.ChatID).find(req.MessageID); m != nil {
+			m.deleted = true
+		}
+		return tgbotapi.Message{}, nil
+	}
+
+	// Everything else that targets a chat posts a new message there; the
+	// chat, text and keyboard sit in the same fields on every send config
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Struct || !v.FieldByName("ChatID").IsValid() {
+		// Callback answers and other requests that show nothing in a chat
+		return tgbotapi.Message{}, nil
+	}
+	chatID := v.FieldByName("ChatID").Int()
+	text := stringField(v, "Text")
+	if text == "" {
+		text = stringField(v, "Caption")
+	}
+	markup, err := parseMarkup(v.FieldByName("ReplyMarkup"))
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	ch := f.chat(chatID)
+	ch.nextID++
+	ch.messages = append(ch.messages, &chatMessage{id: ch.nextID, fromBot: true, text: text, inline: markup.InlineKeyboard})
+	if markup.Keyboard != nil {
+		ch.keyboard = markup.Keyboard
+	} else if markup.RemoveKeyboard {
+		ch.keyboard = nil
+	}
+	return tgbotapi.Message{MessageID: ch.nextID, Chat: &tgbotapi.Chat{ID: chatID}, Text: text}, nil
+}
+
+// edit changes a message in place; like Telegram, an edit without a keyboard removes it
+func (f *fakeAPI) edit(base tgbotapi.BaseEdit, text *string) (tgbotapi.Message, error) {
+	if m := f.chat(base.ChatID).find(base.MessageID); m != nil {
+		if text != nil {
+			m.text = *text
+		}
+		m.inline = nil
+		if base.ReplyMarkup != nil {
+			m.inline = base.ReplyMarkup.InlineKeyboard
+		}
+	}
+	return tgbotapi.Message{MessageID: base.MessageID, Chat: &tgbotapi.Chat{ID: base.ChatID}}, nil
+}
+
+func stringField(v reflect.Value, name string) string {
+	if field := v.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
+
+// parseMarkup reads a reply_markup value through its JSON form, as Telegram would
+func parseMarkup(field reflect.Value) (replyMarkup, error) {
+	var markup replyMarkup
+	if !field.IsValid() || field.IsNil() {
+		return markup, nil
+	}
+	raw, err := json.Marshal(field.Interface())
+	if err != nil {
+		return markup, err
+	}
+	err = json.Unmarshal(raw, &markup)
+	return markup, err
+}
+
+// userMessage adds a message the user wrote to their chat and returns its ID
+func (f *fakeAPI) userMessage(chatID int64, text string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := f.chat(chatID)
+	ch.nextID++
+	ch.messages = append(ch.messages, &chatMessage{id: ch.nextID, text: text})
+	return ch.nextID
+}
+
+// botMessages returns copies of the messages the bot sent to chatID, oldest first
+func (f *fakeAPI) botMessages(chatID int64) []chatMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var msgs []chatMessage
+	for _, m := range f.chat(chatID).messages {
+		if m.fromBot {
+			msgs = append(msgs, *m)
+		}
+	}
+	return msgs
+}
+
+// replyKeyboard returns the reply keyboard chatID currently shows
+func (f *fakeAPI) replyKeyboard(chatID int64) [][]tgbotapi.KeyboardButton {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chat(chatID).keyboard
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/mroshb/game_bot/internal/handlers"
+	"github.com/mroshb/game_bot/internal/metrics"
+	"github.com/mroshb/game_bot/internal/payment"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// startHTTPServer serves health checks and metrics, and the Telegram webhook and payment gateway
+// callbacks when they are enabled, on Config.AppPort
+func (b *Bot) startHTTPServer() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", b.handleHealthz)
+	mux.HandleFunc("/readyz", b.handleReadyz)
+	mux.Handle("/metrics", metrics.Default)
+	if b.config.IsWebhookMode() {
+		mux.HandleFunc(WebhookPath, b.handleWebhook)
+	}
+	if b.handlers.Payments != nil {
+		mux.HandleFunc(payment.CallbackPath, b.handlePaymentCallback)
+	}
+
+	b.httpServer = &http.Server{
+		Addr:              ":" + b.config.AppPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Info("HTTP server listening", "port", b.config.AppPort)
+		if err := b.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "error", err)
+		}
+	}()
+}
+
+func (b *Bot) stopHTTPServer() {
+	if b.httpServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.httpServer.Shutdown(ctx); err != nil {
+		logger.Error("Failed to shut down HTTP server", "error", err)
+	}
+}
+
+// handleHealthz reports that the process is up
+func (b *Bot) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports whether the bot is receiving updates and can reach the database
+func (b *Bot) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !b.ready.Load() {
+		http.Error(w, "not receiving updates", http.StatusServiceUnavailable)
+		return
+	}
+
+	sqlDB, err := b.db.DB()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		logger.Warn("Readiness check failed", "error", err)
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+// handlePaymentCallback is where the gateway sends the buyer back after paying
+func (b *Bot) handlePaymentCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	order, err := b.handlers.HandlePaymentCallback(r.Form, b)
+	switch {
+	case err == nil:
+		writePaymentPage(w, http.StatusOK, "✅ پرداخت موفق",
+			fmt.Sprintf("سفارش #%d تایید شد و سکه‌ها به حسابت اضافه شد. می‌تونی به ربات برگردی.", order.ID))
+	case err == handlers.ErrPaymentNotCompleted:
+		writePaymentPage(w, http.StatusOK, "❌ پرداخت ناموفق",
+			"پرداخت انجام نشد یا تایید نشد. اگر مبلغی از حسابت کم شده، طی ۷۲ ساعت برگشت داده می‌شه.")
+	case err == handlers.ErrPaymentNeedsReview:
+		writePaymentPage(w, http.StatusOK, "⏳ در حال بررسی",
+			fmt.Sprintf("پرداخت سفارش #%d دریافت شد ولی سکه‌ها خودکار واریز نشدند. پشتیبانی بررسی می‌کنه و سکه‌ها رو واریز یا مبلغ رو برگشت می‌ده.", order.ID))
+	default:
+		logger.Error("Payment callback failed", "error", err)
+		writePaymentPage(w, http.StatusBadRequest, "⚠️ خطا",
+			"این پرداخت پیدا نشد. اگر مشکلی هست با پشتیبانی در تماس باش.")
+	}
+}
+
+func writePaymentPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="fa" dir="rtl">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>%s</title></head>
+<body style="font-family: sans-serif; text-align: center; padding: 48px 16px;">
+<h2>%s</h2>
+<p>%s</p>
+</body>
+</html>`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(message))
+}
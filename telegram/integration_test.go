@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/metrics"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+	"github.com/mroshb/game_bot/internal/session"
+	"github.com/mroshb/game_bot/internal/telegramtest"
+)
+
+const integrationToken = "123456:integration"
+
+// startIntegrationBot runs the bot the way InitBot does, polling a local Bot API stand-in
+// with its worker pool and background jobs, on the in-memory stores
+func startIntegrationBot(t *testing.T) *telegramtest.Server {
+	t.Helper()
+
+	server := telegramtest.NewServer(integrationToken)
+	cfg := &config.Config{
+		BotToken:            integrationToken,
+		BotAPIURL:           server.URL,
+		AppPort:             "0",
+		UpdateMode:          "polling",
+		MatchCostCoins:      5,
+		MatchTimeoutMinutes: 5,
+		SendGlobalPerSecond: 1000,
+		SendChatPerSecond:   1000,
+	}
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.BotToken, cfg.GetBotAPIEndpoint())
+	if err != nil {
+		server.Close()
+		t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+	}
+	bot := newBot(cfg, api, api.Self.UserName, newTestHandlers(cfg, memory.NewDB()), session.NewMemoryStore(time.Hour))
+	if err := bot.start(); err != nil {
+		server.Close()
+		t.Fatalf("start() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		bot.Stop()
+		server.Close()
+	})
+	return server
+}
+
+// apiUser is a user talking to the bot through the Bot API stand-in
+type apiUser struct {
+	t      *testing.T
+	server *telegramtest.Server
+	user   tgbotapi.User
+	seen   int
+}
+
+func (u *apiUser) send(text string) {
+	u.server.SendText(u.user, text)
+}
+
+// press waits for an inline button with the given label and presses the newest one
+func (u *apiUser) press(button string) {
+	u.t.Helper()
+
+	deadline := time.Now().Add(scenarioTimeout)
+	for {
+		if messageID, data, ok := u.server.InlineButton(u.user.ID, button); ok {
+			u.server.PressButton(u.user, messageID, data)
+			return
+		}
+		if time.Now().After(deadline) {
+			u.t.Fatalf("%s: no inline button %q, chat: %s", u.user.FirstName, button, u.transcript())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// expect waits for a bot message containing substr that arrived after the last one expected
+func (u *apiUser) expect(substr string) {
+	u.t.Helper()
+
+	deadline := time.Now().Add(scenarioTimeout)
+	for {
+		msgs := u.server.BotMessages(u.user.ID)
+		for i := u.seen; i < len(msgs); i++ {
+			if strings.Contains(msgs[i].Text, substr) {
+				u.seen = i + 1
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			u.t.Fatalf("%s: no message containing %q, chat: %s", u.user.FirstName, substr, u.transcript())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (u *apiUser) transcript() string {
+	var b strings.Builder
+	for _, m := range u.server.BotMessages(u.user.ID) {
+		fmt.Fprintf(&b, "\n  [%d] %q", m.ID, m.Text)
+	}
+	return b.String()
+}
+
+// registerOverAPI signs a user up and leaves them at the main menu
+func registerOverAPI(t *testing.T, server *telegramtest.Server, id int64, name, genderButton string) *apiUser {
+	t.Helper()
+
+	u := &apiUser{t: t, server: server, user: tgbotapi.User{ID: id, FirstName: name}}
+	u.send("/start")
+	u.press(genderButton)
+	u.expect("چی صدات کنیم")
+	u.send(name)
+	u.press("25")
+	u.press("تهران")
+	u.press("⏩ فعلاً رد کن")
+	u.expect("ثبتنامت تکمیل شد")
+	return u
+}
+
+func TestIntegration_ChatOverBotAPI(t *testing.T) {
+	server := startIntegrationBot(t)
+	alice := registerOverAPI(t, server, 401, "Alice", btnGirl)
+	bob := registerOverAPI(t, server, 402, "Bob", btnBoy)
+
+	alice.send(BtnChatNow)
+	alice.press(BtnRandomMatch)
+	// Bob joins the queue after Alice so her search is the one that pairs them
+	time.Sleep(time.Second)
+	bob.send(BtnChatNow)
+	bob.press(BtnRandomMatch)
+	alice.expect("✅ پیدا شد")
+	bob.expect("✅ پیدا شد")
+
+	alice.send("سلام از طریق Bot API")
+	bob.expect("سلام از طریق Bot API")
+	if len(server.CallbackAnswers()) == 0 {
+		t.Error("no callback query was answered")
+	}
+
+	// Bob blocks the bot: the next forward fails with a 403 and Alice is let go
+	errors403 := metrics.TelegramErrors.Value("403")
+	server.Block(bob.user.ID)
+	alice.send("هنوز اینجایی؟")
+	alice.expect("طرف مقابل چت را ترک کرد")
+	if got := metrics.TelegramErrors.Value("403") - errors403; got < 1 {
+		t.Errorf("403 errors counted = %v, want at least 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Default.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`gamebot_updates_total{type="callback_query"}`,
+		`gamebot_update_duration_seconds_count{type="message"}`,
+		`gamebot_worker_queue_depth{worker="9"} 0`,
+		`gamebot_matchmaking_queue_size{game_type="chat"} 0`,
+		`gamebot_active_games{kind="match_session"} 0`,
+		`gamebot_coins_burned_total{type="matchmaking"}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("/metrics has no %s", want)
+		}
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // MainMenuKeyboard creates the main menu keyboard
-func MainMenuKeyboard(_ bool) tgbotapi.ReplyKeyboardMarkup {
+func MainMenuKeyboard(isAdmin bool) tgbotapi.ReplyKeyboardMarkup {
 	var rows [][]tgbotapi.KeyboardButton
 
 	// Row 1 - My Village
@@ -39,6 +39,14 @@ func MainMenuKeyboard(_ bool) tgbotapi.ReplyKeyboardMarkup {
 		tgbotapi.NewKeyboardButton(BtnReferral),
 	))
 
+	// Row 6 - Admin tools (super admin only)
+	if isAdmin {
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(BtnAdminPanel),
+			tgbotapi.NewKeyboardButton(BtnUserManagement),
+		))
+	}
+
 	return tgbotapi.NewReplyKeyboard(rows...)
 }
 
@@ -483,6 +491,10 @@ func CoinsMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
 			tgbotapi.NewInlineKeyboardButtonData("➕ افزایش سکه", "btn:➕ افزایش سکه"),
 			tgbotapi.NewInlineKeyboardButtonData("📊 موجودی", "btn:📊 موجودی"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(BtnDiamonds, "diamonds"),
+			tgbotapi.NewInlineKeyboardButtonData(BtnMyOrders, "my_orders"),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔙 بازگشت", "btn:🔙 بازگشت"),
 		),
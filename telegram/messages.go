@@ -64,6 +64,8 @@ const (
 	MsgErrorInvalidPhoto  = "❌ فایل نامعتبر! فقط عکس (JPG, PNG) با حداکثر 5MB."
 	MsgErrorNotRegistered = "⚠️ اول باید ثبت نام کنی!"
 	MsgErrorUnauthorized  = "🚫 شما دسترسی به این بخش رو نداری!"
+	MsgSlowDown           = "🐢 یکم آروم‌تر! داری خیلی سریع پیام میدی، چند لحظه صبر کن."
+	MsgFloodMuted         = "🔇 به خاطر ارسال بیش از حد، تا %d دقیقه نمی‌تونی از ربات استفاده کنی."
 
 	// General
 	MsgCancel     = "❌ لغو شد."
@@ -72,18 +74,6 @@ const (
 	MsgSuccess    = "✅ با موفقیت انجام شد!"
 
 	// Purchase
-	MsgCoinPurchasePlans = `💎 لیست پکیج‌های افزایش سکه:
-
-▫️ ۲۰۰ سکه ⬅️ ۲۰،۰۰۰ تومان
-▫️ ۵۰۰ سکه ⬅️ ۴۵،۰۰۰ تومان (۱۰٪ تخفیف)
-▫️ ۱۰۰۰ سکه ⬅️ ۸۰،۰۰۰ تومان (۲۰٪ تخفیف)
-▫️ ۵۰۰۰ سکه ⬅️ ۳۵۰،۰۰۰ تومان (۳۰٪ تخفیف ویژه)
-
-💳 اطلاعات واریز:
-۶۲۱۹۸۶۱۸۲۰۱۷۴۹۳۵
-مهدی محمدی - بلو بانک
-
-⚠️ بعد از واریز مبلغ، حتماً گزینه «واریز کردم» را انتخاب کرده و عکس رسید تراکنش را ارسال نمایید.`
 	MsgRequestReceipt  = "📸 لطفاً عکس رسید یا اسکرین‌شات تراکنش خود را ارسال کنید."
 	MsgPurchasePending = "✅ درخواست شما ثبت شد. پس از بررسی و تأیید واریز، سکه‌ها به حساب شما اضافه خواهد شد. از شکیبایی شما سپاسگزاریم."
 
@@ -147,6 +137,8 @@ const (
 
 	BtnCancel         = "❌ لغو"
 	BtnEndChat        = "🔚 پایان چت"
+	BtnBlockUser      = "🚫 بلاک کاربر"
+	BtnReportUser     = "🚩 گزارش تخلف"
 	BtnSkip           = "⏭️ رد شو"
 	BtnMale           = "👨 پسر"
 	BtnFemale         = "👩 دختر"
@@ -154,6 +146,8 @@ const (
 	BtnAccept         = "✅ قبول"
 	BtnReject         = "❌ رد"
 	BtnBack           = "🔙 بازگشت"
+	BtnAdminPanel     = "🔧 پنل مدیریت"
+	BtnUserManagement = "👥 مدیریت کاربران"
 	BtnCreateRoom     = "🏛 ساخت روم"
 	BtnSearchRoom     = "🔍 جستجوی روم"
 	BtnRandomMatch    = "🎲 جستجوی تصادفی"
@@ -167,6 +161,9 @@ const (
 	BtnFilterNearMe   = "📍 نزدیک من"
 	BtnBuyCoins       = "💎 خرید سکه"
 	BtnIHavePaid      = "✅ واریز کردم"
+	BtnMyOrders       = "🧾 سفارش‌های من"
+	BtnPayOnline      = "💳 پرداخت آنلاین"
+	BtnDiamonds       = "💎 الماس‌های من"
 
 	BtnCreateVillage      = "🆕 ساخت دهکده"
 	BtnVillageLeaderboard = "🏅 برترین دهکده‌ها"
@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/metrics"
+	"github.com/mroshb/game_bot/internal/models"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// meteredAPI counts the errors Bot API calls return, by Telegram's error code
+type meteredAPI struct {
+	API
+}
+
+func (m meteredAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	msg, err := m.API.Send(c)
+	countAPIError(err)
+	return msg, err
+}
+
+func (m meteredAPI) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := m.API.Request(c)
+	countAPIError(err)
+	return resp, err
+}
+
+func (m meteredAPI) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	resp, err := m.API.MakeRequest(endpoint, params)
+	countAPIError(err)
+	return resp, err
+}
+
+func countAPIError(err error) {
+	if err == nil {
+		return
+	}
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		metrics.TelegramErrors.Inc(strconv.Itoa(apiErr.Code))
+		return
+	}
+	metrics.TelegramErrors.Inc("network")
+}
+
+// updateType names the kind of an update for the metrics
+func updateType(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.PreCheckoutQuery != nil:
+		return "pre_checkout_query"
+	case update.MyChatMember != nil:
+		return "my_chat_member"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	}
+	return "other"
+}
+
+// observeUpdate counts a handled update and how long handling it took
+func observeUpdate(update tgbotapi.Update, started time.Time) {
+	kind := updateType(update)
+	metrics.UpdatesTotal.Inc(kind)
+	metrics.UpdateDuration.Observe(time.Since(started).Seconds(), kind)
+}
+
+// registerMetrics adds the gauges that read the bot's live state on every scrape
+func (b *Bot) registerMetrics() {
+	metrics.Default.GaugeFunc("gamebot_worker_queue_depth", "Updates waiting for each worker.", []string{"worker"}, func() []metrics.Sample {
+		samples := make([]metrics.Sample, len(b.workerChans))
+		for i, ch := range b.workerChans {
+			samples[i] = metrics.Sample{LabelValues: []string{strconv.Itoa(i)}, Value: float64(len(ch))}
+		}
+		return samples
+	})
+
+	metrics.Default.GaugeFunc("gamebot_matchmaking_queue_size", "Users waiting in the matchmaking queue, by game type.", []string{"game_type"}, func() []metrics.Sample {
+		sizes, err := b.handlers.MatchRepo.GetQueueSizes()
+		if err != nil {
+			logger.Warn("Failed to read queue sizes for metrics", "error", err)
+			return nil
+		}
+		// Known game types are always reported so an empty queue shows as 0
+		for _, gameType := range []string{models.GameTypeChat, models.GameTypeQuiz, models.GameTypeTod} {
+			if _, ok := sizes[gameType]; !ok {
+				sizes[gameType] = 0
+			}
+		}
+		gameTypes := make([]string, 0, len(sizes))
+		for gameType := range sizes {
+			gameTypes = append(gameTypes, gameType)
+		}
+		sort.Strings(gameTypes)
+
+		samples := make([]metrics.Sample, len(gameTypes))
+		for i, gameType := range gameTypes {
+			samples[i] = metrics.Sample{LabelValues: []string{gameType}, Value: float64(sizes[gameType])}
+		}
+		return samples
+	})
+
+	metrics.Default.GaugeFunc("gamebot_active_games", "Anonymous chats, quiz matches and Truth or Dare games in progress.", []string{"kind"}, func() []metrics.Sample {
+		counts := []struct {
+			kind  string
+			count func() (int64, error)
+		}{
+			{"match_session", b.handlers.MatchRepo.CountActiveMatches},
+			{"quiz_match", b.handlers.QuizMatchRepo.CountActiveQuizMatches},
+			{"tod_game", b.handlers.TodRepo.CountActiveGames},
+		}
+
+		var samples []metrics.Sample
+		for _, c := range counts {
+			n, err := c.count()
+			if err != nil {
+				logger.Warn("Failed to count active games for metrics", "kind", c.kind, "error", err)
+				continue
+			}
+			samples = append(samples, metrics.Sample{LabelValues: []string{c.kind}, Value: float64(n)})
+		}
+		return samples
+	})
+}
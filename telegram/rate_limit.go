@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/middleware"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// RATE_LIMIT_PER_USER is counted per rateLimitWindow; strikes older than
+// floodStrikeWindow are forgotten
+const (
+	rateLimitWindow   = time.Minute
+	floodStrikeWindow = 15 * time.Minute
+)
+
+// How much of the user's limit an update uses. Paid and expensive actions weigh more
+// so they run out of budget well before plain navigation does.
+const (
+	weightDefault     = 1
+	weightChatMessage = 2
+	weightMatchmaking = 3
+	weightPurchase    = 5
+)
+
+var purchaseCallbackPrefixes = []string{"buy_pkg_", "pay_pkg_", "stars_buy_", "shop_buy_", "dia_exch_"}
+
+var matchmakingCallbackPrefixes = []string{"search_age_", "search_province_", "room_quick_join", "btn:tod_new_game", "btn:new_quiz_game"}
+
+var matchmakingButtons = map[string]bool{
+	normalizeButton(BtnRandomMatch):    true,
+	normalizeButton(BtnOneVsOneRandom): true,
+	normalizeButton(BtnMale):           true,
+	normalizeButton(BtnFemale):         true,
+	normalizeButton(BtnAny):            true,
+}
+
+// updateWeight returns how much of the user's rate limit an update uses
+func (b *Bot) updateWeight(update tgbotapi.Update) int {
+	if update.CallbackQuery != nil {
+		data := update.CallbackQuery.Data
+		for _, prefix := range purchaseCallbackPrefixes {
+			if strings.HasPrefix(data, prefix) {
+				return weightPurchase
+			}
+		}
+		for _, prefix := range matchmakingCallbackPrefixes {
+			if strings.HasPrefix(data, prefix) {
+				return weightMatchmaking
+			}
+		}
+		if strings.HasPrefix(data, "btn:") && matchmakingButtons[normalizeButton(strings.TrimPrefix(data, "btn:"))] {
+			return weightMatchmaking
+		}
+		return weightDefault
+	}
+
+	if update.Message != nil {
+		if matchmakingButtons[normalizeButton(update.Message.Text)] {
+			return weightMatchmaking
+		}
+		if b.getSession(update.Message.From.ID).State == StateInChat {
+			return weightChatMessage
+		}
+	}
+	return weightDefault
+}
+
+// allowUpdate applies flood control to a user's update and reports whether to handle it.
+// Rejected callback queries are still answered so the button stops spinning.
+func (b *Bot) allowUpdate(userID int64, update tgbotapi.Update) bool {
+	if b.floodControl == nil || userID == b.config.SuperAdminTgID {
+		return true
+	}
+
+	// A limit below an action's weight would refuse that action forever
+	weight := min(b.updateWeight(update), b.config.RateLimitPerUser)
+	verdict := b.floodControl.Check(userID, weight)
+	if verdict == middleware.FloodAllow {
+		return true
+	}
+
+	var notice string
+	switch verdict {
+	case middleware.FloodWarn:
+		notice = MsgSlowDown
+	case middleware.FloodMute:
+		logger.Warn("User muted for flooding", "telegram_id", userID, "minutes", b.config.FloodMuteMinutes)
+		notice = fmt.Sprintf(MsgFloodMuted, b.config.FloodMuteMinutes)
+	}
+
+	if update.CallbackQuery != nil {
+		b.api.Request(tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, notice))
+	} else if notice != "" {
+		b.sendMessage(userID, notice, nil)
+	}
+	return false
+}
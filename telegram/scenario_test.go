@@ -0,0 +1,254 @@
+package telegram
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/internal/config"
+	"github.com/mroshb/game_bot/internal/handlers"
+	"github.com/mroshb/game_bot/internal/repositories/memory"
+	"github.com/mroshb/game_bot/internal/services"
+	"github.com/mroshb/game_bot/internal/session"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// scenarioTimeout bounds every wait for the bot; matchmaking and quiz pacing take a few seconds
+const scenarioTimeout = 10 * time.Second
+
+func TestMain(m *testing.M) {
+	os.Setenv("LOG_LEVEL", "error")
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// scenario is a bot wired to the in-memory stores and a fake Telegram, driven by simulated users.
+// Handlers keep some game state in package variables, so scenarios must not run in parallel.
+type scenario struct {
+	t   *testing.T
+	bot *Bot
+	api *fakeAPI
+	db  *memory.DB
+
+	nextUpdate atomic.Int64
+}
+
+func newScenario(t *testing.T) *scenario {
+	t.Helper()
+
+	db := memory.NewDB()
+	cfg := &config.Config{
+		MatchCostCoins:      5,
+		MatchTimeoutMinutes: 5,
+		SendGlobalPerSecond: 1000,
+		SendChatPerSecond:   1000,
+	}
+
+	api := newFakeAPI()
+	return &scenario{
+		t:   t,
+		bot: newBot(cfg, api, "test_bot", newTestHandlers(cfg, db), session.NewMemoryStore(time.Hour)),
+		api: api,
+		db:  db,
+	}
+}
+
+// newTestHandlers wires the handlers to the in-memory stores in db
+func newTestHandlers(cfg *config.Config, db *memory.DB) *handlers.HandlerManager {
+	userRepo := memory.NewUserRepository(db)
+	villageRepo := memory.NewVillageRepository(db)
+
+	return handlers.NewHandlerManager(
+		cfg,
+		userRepo,
+		memory.NewCoinRepository(db),
+		memory.NewDiamondRepository(db),
+		memory.NewMatchRepository(db),
+		memory.NewFriendRepository(db),
+		memory.NewGameRepository(db),
+		memory.NewRoomRepository(db),
+		villageRepo,
+		memory.NewQuizMatchRepository(db),
+		memory.NewTodRepository(db),
+		memory.NewBlockRepository(db),
+		memory.NewReportRepository(db),
+		memory.NewBanRepository(db),
+		memory.NewPurchaseRepository(db),
+		memory.NewShopRepository(db),
+		memory.NewInventoryRepository(db),
+		memory.NewBroadcastRepository(db),
+		nil,
+		services.NewVillageService(villageRepo, userRepo),
+	)
+}
+
+// simUser is a Telegram user talking to the bot in a private chat
+type simUser struct {
+	sc        *scenario
+	id        int64
+	firstName string
+
+	// seen counts the bot messages already matched by expect
+	seen int
+}
+
+func (s *scenario) user(id int64, firstName string) *simUser {
+	return &simUser{sc: s, id: id, firstName: firstName}
+}
+
+func (u *simUser) from() *tgbotapi.User {
+	return &tgbotapi.User{ID: u.id, FirstName: u.firstName}
+}
+
+func (u *simUser) chat() *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: u.id, Type: "private"}
+}
+
+// deliver hands an update to the bot the way a worker would
+func (s *scenario) deliver(update tgbotapi.Update) {
+	update.UpdateID = int(s.nextUpdate.Add(1))
+	s.bot.handleUpdate(update)
+}
+
+// send writes a text message; a leading slash makes it a command
+func (u *simUser) send(text string) {
+	u.sc.t.Helper()
+
+	msg := &tgbotapi.Message{
+		MessageID: u.sc.api.userMessage(u.id, text),
+		From:      u.from(),
+		Chat:      u.chat(),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command := strings.SplitN(text, " ", 2)[0]
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	u.sc.deliver(tgbotapi.Update{Message: msg})
+}
+
+// tap presses a button of the reply keyboard the chat currently shows
+func (u *simUser) tap(button string) {
+	u.sc.t.Helper()
+
+	for _, row := range u.sc.api.replyKeyboard(u.id) {
+		for _, b := range row {
+			if b.Text == button {
+				u.send(button)
+				return
+			}
+		}
+	}
+	u.sc.t.Fatalf("%s: no %q on the reply keyboard %v", u.firstName, button, u.sc.api.replyKeyboard(u.id))
+}
+
+// press waits for an inline button with the given label and presses the newest one
+func (u *simUser) press(button string) {
+	u.sc.t.Helper()
+
+	deadline := time.Now().Add(scenarioTimeout)
+	for {
+		msgs := u.sc.api.botMessages(u.id)
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].deleted {
+				continue
+			}
+			for _, row := range msgs[i].inline {
+				for _, b := range row {
+					if b.Text == button && b.CallbackData != nil {
+						u.sc.deliver(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+							ID:      fmt.Sprintf("cb-%d-%d", u.id, u.sc.nextUpdate.Load()),
+							From:    u.from(),
+							Message: &tgbotapi.Message{MessageID: msgs[i].id, Chat: u.chat()},
+							Data:    *b.CallbackData,
+						}})
+						return
+					}
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			u.sc.t.Fatalf("%s: no inline button %q, chat: %s", u.firstName, button, u.transcript())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// expect waits for a bot message containing substr that arrived after the last one expected
+func (u *simUser) expect(substr string) chatMessage {
+	u.sc.t.Helper()
+
+	msg, ok := u.awaitFor(substr, scenarioTimeout)
+	if !ok {
+		u.sc.t.Fatalf("%s: no message containing %q, chat: %s", u.firstName, substr, u.transcript())
+	}
+	return msg
+}
+
+// awaitFor is expect without failing the test when nothing arrives within timeout
+func (u *simUser) awaitFor(substr string, timeout time.Duration) (chatMessage, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msgs := u.sc.api.botMessages(u.id)
+		for i := u.seen; i < len(msgs); i++ {
+			if strings.Contains(msgs[i].text, substr) {
+				u.seen = i + 1
+				return msgs[i], true
+			}
+		}
+		if time.Now().After(deadline) {
+			return chatMessage{}, false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// expectKeyboard checks the reply keyboard the chat shows has the given button
+func (u *simUser) expectKeyboard(button string) {
+	u.sc.t.Helper()
+
+	for _, row := range u.sc.api.replyKeyboard(u.id) {
+		for _, b := range row {
+			if b.Text == button {
+				return
+			}
+		}
+	}
+	u.sc.t.Errorf("%s: no %q on the reply keyboard", u.firstName, button)
+}
+
+// transcript lists the bot messages in the chat for failure output
+func (u *simUser) transcript() string {
+	var b strings.Builder
+	for _, m := range u.sc.api.botMessages(u.id) {
+		fmt.Fprintf(&b, "\n  [%d] %q", m.id, m.text)
+		for _, row := range m.inline {
+			for _, btn := range row {
+				fmt.Fprintf(&b, " [%s]", btn.Text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// register walks the user through sign-up and leaves them at the main menu
+func (s *scenario) register(id int64, name, genderButton string) *simUser {
+	s.t.Helper()
+
+	u := s.user(id, name)
+	u.send("/start")
+	u.press(genderButton)
+	u.expect("چی صدات کنیم")
+	u.send(name)
+	u.press("25")
+	u.press("تهران")
+	u.press("⏩ فعلاً رد کن")
+	u.expect("ثبتنامت تکمیل شد")
+	u.expectKeyboard(BtnChatNow)
+	return u
+}
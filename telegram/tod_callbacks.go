@@ -21,13 +21,13 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 		switch {
 		case data == "btn:tod_new_game":
 			b.handlers.StartTodMatchmaking(userID, b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case data == "btn:tod_cancel_search":
 			// Cancel matchmaking
 			b.handlers.CancelTodMatchmaking(userID, b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, "جستجو لغو شد"))
+			b.api.Request(tgbotapi.NewCallback(query.ID, "جستجو لغو شد"))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_start_"):
@@ -39,7 +39,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.HandleTodStart(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_choice_"):
@@ -54,7 +54,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 					return true
 				}
 				b.handlers.HandleTodChoice(userID, uint(gameID), choice, b)
-				b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+				b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			}
 			return true
 
@@ -66,7 +66,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.HandleTodConfirmProof(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_resubmit_"):
@@ -77,7 +77,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.HandleTodResubmit(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_judge_"):
@@ -92,7 +92,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 					return true
 				}
 				b.handlers.HandleTodJudgment(userID, uint(gameID), result, b)
-				b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+				b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			}
 			return true
 
@@ -104,7 +104,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.ShowTodItemMenu(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_use_item_"):
@@ -119,7 +119,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 					return true
 				}
 				b.handlers.HandleTodItemUse(userID, uint(gameID), itemType, b)
-				b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+				b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			}
 			return true
 
@@ -131,7 +131,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.HandleTodQuit(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_nudge_"):
@@ -142,7 +142,7 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.HandleTodNudge(userID, uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, "تلنگر ارسال شد!"))
+			b.api.Request(tgbotapi.NewCallback(query.ID, "تلنگر ارسال شد!"))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_back_"):
@@ -153,12 +153,12 @@ func (b *Bot) HandleTodCallbacks(query *tgbotapi.CallbackQuery, data string) boo
 				return true
 			}
 			b.handlers.ShowTodChoiceScreen(uint(gameID), b)
-			b.api.Send(tgbotapi.NewCallback(query.ID, ""))
+			b.api.Request(tgbotapi.NewCallback(query.ID, ""))
 			return true
 
 		case strings.HasPrefix(data, "btn:tod_chat_"):
 			// Limited chat feature (future implementation)
-			b.api.Send(tgbotapi.NewCallback(query.ID, "این قابلیت به زودی اضافه می‌شود!"))
+			b.api.Request(tgbotapi.NewCallback(query.ID, "این قابلیت به زودی اضافه می‌شود!"))
 			return true
 		}
 	}
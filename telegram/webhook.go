@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/mroshb/game_bot/pkg/logger"
+)
+
+// WebhookPath is where Telegram posts updates in webhook mode
+const WebhookPath = "/telegram/webhook"
+
+// webhookSecretHeader carries the secret token given to setWebhook on every update
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxWebhookBody caps the size of an update body; real updates are a few KB
+const maxWebhookBody = 1 << 20
+
+// setWebhook points Telegram at our webhook endpoint. The library's WebhookConfig has
+// no secret token, so the request is built by hand.
+func (b *Bot) setWebhook() error {
+	params := tgbotapi.Params{}
+	params["url"] = b.config.WebhookURL + WebhookPath
+	params["secret_token"] = b.config.WebhookSecret
+
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	logger.Info("Webhook set", "url", b.config.WebhookURL+WebhookPath)
+	return nil
+}
+
+// deleteWebhook stops Telegram from pushing updates. Pending updates are kept and
+// delivered to whoever receives updates next.
+func (b *Bot) deleteWebhook() error {
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// handleWebhook receives an update from Telegram and hands it to the workers
+func (b *Bot) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.Header.Get(webhookSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(b.config.WebhookSecret)) != 1 {
+		logger.Warn("Webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBody)
+	update, err := b.api.HandleUpdate(r)
+	if err != nil {
+		logger.Warn("Invalid webhook update", "error", err)
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Blocks while the user's worker is busy, which makes Telegram slow down instead
+	// of us piling up updates in memory
+	b.dispatchUpdate(*update)
+	w.WriteHeader(http.StatusOK)
+}